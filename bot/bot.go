@@ -2,87 +2,95 @@ package bot
 
 import (
 	"errors"
-	"strings"
+	"fmt"
 
 	"github.com/bradselph/CODStatusBot/command"
-	"github.com/bradselph/CODStatusBot/command/accountage"
-	"github.com/bradselph/CODStatusBot/command/accountlogs"
-	"github.com/bradselph/CODStatusBot/command/addaccount"
-	"github.com/bradselph/CODStatusBot/command/checknow"
-	"github.com/bradselph/CODStatusBot/command/feedback"
-	"github.com/bradselph/CODStatusBot/command/globalannouncement"
-	"github.com/bradselph/CODStatusBot/command/listaccounts"
-	"github.com/bradselph/CODStatusBot/command/removeaccount"
-	"github.com/bradselph/CODStatusBot/command/setcaptchaservice"
-	"github.com/bradselph/CODStatusBot/command/setcheckinterval"
-	"github.com/bradselph/CODStatusBot/command/setnotifications"
-	"github.com/bradselph/CODStatusBot/command/togglecheck"
-	"github.com/bradselph/CODStatusBot/command/updateaccount"
-	"github.com/bradselph/CODStatusBot/command/verdansk"
 	"github.com/bradselph/CODStatusBot/configuration"
 	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/services"
 	"github.com/bwmarrin/discordgo"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const BotStatusMessage = "the Status of your Accounts so you dont have to."
 
-var discord *discordgo.Session
+var shards *services.ShardManager
 
-func StartBot() (*discordgo.Session, error) {
+// StartBot connects to the gateway, sharded when Discord recommends more
+// than one shard. Every shard shares the same interaction router.
+func StartBot() (*services.ShardManager, error) {
 	cfg := configuration.Get()
 	if cfg.Discord.Token == "" {
 		return nil, errors.New("discord token not configured")
 	}
 
-	var err error
-	discord, err = discordgo.New("Bot " + cfg.Discord.Token)
-	if err != nil {
-		return nil, err
-	}
-
-	discord.Identify.Intents = discordgo.IntentsGuildMessages |
-		discordgo.IntentsDirectMessages |
-		discordgo.IntentsGuilds
-
-	err = discord.Open()
+	router := newRouter()
+	shards = services.NewShardManager(cfg.Discord.Token)
+	err := shards.StartShards(cfg.Discord.Token, func(s *discordgo.Session) {
+		s.Identify.Intents = discordgo.IntentsGuildMessages |
+			discordgo.IntentsDirectMessages |
+			discordgo.IntentsGuilds
+		s.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+			handleInteraction(router, s, i)
+		})
+		s.AddHandler(handleDirectMessage)
+	})
 	if err != nil {
+		shards.Close()
 		return nil, err
 	}
+	services.SetShardManager(shards)
 
-	err = discord.UpdateWatchStatus(0, BotStatusMessage)
-	if err != nil {
+	if err := RefreshPresence(); err != nil {
 		return nil, err
 	}
 
-	command.RegisterCommands(discord)
+	command.RegisterCommands(shards.Primary())
 	logger.Log.Info("Registering global commands")
 
-	discord.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-		installationType := getInstallationType(i)
-		logger.Log.Infof("Handling interaction in context: %s", installationType)
-
-		switch i.Type {
-		case discordgo.InteractionApplicationCommand:
-			command.HandleCommand(s, i)
-		case discordgo.InteractionModalSubmit:
-			handleModalSubmit(s, i)
-		case discordgo.InteractionMessageComponent:
-			handleMessageComponent(s, i)
-		}
-	})
+	return shards, nil
+}
 
-	discord.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
-		if m.Author.ID == s.State.User.ID {
-			return
+// RefreshPresence sets the watch status on every shard; presence is per
+// gateway connection.
+func RefreshPresence() error {
+	for _, s := range services.AllSessions(nil) {
+		if err := s.UpdateWatchStatus(0, BotStatusMessage); err != nil {
+			return fmt.Errorf("shard %d: %w", s.ShardID, err)
 		}
+	}
+	return nil
+}
 
-		channel, err := s.Channel(m.ChannelID)
-		if err == nil && channel.Type == discordgo.ChannelTypeDM {
-			logger.Log.Infof("Received DM from user %s: %s", m.Author.Username, m.Content)
-		}
-	})
+func newRouter() *services.InteractionRouter {
+	r := services.NewInteractionRouter()
+	command.Routes(r)
+	return r
+}
 
-	return discord, nil
+func handleInteraction(router *services.InteractionRouter, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	installationType := getInstallationType(i)
+	logger.FromContext(services.InteractionContext(i)).Infof("Handling interaction in context: %s (shard %d)", installationType, s.ShardID)
+
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		router.Route(s, i)
+	case discordgo.InteractionModalSubmit:
+		handleModalSubmit(router, s, i)
+	case discordgo.InteractionMessageComponent:
+		handleMessageComponent(router, s, i)
+	}
+}
+
+func handleDirectMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.Author.ID == s.State.User.ID {
+		return
+	}
+
+	channel, err := s.Channel(m.ChannelID)
+	if err == nil && channel.Type == discordgo.ChannelTypeDM {
+		logger.Log.Infof("Received DM from user %s: %s", m.Author.Username, m.Content)
+	}
 }
 
 func getInstallationType(i *discordgo.InteractionCreate) string {
@@ -92,65 +100,24 @@ func getInstallationType(i *discordgo.InteractionCreate) string {
 	return "direct"
 }
 
-func handleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func handleModalSubmit(router *services.InteractionRouter, s *discordgo.Session, i *discordgo.InteractionCreate) {
 	customID := i.ModalSubmitData().CustomID
-	switch {
-	case strings.HasPrefix(customID, "set_notifications_modal_"):
-		setnotifications.HandleModalSubmit(s, i)
-	case customID == "set_captcha_service_modal" ||
-		strings.HasPrefix(customID, "set_captcha_service_modal_capsolver") ||
-		strings.HasPrefix(customID, "set_captcha_service_modal_ezcaptcha") ||
-		strings.HasPrefix(customID, "set_captcha_service_modal_2captcha"):
-		setcaptchaservice.HandleModalSubmit(s, i)
-	case customID == "add_account_modal":
-		addaccount.HandleModalSubmit(s, i)
-	case strings.HasPrefix(customID, "update_account_modal_"):
-		updateaccount.HandleModalSubmit(s, i)
-	case customID == "set_check_interval_modal":
-		setcheckinterval.HandleModalSubmit(s, i)
-	case customID == "global_announcement_modal":
-		globalannouncement.HandleModalSubmit(s, i)
-	case customID == "verdansk_activision_id_modal":
-		verdansk.HandleActivisionIDModal(s, i)
-	default:
+	_, span := services.StartInteractionSpan(i, "interaction.modal_submit")
+	span.SetAttributes(attribute.String("discord.custom_id", customID))
+	defer span.End()
+
+	if !router.Route(s, i) {
 		logger.Log.WithField("customID", customID).Error("Unknown modal submission")
 	}
 }
 
-func handleMessageComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func handleMessageComponent(router *services.InteractionRouter, s *discordgo.Session, i *discordgo.InteractionCreate) {
 	customID := i.MessageComponentData().CustomID
-	switch {
-	case customID == "listaccounts":
-		listaccounts.CommandListAccounts(s, i)
-	case strings.HasPrefix(customID, "set_captcha_"):
-		setcaptchaservice.HandleCaptchaServiceSelection(s, i)
-	case strings.HasPrefix(customID, "feedback_"):
-		feedback.HandleFeedbackChoice(s, i)
-	case strings.HasPrefix(customID, "account_age_"):
-		accountage.HandleAccountSelection(s, i)
-	case strings.HasPrefix(customID, "account_logs_"):
-		accountlogs.HandleAccountSelection(s, i)
-	case customID == "account_logs_all":
-		accountlogs.HandleAccountSelection(s, i)
-	case strings.HasPrefix(customID, "update_account_"):
-		updateaccount.HandleAccountSelection(s, i)
-	case strings.HasPrefix(customID, "remove_account_"):
-		removeaccount.HandleAccountSelection(s, i)
-	case customID == "cancel_remove" || strings.HasPrefix(customID, "confirm_remove_"):
-		removeaccount.HandleConfirmation(s, i)
-	case strings.HasPrefix(customID, "check_now_"):
-		checknow.HandleAccountSelection(s, i)
-	case strings.HasPrefix(customID, "toggle_check_"):
-		togglecheck.HandleAccountSelection(s, i)
-	case strings.HasPrefix(customID, "confirm_reenable_") || customID == "cancel_reenable":
-		togglecheck.HandleConfirmation(s, i)
-	case customID == "show_interval_modal":
-		setcheckinterval.HandleButton(s, i)
-	case customID == "verdansk_provide_id" || customID == "verdansk_select_account":
-		verdansk.HandleMethodSelection(s, i)
-	case strings.HasPrefix(customID, "verdansk_account_"):
-		verdansk.HandleAccountSelection(s, i)
-	default:
+	_, span := services.StartInteractionSpan(i, "interaction.component")
+	span.SetAttributes(attribute.String("discord.custom_id", customID))
+	defer span.End()
+
+	if !router.Route(s, i) {
 		logger.Log.WithField("customID", customID).Error("Unknown message component interaction")
 	}
 }
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/configuration"
+	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/services"
+)
+
+// runSubcommand handles offline maintenance commands. It reports whether
+// args named a subcommand along with the process exit code.
+func runSubcommand(args []string) (bool, int) {
+	if len(args) == 0 {
+		return false, 0
+	}
+
+	switch args[0] {
+	case "replay":
+		return true, runReplay(args[1:])
+	case "keys":
+		return true, runKeys(args[1:])
+	case "config":
+		return true, runConfig(args[1:])
+	default:
+		return false, 0
+	}
+}
+
+func runReplay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	dir := fs.String("dir", "testdata/activision", "directory containing recorded Activision responses")
+	verbose := fs.Bool("v", false, "print every recording, not just drifted ones")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		*dir = fs.Arg(0)
+	}
+
+	results, err := services.ReplayActivisionCorpus(*dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay failed: %v\n", err)
+		return 1
+	}
+
+	if len(results) == 0 {
+		fmt.Fprintf(os.Stderr, "no recordings found in %s\n", *dir)
+		return 1
+	}
+
+	drifted := 0
+	for _, result := range results {
+		if result.Drifted() {
+			drifted++
+			fmt.Printf("DRIFT %s [%s]: %s\n", result.File, result.Endpoint, result.Summary())
+		} else if *verbose {
+			fmt.Printf("ok    %s [%s]\n", result.File, result.Endpoint)
+		}
+	}
+
+	fmt.Printf("%d recordings replayed, %d drifted\n", len(results), drifted)
+	if drifted > 0 {
+		return 1
+	}
+	return 0
+}
+
+var keysUsage = `usage:
+  keys create -name NAME -scopes SCOPE[,SCOPE...] [-expires DURATION]
+  keys list
+  keys revoke -name NAME
+
+scopes: ` + strings.Join(services.AdminScopes, ", ")
+
+// runKeys manages admin API keys stored in the database.
+func runKeys(args []string) int {
+	if len(args) == 0 || (args[0] != "create" && args[0] != "list" && args[0] != "revoke") {
+		fmt.Fprintln(os.Stderr, keysUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("keys "+args[0], flag.ContinueOnError)
+	envFile := fs.String("env", "config.env", "environment file to load")
+	name := fs.String("name", "", "key name")
+	scopes := fs.String("scopes", "", "comma-separated scopes")
+	expires := fs.Duration("expires", 0, "key lifetime, e.g. 720h (0 = never)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	if err := connectForCLI(*envFile); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "create":
+		parsed, err := services.ParseAdminScopes(*scopes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid scopes: %v\n", err)
+			return 2
+		}
+		key, record, err := services.CreateAdminAPIKey(*name, parsed, *expires)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+		fmt.Printf("Created key %q with scopes %s\n", record.Name, record.Scopes)
+		if record.ExpiresAt != nil {
+			fmt.Printf("Expires: %s\n", record.ExpiresAt.Format(time.RFC3339))
+		}
+		fmt.Printf("\n%s\n\nStore this key now; it cannot be shown again.\n", key)
+		return 0
+
+	case "list":
+		keys, err := services.ListAdminAPIKeys()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to list keys: %v\n", err)
+			return 1
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tPREFIX\tSCOPES\tEXPIRES\tLAST USED\tSTATE")
+		for _, key := range keys {
+			state := "active"
+			if key.RevokedAt != nil {
+				state = "revoked"
+			} else if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
+				state = "expired"
+			}
+			fmt.Fprintf(tw, "%s\t%s…\t%s\t%s\t%s\t%s\n",
+				key.Name, key.Prefix, key.Scopes, formatCLITime(key.ExpiresAt), formatCLITime(key.LastUsedAt), state)
+		}
+		tw.Flush()
+		return 0
+
+	case "revoke":
+		if strings.TrimSpace(*name) == "" {
+			fmt.Fprintln(os.Stderr, "-name is required")
+			return 2
+		}
+		if err := services.RevokeAdminAPIKey(*name); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+		fmt.Printf("Revoked key %q\n", *name)
+		return 0
+
+	default:
+		fmt.Fprintln(os.Stderr, keysUsage)
+		return 2
+	}
+}
+
+var configUsage = `usage:
+  config validate [-config FILE] [-env FILE]
+  config print [-config FILE] [-env FILE] [--redacted]`
+
+// runConfig checks or prints the configuration without starting the bot.
+func runConfig(args []string) int {
+	if len(args) == 0 || (args[0] != "validate" && args[0] != "print") {
+		fmt.Fprintln(os.Stderr, configUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config "+args[0], flag.ContinueOnError)
+	envFile := fs.String("env", "config.env", "environment file to load")
+	configFile := fs.String("config", "", "YAML or TOML config file (default: CONFIG_FILE, then config.yaml, config.yml or config.toml)")
+	redacted := fs.Bool("redacted", false, "mask secrets when printing")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	if err := loadEnv(*envFile); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load environment variables: %v\n", err)
+		return 1
+	}
+	configuration.SetFile(*configFile)
+
+	resolved, problems := configuration.Check()
+	file := configuration.File()
+	if file == "" {
+		file = "(none, environment only)"
+	}
+
+	if args[0] == "print" {
+		fmt.Printf("# config file: %s\n", file)
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "KEY\tENV\tVALUE\tSOURCE\tRELOAD")
+		for _, setting := range resolved {
+			value := setting.Value
+			if *redacted && setting.Secret && value != "" {
+				value = "[REDACTED]"
+			}
+			reload := "restart"
+			if setting.Reloadable {
+				reload = "live"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%q\t%s\t%s\n", setting.Key, setting.Env, value, setting.Source, reload)
+		}
+		tw.Flush()
+	}
+
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%d configuration problem(s):\n", len(problems))
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stderr, "  - %v\n", problem)
+		}
+		return 1
+	}
+	if args[0] == "validate" {
+		fmt.Printf("Configuration is valid (config file: %s)\n", file)
+	}
+	return 0
+}
+
+func connectForCLI(envFile string) error {
+	if err := loadEnv(envFile); err != nil {
+		return fmt.Errorf("failed to load environment variables: %w", err)
+	}
+	if err := configuration.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if err := database.Databaselogin(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return nil
+}
+
+func formatCLITime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04")
+}
@@ -6,35 +6,44 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
 	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
 	"github.com/bradselph/CODStatusBot/services"
 	"github.com/bwmarrin/discordgo"
 )
 
+var Command = registry.Command{
+	Name:        "accountage",
+	Description: "Check the age and VIP status of an account",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Consulta la antigüedad y el estado VIP de una cuenta",
+		discordgo.SpanishLATAM: "Consulta la antigüedad y el estado VIP de una cuenta",
+		discordgo.PortugueseBR: "Veja a idade e o status VIP de uma conta",
+	},
+	Handler: CommandAccountAge,
+	Components: []registry.Route{
+		registry.Prefix("account_age_", HandleAccountSelection),
+	},
+}
+
 func CommandAccountAge(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	var userID string
-	if i.Member != nil {
-		userID = i.Member.User.ID
-	} else if i.User != nil {
-		userID = i.User.ID
-	} else {
-		logger.Log.Error("Interaction doesn't have Member or User")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
+	locale := services.InteractionLocale(i)
+	userID := middleware.Current(i).UserID
 
 	var accounts []models.Account
 	result := database.DB.Where("user_id = ?", userID).Find(&accounts)
 	if result.Error != nil {
 		logger.Log.WithError(result.Error).Error("Error fetching user accounts")
-		respondToInteraction(s, i, "Error fetching your accounts. Please try again.")
+		middleware.Reply(s, i, i18n.T(locale, "error.fetch_accounts"))
 		return
 	}
 
 	if len(accounts) == 0 {
-		respondToInteraction(s, i, "You don't have any monitored accounts.")
+		middleware.Reply(s, i, i18n.T(locale, "accounts.none"))
 		return
 	}
 
@@ -60,10 +69,10 @@ func CommandAccountAge(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		components = append(components, discordgo.ActionsRow{Components: currentRow})
 	}
 
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err := middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content:    "Select an account to check its age:",
+			Content:    i18n.T(locale, "accountage.select"),
 			Flags:      discordgo.MessageFlagsEphemeral,
 			Components: components,
 		},
@@ -74,11 +83,12 @@ func CommandAccountAge(s *discordgo.Session, i *discordgo.InteractionCreate) {
 }
 
 func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 	customID := i.MessageComponentData().CustomID
 	accountID, err := strconv.Atoi(strings.TrimPrefix(customID, "account_age_"))
 	if err != nil {
 		logger.Log.WithError(err).Error("Error parsing account ID")
-		respondToInteraction(s, i, "Error processing your selection. Please try again.")
+		middleware.Reply(s, i, i18n.T(locale, "error.selection"))
 		return
 	}
 
@@ -86,28 +96,38 @@ func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate
 	result := database.DB.First(&account, accountID)
 	if result.Error != nil {
 		logger.Log.WithError(result.Error).Error("Error fetching account")
-		respondToInteraction(s, i, "Error: Account not found or you don't have permission to check its age.")
+		middleware.Reply(s, i, i18n.T(locale, "accountage.not_found"))
 		return
 	}
 
 	if account.IsExpiredCookie || !services.VerifySSOCookie(account.SSOCookie) {
 		account.IsExpiredCookie = true
 		database.DB.Save(&account)
-		respondToInteraction(s, i, fmt.Sprintf("Error: The SSO Cookie for account '%s' has expired. Please update the cookie using /updateaccount.", account.Title))
+		middleware.Reply(s, i, i18n.T(locale, "error.cookie_expired", account.Title))
 		return
 	}
 
 	years, months, days, createdEpoch, err := services.CheckAccountAge(account.SSOCookie)
 	if err != nil {
 		logger.Log.WithError(err).Errorf("Error checking account age for account %s", account.Title)
-		respondToInteraction(s, i, "There was an error checking the account age.")
+		errorEmbed := services.UserErrorEmbed(locale, err)
+		errorEmbed.Title = fmt.Sprintf("%s - %s", account.Title, errorEmbed.Title)
+		if err := middleware.Respond(s, i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{errorEmbed},
+				Components: []discordgo.MessageComponent{},
+			},
+		}); err != nil {
+			logger.Log.WithError(err).Error("Error responding to interaction with account age error")
+		}
 		return
 	}
 
 	isVIP, vipErr := services.CheckVIPStatus(account.SSOCookie)
-	vipStatus := "No"
+	vipStatus := i18n.T(locale, "common.no")
 	if vipErr == nil && isVIP {
-		vipStatus = "Yes ⭐"
+		vipStatus = i18n.T(locale, "common.yes") + " ⭐"
 	}
 
 	account.Created = createdEpoch
@@ -115,41 +135,44 @@ func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate
 		logger.Log.WithError(err).Errorf("Error saving account creation timestamp for account %s", account.Title)
 	}
 
-	creationDate := time.Unix(createdEpoch, 0).UTC().Format("January 2, 2006")
+	// Discord renders timestamps in the reader's own language.
+	creationDate := fmt.Sprintf("<t:%d:D>", createdEpoch)
+	age := i18n.T(locale, "age.ymd",
+		i18n.N(locale, "age.years", years), i18n.N(locale, "age.months", months), i18n.N(locale, "age.days", days))
 
 	embed := &discordgo.MessageEmbed{
-		Title:       fmt.Sprintf("%s - Account Age", account.Title),
-		Description: fmt.Sprintf("The account is %d years, %d months, and %d days old.", years, months, days),
+		Title:       i18n.T(locale, "accountage.title", account.Title),
+		Description: i18n.T(locale, "accountage.description", age),
 		Color:       0x00ff00,
 		Timestamp:   time.Now().Format(time.RFC3339),
 		Fields: []*discordgo.MessageEmbedField{
 			{
-				Name:   "Last Status",
-				Value:  string(account.LastStatus),
+				Name:   i18n.T(locale, "field.last_status"),
+				Value:  services.StatusName(locale, account.LastStatus),
 				Inline: true,
 			},
 			{
-				Name:   "VIP Status",
+				Name:   i18n.T(locale, "field.vip_status"),
 				Value:  vipStatus,
 				Inline: true,
 			},
 			{
-				Name:   "Creation Date",
+				Name:   i18n.T(locale, "field.creation_date"),
 				Value:  creationDate,
 				Inline: true,
 			},
 			{
-				Name:   "Account Age",
-				Value:  fmt.Sprintf("%d years, %d months, %d days", years, months, days),
+				Name:   i18n.T(locale, "field.account_age"),
+				Value:  age,
 				Inline: true,
 			},
 		},
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: "VIP status indicates priority access to Activision Support services",
+			Text: i18n.T(locale, "accountage.footer"),
 		},
 	}
 
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err = middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseUpdateMessage,
 		Data: &discordgo.InteractionResponseData{
 			Embeds:     []*discordgo.MessageEmbed{embed},
@@ -158,19 +181,6 @@ func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate
 	})
 	if err != nil {
 		logger.Log.WithError(err).Error("Error responding to interaction with account age")
-		respondToInteraction(s, i, "Error displaying account age. Please try again.")
-	}
-}
-
-func respondToInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: content,
-			Flags:   discordgo.MessageFlagsEphemeral,
-		},
-	})
-	if err != nil {
-		logger.Log.WithError(err).Error("Error responding to interaction")
+		middleware.Reply(s, i, i18n.T(locale, "accountage.display_error"))
 	}
 }
@@ -6,35 +6,44 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
 	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
 	"github.com/bradselph/CODStatusBot/services"
 	"github.com/bwmarrin/discordgo"
 )
 
+var Command = registry.Command{
+	Name:        "accountlogs",
+	Description: "View the status logs for an account",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Consulta el historial de estados de una cuenta",
+		discordgo.SpanishLATAM: "Consulta el historial de estados de una cuenta",
+		discordgo.PortugueseBR: "Veja o histórico de status de uma conta",
+	},
+	Handler: CommandAccountLogs,
+	Components: []registry.Route{
+		registry.Prefix("account_logs_", HandleAccountSelection),
+	},
+}
+
 func CommandAccountLogs(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	var userID string
-	if i.Member != nil {
-		userID = i.Member.User.ID
-	} else if i.User != nil {
-		userID = i.User.ID
-	} else {
-		logger.Log.Error("Interaction doesn't have Member or User")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
+	locale := services.InteractionLocale(i)
+	userID := middleware.Current(i).UserID
 
 	var accounts []models.Account
 	result := database.DB.Where("user_id = ?", userID).Find(&accounts)
 	if result.Error != nil {
 		logger.Log.WithError(result.Error).Error("Error fetching user accounts")
-		respondToInteraction(s, i, "Error fetching your accounts. Please try again.")
+		middleware.Reply(s, i, i18n.T(locale, "error.fetch_accounts"))
 		return
 	}
 
 	if len(accounts) == 0 {
-		respondToInteraction(s, i, "You don't have any monitored accounts.")
+		middleware.Reply(s, i, i18n.T(locale, "accounts.none"))
 		return
 	}
 
@@ -58,7 +67,7 @@ func CommandAccountLogs(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 	if len(currentRow) < 5 {
 		currentRow = append(currentRow, discordgo.Button{
-			Label:    "View All Logs",
+			Label:    i18n.T(locale, "accountlogs.view_all"),
 			Style:    discordgo.SuccessButton,
 			CustomID: "account_logs_all",
 		})
@@ -66,7 +75,7 @@ func CommandAccountLogs(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		components = append(components, discordgo.ActionsRow{Components: currentRow})
 		currentRow = []discordgo.MessageComponent{
 			discordgo.Button{
-				Label:    "View All Logs",
+				Label:    i18n.T(locale, "accountlogs.view_all"),
 				Style:    discordgo.SuccessButton,
 				CustomID: "account_logs_all",
 			},
@@ -75,10 +84,10 @@ func CommandAccountLogs(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 	components = append(components, discordgo.ActionsRow{Components: currentRow})
 
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err := middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content:    "Select an account to view its logs, or 'View All Logs' to see logs for all accounts:",
+			Content:    i18n.T(locale, "accountlogs.select"),
 			Flags:      discordgo.MessageFlagsEphemeral,
 			Components: components,
 		},
@@ -89,17 +98,18 @@ func CommandAccountLogs(s *discordgo.Session, i *discordgo.InteractionCreate) {
 }
 
 func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 	customID := i.MessageComponentData().CustomID
 
 	if customID == "account_logs_all" {
-		handleAllAccountLogs(s, i)
+		handleAllAccountLogs(s, i, locale)
 		return
 	}
 
 	accountID, err := strconv.Atoi(strings.TrimPrefix(customID, "account_logs_"))
 	if err != nil {
 		logger.Log.WithError(err).Error("Error parsing account ID")
-		respondToInteraction(s, i, "Error processing your selection. Please try again.")
+		middleware.Reply(s, i, i18n.T(locale, "error.selection"))
 		return
 	}
 
@@ -107,7 +117,7 @@ func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate
 	result := database.DB.First(&account, accountID)
 	if result.Error != nil {
 		logger.Log.WithError(result.Error).Error("Error fetching account")
-		respondToInteraction(s, i, "Error: Account not found or you don't have permission to view its logs.")
+		middleware.Reply(s, i, i18n.T(locale, "accountlogs.not_found"))
 		return
 	}
 
@@ -116,9 +126,9 @@ func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate
 		database.DB.Save(&account)
 	}
 
-	embed := createAccountLogEmbed(account)
+	embed := createAccountLogEmbed(locale, account)
 
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err = middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseUpdateMessage,
 		Data: &discordgo.InteractionResponseData{
 			Embeds:     []*discordgo.MessageEmbed{embed},
@@ -127,27 +137,18 @@ func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate
 	})
 	if err != nil {
 		logger.Log.WithError(err).Error("Error responding to interaction with account logs")
-		respondToInteraction(s, i, "Error displaying account logs. Please try again.")
+		middleware.Reply(s, i, i18n.T(locale, "accountlogs.display_error"))
 	}
 }
 
-func handleAllAccountLogs(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	var userID string
-	if i.Member != nil {
-		userID = i.Member.User.ID
-	} else if i.User != nil {
-		userID = i.User.ID
-	} else {
-		logger.Log.Error("Interaction doesn't have Member or User")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
+func handleAllAccountLogs(s *discordgo.Session, i *discordgo.InteractionCreate, locale string) {
+	userID := middleware.Current(i).UserID
 
 	var accounts []models.Account
 	result := database.DB.Where("user_id = ?", userID).Find(&accounts)
 	if result.Error != nil {
 		logger.Log.WithError(result.Error).Error("Error fetching user accounts")
-		respondToInteraction(s, i, "Error fetching your accounts. Please try again.")
+		middleware.Reply(s, i, i18n.T(locale, "error.fetch_accounts"))
 		return
 	}
 
@@ -158,7 +159,7 @@ func handleAllAccountLogs(s *discordgo.Session, i *discordgo.InteractionCreate)
 			database.DB.Save(&account)
 		}
 
-		embed := createAccountLogEmbed(account)
+		embed := createAccountLogEmbed(locale, account)
 		embeds = append(embeds, embed)
 	}
 
@@ -170,7 +171,7 @@ func handleAllAccountLogs(s *discordgo.Session, i *discordgo.InteractionCreate)
 
 		var err error
 		if j == 0 {
-			err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			err = middleware.Respond(s, i, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseUpdateMessage,
 				Data: &discordgo.InteractionResponseData{
 					Content:    "",
@@ -191,7 +192,7 @@ func handleAllAccountLogs(s *discordgo.Session, i *discordgo.InteractionCreate)
 	}
 }
 
-func createAccountLogEmbed(account models.Account) *discordgo.MessageEmbed {
+func createAccountLogEmbed(locale string, account models.Account) *discordgo.MessageEmbed {
 	var logs []models.Ban
 	database.DB.Where("account_id = ?", account.ID).Order("timestamp desc").Limit(15).Find(&logs)
 
@@ -203,33 +204,33 @@ func createAccountLogEmbed(account models.Account) *discordgo.MessageEmbed {
 	}
 
 	embed := &discordgo.MessageEmbed{
-		Title:       fmt.Sprintf("%s - Account History", account.Title),
-		Description: fmt.Sprintf("Recent account history (showing last %d entries)", len(validLogs)),
+		Title:       i18n.T(locale, "accountlogs.title", account.Title),
+		Description: i18n.N(locale, "accountlogs.description", len(validLogs)),
 		Color:       services.GetColorForStatus(account.LastStatus, account.IsExpiredCookie, account.IsCheckDisabled),
 		Fields:      make([]*discordgo.MessageEmbedField, 0),
 		Timestamp:   time.Now().Format(time.RFC3339),
 	}
 
 	if len(validLogs) == 0 {
-		embed.Description = "No valid history found for this account."
+		embed.Description = i18n.T(locale, "accountlogs.empty")
 		return embed
 	}
 
-	createdTime := "Unknown"
+	createdTime := i18n.T(locale, "common.unknown")
 	if account.Created > 0 {
-		createdTime = time.Unix(account.Created, 0).Format("Jan 02, 2006 15:04:05 MST")
+		createdTime = fmt.Sprintf("<t:%d:f>", account.Created)
 	}
 
-	lastCheckTime := "Never checked"
+	lastCheckTime := i18n.T(locale, "common.never_checked")
 	if account.LastCheck > 0 {
-		lastCheckTime = time.Unix(account.LastCheck, 0).Format("Jan 02, 2006 15:04:05 MST")
+		lastCheckTime = fmt.Sprintf("<t:%d:f>", account.LastCheck)
 	}
 
 	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-		Name: "Account Information",
-		Value: fmt.Sprintf("Current Status: %s\nChecks: %s\nCreated: %s\nLast Checked: %s",
-			account.LastStatus,
-			services.GetCheckStatus(account.IsCheckDisabled),
+		Name: i18n.T(locale, "accountlogs.info.name"),
+		Value: i18n.T(locale, "accountlogs.info.value",
+			services.StatusName(locale, account.LastStatus),
+			services.GetCheckStatus(locale, account.IsCheckDisabled),
 			createdTime,
 			lastCheckTime),
 		Inline: false,
@@ -244,24 +245,24 @@ func createAccountLogEmbed(account models.Account) *discordgo.MessageEmbed {
 
 		switch log.LogType {
 		case "account_added":
-			fieldValue.WriteString("Account added to monitoring\n")
+			fieldValue.WriteString(i18n.T(locale, "accountlogs.entry.added") + "\n")
 		case "status_change":
-			fieldValue.WriteString(fmt.Sprintf("%s -> %s\n", log.PreviousStatus, log.Status))
+			fieldValue.WriteString(fmt.Sprintf("%s -> %s\n", services.StatusName(locale, log.PreviousStatus), services.StatusName(locale, log.Status)))
 			if log.Message != "" {
 				fieldValue.WriteString(fmt.Sprintf("%s\n", log.Message))
 			}
 			if log.AffectedGames != "" {
-				fieldValue.WriteString(fmt.Sprintf("Affected Games: %s\n", log.AffectedGames))
+				fieldValue.WriteString(i18n.T(locale, "accountlogs.entry.affected_games", log.AffectedGames) + "\n")
 			}
 			if log.TempBanDuration != "" {
-				fieldValue.WriteString(fmt.Sprintf("Duration: %s\n", log.TempBanDuration))
+				fieldValue.WriteString(i18n.T(locale, "accountlogs.entry.duration", log.TempBanDuration) + "\n")
 			}
 		case "check_status":
 			fieldValue.WriteString(log.Message + "\n")
 		case "cookie_update":
-			fieldValue.WriteString("SSO Cookie updated\n")
+			fieldValue.WriteString(i18n.T(locale, "accountlogs.entry.cookie_update") + "\n")
 		case "error":
-			fieldValue.WriteString(fmt.Sprintf("Error: %s\n", log.ErrorDetails))
+			fieldValue.WriteString(i18n.T(locale, "accountlogs.entry.error", log.ErrorDetails) + "\n")
 		}
 
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
@@ -280,38 +281,3 @@ func formatTimeField(timestamp int64) string {
 	}
 	return time.Unix(timestamp, 0).Format("Jan 02, 2006 15:04:05 MST")
 }
-
-func respondToInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: content,
-			Flags:   discordgo.MessageFlagsEphemeral,
-		},
-	})
-
-	if err != nil {
-		logger.Log.WithError(err).Error("Error responding with channel message, trying update message")
-
-		err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseUpdateMessage,
-			Data: &discordgo.InteractionResponseData{
-				Content: content,
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-
-		if err != nil {
-			logger.Log.WithError(err).Error("Error updating message, trying followup")
-
-			_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-				Content: content,
-				Flags:   discordgo.MessageFlagsEphemeral,
-			})
-
-			if err != nil {
-				logger.Log.WithError(err).Error("All response methods failed")
-			}
-		}
-	}
-}
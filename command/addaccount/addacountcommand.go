@@ -5,8 +5,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
 	"github.com/bradselph/CODStatusBot/configuration"
 	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
 	"github.com/bradselph/CODStatusBot/services"
@@ -15,69 +18,83 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-var (
-	rateLimit time.Duration
-)
-
-func init() {
-	cfg := configuration.Get()
-	rateLimit = cfg.RateLimits.CheckNow
+var Command = registry.Command{
+	Name:        "addaccount",
+	Description: "Add a new account to monitor",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Añade una cuenta nueva para vigilar",
+		discordgo.SpanishLATAM: "Añade una cuenta nueva para vigilar",
+		discordgo.PortugueseBR: "Adicione uma nova conta para monitorar",
+	},
+	// Users on the bot's default captcha key may add one account per
+	// check-now window.
+	RateLimit: middleware.Policy{
+		Dynamic: func(*middleware.Request) []services.RateLimitPolicy {
+			return []services.RateLimitPolicy{{Limit: 1, Window: configuration.Get().RateLimits.CheckNow}}
+		},
+		Exempt:  (*middleware.Request).HasOwnCaptchaKey,
+		Message: "addaccount.wait",
+	},
+	Handler: CommandAddAccount,
+	Modals: []registry.Route{
+		registry.Exact("add_account_modal", HandleModalSubmit),
+	},
 }
 
-func getMaxAccounts(hasCustomKey bool) int {
-	cfg := configuration.Get()
-	if hasCustomKey {
-		return cfg.RateLimits.PremiumMaxAccounts
+// accountLimitMessage returns the reply for a user who already monitors as
+// many accounts as their tier allows, or "" if they may add another.
+func accountLimitMessage(locale string, settings models.UserSettings, accountCount int64) string {
+	tier := services.UserTier(settings)
+	maxAccounts := tier.Quotas().MaxAccounts
+	if maxAccounts == 0 || accountCount < int64(maxAccounts) {
+		return ""
 	}
-	return cfg.RateLimits.DefaultMaxAccounts
+
+	msg := i18n.T(locale, "addaccount.limit", maxAccounts) + " "
+	if tier == services.TierFree {
+		msg += i18n.T(locale, "addaccount.limit_upgrade")
+	} else {
+		msg += i18n.T(locale, "addaccount.limit_remove")
+	}
+	return msg
 }
 
 func CommandAddAccount(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	userID, err := services.GetUserID(i)
-	if userID == "" {
-		logger.Log.Error("Failed to get user ID")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
+	locale := services.InteractionLocale(i)
+	userID := middleware.Current(i).UserID
 
-	userSettings, err := services.GetUserSettings(userID)
+	userSettings, err := middleware.Current(i).Settings()
 	if err != nil {
 		logger.Log.WithError(err).Error("Error fetching user settings")
-		respondToInteraction(s, i, "Error fetching user settings. Please try again.")
-		return
-	}
-
-	hasCustomKey := userSettings.CapSolverAPIKey != "" || userSettings.EZCaptchaAPIKey != "" || userSettings.TwoCaptchaAPIKey != ""
-	if !hasCustomKey && !checkRateLimit(userID) {
-		respondToInteraction(s, i, fmt.Sprintf("Please wait %v before adding another account.", rateLimit))
+		middleware.Reply(s, i, i18n.T(locale, "error.fetch_settings"))
 		return
 	}
 
 	if !services.IsServiceEnabled(userSettings.PreferredCaptchaProvider) {
-		msg := fmt.Sprintf("Your preferred captcha service (%s) is currently disabled. ", userSettings.PreferredCaptchaProvider)
+		msg := i18n.T(locale, "addaccount.provider_disabled", userSettings.PreferredCaptchaProvider) + " "
 		if services.IsServiceEnabled("ezcaptcha") {
-			msg += "Please set up EZCaptcha using /setcaptchaservice before adding accounts."
+			msg += i18n.T(locale, "addaccount.setup_provider", "EZCaptcha")
 		} else if services.IsServiceEnabled("2captcha") {
-			msg += "Please set up 2Captcha using /setcaptchaservice before adding accounts."
+			msg += i18n.T(locale, "addaccount.setup_provider", "2Captcha")
 		} else if services.IsServiceEnabled("capsolver") {
-			msg += "Please set up Capsolver using /setcaptchaservice before adding accounts."
+			msg += i18n.T(locale, "addaccount.setup_provider", "Capsolver")
 		} else {
-			msg += "No captcha services are currently available. Please try again later."
+			msg += i18n.T(locale, "addaccount.no_provider")
 		}
-		respondToInteraction(s, i, msg)
+		middleware.Reply(s, i, msg)
 		return
 	}
 
-	if userSettings.CapSolverAPIKey != "" || userSettings.EZCaptchaAPIKey != "" || userSettings.TwoCaptchaAPIKey != "" {
+	if middleware.Current(i).HasOwnCaptchaKey() {
 		_, balance, err := services.GetUserCaptchaKey(userID)
 		if err != nil {
 			logger.Log.WithError(err).Error("Error checking captcha balance")
-			respondToInteraction(s, i, "Error validating your captcha API key. Please check your key using /setcaptchaservice.")
+			middleware.Reply(s, i, i18n.T(locale, "addaccount.key_error"))
 			return
 		}
 
 		if balance <= 0 {
-			respondToInteraction(s, i, fmt.Sprintf("Your captcha balance (%.2f) is too low to add new accounts. Please recharge your balance.", balance))
+			middleware.Reply(s, i, i18n.T(locale, "addaccount.low_balance", balance))
 			return
 		}
 	}
@@ -85,40 +102,32 @@ func CommandAddAccount(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	var accountCount int64
 	if err := database.DB.Model(&models.Account{}).Where("user_id = ?", userID).Count(&accountCount).Error; err != nil {
 		logger.Log.WithError(err).Error("Error counting user accounts")
-		respondToInteraction(s, i, "Error checking account limit. Please try again.")
+		middleware.Reply(s, i, i18n.T(locale, "error.account_limit_check"))
 		return
 	}
 
-	maxAccounts := getMaxAccounts(hasCustomKey)
-
-	if accountCount >= int64(maxAccounts) {
-		msg := fmt.Sprintf("You've reached the maximum limit of %d accounts.", maxAccounts)
-		if !hasCustomKey {
-			msg += " Upgrade to premium by adding your own API key using /setcaptchaservice to increase your account limit!"
-		} else {
-			msg += " Please remove some accounts before adding new ones."
-		}
-		respondToInteraction(s, i, msg)
+	if msg := accountLimitMessage(locale, userSettings, accountCount); msg != "" {
+		middleware.Reply(s, i, msg)
 		return
 	}
 
-	showAddAccountModal(s, i)
+	showAddAccountModal(s, i, locale)
 }
 
-func showAddAccountModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+func showAddAccountModal(s *discordgo.Session, i *discordgo.InteractionCreate, locale string) {
+	err := middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseModal,
 		Data: &discordgo.InteractionResponseData{
 			CustomID: "add_account_modal",
-			Title:    "Add New Account",
+			Title:    i18n.T(locale, "addaccount.modal.title"),
 			Components: []discordgo.MessageComponent{
 				discordgo.ActionsRow{
 					Components: []discordgo.MessageComponent{
 						discordgo.TextInput{
 							CustomID:    "account_title",
-							Label:       "Account Title",
+							Label:       i18n.T(locale, "modal.account_title.label"),
 							Style:       discordgo.TextInputShort,
-							Placeholder: "Enter a name for this account",
+							Placeholder: i18n.T(locale, "modal.account_title.placeholder"),
 							Required:    true,
 							MinLength:   3,
 							MaxLength:   40,
@@ -129,9 +138,9 @@ func showAddAccountModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
 					Components: []discordgo.MessageComponent{
 						discordgo.TextInput{
 							CustomID:    "sso_cookie",
-							Label:       "SSO Cookie",
+							Label:       i18n.T(locale, "modal.sso_cookie.label"),
 							Style:       discordgo.TextInputParagraph,
-							Placeholder: "Enter the SSO cookie for this account",
+							Placeholder: i18n.T(locale, "modal.sso_cookie.placeholder"),
 							Required:    true,
 							MinLength:   60,
 							MaxLength:   95,
@@ -147,26 +156,16 @@ func showAddAccountModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
 }
 
 func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 	data := i.ModalSubmitData()
-	userID, _ := services.GetUserID(i)
-
-	if userID == "" {
-		logger.Log.Error("Failed to get user ID")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
+	userID := middleware.Current(i).UserID
 
 	title := utils.SanitizeInput(strings.TrimSpace(data.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value))
 	ssoCookie := strings.TrimSpace(data.Components[1].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value)
 
 	logger.Log.Infof("Attempting to add account. Title: %s, SSO Cookie length: %d", title, len(ssoCookie))
 
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Flags: discordgo.MessageFlagsEphemeral,
-		},
-	})
+	err := middleware.Defer(s, i)
 	if err != nil {
 		logger.Log.WithError(err).Error("Error sending deferred response")
 		return
@@ -175,47 +174,38 @@ func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	validationResult, err := services.ValidateAndGetAccountInfo(ssoCookie)
 	if err != nil {
 		logger.Log.WithError(err).Error("Error validating account")
-		sendFollowupMessage(s, i, fmt.Sprintf("Error processing account: %v", err))
+		middleware.Followup(s, i, "", services.UserErrorEmbed(locale, err))
 		return
 	}
 
 	if !validationResult.IsValid {
 		logger.Log.Error("Invalid SSO cookie provided")
-		sendFollowupMessage(s, i, "Invalid SSO cookie. Please make sure you've copied the entire cookie value.")
+		middleware.Followup(s, i, i18n.T(locale, "error.invalid_cookie"))
 		return
 	}
 
 	channelID := getChannelID(s, i)
 	if channelID == "" {
-		sendFollowupMessage(s, i, "An error occurred while processing your request.")
+		middleware.Followup(s, i, i18n.T(locale, "error.processing"))
 		return
 	}
 
 	userSettings, err := services.GetUserSettings(userID)
 	if err != nil {
 		logger.Log.WithError(err).Error("Error fetching user settings")
-		sendFollowupMessage(s, i, "Error fetching user settings. Please try again.")
+		middleware.Followup(s, i, i18n.T(locale, "error.fetch_settings"))
 		return
 	}
 
 	var accountCount int64
 	if err := database.DB.Model(&models.Account{}).Where("user_id = ?", userID).Count(&accountCount).Error; err != nil {
 		logger.Log.WithError(err).Error("Error counting user accounts")
-		sendFollowupMessage(s, i, "Error checking account limit. Please try again.")
+		middleware.Followup(s, i, i18n.T(locale, "error.account_limit_check"))
 		return
 	}
 
-	hasCustomKey := userSettings.CapSolverAPIKey != "" || userSettings.EZCaptchaAPIKey != "" || userSettings.TwoCaptchaAPIKey != ""
-	maxAccounts := getMaxAccounts(hasCustomKey)
-
-	if accountCount >= int64(maxAccounts) {
-		msg := fmt.Sprintf("You've reached the maximum limit of %d accounts.", maxAccounts)
-		if !hasCustomKey {
-			msg += " Upgrade to premium by adding your own API key using /setcaptchaservice to increase your account limit!"
-		} else {
-			msg += " Please remove some accounts before adding new ones."
-		}
-		sendFollowupMessage(s, i, msg)
+	if msg := accountLimitMessage(locale, userSettings, accountCount); msg != "" {
+		middleware.Followup(s, i, msg)
 		return
 	}
 
@@ -234,7 +224,7 @@ func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 	if err := database.DB.Create(&account).Error; err != nil {
 		logger.Log.WithError(err).WithFields(logrus.Fields{"userID": userID, "title": title}).Error("Error creating account")
-		sendFollowupMessage(s, i, "Error creating account. Please try again.")
+		middleware.Followup(s, i, i18n.T(locale, "addaccount.create_error"))
 		return
 	}
 
@@ -250,52 +240,54 @@ func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		logger.Log.WithError(err).Error("Failed to create account creation log")
 	}
 
-	vipStatus := "Regular Account"
+	vipStatus := i18n.T(locale, "vip.no")
 	if account.IsVIP {
-		vipStatus = "VIP Account"
+		vipStatus = i18n.T(locale, "vip.yes")
 	}
 
-	remainingSlots := maxAccounts - int(accountCount) - 1
-	slotInfo := fmt.Sprintf("\nYou have %d account slot(s) remaining.", remainingSlots)
+	description := i18n.T(locale, "addaccount.success.description", account.Title)
+	if maxAccounts := services.UserQuotas(userSettings).MaxAccounts; maxAccounts > 0 {
+		description += "\n" + i18n.N(locale, "addaccount.slots", maxAccounts-int(accountCount)-1)
+	}
 
 	embed := &discordgo.MessageEmbed{
-		Title:       "Account Added Successfully",
-		Description: fmt.Sprintf("Account '%s' has been added to monitoring.%s", account.Title, slotInfo),
+		Title:       i18n.T(locale, "addaccount.success.title"),
+		Description: description,
 		Color:       0x00ff00,
 		Fields: []*discordgo.MessageEmbedField{
 			{
-				Name:   "Account Type",
+				Name:   i18n.T(locale, "field.account_type"),
 				Value:  vipStatus,
 				Inline: true,
 			},
 			{
-				Name:   "Cookie Expiration",
-				Value:  services.FormatExpirationTime(account.SSOCookieExpiration),
+				Name:   i18n.T(locale, "field.cookie_expiration"),
+				Value:  fmt.Sprintf("<t:%d:R>", account.SSOCookieExpiration),
 				Inline: true,
 			},
 			{
-				Name:   "Account Age",
-				Value:  formatAccountAge(time.Unix(account.Created, 0)),
+				Name:   i18n.T(locale, "field.account_age"),
+				Value:  formatAccountAge(locale, time.Unix(account.Created, 0)),
 				Inline: true,
 			},
 			{
-				Name:   "Notification Type",
+				Name:   i18n.T(locale, "field.notification_type"),
 				Value:  account.NotificationType,
 				Inline: true,
 			},
 		},
 		Timestamp: time.Now().Format(time.RFC3339),
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Use /listaccounts to view all your monitored accounts",
+			Text: i18n.T(locale, "footer.listaccounts"),
 		},
 	}
 
-	sendFollowupMessageWithEmbed(s, i, "Account added successfully!", embed)
+	middleware.Followup(s, i, i18n.T(locale, "addaccount.success.content"), embed)
 
 	go func() {
 		time.Sleep(2 * time.Second)
 
-		status, err := services.CheckAccount(ssoCookie, userID, "")
+		status, err := services.CheckAccount(services.InteractionContext(i), ssoCookie, userID, "")
 		if err != nil {
 			logger.Log.WithError(err).Error("Error performing initial status check")
 			return
@@ -311,49 +303,15 @@ func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	}()
 }
 
-func sendFollowupMessage(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
-	_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Content: content,
-		Flags:   discordgo.MessageFlagsEphemeral,
-	})
-	if err != nil {
-		logger.Log.WithError(err).Error("Error sending followup message")
-	}
-}
-
-func sendFollowupMessageWithEmbed(s *discordgo.Session, i *discordgo.InteractionCreate, content string, embed *discordgo.MessageEmbed) {
-	_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Content: content,
-		Embeds:  []*discordgo.MessageEmbed{embed},
-		Flags:   discordgo.MessageFlagsEphemeral,
-	})
-	if err != nil {
-		logger.Log.WithError(err).Error("Error sending followup message with embed")
-	}
-}
-
-func formatAccountAge(created time.Time) string {
+func formatAccountAge(locale string, created time.Time) string {
 	age := time.Since(created)
 	years := int(age.Hours() / 24 / 365)
 	months := int(age.Hours()/24/30.44) % 12
-	return fmt.Sprintf("%d years, %d months", years, months)
-}
-
-func getUserID(i *discordgo.InteractionCreate) string {
-	if i.Member != nil {
-		return i.Member.User.ID
-	}
-	if i.User != nil {
-		return i.User.ID
-	}
-	return ""
+	return i18n.T(locale, "age.ym", i18n.N(locale, "age.years", years), i18n.N(locale, "age.months", months))
 }
 
 func getChannelID(s *discordgo.Session, i *discordgo.InteractionCreate) string {
-	userID := getUserID(i)
-	if userID == "" {
-		return ""
-	}
+	userID := middleware.Current(i).UserID
 
 	if i.ChannelID != "" {
 		return i.ChannelID
@@ -366,38 +324,3 @@ func getChannelID(s *discordgo.Session, i *discordgo.InteractionCreate) string {
 	}
 	return channel.ID
 }
-
-func checkRateLimit(userID string) bool {
-	var userSettings models.UserSettings
-	if err := database.DB.Where("user_id = ?", userID).First(&userSettings).Error; err != nil {
-		logger.Log.WithError(err).Error("Error fetching user settings")
-		return false
-	}
-
-	userSettings.EnsureMapsInitialized()
-	now := time.Now()
-	lastAddTime := userSettings.LastCommandTimes["add_account"]
-
-	if lastAddTime.IsZero() || time.Since(lastAddTime) >= rateLimit {
-		userSettings.LastCommandTimes["add_account"] = now
-		if err := database.DB.Save(&userSettings).Error; err != nil {
-			logger.Log.WithError(err).Error("Error saving user settings")
-			return false
-		}
-		return true
-	}
-	return false
-}
-
-func respondToInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: message,
-			Flags:   discordgo.MessageFlagsEphemeral,
-		},
-	})
-	if err != nil {
-		logger.Log.WithError(err).Error("Error responding to interaction")
-	}
-}
@@ -0,0 +1,450 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
+	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bradselph/CODStatusBot/services"
+	"github.com/bwmarrin/discordgo"
+	"gorm.io/gorm"
+)
+
+// maxListed caps the accounts listed in one reply, to stay inside Discord's
+// embed limits.
+const maxListed = 20
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+var Command = registry.Command{
+	Name:        "admin",
+	Description: "Bot administration (Admins only)",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Administración del bot (solo administradores)",
+		discordgo.SpanishLATAM: "Administración del bot (solo administradores)",
+		discordgo.PortugueseBR: "Administração do bot (somente administradores)",
+	},
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "user",
+			Description: "Look up a user's settings, plan and accounts",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "User to look up", Required: true},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "account",
+			Description: "Find accounts by Activision ID",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "activision_id", Description: "Activision ID, or the start of one", Required: true},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "recheck",
+			Description: "Check an account right away",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionInteger, Name: "account_id", Description: "Account ID", Required: true},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "checks",
+			Description: "Enable or disable checks on any account",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionInteger, Name: "account_id", Description: "Account ID", Required: true},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "enabled", Description: "Whether the account is checked", Required: true},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "reason", Description: "Why, required when disabling"},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "ratelimits",
+			Description: "Reset a user's rate limits",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "User whose limits to reset", Required: true},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "captcha",
+			Description: "Show the captcha providers' status and balances",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "scheduler",
+			Description: "Pause, resume or inspect scheduled checks",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "What to do",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Status", Value: "status"},
+						{Name: "Pause", Value: "pause"},
+						{Name: "Resume", Value: "resume"},
+					},
+				},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "reason", Description: "Why, required when pausing"},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "queue",
+			Description: "Show the notification queue depth",
+		},
+	},
+	Permissions: discordgo.PermissionAdministrator,
+	AdminOnly:   true,
+	Handler:     CommandAdmin,
+}
+
+// options indexes a subcommand's options by name.
+type options map[string]*discordgo.ApplicationCommandInteractionDataOption
+
+func (o options) string(name string) string {
+	if option, ok := o[name]; ok {
+		return strings.TrimSpace(option.StringValue())
+	}
+	return ""
+}
+
+func CommandAdmin(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		middleware.Reply(s, i, "Pick a subcommand.")
+		return
+	}
+
+	sub := data.Options[0]
+	opts := make(options, len(sub.Options))
+	for _, option := range sub.Options {
+		opts[option.Name] = option
+	}
+
+	switch sub.Name {
+	case "user":
+		lookupUser(s, i, opts["user"].UserValue(nil).ID)
+	case "account":
+		lookupAccounts(s, i, opts.string("activision_id"))
+	case "recheck":
+		recheck(s, i, uint(opts["account_id"].IntValue()))
+	case "checks":
+		setChecks(s, i, uint(opts["account_id"].IntValue()), opts["enabled"].BoolValue(), opts.string("reason"))
+	case "ratelimits":
+		resetRateLimits(s, i, opts["user"].UserValue(nil).ID)
+	case "captcha":
+		captchaStatus(s, i)
+	case "scheduler":
+		scheduler(s, i, opts.string("action"), opts.string("reason"))
+	case "queue":
+		queueDepth(s, i)
+	default:
+		middleware.Reply(s, i, "Unknown subcommand.")
+	}
+}
+
+func lookupUser(s *discordgo.Session, i *discordgo.InteractionCreate, userID string) {
+	var settings models.UserSettings
+	if err := database.DB.Where("user_id = ?", userID).First(&settings).Error; err != nil {
+		replyLookupError(s, i, "user", err)
+		return
+	}
+
+	var accounts []models.Account
+	if err := database.DB.Where("user_id = ?", userID).Order("id").Find(&accounts).Error; err != nil {
+		logger.Log.WithError(err).Errorf("Error fetching accounts of user %s", userID)
+		middleware.Reply(s, i, "Error fetching the user's accounts.")
+		return
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{Name: "Plan", Value: string(services.UserTier(settings)), Inline: true},
+		{Name: "Own captcha key", Value: yesNo(services.HasOwnCaptchaKey(settings)), Inline: true},
+		{Name: "Provider", Value: orNone(settings.PreferredCaptchaProvider), Inline: true},
+		{Name: "Check interval", Value: fmt.Sprintf("%d minutes", settings.CheckInterval), Inline: true},
+		{Name: "Notifications", Value: orNone(settings.NotificationType), Inline: true},
+		{Name: "Language", Value: orNone(settings.Locale), Inline: true},
+		{Name: "Installed", Value: installation(settings), Inline: true},
+		{Name: "Reachable", Value: reachability(settings), Inline: true},
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("User %s", userID),
+		Description: fmt.Sprintf("<@%s>\n\n**Accounts (%d)**\n%s", userID, len(accounts), accountLines(accounts, false)),
+		Color:       0x00ff00,
+		Fields:      fields,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+	record(i, "get_user", userID, 0, fmt.Sprintf("%d accounts", len(accounts)), nil)
+	middleware.Reply(s, i, "", embed)
+}
+
+func lookupAccounts(s *discordgo.Session, i *discordgo.InteractionCreate, activisionID string) {
+	if activisionID == "" {
+		middleware.Reply(s, i, "Give an Activision ID to look up.")
+		return
+	}
+
+	var accounts []models.Account
+	err := database.DB.Where("activision_id LIKE ?", likeEscaper.Replace(activisionID)+"%").
+		Order("activision_id").Find(&accounts).Error
+	if err != nil {
+		logger.Log.WithError(err).Error("Error looking up accounts by Activision ID")
+		middleware.Reply(s, i, "Error looking up accounts.")
+		return
+	}
+	if len(accounts) == 0 {
+		middleware.Reply(s, i, fmt.Sprintf("No accounts match Activision ID `%s`.", activisionID))
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Accounts matching %s", activisionID),
+		Description: accountLines(accounts, true),
+		Color:       0x00ff00,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+	middleware.Reply(s, i, "", embed)
+}
+
+func recheck(s *discordgo.Session, i *discordgo.InteractionCreate, accountID uint) {
+	account, ok := findAccount(s, i, accountID)
+	if !ok {
+		return
+	}
+
+	if err := middleware.Defer(s, i); err != nil {
+		logger.Log.WithError(err).Error("Error sending deferred response")
+		return
+	}
+
+	settings, err := services.GetUserSettings(account.UserID)
+	if err != nil {
+		logger.Log.WithError(err).Errorf("Error fetching settings of user %s", account.UserID)
+		record(i, "force_check", account.UserID, account.ID, "", err)
+		middleware.Reply(s, i, "Error fetching the owner's settings.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(services.InteractionContext(i), 2*time.Minute)
+	defer cancel()
+
+	previous := account.LastStatus
+	status, err := services.ForceCheck(ctx, s, account, settings)
+	if err != nil {
+		category := services.ClassifyCheckError(err)
+		record(i, "force_check", account.UserID, account.ID, fmt.Sprintf("category=%s", category), err)
+		middleware.Reply(s, i, fmt.Sprintf("Check of account #%d failed (%s): %v", account.ID, category, err))
+		return
+	}
+
+	record(i, "force_check", account.UserID, account.ID, fmt.Sprintf("status=%s previous=%s", status, previous), nil)
+	middleware.Reply(s, i, fmt.Sprintf("Account #%d (%s) checked: %s, was %s.", account.ID, account.Title, status, previous))
+}
+
+func setChecks(s *discordgo.Session, i *discordgo.InteractionCreate, accountID uint, enabled bool, reason string) {
+	if !enabled && reason == "" {
+		middleware.Reply(s, i, "Give a reason when disabling checks.")
+		return
+	}
+
+	account, ok := findAccount(s, i, accountID)
+	if !ok {
+		return
+	}
+
+	message, err := services.SetAccountChecks(&account, enabled, reason, "admin")
+	record(i, "set_checks", account.UserID, account.ID, message, err)
+	if err != nil {
+		logger.Log.WithError(err).Errorf("Error updating checks on account %d", account.ID)
+		middleware.Reply(s, i, "Error updating the account.")
+		return
+	}
+
+	state := "enabled"
+	if !enabled {
+		state = "disabled"
+	}
+	middleware.Reply(s, i, fmt.Sprintf("Checks %s on account #%d (%s).", state, account.ID, account.Title))
+}
+
+func resetRateLimits(s *discordgo.Session, i *discordgo.InteractionCreate, userID string) {
+	cleared, err := services.ResetUserRateLimits(userID)
+	record(i, "clear_rate_limits", userID, 0, fmt.Sprintf("%d entries cleared", cleared), err)
+	if err != nil {
+		logger.Log.WithError(err).Errorf("Error resetting rate limits of user %s", userID)
+		middleware.Reply(s, i, "Error resetting the rate limits.")
+		return
+	}
+	middleware.Reply(s, i, fmt.Sprintf("Reset %d rate limits of <@%s>.", cleared, userID))
+}
+
+func captchaStatus(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if err := middleware.Defer(s, i); err != nil {
+		logger.Log.WithError(err).Error("Error sending deferred response")
+		return
+	}
+
+	report := services.HealthReport{Status: services.HealthOK, Time: time.Now()}
+	for _, component := range services.CaptchaProviderHealth() {
+		component.Critical = false
+		report.Components = append(report.Components, component)
+	}
+	if len(report.Components) > 0 {
+		report.Status = report.Components[0].Status
+	}
+
+	embed := services.HealthReportEmbed(report)
+	embed.Title = "Captcha providers"
+	if open := services.OpenCircuitBreakers(); len(open) > 0 {
+		embed.Description += "\nOpen circuit breakers: " + strings.Join(open, ", ")
+	}
+	middleware.Reply(s, i, "", embed)
+}
+
+func scheduler(s *discordgo.Session, i *discordgo.InteractionCreate, action, reason string) {
+	userID := middleware.Current(i).UserID
+
+	switch action {
+	case "pause", "resume":
+		paused := action == "pause"
+		if paused && reason == "" {
+			middleware.Reply(s, i, "Give a reason when pausing the scheduler.")
+			return
+		}
+		_, err := services.SetSchedulerPaused(paused, userID, reason)
+		record(i, action+"_scheduler", "", 0, reason, err)
+		if err != nil {
+			logger.Log.WithError(err).Error("Error changing the scheduler pause")
+			middleware.Reply(s, i, "Error updating the scheduler.")
+			return
+		}
+		if paused {
+			middleware.Reply(s, i, "Scheduled checks paused on every instance. Manual checks still run.")
+		} else {
+			middleware.Reply(s, i, "Scheduled checks resumed.")
+		}
+	default:
+		state, err := services.GetSchedulerPause()
+		if err != nil {
+			logger.Log.WithError(err).Error("Error loading the scheduler pause")
+			middleware.Reply(s, i, "Error loading the scheduler state.")
+			return
+		}
+
+		var lines []string
+		if state.Paused {
+			lines = append(lines, fmt.Sprintf("Paused by <@%s> <t:%d:R>: %s", state.ChangedBy, state.ChangedAt.Unix(), state.Reason))
+		} else {
+			lines = append(lines, "Running.")
+		}
+		if services.IsCheckingPausedForDrift() {
+			lines = append(lines, "Also held back by schema drift awaiting acknowledgement.")
+		}
+		if open := services.OpenCircuitBreakers(); len(open) > 0 {
+			lines = append(lines, "Open circuit breakers: "+strings.Join(open, ", "))
+		}
+		middleware.Reply(s, i, strings.Join(lines, "\n"))
+	}
+}
+
+func queueDepth(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	queued, undelivered, err := services.NotificationBacklog(services.InteractionContext(i))
+	if err != nil {
+		logger.Log.WithError(err).Error("Error counting undelivered messages")
+		middleware.Reply(s, i, fmt.Sprintf("%d notifications queued on this instance; undelivered messages unavailable.", queued))
+		return
+	}
+	middleware.Reply(s, i, fmt.Sprintf("%d notifications queued on this instance, %d messages waiting for delivery.", queued, undelivered))
+}
+
+func findAccount(s *discordgo.Session, i *discordgo.InteractionCreate, accountID uint) (models.Account, bool) {
+	var account models.Account
+	if err := database.DB.First(&account, accountID).Error; err != nil {
+		replyLookupError(s, i, "account", err)
+		return account, false
+	}
+	return account, true
+}
+
+func replyLookupError(s *discordgo.Session, i *discordgo.InteractionCreate, what string, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		middleware.Reply(s, i, fmt.Sprintf("No such %s.", what))
+		return
+	}
+	logger.Log.WithError(err).Errorf("Error looking up %s", what)
+	middleware.Reply(s, i, fmt.Sprintf("Error looking up the %s.", what))
+}
+
+// record writes the action to the admin audit log under the invoking user.
+func record(i *discordgo.InteractionCreate, action, targetUserID string, targetAccountID uint, details string, err error) {
+	services.RecordAdminCommand(middleware.Current(i).UserID, action, targetUserID, targetAccountID, details, err)
+}
+
+func accountLines(accounts []models.Account, withOwner bool) string {
+	if len(accounts) == 0 {
+		return "None"
+	}
+
+	lines := make([]string, 0, len(accounts))
+	for n, account := range accounts {
+		if n == maxListed {
+			lines = append(lines, fmt.Sprintf("…and %d more", len(accounts)-maxListed))
+			break
+		}
+		line := fmt.Sprintf("`#%d` **%s** %s, %s", account.ID, account.Title, orNone(account.ActivisionID), account.LastStatus)
+		if withOwner {
+			line += fmt.Sprintf(", owner <@%s>", account.UserID)
+		}
+		if account.IsCheckDisabled {
+			line += ", checks disabled: " + orNone(account.DisabledReason)
+		}
+		if account.IsExpiredCookie {
+			line += ", cookie expired"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func installation(settings models.UserSettings) string {
+	if settings.InstallationType == "" {
+		return "unknown"
+	}
+	if settings.InstallationGuildID != "" {
+		return settings.InstallationType + " " + settings.InstallationGuildID
+	}
+	return settings.InstallationType
+}
+
+func reachability(settings models.UserSettings) string {
+	if !settings.IsUnreachable {
+		return fmt.Sprintf("yes (%d failures)", settings.MessageFailures)
+	}
+	return fmt.Sprintf("no, since <t:%d:R>", settings.UnreachableSince.Unix())
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
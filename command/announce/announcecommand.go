@@ -0,0 +1,383 @@
+package announce
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bradselph/CODStatusBot/services"
+	"github.com/bradselph/CODStatusBot/utils"
+	"github.com/bwmarrin/discordgo"
+	"gorm.io/gorm"
+)
+
+// listLimit is how many announcements /announce list shows.
+const listLimit = 10
+
+var audienceNames = map[string]string{
+	services.AudienceAll:    "All users",
+	services.AudienceServer: "Server installs",
+	services.AudienceDirect: "Direct installs",
+	services.AudienceOwnKey: "Users with their own captcha key",
+	services.AudienceBanned: "Users with banned accounts",
+	services.AudienceGuild:  "One guild",
+}
+
+var idOption = &discordgo.ApplicationCommandOption{
+	Type:        discordgo.ApplicationCommandOptionInteger,
+	Name:        "id",
+	Description: "Announcement ID",
+	Required:    true,
+}
+
+var Command = registry.Command{
+	Name:        "announce",
+	Description: "Create and manage announcements (Developer only)",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Crea y gestiona anuncios (solo desarrollador)",
+		discordgo.SpanishLATAM: "Crea y gestiona anuncios (solo desarrollador)",
+		discordgo.PortugueseBR: "Crie e gerencie anúncios (somente desenvolvedor)",
+	},
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "create",
+			Description: "Write an announcement for an audience, now or later",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "audience",
+					Description: "Who receives it",
+					Required:    true,
+					Choices:     audienceChoices(),
+				},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "guild", Description: "Guild ID, for the guild audience"},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "send_at", Description: "When to send, as 2006-01-02 15:04 in UTC (default now)"},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "list",
+			Description: "Show the latest announcements",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "progress",
+			Description: "Show an announcement's delivery progress",
+			Options:     []*discordgo.ApplicationCommandOption{idOption},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "edit",
+			Description: "Change an announcement, including messages already sent",
+			Options:     []*discordgo.ApplicationCommandOption{idOption},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "recall",
+			Description: "Stop an announcement and delete the messages already sent",
+			Options:     []*discordgo.ApplicationCommandOption{idOption},
+		},
+	},
+	Permissions:   discordgo.PermissionAdministrator,
+	DeveloperOnly: true,
+	Handler:       CommandAnnounce,
+	Modals: []registry.Route{
+		registry.Prefix("announce_create_", HandleCreateSubmit),
+		registry.Prefix("announce_edit_", HandleEditSubmit),
+	},
+}
+
+func audienceChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(services.Audiences))
+	for _, audience := range services.Audiences {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: audienceNames[audience], Value: audience})
+	}
+	return choices
+}
+
+func CommandAnnounce(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		middleware.Reply(s, i, "Pick a subcommand.")
+		return
+	}
+
+	sub := data.Options[0]
+	var audience, guildID, sendAt string
+	var id uint
+	for _, option := range sub.Options {
+		switch option.Name {
+		case "audience":
+			audience = option.StringValue()
+		case "guild":
+			guildID = strings.TrimSpace(option.StringValue())
+		case "send_at":
+			sendAt = option.StringValue()
+		case "id":
+			id = uint(option.IntValue())
+		}
+	}
+
+	switch sub.Name {
+	case "create":
+		showCreateModal(s, i, audience, guildID, sendAt)
+	case "list":
+		listAnnouncements(s, i)
+	case "progress":
+		showProgress(s, i, id)
+	case "edit":
+		showEditModal(s, i, id)
+	case "recall":
+		recall(s, i, id)
+	default:
+		middleware.Reply(s, i, "Unknown subcommand.")
+	}
+}
+
+func showCreateModal(s *discordgo.Session, i *discordgo.InteractionCreate, audience, guildID, sendAt string) {
+	if audience == services.AudienceGuild && guildID == "" {
+		middleware.Reply(s, i, "The guild audience needs a guild ID.")
+		return
+	}
+	scheduledAt, err := services.ParseAnnouncementTime(sendAt)
+	if err != nil {
+		middleware.Reply(s, i, err.Error())
+		return
+	}
+
+	// The modal's custom ID carries the options until it is submitted.
+	customID := fmt.Sprintf("announce_create_%s_%s_%d", audience, guildID, scheduledAt.Unix())
+	showModal(s, i, customID, "New announcement", models.Announcement{})
+}
+
+func showEditModal(s *discordgo.Session, i *discordgo.InteractionCreate, id uint) {
+	announcement, err := services.GetAnnouncement(id)
+	if err != nil {
+		replyLookupError(s, i, err)
+		return
+	}
+	showModal(s, i, fmt.Sprintf("announce_edit_%d", announcement.ID), fmt.Sprintf("Edit announcement #%d", announcement.ID), announcement)
+}
+
+func showModal(s *discordgo.Session, i *discordgo.InteractionCreate, customID, title string, announcement models.Announcement) {
+	err := middleware.Respond(s, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: customID,
+			Title:    title,
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:  "announcement_title",
+							Label:     "Announcement Title",
+							Style:     discordgo.TextInputShort,
+							Required:  true,
+							MinLength: 1,
+							MaxLength: 100,
+							Value:     announcement.Title,
+						},
+					},
+				},
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:  "announcement_content",
+							Label:     "Announcement Content",
+							Style:     discordgo.TextInputParagraph,
+							Required:  true,
+							MinLength: 1,
+							MaxLength: 4000,
+							Value:     announcement.Content,
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		logger.Log.WithError(err).Error("Error showing announcement modal")
+		middleware.Reply(s, i, "Error creating announcement modal. Please try again.")
+	}
+}
+
+func HandleCreateSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ModalSubmitData()
+	parts := strings.Split(strings.TrimPrefix(data.CustomID, "announce_create_"), "_")
+	if len(parts) < 3 {
+		middleware.Reply(s, i, "Invalid announcement form.")
+		return
+	}
+	// Audiences may contain underscores, so read the guild and time from the
+	// end.
+	unix, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+	if err != nil {
+		middleware.Reply(s, i, "Invalid announcement form.")
+		return
+	}
+	guildID := parts[len(parts)-2]
+	audience := strings.Join(parts[:len(parts)-2], "_")
+
+	title, content := modalText(data)
+	announcement, err := services.CreateAnnouncement(title, content, audience, guildID, time.Unix(unix, 0), middleware.Current(i).UserID)
+	if err != nil {
+		logger.Log.WithError(err).Error("Error creating announcement")
+		middleware.Reply(s, i, fmt.Sprintf("Error creating the announcement: %v", err))
+		return
+	}
+
+	when := "now"
+	if time.Until(announcement.ScheduledAt) > 0 {
+		when = fmt.Sprintf("<t:%d:f>", announcement.ScheduledAt.Unix())
+	}
+	middleware.Reply(s, i, fmt.Sprintf("Announcement #%d to %s will be sent %s. Follow it with `/announce progress id:%d`.",
+		announcement.ID, strings.ToLower(audienceNames[audience]), when, announcement.ID))
+}
+
+func HandleEditSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ModalSubmitData()
+	id, err := strconv.ParseUint(strings.TrimPrefix(data.CustomID, "announce_edit_"), 10, 64)
+	if err != nil {
+		middleware.Reply(s, i, "Invalid announcement form.")
+		return
+	}
+
+	title, content := modalText(data)
+	announcement, err := services.EditAnnouncement(uint(id), title, content)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, services.ErrAnnouncementRecalled) {
+			replyLookupError(s, i, err)
+			return
+		}
+		logger.Log.WithError(err).Errorf("Error editing announcement %d", id)
+		middleware.Reply(s, i, fmt.Sprintf("Error editing the announcement: %v", err))
+		return
+	}
+	middleware.Reply(s, i, fmt.Sprintf("Announcement #%d updated; messages already sent will be edited.", announcement.ID))
+}
+
+func modalText(data discordgo.ModalSubmitInteractionData) (title, content string) {
+	for _, comp := range data.Components {
+		row, ok := comp.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, rowComp := range row.Components {
+			textInput, ok := rowComp.(*discordgo.TextInput)
+			if !ok {
+				continue
+			}
+			switch textInput.CustomID {
+			case "announcement_title":
+				title = utils.SanitizeInput(strings.TrimSpace(textInput.Value))
+			case "announcement_content":
+				content = utils.SanitizeAnnouncement(strings.TrimSpace(textInput.Value))
+			}
+		}
+	}
+	return title, content
+}
+
+func listAnnouncements(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	announcements, err := services.ListAnnouncements(listLimit)
+	if err != nil {
+		logger.Log.WithError(err).Error("Error listing announcements")
+		middleware.Reply(s, i, "Error listing announcements.")
+		return
+	}
+	if len(announcements) == 0 {
+		middleware.Reply(s, i, "No announcements yet.")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:     "Announcements",
+		Color:     0xFFD700,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	for _, announcement := range announcements {
+		progress, err := services.GetAnnouncementProgress(announcement.ID)
+		if err != nil {
+			logger.Log.WithError(err).Errorf("Error fetching progress of announcement %d", announcement.ID)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("#%d %s", announcement.ID, announcement.Title),
+			Value: fmt.Sprintf("%s, %s <t:%d:R>\n%s", audienceLabel(announcement), announcement.Status, announcement.ScheduledAt.Unix(), progressLine(progress)),
+		})
+	}
+	middleware.Reply(s, i, "", embed)
+}
+
+func showProgress(s *discordgo.Session, i *discordgo.InteractionCreate, id uint) {
+	announcement, err := services.GetAnnouncement(id)
+	if err != nil {
+		replyLookupError(s, i, err)
+		return
+	}
+	progress, err := services.GetAnnouncementProgress(announcement.ID)
+	if err != nil {
+		logger.Log.WithError(err).Errorf("Error fetching progress of announcement %d", announcement.ID)
+		middleware.Reply(s, i, "Error fetching the announcement's progress.")
+		return
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{Name: "Audience", Value: audienceLabel(announcement), Inline: true},
+		{Name: "Status", Value: announcement.Status, Inline: true},
+		{Name: "Scheduled", Value: fmt.Sprintf("<t:%d:f>", announcement.ScheduledAt.Unix()), Inline: true},
+		{Name: "Recipients", Value: strconv.FormatInt(progress.Total, 10), Inline: true},
+		{Name: "Sent", Value: strconv.FormatInt(progress.Sent, 10), Inline: true},
+		{Name: "Pending", Value: strconv.FormatInt(progress.Pending, 10), Inline: true},
+		{Name: "Failed", Value: strconv.FormatInt(progress.Failed, 10), Inline: true},
+		{Name: "Unreachable", Value: strconv.FormatInt(progress.Unreachable, 10), Inline: true},
+		{Name: "Recalled", Value: strconv.FormatInt(progress.Recalled, 10), Inline: true},
+	}
+	if announcement.Revision > 1 {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Edits", Value: strconv.Itoa(announcement.Revision - 1), Inline: true})
+	}
+
+	embed := services.AnnouncementEmbed(announcement)
+	embed.Title = fmt.Sprintf("#%d %s", announcement.ID, announcement.Title)
+	embed.Fields = fields
+	embed.Footer = nil
+	middleware.Reply(s, i, "", embed)
+}
+
+func recall(s *discordgo.Session, i *discordgo.InteractionCreate, id uint) {
+	announcement, err := services.RecallAnnouncement(id)
+	if err != nil {
+		replyLookupError(s, i, err)
+		return
+	}
+	middleware.Reply(s, i, fmt.Sprintf("Announcement #%d recalled; messages already sent will be deleted.", announcement.ID))
+}
+
+func replyLookupError(s *discordgo.Session, i *discordgo.InteractionCreate, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		middleware.Reply(s, i, "No such announcement.")
+	case errors.Is(err, services.ErrAnnouncementRecalled):
+		middleware.Reply(s, i, "That announcement was recalled and can no longer be changed.")
+	default:
+		logger.Log.WithError(err).Error("Error loading announcement")
+		middleware.Reply(s, i, "Error loading the announcement.")
+	}
+}
+
+func audienceLabel(announcement models.Announcement) string {
+	if announcement.Audience == services.AudienceGuild {
+		return "Guild " + announcement.GuildID
+	}
+	return audienceNames[announcement.Audience]
+}
+
+func progressLine(progress services.AnnouncementProgress) string {
+	return fmt.Sprintf("%d sent, %d pending, %d failed, %d unreachable of %d", progress.Sent, progress.Pending, progress.Failed, progress.Unreachable, progress.Total)
+}
@@ -5,28 +5,34 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
 	"github.com/bradselph/CODStatusBot/configuration"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/services"
 	"github.com/bwmarrin/discordgo"
 )
 
+var Command = registry.Command{
+	Name:        "checkcaptchabalance",
+	Description: "Check your captcha service balance",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Consulta el saldo de tu servicio de captcha",
+		discordgo.SpanishLATAM: "Consulta el saldo de tu servicio de captcha",
+		discordgo.PortugueseBR: "Veja o saldo do seu serviço de captcha",
+	},
+	Handler: CommandCheckCaptchaBalance,
+}
+
 func CommandCheckCaptchaBalance(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	var userID string
-	if i.Member != nil {
-		userID = i.Member.User.ID
-	} else if i.User != nil {
-		userID = i.User.ID
-	} else {
-		logger.Log.Error("Interaction doesn't have Member or User")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
+	locale := services.InteractionLocale(i)
+	userID := middleware.Current(i).UserID
 
 	userSettings, err := services.GetUserSettings(userID)
 	if err != nil {
 		logger.Log.WithError(err).Error("Error fetching user settings")
-		respondToInteraction(s, i, "Error fetching your settings. Please try again.")
+		middleware.Reply(s, i, "", services.UserErrorEmbed(locale, err))
 		return
 	}
 
@@ -36,12 +42,12 @@ func CommandCheckCaptchaBalance(s *discordgo.Session, i *discordgo.InteractionCr
 	if !services.IsServiceEnabled("capsolver") &&
 		!services.IsServiceEnabled("ezcaptcha") &&
 		!services.IsServiceEnabled("2captcha") {
-		respondToInteraction(s, i, "No captcha services are currently available. Please try again later.")
+		middleware.Reply(s, i, i18n.T(locale, "addaccount.no_provider"))
 		return
 	}
 
 	embed := &discordgo.MessageEmbed{
-		Title:     "Captcha Service Status",
+		Title:     i18n.T(locale, "balance.title"),
 		Color:     0x00ff00,
 		Timestamp: time.Now().Format(time.RFC3339),
 		Fields:    []*discordgo.MessageEmbedField{},
@@ -59,22 +65,24 @@ func CommandCheckCaptchaBalance(s *discordgo.Session, i *discordgo.InteractionCr
 	}
 
 	if !services.IsServiceEnabled(userSettings.PreferredCaptchaProvider) {
-		embed.Description = fmt.Sprintf("Warning: Your preferred service (%s) is currently disabled.\n"+
-			"Available services: %s\n"+
-			"Use /setcaptchaservice to switch to an available service.",
+		embed.Description = i18n.T(locale, "balance.preferred_disabled",
 			userSettings.PreferredCaptchaProvider,
 			strings.Join(availableServices, ", "))
 		embed.Color = 0xFFA500
 	}
 
 	hasUserKey := false
+	var keyErr error
 
 	if userSettings.CapSolverAPIKey != "" && services.IsServiceEnabled("capsolver") {
 		isValid, balance, err := services.ValidateCaptchaKey(userSettings.CapSolverAPIKey, "capsolver")
+		if err != nil && keyErr == nil {
+			keyErr = err
+		}
 		if err == nil && isValid {
 			hasUserKey = true
 			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-				Name:   "Capsolver Balance",
+				Name:   i18n.T(locale, "balance.field", "Capsolver"),
 				Value:  fmt.Sprintf("$%.3f", balance),
 				Inline: true,
 			})
@@ -83,11 +91,14 @@ func CommandCheckCaptchaBalance(s *discordgo.Session, i *discordgo.InteractionCr
 
 	if userSettings.EZCaptchaAPIKey != "" && services.IsServiceEnabled("ezcaptcha") {
 		isValid, balance, err := services.ValidateCaptchaKey(userSettings.EZCaptchaAPIKey, "ezcaptcha")
+		if err != nil && keyErr == nil {
+			keyErr = err
+		}
 		if err == nil && isValid {
 			hasUserKey = true
 			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-				Name:   "EZCaptcha Balance",
-				Value:  fmt.Sprintf("%.0f points", balance),
+				Name:   i18n.T(locale, "balance.field", "EZCaptcha"),
+				Value:  i18n.T(locale, "balance.points", balance),
 				Inline: true,
 			})
 		}
@@ -95,10 +106,13 @@ func CommandCheckCaptchaBalance(s *discordgo.Session, i *discordgo.InteractionCr
 
 	if userSettings.TwoCaptchaAPIKey != "" && services.IsServiceEnabled("2captcha") {
 		isValid, balance, err := services.ValidateCaptchaKey(userSettings.TwoCaptchaAPIKey, "2captcha")
+		if err != nil && keyErr == nil {
+			keyErr = err
+		}
 		if err == nil && isValid {
 			hasUserKey = true
 			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-				Name:   "2Captcha Balance",
+				Name:   i18n.T(locale, "balance.field", "2Captcha"),
 				Value:  fmt.Sprintf("$%.2f", balance),
 				Inline: true,
 			})
@@ -106,19 +120,19 @@ func CommandCheckCaptchaBalance(s *discordgo.Session, i *discordgo.InteractionCr
 	}
 
 	if !hasUserKey {
-		embed.Description = "You are currently using the bot's default API key. Consider setting up your own key using /setcaptchaservice for unlimited checks."
+		embed.Description = i18n.T(locale, "balance.default_key")
 		embed.Color = 0xFFA500
 
 		if services.IsServiceEnabled(userSettings.PreferredCaptchaProvider) {
 			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-				Name:   "Default Service",
+				Name:   i18n.T(locale, "field.default_service"),
 				Value:  userSettings.PreferredCaptchaProvider,
 				Inline: true,
 			})
 		}
 	} else {
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-			Name:   "Preferred Provider",
+			Name:   i18n.T(locale, "field.preferred_provider"),
 			Value:  userSettings.PreferredCaptchaProvider,
 			Inline: false,
 		})
@@ -129,7 +143,7 @@ func CommandCheckCaptchaBalance(s *discordgo.Session, i *discordgo.InteractionCr
 		thresholds = append(thresholds, fmt.Sprintf("Capsolver: $%.3f", cfg.CaptchaService.Capsolver.BalanceMin))
 	}
 	if services.IsServiceEnabled("ezcaptcha") {
-		thresholds = append(thresholds, fmt.Sprintf("EZCaptcha: %.0f points", cfg.CaptchaService.EZCaptcha.BalanceMin))
+		thresholds = append(thresholds, "EZCaptcha: "+i18n.T(locale, "balance.points", cfg.CaptchaService.EZCaptcha.BalanceMin))
 	}
 	if services.IsServiceEnabled("2captcha") {
 		thresholds = append(thresholds, fmt.Sprintf("2Captcha: $%.2f", cfg.CaptchaService.TwoCaptcha.BalanceMin))
@@ -137,30 +151,22 @@ func CommandCheckCaptchaBalance(s *discordgo.Session, i *discordgo.InteractionCr
 
 	if len(thresholds) > 0 {
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-			Name:   "Minimum Balance Thresholds",
+			Name:   i18n.T(locale, "field.balance_thresholds"),
 			Value:  strings.Join(thresholds, "\n"),
 			Inline: false,
 		})
 	}
 
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Embeds: []*discordgo.MessageEmbed{embed},
-			Flags:  discordgo.MessageFlagsEphemeral,
-		},
-	})
-	if err != nil {
-		logger.Log.WithError(err).Error("Error responding to interaction")
+	embeds := []*discordgo.MessageEmbed{embed}
+	if keyErr != nil {
+		embeds = append(embeds, services.UserErrorEmbed(locale, keyErr))
 	}
-}
 
-func respondToInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err = middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: message,
-			Flags:   discordgo.MessageFlagsEphemeral,
+			Embeds: embeds,
+			Flags:  discordgo.MessageFlagsEphemeral,
 		},
 	})
 	if err != nil {
@@ -4,11 +4,12 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
-	"github.com/bradselph/CODStatusBot/configuration"
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
 	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
 	"github.com/bradselph/CODStatusBot/services"
@@ -16,62 +17,54 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
-var (
-	rateLimiter     = make(map[string]time.Time)
-	rateLimiterLock sync.Mutex
-	rateLimit       time.Duration
-)
-
-func init() {
-	cfg := configuration.Get()
-	rateLimit = cfg.RateLimits.CheckNow
+var Command = registry.Command{
+	Name:        "checknow",
+	Description: "Check account status now (daily checks depend on your plan)",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Comprueba ahora el estado de la cuenta (las comprobaciones diarias dependen de tu plan)",
+		discordgo.SpanishLATAM: "Comprueba ahora el estado de la cuenta (las comprobaciones diarias dependen de tu plan)",
+		discordgo.PortugueseBR: "Verifique o status da conta agora (as verificações diárias dependem do seu plano)",
+	},
+	Handler: CommandCheckNow,
+	Components: []registry.Route{
+		registry.Prefix("check_now_", HandleAccountSelection),
+	},
 }
 
 func CommandCheckNow(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	userID, err := getUserID(i)
-	if err != nil {
-		logger.Log.WithError(err).Error("Failed to get user ID")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
+	locale := services.InteractionLocale(i)
+	userID := middleware.Current(i).UserID
 
-	userSettings, err := services.GetUserSettings(userID)
+	userSettings, err := middleware.Current(i).Settings()
 	if err != nil {
 		logger.Log.WithError(err).Error("Error fetching user settings")
-		respondToInteraction(s, i, "Error fetching user settings. Please try again later.")
+		middleware.Reply(s, i, i18n.T(locale, "error.fetch_settings"))
 		return
 	}
 
 	if !services.IsServiceEnabled(userSettings.PreferredCaptchaProvider) {
-		msg := fmt.Sprintf("Your preferred captcha service (%s) is currently disabled. ", userSettings.PreferredCaptchaProvider)
+		msg := i18n.T(locale, "addaccount.provider_disabled", userSettings.PreferredCaptchaProvider) + " "
 		if services.IsServiceEnabled("ezcaptcha") {
-			msg += "Please switch to EZCaptcha using /setcaptchaservice."
+			msg += i18n.T(locale, "checknow.switch_provider", "EZCaptcha")
 		} else if services.IsServiceEnabled("2captcha") {
-			msg += "Please switch to 2Captcha using /setcaptchaservice."
+			msg += i18n.T(locale, "checknow.switch_provider", "2Captcha")
 		} else {
-			msg += "No captcha services are currently available. Please try again later."
+			msg += i18n.T(locale, "addaccount.no_provider")
 		}
-		respondToInteraction(s, i, msg)
+		middleware.Reply(s, i, msg)
 		return
 	}
 
-	if userSettings.CapSolverAPIKey != "" && userSettings.EZCaptchaAPIKey == "" && userSettings.TwoCaptchaAPIKey == "" {
-		if !checkRateLimit(userID) {
-			respondToInteraction(s, i, fmt.Sprintf("You're using the bot's default API key and are rate limited. Please wait %v before trying again, or set up your own API key using /setcaptchaservice for unlimited checks.", rateLimit))
-			return
-		}
-	}
-
-	if userSettings.CapSolverAPIKey != "" || userSettings.EZCaptchaAPIKey != "" || userSettings.TwoCaptchaAPIKey != "" {
+	if services.HasOwnCaptchaKey(userSettings) {
 		_, balance, err := services.GetUserCaptchaKey(userID)
 		if err != nil {
 			logger.Log.WithError(err).Error("Error getting captcha key")
-			respondToInteraction(s, i, "Error validating your captcha API key. Please check your key using /setcaptchaservice.")
+			middleware.Reply(s, i, "", services.UserErrorEmbed(locale, err))
 			return
 		}
 
 		if balance < 0 {
-			respondToInteraction(s, i, fmt.Sprintf("Your captcha balance (%.2f) is too low for checking accounts. Please recharge your balance.", balance))
+			middleware.Reply(s, i, i18n.T(locale, "checknow.low_balance", balance))
 			return
 		}
 	}
@@ -82,12 +75,12 @@ func CommandCheckNow(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 	if result.Error != nil {
 		logger.Log.WithError(result.Error).Error("Error fetching accounts")
-		respondToInteraction(s, i, "Error fetching accounts. Please try again later.")
+		middleware.Reply(s, i, i18n.T(locale, "error.fetch_accounts"))
 		return
 	}
 
 	if len(accounts) == 0 {
-		respondToInteraction(s, i, "You don't have any monitored accounts.")
+		middleware.Reply(s, i, i18n.T(locale, "accounts.none"))
 		return
 	}
 
@@ -95,12 +88,8 @@ func CommandCheckNow(s *discordgo.Session, i *discordgo.InteractionCreate) {
 }
 
 func showAccountButtons(s *discordgo.Session, i *discordgo.InteractionCreate, accounts []models.Account) {
-	userID, err := getUserID(i)
-	if err != nil {
-		logger.Log.WithError(err).Error("Failed to get user ID")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
+	locale := services.InteractionLocale(i)
+	userID := middleware.Current(i).UserID
 
 	var components []discordgo.MessageComponent
 	var currentRow []discordgo.MessageComponent
@@ -120,7 +109,7 @@ func showAccountButtons(s *discordgo.Session, i *discordgo.InteractionCreate, ac
 
 	if len(currentRow) < 5 {
 		currentRow = append(currentRow, discordgo.Button{
-			Label:    "Check All",
+			Label:    i18n.T(locale, "checknow.check_all"),
 			Style:    discordgo.SuccessButton,
 			CustomID: fmt.Sprintf("check_now_%s_all", userID),
 		})
@@ -130,10 +119,10 @@ func showAccountButtons(s *discordgo.Session, i *discordgo.InteractionCreate, ac
 		components = append(components, discordgo.ActionsRow{Components: currentRow})
 	}
 
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err := middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content:    "Select an account to check, or 'Check All' to check all accounts:",
+			Content:    i18n.T(locale, "checknow.select"),
 			Flags:      discordgo.MessageFlagsEphemeral,
 			Components: components,
 		},
@@ -144,12 +133,13 @@ func showAccountButtons(s *discordgo.Session, i *discordgo.InteractionCreate, ac
 }
 
 func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 	customID := i.MessageComponentData().CustomID
 	parts := strings.Split(customID, "_")
 
 	if len(parts) != 4 {
 		logger.Log.Error("Invalid custom ID format")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
+		middleware.Reply(s, i, i18n.T(locale, "error.processing"))
 		return
 	}
 
@@ -159,112 +149,102 @@ func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate
 	userSettings, err := services.GetUserSettings(userID)
 	if err != nil {
 		logger.Log.WithError(err).Error("Error fetching user settings")
-		respondToInteraction(s, i, "Error fetching settings. Please try again.")
+		middleware.Reply(s, i, i18n.T(locale, "error.fetch_settings"))
 		return
 	}
 
-	isUsingDefaultKey := userSettings.CapSolverAPIKey == "" &&
-		userSettings.EZCaptchaAPIKey == "" &&
-		userSettings.TwoCaptchaAPIKey == ""
-
-	if isUsingDefaultKey {
-		lastCheck := userSettings.LastCommandTimes["check_now"]
-		cfg := configuration.Get()
-
-		userSettings.EnsureMapsInitialized()
-		checksUsed := userSettings.ActionCounts["check_now"]
-		maxChecks := cfg.RateLimits.DefaultMaxAccounts
-
-		if !lastCheck.IsZero() && time.Since(lastCheck) >= cfg.RateLimits.CheckNow {
-			checksUsed = 0
-			userSettings.ActionCounts["check_now"] = 0
-			userSettings.LastCommandTimes["check_now"] = time.Now()
-			if err := database.DB.Save(&userSettings).Error; err != nil {
-				logger.Log.WithError(err).Error("Error resetting check count")
-			}
-		}
+	// Checks that cannot run right now must not use up the daily quota.
+	if err := services.CheckBlocked(userSettings); err != nil {
+		middleware.Reply(s, i, "", services.UserErrorEmbed(locale, err))
+		return
+	}
 
+	quotas := services.UserQuotas(userSettings)
+	if quotas.DailyManualChecks > 0 {
+		checks := 1
 		if accountIDOrAll == "all" {
 			var accountCount int64
 			if err := database.DB.Model(&models.Account{}).Where("user_id = ?", userID).Count(&accountCount).Error; err != nil {
 				logger.Log.WithError(err).Error("Error counting accounts")
-				respondToInteraction(s, i, "Error counting accounts. Please try again.")
+				middleware.Reply(s, i, i18n.T(locale, "checknow.count_error"))
 				return
 			}
+			checks = int(accountCount)
+		}
 
-			if int(accountCount) > (maxChecks - checksUsed) {
-				timeUntilNext := cfg.RateLimits.CheckNow - time.Since(lastCheck)
-				embed := &discordgo.MessageEmbed{
-					Title: "Insufficient Checks Available",
-					Description: fmt.Sprintf("You need %d checks but only have %d remaining.\n\n"+
-						"Next reset in: %s\n\n"+
-						"To remove this limit, set up your own API key using `/setcaptchaservice`",
-						accountCount, maxChecks-checksUsed, formatDuration(timeUntilNext)),
-					Color: 0xFFA500,
-					Fields: []*discordgo.MessageEmbedField{
-						{
-							Name:   "Available Checks",
-							Value:  fmt.Sprintf("%d/%d checks remaining", maxChecks-checksUsed, maxChecks),
-							Inline: true,
-						},
-						{
-							Name:   "Remove Limits",
-							Value:  "Add your own API key to get:\n• Unlimited checks\n• Faster intervals\n• More account slots",
-							Inline: true,
-						},
-					},
-					Timestamp: time.Now().Format(time.RFC3339),
-				}
-				respondToInteractionWithEmbed(s, i, "", embed)
-				return
-			}
+		// Manual checks come from a bucket of the tier's daily quota that
+		// refills over the day.
+		maxChecks := quotas.DailyManualChecks
+		decision, err := services.Limiter().AllowN(services.UserSubject(userID), checks, services.RateLimitPolicy{
+			Action:    "checknow.checks",
+			Algorithm: services.TokenBucket,
+			Limit:     maxChecks,
+			Window:    24 * time.Hour,
+		})
+		if err != nil {
+			logger.Log.WithError(err).Error("Error updating check count")
+			middleware.Reply(s, i, i18n.T(locale, "checknow.count_update_error"))
+			return
+		}
 
-			userSettings.ActionCounts["check_now"] += int(accountCount)
-		} else {
-			if checksUsed >= maxChecks {
-				timeUntilNext := cfg.RateLimits.CheckNow - time.Since(lastCheck)
-				embed := &discordgo.MessageEmbed{
-					Title: "Rate Limit Reached",
-					Description: fmt.Sprintf("You have used all available checks.\n\n"+
-						"Next reset in: %s\n\n"+
-						"To remove this limit, set up your own API key using `/setcaptchaservice`",
-						formatDuration(timeUntilNext)),
-					Color: 0xFFA500,
-					Fields: []*discordgo.MessageEmbedField{
-						{
-							Name:   "Check Status",
-							Value:  fmt.Sprintf("%d/%d checks used", checksUsed, maxChecks),
-							Inline: true,
-						},
-						{
-							Name:   "Remove Limits",
-							Value:  "Add your own API key to get:\n• Unlimited checks\n• Faster intervals\n• More account slots",
-							Inline: true,
-						},
+		if !decision.Allowed && accountIDOrAll == "all" {
+			embed := &discordgo.MessageEmbed{
+				Title: i18n.T(locale, "checknow.insufficient.title"),
+				Description: i18n.T(locale, "checknow.insufficient.description",
+					checks, decision.Remaining, formatDuration(decision.RetryAfter)),
+				Color: 0xFFA500,
+				Fields: []*discordgo.MessageEmbedField{
+					{
+						Name:   i18n.T(locale, "field.available_checks"),
+						Value:  i18n.T(locale, "checknow.remaining", decision.Remaining, maxChecks),
+						Inline: true,
 					},
-					Timestamp: time.Now().Format(time.RFC3339),
-				}
-				respondToInteractionWithEmbed(s, i, "", embed)
-				return
+					{
+						Name:   i18n.T(locale, "field.remove_limits"),
+						Value:  i18n.T(locale, "checknow.remove_limits"),
+						Inline: true,
+					},
+				},
+				Timestamp: time.Now().Format(time.RFC3339),
 			}
-			userSettings.ActionCounts["check_now"]++
+			middleware.Reply(s, i, "", embed)
+			return
 		}
 
-		if err := database.DB.Save(&userSettings).Error; err != nil {
-			logger.Log.WithError(err).Error("Error saving check count")
-			respondToInteraction(s, i, "Error updating check count. Please try again.")
+		if !decision.Allowed {
+			embed := &discordgo.MessageEmbed{
+				Title:       i18n.T(locale, "checknow.limit.title"),
+				Description: i18n.T(locale, "checknow.limit.description", formatDuration(decision.RetryAfter)),
+				Color:       0xFFA500,
+				Fields: []*discordgo.MessageEmbedField{
+					{
+						Name:   i18n.T(locale, "field.check_status"),
+						Value:  i18n.T(locale, "checknow.used", maxChecks-decision.Remaining, maxChecks),
+						Inline: true,
+					},
+					{
+						Name:   i18n.T(locale, "field.remove_limits"),
+						Value:  i18n.T(locale, "checknow.remove_limits"),
+						Inline: true,
+					},
+				},
+				Timestamp: time.Now().Format(time.RFC3339),
+			}
+			middleware.Reply(s, i, "", embed)
 			return
 		}
-	} else {
+	}
+
+	if services.HasOwnCaptchaKey(userSettings) {
 		apiKey, balance, err := services.GetUserCaptchaKey(userID)
 		if err != nil || apiKey == "" {
 			logger.Log.WithError(err).Error("Error getting captcha key")
-			respondToInteraction(s, i, "Error validating your captcha API key. Please check your key using /setcaptchaservice.")
+			middleware.Reply(s, i, i18n.T(locale, "addaccount.key_error"))
 			return
 		}
 
 		if balance < 0 {
-			respondToInteraction(s, i, fmt.Sprintf("Your captcha balance (%.2f) is too low for checking accounts. Please recharge your balance.", balance))
+			middleware.Reply(s, i, i18n.T(locale, "checknow.low_balance", balance))
 			return
 		}
 	}
@@ -274,14 +254,14 @@ func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate
 		result := database.DB.Where("user_id = ?", userID).Find(&accounts)
 		if result.Error != nil {
 			logger.Log.WithError(result.Error).Error("Error fetching accounts")
-			respondToInteraction(s, i, "Error fetching accounts. Please try again later.")
+			middleware.Reply(s, i, i18n.T(locale, "error.fetch_accounts"))
 			return
 		}
 	} else {
 		accountID, err := strconv.Atoi(accountIDOrAll)
 		if err != nil {
 			logger.Log.WithError(err).Error("Error parsing account ID")
-			respondToInteraction(s, i, "Error processing your selection. Please try again.")
+			middleware.Reply(s, i, i18n.T(locale, "error.selection"))
 			return
 		}
 
@@ -289,7 +269,7 @@ func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate
 		result := database.DB.First(&account, accountID)
 		if result.Error != nil {
 			logger.Log.WithError(result.Error).Error("Error fetching account")
-			respondToInteraction(s, i, "Error: Account not found or you don't have permission to check it.")
+			middleware.Reply(s, i, i18n.T(locale, "checknow.not_found"))
 			return
 		}
 
@@ -316,55 +296,25 @@ func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%ds", s)
 }
 
-func respondToInteractionWithEmbed(s *discordgo.Session, i *discordgo.InteractionCreate, content string, embed *discordgo.MessageEmbed) {
-	responseData := &discordgo.InteractionResponseData{
-		Flags: discordgo.MessageFlagsEphemeral,
-	}
-
-	if content != "" {
-		responseData.Content = content
-	}
-	if embed != nil {
-		responseData.Embeds = []*discordgo.MessageEmbed{embed}
-	}
-
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: responseData,
-	})
-	if err != nil {
-		logger.Log.WithError(err).Error("Error responding to interaction with embed")
-	}
-}
-
 func checkAccounts(s *discordgo.Session, i *discordgo.InteractionCreate, accounts []models.Account) {
-	userID, err := services.GetUserID(i)
-	if err != nil {
-		logger.Log.WithError(err).Error("Failed to get user ID")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
+	locale := services.InteractionLocale(i)
+	userID := middleware.Current(i).UserID
 
 	userSettings, err := services.GetUserSettings(userID)
 	if err != nil {
 		logger.Log.WithError(err).Error("Error fetching user settings")
-		respondToInteraction(s, i, "Error fetching settings. Please try again.")
+		middleware.Reply(s, i, i18n.T(locale, "error.fetch_settings"))
 		return
 	}
 
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Flags: discordgo.MessageFlagsEphemeral,
-		},
-	})
+	err = middleware.Defer(s, i)
 	if err != nil {
 		logger.Log.WithError(err).Error("Failed to defer interaction response")
 		return
 	}
 
 	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Content: fmt.Sprintf("Starting check of %d accounts...", len(accounts)),
+		Content: i18n.N(locale, "checknow.starting", len(accounts)),
 		Flags:   discordgo.MessageFlagsEphemeral,
 	})
 	if err != nil {
@@ -377,8 +327,8 @@ func checkAccounts(s *discordgo.Session, i *discordgo.InteractionCreate, account
 
 		if account.IsCheckDisabled {
 			embed = &discordgo.MessageEmbed{
-				Title:       fmt.Sprintf("%s - Checks Disabled", account.Title),
-				Description: fmt.Sprintf("Checks are disabled for this account. Reason: %s", account.DisabledReason),
+				Title:       i18n.T(locale, "checknow.disabled.title", account.Title),
+				Description: i18n.T(locale, "checknow.disabled.description", services.AccountDisabledReason(locale, account)),
 				Color:       services.GetColorForStatus(account.LastStatus, account.IsExpiredCookie, true),
 				Timestamp:   time.Now().Format(time.RFC3339),
 			}
@@ -389,41 +339,28 @@ func checkAccounts(s *discordgo.Session, i *discordgo.InteractionCreate, account
 			}
 
 			embed = &discordgo.MessageEmbed{
-				Title:       fmt.Sprintf("%s - Expired Cookie", account.Title),
-				Description: "The SSO cookie for this account has expired. Please update it using the /updateaccount command.",
+				Title:       i18n.T(locale, "checknow.expired.title", account.Title),
+				Description: i18n.T(locale, "checknow.expired.description"),
 				Color:       services.GetColorForStatus(models.StatusUnknown, true, false),
 				Timestamp:   time.Now().Format(time.RFC3339),
 			}
 		} else {
-			result, err := services.CheckAccount(account.SSOCookie, userID, "")
+			result, err := services.CheckAccount(services.InteractionContext(i), account.SSOCookie, userID, "")
 			if err != nil {
 				logger.Log.WithError(err).Errorf("Error checking account %s", account.Title)
-				description := "An error occurred while checking this account. "
-				if strings.Contains(err.Error(), "insufficient balance") {
-					description += "Your captcha balance is too low. Please recharge your balance."
-				} else if strings.Contains(err.Error(), "invalid captcha") {
-					description += "There was an issue with your captcha API key. Please verify it using /setcaptchaservice."
-				} else {
-					description += "Please try again later."
-				}
-
-				embed = &discordgo.MessageEmbed{
-					Title:       fmt.Sprintf("%s - Error", account.Title),
-					Description: description,
-					Color:       0xFF0000,
-					Timestamp:   time.Now().Format(time.RFC3339),
-				}
+				embed = services.UserErrorEmbed(locale, err)
+				embed.Title = fmt.Sprintf("%s - %s", account.Title, embed.Title)
 			} else {
 				services.HandleStatusChange(s, account, result, userSettings)
 
 				embed = &discordgo.MessageEmbed{
-					Title:       fmt.Sprintf("%s - Status Check", account.Title),
-					Description: fmt.Sprintf("Current status: %s", result),
+					Title:       i18n.T(locale, "checknow.result.title", account.Title),
+					Description: i18n.T(locale, "checknow.result.description", services.StatusName(locale, result)),
 					Color:       services.GetColorForStatus(result, account.IsExpiredCookie, account.IsCheckDisabled),
 					Fields: []*discordgo.MessageEmbedField{
 						{
-							Name:   "Last Checked",
-							Value:  time.Now().Format(time.RFC1123),
+							Name:   i18n.T(locale, "field.last_checked"),
+							Value:  fmt.Sprintf("<t:%d:f>", time.Now().Unix()),
 							Inline: true,
 						},
 					},
@@ -444,7 +381,7 @@ func checkAccounts(s *discordgo.Session, i *discordgo.InteractionCreate, account
 		time.Sleep(time.Second)
 	}
 
-	completionMessage := fmt.Sprintf("Completed checking all %d accounts.", processedCount)
+	completionMessage := i18n.N(locale, "checknow.completed", processedCount)
 	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
 		Content: completionMessage,
 		Flags:   discordgo.MessageFlagsEphemeral,
@@ -453,59 +390,3 @@ func checkAccounts(s *discordgo.Session, i *discordgo.InteractionCreate, account
 		logger.Log.WithError(err).Error("Failed to send completion message")
 	}
 }
-
-func checkRateLimit(userID string) bool {
-	var userSettings models.UserSettings
-	if err := database.DB.Where("user_id = ?", userID).First(&userSettings).Error; err != nil {
-		logger.Log.WithError(err).Error("Error fetching user settings")
-		return false
-	}
-
-	userSettings.EnsureMapsInitialized()
-	now := time.Now()
-	lastCheckTime := userSettings.LastCommandTimes["check_now"]
-
-	if lastCheckTime.IsZero() || time.Since(lastCheckTime) >= rateLimit {
-		userSettings.ActionCounts["check_now"] = 0
-		userSettings.LastCommandTimes["check_now"] = now
-	}
-
-	cfg := configuration.Get()
-	maxChecks := cfg.RateLimits.DefaultMaxAccounts
-
-	if userSettings.ActionCounts["check_now"] >= maxChecks {
-		return false
-	}
-
-	userSettings.ActionCounts["check_now"]++
-
-	if err := database.DB.Save(&userSettings).Error; err != nil {
-		logger.Log.WithError(err).Error("Error saving user settings")
-		return false
-	}
-
-	return true
-}
-
-func getUserID(i *discordgo.InteractionCreate) (string, error) {
-	if i.Member != nil && i.Member.User != nil {
-		return i.Member.User.ID, nil
-	}
-	if i.User != nil {
-		return i.User.ID, nil
-	}
-	return "", fmt.Errorf("unable to determine user ID")
-}
-
-func respondToInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: message,
-			Flags:   discordgo.MessageFlagsEphemeral,
-		},
-	})
-	if err != nil {
-		logger.Log.WithError(err).Error("Error responding to interaction")
-	}
-}
@@ -7,11 +7,43 @@ import (
 	"sync"
 	"time"
 
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
 	"github.com/bradselph/CODStatusBot/configuration"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/services"
 	"github.com/bwmarrin/discordgo"
 )
 
+var Command = registry.Command{
+	Name:        "feedback",
+	Description: "Send anonymous feedback to the bot developer",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Envía comentarios anónimos al desarrollador del bot",
+		discordgo.SpanishLATAM: "Envía comentarios anónimos al desarrollador del bot",
+		discordgo.PortugueseBR: "Envie feedback anônimo ao desenvolvedor do bot",
+	},
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "message",
+			Description: "Your feedback or suggestion",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.SpanishES:    "Tu comentario o sugerencia",
+				discordgo.SpanishLATAM: "Tu comentario o sugerencia",
+				discordgo.PortugueseBR: "Seu feedback ou sugestão",
+			},
+			Required: true,
+		},
+	},
+	RateLimit: middleware.Policy{Limits: []services.RateLimitPolicy{{Limit: 3, Window: 10 * time.Minute}}},
+	Handler:   CommandFeedback,
+	Components: []registry.Route{
+		registry.Prefix("feedback_", HandleFeedbackChoice),
+	},
+}
+
 var tempFeedbackStore = struct {
 	sync.RWMutex
 	m map[string]feedbackEntry
@@ -25,21 +57,17 @@ type feedbackEntry struct {
 const feedbackTimeout = 5 * time.Minute
 
 func CommandFeedback(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 	feedbackMessage := i.ApplicationCommandData().Options[0].StringValue()
 	cfg := configuration.Get()
 	developerID := cfg.Discord.DeveloperID
 	if developerID == "" {
 		logger.Log.Error("Developer ID not configured")
-		sendResponse(s, i, "Configuration error. Please try again later.", true)
+		middleware.Reply(s, i, i18n.T(locale, "error.configuration"))
 		return
 	}
 
-	userID, err := getUserID(i)
-	if err != nil {
-		logger.Log.WithError(err).Error("Failed to get user ID")
-		sendResponse(s, i, "An error occurred while processing your request.", true)
-		return
-	}
+	userID := middleware.Current(i).UserID
 
 	tempFeedbackStore.Lock()
 	tempFeedbackStore.m[userID] = feedbackEntry{
@@ -50,21 +78,21 @@ func CommandFeedback(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 	logger.Log.WithField("userID", userID).Info("Stored feedback message")
 
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err := middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: "Do you want to send this feedback anonymously?",
+			Content: i18n.T(locale, "feedback.anonymous_prompt"),
 			Flags:   discordgo.MessageFlagsEphemeral,
 			Components: []discordgo.MessageComponent{
 				discordgo.ActionsRow{
 					Components: []discordgo.MessageComponent{
 						discordgo.Button{
-							Label:    "Send Anonymously",
+							Label:    i18n.T(locale, "feedback.send_anonymous"),
 							Style:    discordgo.PrimaryButton,
 							CustomID: fmt.Sprintf("feedback_anonymous_%s", userID),
 						},
 						discordgo.Button{
-							Label:    "Send with ID",
+							Label:    i18n.T(locale, "feedback.send_with_id"),
 							Style:    discordgo.SecondaryButton,
 							CustomID: fmt.Sprintf("feedback_with_id_%s", userID),
 						},
@@ -75,17 +103,18 @@ func CommandFeedback(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	})
 	if err != nil {
 		logger.Log.WithError(err).Error("Failed to send anonymity choice message")
-		sendResponse(s, i, "There was an error processing your feedback. Please try again later.", true)
+		middleware.Reply(s, i, i18n.T(locale, "feedback.processing_error"))
 		return
 	}
 }
 
 func HandleFeedbackChoice(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 	customID := i.MessageComponentData().CustomID
 	parts := strings.SplitN(customID, "_", 3)
 	if len(parts) != 3 {
 		logger.Log.Error("Invalid custom ID format for feedback choice")
-		sendResponse(s, i, "An error occurred while processing your request.", true)
+		middleware.Update(s, i, i18n.T(locale, "error.processing"))
 		return
 	}
 
@@ -94,10 +123,10 @@ func HandleFeedbackChoice(s *discordgo.Session, i *discordgo.InteractionCreate)
 
 	userID = strings.TrimPrefix(userID, "id_")
 
-	interactionUserID, err := getUserID(i)
-	if err != nil || interactionUserID != userID {
+	interactionUserID := middleware.Current(i).UserID
+	if interactionUserID != userID {
 		logger.Log.WithField("buttonUserID", userID).WithField("interactionUserID", interactionUserID).Error("User ID mismatch")
-		sendResponse(s, i, "An error occurred while processing your request.", true)
+		middleware.Update(s, i, i18n.T(locale, "error.processing"))
 		return
 	}
 
@@ -107,7 +136,7 @@ func HandleFeedbackChoice(s *discordgo.Session, i *discordgo.InteractionCreate)
 
 	if !ok || time.Since(entry.timestamp) > feedbackTimeout {
 		logger.Log.WithField("userID", userID).Error("Feedback message not found or expired")
-		sendResponse(s, i, "Your feedback session has expired. Please submit your feedback again.", true)
+		middleware.Update(s, i, i18n.T(locale, "feedback.expired"))
 		return
 	}
 
@@ -124,11 +153,11 @@ func HandleFeedbackChoice(s *discordgo.Session, i *discordgo.InteractionCreate)
 
 	if err := sendFeedbackToDeveloper(s, feedbackToSend); err != nil {
 		logger.Log.WithError(err).Error("Failed to send feedback to developer")
-		sendResponse(s, i, "There was an error sending your feedback. Please try again later.", true)
+		middleware.Update(s, i, i18n.T(locale, "feedback.send_error"))
 		return
 	}
 
-	sendResponse(s, i, "Your feedback has been sent to the developer. Thank you for your input!", true)
+	middleware.Update(s, i, i18n.T(locale, "feedback.sent"))
 }
 
 func sendFeedbackToDeveloper(s *discordgo.Session, feedback string) error {
@@ -145,31 +174,3 @@ func sendFeedbackToDeveloper(s *discordgo.Session, feedback string) error {
 
 	return nil
 }
-
-func sendResponse(s *discordgo.Session, i *discordgo.InteractionCreate, content string, ephemeral bool) {
-	flags := discordgo.MessageFlags(0)
-	if ephemeral {
-		flags = discordgo.MessageFlagsEphemeral
-	}
-
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseUpdateMessage,
-		Data: &discordgo.InteractionResponseData{
-			Content: content,
-			Flags:   flags,
-		},
-	})
-	if err != nil {
-		logger.Log.WithError(err).Error("Failed to send interaction response")
-	}
-}
-
-func getUserID(i *discordgo.InteractionCreate) (string, error) {
-	if i.Member != nil && i.Member.User != nil {
-		return i.Member.User.ID, nil
-	}
-	if i.User != nil {
-		return i.User.ID, nil
-	}
-	return "", fmt.Errorf("unable to determine user ID")
-}
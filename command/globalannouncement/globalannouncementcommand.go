@@ -5,7 +5,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/bradselph/CODStatusBot/configuration"
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
 	"github.com/bradselph/CODStatusBot/database"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
@@ -14,6 +15,22 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
+var Command = registry.Command{
+	Name:        "globalannouncement",
+	Description: "Send a global announcement to all users (Admin only)",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Envía un anuncio global a todos los usuarios (solo administradores)",
+		discordgo.SpanishLATAM: "Envía un anuncio global a todos los usuarios (solo administradores)",
+		discordgo.PortugueseBR: "Envie um anúncio global a todos os usuários (somente administradores)",
+	},
+	Permissions:   discordgo.PermissionAdministrator,
+	DeveloperOnly: true,
+	Handler:       CommandGlobalAnnouncement,
+	Modals: []registry.Route{
+		registry.Exact("global_announcement_modal", HandleModalSubmit),
+	},
+}
+
 func SendGlobalAnnouncement(s *discordgo.Session, userID string) error {
 	var userSettings models.UserSettings
 	result := database.DB.Where(models.UserSettings{UserID: userID}).FirstOrCreate(&userSettings)
@@ -23,15 +40,15 @@ func SendGlobalAnnouncement(s *discordgo.Session, userID string) error {
 	}
 
 	if !userSettings.HasSeenAnnouncement {
-		channelID, err := getChannelForAnnouncement(s, userID, userSettings)
+		session, channelID, err := services.GetAnnouncementChannel(s, userID, userSettings)
 		if err != nil {
 			logger.Log.WithError(err).Error("Error finding channel for user")
 			return err
 		}
 
-		announcementEmbed := services.CreateAnnouncementEmbed()
+		announcementEmbed := services.CreateAnnouncementEmbed(services.UserLocale(userID))
 
-		_, err = s.ChannelMessageSendEmbed(channelID, announcementEmbed)
+		_, err = session.ChannelMessageSendEmbed(channelID, announcementEmbed)
 		if err != nil {
 			logger.Log.WithError(err).Error("Error sending global announcement")
 			return err
@@ -48,32 +65,7 @@ func SendGlobalAnnouncement(s *discordgo.Session, userID string) error {
 }
 
 func CommandGlobalAnnouncement(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	cfg := configuration.Get()
-	developerID := cfg.Discord.DeveloperID
-	if developerID == "" {
-		logger.Log.Error("DEVELOPER_ID not set in environment variables")
-		respondToInteraction(s, i, "Error: Developer ID not configured.")
-		return
-	}
-
-	var userID string
-	if i.Member != nil {
-		userID = i.Member.User.ID
-	} else if i.User != nil {
-		userID = i.User.ID
-	} else {
-		logger.Log.Error("Interaction doesn't have Member or User")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
-
-	if userID != developerID {
-		logger.Log.Warnf("Unauthorized user %s attempted to use global announcement command", userID)
-		respondToInteraction(s, i, "You don't have permission to use this command. Only the bot developer can send global announcements.")
-		return
-	}
-
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err := middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseModal,
 		Data: &discordgo.InteractionResponseData{
 			CustomID: "global_announcement_modal",
@@ -103,33 +95,20 @@ func CommandGlobalAnnouncement(s *discordgo.Session, i *discordgo.InteractionCre
 						},
 					},
 				},
-				discordgo.ActionsRow{
-					Components: []discordgo.MessageComponent{
-						discordgo.TextInput{
-							CustomID:  "reset_flags",
-							Label:     "Reset Initial Announcement Flag (yes/no)",
-							Style:     discordgo.TextInputShort,
-							Required:  true,
-							MinLength: 2,
-							MaxLength: 3,
-							Value:     "no",
-						},
-					},
-				},
 			},
 		},
 	})
 
 	if err != nil {
 		logger.Log.WithError(err).Error("Error showing announcement modal")
-		respondToInteraction(s, i, "Error creating announcement modal. Please try again.")
+		middleware.Reply(s, i, "Error creating announcement modal. Please try again.")
 	}
 }
 
 func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	data := i.ModalSubmitData()
 
-	var title, content, resetFlags string
+	var title, content string
 	for _, comp := range data.Components {
 		if row, ok := comp.(*discordgo.ActionsRow); ok {
 			for _, rowComp := range row.Components {
@@ -139,102 +118,18 @@ func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 						title = utils.SanitizeInput(strings.TrimSpace(textInput.Value))
 					case "announcement_content":
 						content = utils.SanitizeAnnouncement(strings.TrimSpace(textInput.Value))
-					case "reset_flags":
-						resetFlags = strings.ToLower(utils.SanitizeInput(strings.TrimSpace(textInput.Value)))
 					}
 				}
 			}
 		}
 	}
 
-	if resetFlags == "yes" {
-		logger.Log.Info("Resetting announcement flags for all users")
-		if err := database.DB.Exec("UPDATE user_settings SET has_seen_announcement = ?", false).Error; err != nil {
-			logger.Log.WithError(err).Error("Failed to reset announcement flags")
-			respondToInteraction(s, i, "Error resetting announcement flags. Please try again.")
-			return
-		}
-		logger.Log.Info("Reset all users' announcement flags successfully")
-	}
-
-	embed := &discordgo.MessageEmbed{
-		Title:       title,
-		Description: content,
-		Color:       0xFFD700,
-		Timestamp:   time.Now().Format(time.RFC3339),
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: "COD Status Bot Announcement",
-		},
-	}
-
-	var users []models.UserSettings
-	if err := database.DB.Find(&users).Error; err != nil {
-		logger.Log.WithError(err).Error("Error fetching users for announcement")
-		respondToInteraction(s, i, "Error fetching users. Please try again.")
-		return
-	}
-
-	successCount := 0
-	failCount := 0
-
-	for _, user := range users {
-		if err := sendDynamicAnnouncementToUser(s, user.UserID, embed); err != nil {
-			logger.Log.WithError(err).Errorf("Failed to send announcement to user %s", user.UserID)
-			failCount++
-		} else {
-			successCount++
-		}
-	}
-
-	respondToInteraction(s, i, fmt.Sprintf("Announcement sent successfully to %d users. %d users could not be reached.", successCount, failCount))
-}
-
-func sendDynamicAnnouncementToUser(s *discordgo.Session, userID string, embed *discordgo.MessageEmbed) error {
-	var userSettings models.UserSettings
-	result := database.DB.Where(models.UserSettings{UserID: userID}).FirstOrCreate(&userSettings)
-	if result.Error != nil {
-		logger.Log.WithError(result.Error).Error("Error getting user settings for global announcement")
-		return result.Error
-	}
-
-	channelID, err := getChannelForAnnouncement(s, userID, userSettings)
+	announcement, err := services.CreateAnnouncement(title, content, services.AudienceAll, "", time.Time{}, middleware.Current(i).UserID)
 	if err != nil {
-		return err
-	}
-
-	_, err = s.ChannelMessageSendEmbed(channelID, embed)
-	return err
-}
-
-func getChannelForAnnouncement(s *discordgo.Session, userID string, userSettings models.UserSettings) (string, error) {
-	if userSettings.NotificationType == "dm" {
-		channel, err := s.UserChannelCreate(userID)
-		if err != nil {
-			return "", fmt.Errorf("failed to create DM channel: %w", err)
-		}
-		return channel.ID, nil
-	}
-
-	var account models.Account
-	if err := database.DB.Where("user_id = ?", userID).Order("updated_at DESC").First(&account).Error; err != nil {
-		channel, err := s.UserChannelCreate(userID)
-		if err != nil {
-			return "", fmt.Errorf("both channel lookup and DM creation failed: %w", err)
-		}
-		return channel.ID, nil
+		logger.Log.WithError(err).Error("Error creating global announcement")
+		middleware.Reply(s, i, fmt.Sprintf("Error creating the announcement: %v", err))
+		return
 	}
-	return account.ChannelID, nil
-}
 
-func respondToInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: message,
-			Flags:   discordgo.MessageFlagsEphemeral,
-		},
-	})
-	if err != nil {
-		logger.Log.WithError(err).Error("Error responding to interaction")
-	}
+	middleware.Reply(s, i, fmt.Sprintf("Announcement #%d is being sent to all users. Follow it with `/announce progress id:%d`.", announcement.ID, announcement.ID))
 }
@@ -0,0 +1,42 @@
+package health
+
+import (
+	"context"
+
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/services"
+	"github.com/bwmarrin/discordgo"
+)
+
+var Command = registry.Command{
+	Name:        "health",
+	Description: "Show component health for the bot (Developer only)",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Muestra el estado de los componentes del bot (solo desarrollador)",
+		discordgo.SpanishLATAM: "Muestra el estado de los componentes del bot (solo desarrollador)",
+		discordgo.PortugueseBR: "Mostra a saúde dos componentes do bot (somente desenvolvedor)",
+	},
+	Permissions:   discordgo.PermissionAdministrator,
+	DeveloperOnly: true,
+	Handler:       CommandHealth,
+}
+
+func CommandHealth(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := middleware.Defer(s, i)
+	if err != nil {
+		logger.Log.WithError(err).Error("Error sending deferred response")
+		return
+	}
+
+	report := services.RunHealthChecks(context.Background(), s)
+
+	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{services.HealthReportEmbed(report)},
+		Flags:  discordgo.MessageFlagsEphemeral,
+	})
+	if err != nil {
+		logger.Log.WithError(err).Error("Error sending health report")
+	}
+}
@@ -3,15 +3,31 @@ package listaccounts
 import (
 	"fmt"
 	"os"
-	"time"
 
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
 	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
 	"github.com/bradselph/CODStatusBot/services"
 	"github.com/bwmarrin/discordgo"
 )
 
+var Command = registry.Command{
+	Name:        "listaccounts",
+	Description: "List all your monitored accounts with status and last checked time",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Lista tus cuentas vigiladas con su estado y última comprobación",
+		discordgo.SpanishLATAM: "Lista tus cuentas vigiladas con su estado y última comprobación",
+		discordgo.PortugueseBR: "Liste suas contas monitoradas com status e última verificação",
+	},
+	Handler: CommandListAccounts,
+	Components: []registry.Route{
+		registry.Exact("listaccounts", CommandListAccounts),
+	},
+}
+
 var (
 	//	checkCircle    = os.Getenv("CHECKCIRCLE")
 	banCircle = os.Getenv("BANCIRCLE")
@@ -21,95 +37,91 @@ var (
 )
 
 func CommandListAccounts(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Flags: discordgo.MessageFlagsEphemeral,
-		},
-	})
+	locale := services.InteractionLocale(i)
+	err := middleware.Defer(s, i)
 	if err != nil {
 		logger.Log.WithError(err).Error("Failed to defer response")
 		return
 	}
 
-	var userID string
-	if i.Member != nil {
-		userID = i.Member.User.ID
-	} else if i.User != nil {
-		userID = i.User.ID
-	} else {
-		logger.Log.Error("Interaction doesn't have Member or User")
-		sendFollowup(s, i, "An error occurred while processing your request.")
-		return
-	}
+	userID := middleware.Current(i).UserID
 
 	var accounts []models.Account
 	result := database.DB.Where("user_id = ?", userID).Find(&accounts)
 	if result.Error != nil {
 		logger.Log.WithError(result.Error).Error("Error fetching user accounts")
-		sendFollowup(s, i, "Error fetching your accounts. Please try again.")
+		middleware.Followup(s, i, "", services.UserErrorEmbed(locale, result.Error))
 		return
 	}
 
 	if len(accounts) == 0 {
-		sendFollowup(s, i, "You don't have any monitored accounts.")
+		middleware.Followup(s, i, i18n.T(locale, "accounts.none"))
 		return
 	}
 
-	balanceInfo := getBalanceInfo(userID)
-	description := "Here's a detailed list of all your monitored accounts:"
+	balanceInfo, keyErr := getBalanceInfo(locale, userID)
+	description := i18n.T(locale, "listaccounts.description")
 	if balanceInfo != "" {
 		description += balanceInfo
 	}
 
 	embed := &discordgo.MessageEmbed{
-		Title:       "Your Monitored Accounts",
+		Title:       i18n.T(locale, "listaccounts.title"),
 		Description: description,
 		Color:       0x00ff00,
 		Fields:      make([]*discordgo.MessageEmbedField, 0),
 	}
 
 	for _, account := range accounts {
-		checkStatus := services.GetCheckStatus(account.IsCheckDisabled)
-		cookieExpiration := services.FormatExpirationTime(account.SSOCookieExpiration)
-		creationDate := time.Unix(account.Created, 0).Format("2006-01-02")
-		lastCheckTime := time.Unix(account.LastCheck, 0).Format("2006-01-02 15:04:05")
+		checkStatus := services.GetCheckStatus(locale, account.IsCheckDisabled)
+		cookieExpiration := fmt.Sprintf("<t:%d:R>", account.SSOCookieExpiration)
+		creationDate := i18n.T(locale, "common.unknown")
+		if account.Created > 0 {
+			creationDate = fmt.Sprintf("<t:%d:d>", account.Created)
+		}
+		lastCheckTime := i18n.T(locale, "common.never_checked")
+		if account.LastCheck > 0 {
+			lastCheckTime = fmt.Sprintf("<t:%d:f>", account.LastCheck)
+		}
 
-		vipStatus := "No"
+		vipStatus := i18n.T(locale, "common.no")
 		if account.IsVIP {
-			vipStatus = "Yes ✓"
+			vipStatus = i18n.T(locale, "common.yes") + " ✓"
 		}
 
-		ogVerdan := "No"
+		ogVerdan := i18n.T(locale, "common.no")
 		if account.IsOGVerdansk {
 			ogVerdan = "OG Verdansk"
 		}
 
-		fieldValue := fmt.Sprintf("Status: %s\n", account.LastStatus)
+		fieldValue := i18n.T(locale, "listaccounts.status", services.StatusName(locale, account.LastStatus)) + "\n"
 
 		if account.IsPermabanned {
-			fieldValue += banCircle + "Account Permanently Banned\n"
+			fieldValue += banCircle + i18n.T(locale, "listaccounts.permaban") + "\n"
 		}
 		if account.IsTempbanned {
-			fieldValue += stopWatch + "Account Temporarily Banned\n"
+			fieldValue += stopWatch + i18n.T(locale, "listaccounts.tempban") + "\n"
 		}
 		if account.IsShadowbanned {
-			fieldValue += questionCircle + "Account Under Review\n"
+			fieldValue += questionCircle + i18n.T(locale, "listaccounts.shadowban") + "\n"
 		}
 		if account.IsExpiredCookie {
-			fieldValue += "⚠ Cookie Expired\n"
+			fieldValue += "⚠ " + i18n.T(locale, "listaccounts.cookie_expired") + "\n"
 		}
 		if account.ConsecutiveErrors > 0 {
-			fieldValue += fmt.Sprintf("⚠ Check Errors: %d\n", account.ConsecutiveErrors)
+			fieldValue += "⚠ " + i18n.T(locale, "listaccounts.check_errors", account.ConsecutiveErrors)
+			if label := services.CheckErrorCategory(account.LastErrorCategory).LocalizedLabel(locale); label != "" {
+				fieldValue += fmt.Sprintf(" (%s)", label)
+			}
+			fieldValue += "\n"
 		}
 
-		fieldValue += fmt.Sprintf("VIP Status: %s\nOG Verdansk: %s\nChecks: %s\nNotification Type: %s\n"+
-			"Cookie Expires: %s\nCreated: %s\nLast Checked: %s",
+		fieldValue += i18n.T(locale, "listaccounts.details",
 			vipStatus, ogVerdan, checkStatus, account.NotificationType,
 			cookieExpiration, creationDate, lastCheckTime)
 
 		if account.IsCheckDisabled {
-			fieldValue += fmt.Sprintf("\nDisabled Reason: %s", account.DisabledReason)
+			fieldValue += "\n" + i18n.T(locale, "listaccounts.disabled_reason", services.AccountDisabledReason(locale, account))
 		}
 
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
@@ -124,19 +136,14 @@ func CommandListAccounts(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		}
 	}
 
-	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Embeds: []*discordgo.MessageEmbed{embed},
-		Flags:  discordgo.MessageFlagsEphemeral,
-	})
-	if err != nil {
-		logger.Log.WithError(err).Error("Error sending followup message")
+	embeds := []*discordgo.MessageEmbed{embed}
+	if keyErr != nil {
+		embeds = append(embeds, services.UserErrorEmbed(locale, keyErr))
 	}
-}
 
-func sendFollowup(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
-	_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Content: content,
-		Flags:   discordgo.MessageFlagsEphemeral,
+	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Embeds: embeds,
+		Flags:  discordgo.MessageFlagsEphemeral,
 	})
 	if err != nil {
 		logger.Log.WithError(err).Error("Error sending followup message")
@@ -150,25 +157,27 @@ func getDisabledEmoji(isDisabled bool) string {
 	return "✓"
 }
 
-func getBalanceInfo(userID string) string {
+// getBalanceInfo returns the balance line for the list embed, or the error
+// that kept it from loading the user's captcha key.
+func getBalanceInfo(locale, userID string) (string, error) {
 	userSettings, err := services.GetUserSettings(userID)
 	if err != nil {
 		logger.Log.WithError(err).Error("Error fetching user settings")
-		return ""
+		return "", nil
 	}
 
 	if !services.IsServiceEnabled(userSettings.PreferredCaptchaProvider) {
-		return ""
+		return "", nil
 	}
 
 	apiKey, balance, err := services.GetUserCaptchaKey(userID)
 	if err != nil {
 		logger.Log.WithError(err).Error("Error getting user captcha key")
-		return ""
+		return "", err
 	}
 
 	if apiKey == "" {
-		return "\n\nYou are using the bot's default API key. Consider setting up your own key using /setcaptchaservice for unlimited checks."
+		return "\n\n" + i18n.T(locale, "balance.default_key"), nil
 	}
 
 	var threshold float64
@@ -181,12 +190,11 @@ func getBalanceInfo(userID string) string {
 		threshold = 250
 	}
 
-	balanceMsg := fmt.Sprintf("\n\nYour current %s balance: %.2f points",
-		userSettings.PreferredCaptchaProvider, balance)
+	balanceMsg := "\n\n" + i18n.T(locale, "listaccounts.balance", userSettings.PreferredCaptchaProvider, balance)
 
 	if balance < threshold {
-		balanceMsg += fmt.Sprintf(" (Warning: Below recommended %.2f points)", threshold)
+		balanceMsg += " " + i18n.T(locale, "listaccounts.balance_low", threshold)
 	}
 
-	return balanceMsg
+	return balanceMsg, nil
 }
@@ -0,0 +1,243 @@
+package middleware
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/configuration"
+	"github.com/bradselph/CODStatusBot/i18n"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bradselph/CODStatusBot/services"
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickmn/go-cache"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Middleware wraps an interaction handler with behaviour shared between
+// commands. A middleware that does not call next ends the interaction.
+type Middleware func(next services.InteractionHandler) services.InteractionHandler
+
+// Chain wraps handler in middlewares, the first one outermost.
+func Chain(handler services.InteractionHandler, middlewares ...Middleware) services.InteractionHandler {
+	for n := len(middlewares) - 1; n >= 0; n-- {
+		handler = middlewares[n](handler)
+	}
+	return handler
+}
+
+// Request is the state the pipeline keeps for one interaction. Handlers
+// reach it through Current.
+type Request struct {
+	// UserID is empty only when the interaction carries no user, which
+	// ResolveUser rejects.
+	UserID string
+	// Name is the command name, or the custom ID of a component or modal.
+	Name  string
+	Start time.Time
+
+	mu        sync.Mutex
+	settings  *models.UserSettings
+	responded bool
+	failure   string
+}
+
+// requests outlive the handler so goroutines it starts can still follow up;
+// Discord keeps interaction tokens valid for 15 minutes.
+var requests = cache.New(15*time.Minute, 5*time.Minute)
+
+// Current returns the request state of i, creating it on first use.
+func Current(i *discordgo.InteractionCreate) *Request {
+	if req, ok := requests.Get(i.ID); ok {
+		return req.(*Request)
+	}
+	req := &Request{Name: services.InteractionKey(i), Start: time.Now()}
+	req.UserID, _ = services.GetUserID(i)
+	requests.SetDefault(i.ID, req)
+	return req
+}
+
+// Settings returns the user's settings, loading them on first use. The copy
+// is the handler's to change and save.
+func (r *Request) Settings() (models.UserSettings, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.settings == nil {
+		settings, err := services.GetUserSettings(r.UserID)
+		if err != nil {
+			return models.UserSettings{}, err
+		}
+		r.settings = &settings
+	}
+	return *r.settings, nil
+}
+
+// HasOwnCaptchaKey reports whether the user set up an API key of their own
+// instead of using the bot's default one.
+func (r *Request) HasOwnCaptchaKey() bool {
+	settings, err := r.Settings()
+	if err != nil {
+		return false
+	}
+	return services.HasOwnCaptchaKey(settings)
+}
+
+// Tier returns the user's plan, or the free tier when their settings cannot
+// be loaded.
+func (r *Request) Tier() services.Tier {
+	settings, err := r.Settings()
+	if err != nil {
+		return services.TierFree
+	}
+	return services.UserTier(settings)
+}
+
+// Fail records why the request did not succeed, for the command log and
+// metrics. The first reason wins.
+func (r *Request) Fail(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failure == "" {
+		r.failure = reason
+	}
+}
+
+func (r *Request) failed() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.failure
+}
+
+// markResponded records that the initial response was sent and reports
+// whether it already had been.
+func (r *Request) markResponded() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	already := r.responded
+	r.responded = true
+	return already
+}
+
+// Measure traces a slash command and records its latency and outcome in the
+// metrics and the command log.
+func Measure(next services.InteractionHandler) services.InteractionHandler {
+	return func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		req := Current(i)
+		_, span := services.StartInteractionSpan(i, "command."+req.Name)
+		defer func() {
+			failure := req.failed()
+			success := failure == ""
+			span.SetAttributes(attribute.String("discord.command", req.Name), attribute.Bool("command.success", success))
+			if !success {
+				span.SetStatus(codes.Error, failure)
+			}
+			span.End()
+
+			userID := req.UserID
+			if userID == "" {
+				userID = "unknown"
+			}
+			elapsed := time.Since(req.Start)
+			services.ObserveCommandLatency(req.Name, success, elapsed)
+			services.LogCommandExecution(req.Name, userID, i.GuildID, success, elapsed.Milliseconds(), failure)
+		}()
+		next(s, i)
+	}
+}
+
+// Recover turns a panicking handler into a logged failure and an error reply
+// instead of a crashed gateway goroutine.
+func Recover(next services.InteractionHandler) services.InteractionHandler {
+	return func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		defer func() {
+			if r := recover(); r != nil {
+				req := Current(i)
+				logger.FromContext(services.InteractionContext(i)).Errorf("Recovered from panic handling %s: %v\n%s", req.Name, r, debug.Stack())
+				req.Fail(fmt.Sprintf("panic: %v", r))
+				Reply(s, i, i18n.T(services.InteractionLocale(i), "error.processing"))
+			}
+		}()
+		next(s, i)
+	}
+}
+
+// ResolveUser stops interactions that carry no user, so later middleware and
+// handlers can rely on Request.UserID.
+func ResolveUser(next services.InteractionHandler) services.InteractionHandler {
+	return func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		req := Current(i)
+		if req.UserID == "" {
+			logger.FromContext(services.InteractionContext(i)).Error("Interaction doesn't have Member or User")
+			req.Fail("Missing user information")
+			Reply(s, i, i18n.T(services.InteractionLocale(i), "error.processing"))
+			return
+		}
+		next(s, i)
+	}
+}
+
+// TrackContext records where the user is interacting from. Failures are
+// logged and do not stop the interaction.
+func TrackContext(next services.InteractionHandler) services.InteractionHandler {
+	return func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		if err := services.TrackUserInteraction(s, i); err != nil {
+			logger.FromContext(services.InteractionContext(i)).WithError(err).Error("Failed to track user interaction context")
+		}
+		next(s, i)
+	}
+}
+
+// LoadSettings loads the user's settings ahead of the handler and stops the
+// interaction when they cannot be read.
+func LoadSettings(next services.InteractionHandler) services.InteractionHandler {
+	return func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		req := Current(i)
+		if _, err := req.Settings(); err != nil {
+			logger.FromContext(services.InteractionContext(i)).WithError(err).Error("Error getting user settings")
+			req.Fail("Error getting user settings")
+			Reply(s, i, i18n.T(services.InteractionLocale(i), "error.fetch_settings"))
+			return
+		}
+		next(s, i)
+	}
+}
+
+// DeveloperOnly lets only the configured developer through.
+func DeveloperOnly(next services.InteractionHandler) services.InteractionHandler {
+	return func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		req := Current(i)
+		developerID := configuration.Get().Discord.DeveloperID
+		if developerID == "" {
+			logger.Log.Error("DEVELOPER_ID not set in environment variables")
+			req.Fail("Developer ID not configured")
+			Reply(s, i, i18n.T(services.InteractionLocale(i), "middleware.developer_unset"))
+			return
+		}
+		if req.UserID != developerID {
+			logger.Log.Warnf("Unauthorized user %s attempted to use %s", req.UserID, req.Name)
+			req.Fail("Unauthorized")
+			Reply(s, i, i18n.T(services.InteractionLocale(i), "middleware.developer_only"))
+			return
+		}
+		next(s, i)
+	}
+}
+
+// AdminOnly lets through the developer and the admins listed in
+// ADMIN_USER_IDS.
+func AdminOnly(next services.InteractionHandler) services.InteractionHandler {
+	return func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		req := Current(i)
+		if !services.IsBotAdmin(req.UserID) {
+			logger.Log.Warnf("Unauthorized user %s attempted to use %s", req.UserID, req.Name)
+			req.Fail("Unauthorized")
+			Reply(s, i, i18n.T(services.InteractionLocale(i), "middleware.admin_only"))
+			return
+		}
+		next(s, i)
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/bradselph/CODStatusBot/i18n"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/services"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Policy declares how often one user may run a command. Every limit must
+// allow an invocation for it to go through. The zero value is unlimited.
+type Policy struct {
+	// Limits are checked with the shared rate limiter. A limit without an
+	// action is keyed by the command name.
+	Limits []services.RateLimitPolicy
+	// Dynamic, when set, is read on every invocation instead of Limits, so
+	// limits can follow configuration reloads and the user's tier.
+	Dynamic func(*Request) []services.RateLimitPolicy
+	// Exempt lets some requests through, such as users with their own
+	// captcha key.
+	Exempt func(*Request) bool
+	// Message is the catalog ID of the reply when limited, rendered with the
+	// command name and the wait. Defaults to "middleware.rate_limited".
+	Message string
+}
+
+func (p Policy) limits(req *Request) []services.RateLimitPolicy {
+	limits := p.Limits
+	if p.Dynamic != nil {
+		limits = p.Dynamic(req)
+	}
+	active := make([]services.RateLimitPolicy, 0, len(limits))
+	for _, limit := range limits {
+		if limit.Limit <= 0 || limit.Window <= 0 {
+			continue
+		}
+		if limit.Action == "" {
+			limit.Action = req.Name
+		}
+		active = append(active, limit)
+	}
+	return active
+}
+
+// RateLimit enforces policy per user and command name. If the limiter's
+// store fails the invocation goes through.
+func RateLimit(policy Policy) Middleware {
+	return func(next services.InteractionHandler) services.InteractionHandler {
+		return func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+			req := Current(i)
+			limits := policy.limits(req)
+			if len(limits) == 0 || (policy.Exempt != nil && policy.Exempt(req)) {
+				next(s, i)
+				return
+			}
+
+			decision, err := services.Limiter().Allow(services.UserSubject(req.UserID), limits...)
+			if err != nil {
+				logger.Log.WithError(err).Errorf("Rate limiter unavailable for %s", req.Name)
+			}
+			if !decision.Allowed {
+				logger.Log.Infof("Rate limited %s for user %s, %s left", req.Name, req.UserID, decision.RetryAfter)
+				req.Fail("Rate limited")
+				message := policy.Message
+				if message == "" {
+					message = "middleware.rate_limited"
+				}
+				Reply(s, i, i18n.T(services.InteractionLocale(i), message, req.Name, services.FormatDuration(decision.RetryAfter+time.Minute-1)))
+				return
+			}
+			next(s, i)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Respond sends the initial response to i. Use it instead of
+// Session.InteractionRespond so later replies know to follow up.
+func Respond(s *discordgo.Session, i *discordgo.InteractionCreate, response *discordgo.InteractionResponse) error {
+	err := s.InteractionRespond(i.Interaction, response)
+	if err == nil {
+		Current(i).markResponded()
+	}
+	return err
+}
+
+// Defer acknowledges i with an ephemeral "thinking" state for handlers that
+// need more than Discord's three seconds. Replies after it are follow-ups.
+func Defer(s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	return Respond(s, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// Reply sends an ephemeral message: the initial response if there is none
+// yet, otherwise a follow-up.
+func Reply(s *discordgo.Session, i *discordgo.InteractionCreate, content string, embeds ...*discordgo.MessageEmbed) {
+	if Current(i).markResponded() {
+		Followup(s, i, content, embeds...)
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Embeds:  embeds,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		logger.Log.WithError(err).Error("Error responding to interaction, trying followup")
+		Followup(s, i, content, embeds...)
+	}
+}
+
+// Update replaces the message a component belongs to with content and
+// removes its buttons. Other interactions get a Reply.
+func Update(s *discordgo.Session, i *discordgo.InteractionCreate, content string, embeds ...*discordgo.MessageEmbed) {
+	if i.Type != discordgo.InteractionMessageComponent || Current(i).markResponded() {
+		Reply(s, i, content, embeds...)
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Embeds:     embeds,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		logger.Log.WithError(err).Error("Error updating message, trying followup")
+		Followup(s, i, content, embeds...)
+	}
+}
+
+// Followup sends an ephemeral follow-up message to an acknowledged
+// interaction.
+func Followup(s *discordgo.Session, i *discordgo.InteractionCreate, content string, embeds ...*discordgo.MessageEmbed) {
+	if i.Interaction == nil || i.Interaction.Token == "" {
+		logger.Log.Error("Cannot send followup: invalid interaction or token")
+		return
+	}
+
+	_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: content,
+		Embeds:  embeds,
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+	if err != nil {
+		logger.Log.WithError(err).Error("Error sending followup message")
+	}
+}
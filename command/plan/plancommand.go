@@ -0,0 +1,72 @@
+package plan
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
+	"github.com/bradselph/CODStatusBot/i18n"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/services"
+	"github.com/bwmarrin/discordgo"
+)
+
+var Command = registry.Command{
+	Name:        "plan",
+	Description: "See your plan and its limits",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Consulta tu plan y sus límites",
+		discordgo.SpanishLATAM: "Consulta tu plan y sus límites",
+		discordgo.PortugueseBR: "Veja seu plano e seus limites",
+	},
+	Handler: CommandPlan,
+}
+
+func CommandPlan(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
+	req := middleware.Current(i)
+
+	settings, err := req.Settings()
+	if err != nil {
+		logger.Log.WithError(err).Errorf("Error fetching settings for user %s", req.UserID)
+		middleware.Reply(s, i, i18n.T(locale, "plan.error"))
+		return
+	}
+
+	tier := services.UserTier(settings)
+	quotas := tier.Quotas()
+	description := i18n.T(locale, "plan.description")
+	if tier == services.TierFree {
+		description += "\n\n" + i18n.T(locale, "plan.upgrade")
+	}
+
+	interval := i18n.T(locale, "plan.no_minimum")
+	if quotas.MinCheckInterval > 0 {
+		interval = services.FormatDuration(quotas.MinCheckInterval)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       i18n.T(locale, "plan.title", i18n.T(locale, "tier."+string(tier))),
+		Description: description,
+		Color:       0x00ff00,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: i18n.T(locale, "plan.field.max_accounts"), Value: quota(locale, quotas.MaxAccounts), Inline: true},
+			{Name: i18n.T(locale, "plan.field.min_check_interval"), Value: interval, Inline: true},
+			{Name: i18n.T(locale, "plan.field.daily_checks"), Value: quota(locale, quotas.DailyManualChecks), Inline: true},
+			{Name: i18n.T(locale, "plan.field.notifications"), Value: quota(locale, quotas.NotificationsPerHour), Inline: true},
+			{Name: i18n.T(locale, "plan.field.verdansk"), Value: quota(locale, quotas.VerdanskPerDay), Inline: true},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	middleware.Reply(s, i, "", embed)
+}
+
+// quota renders a limit where zero means none.
+func quota(locale string, n int) string {
+	if n <= 0 {
+		return i18n.T(locale, "plan.unlimited")
+	}
+	return fmt.Sprint(n)
+}
@@ -0,0 +1,119 @@
+package ratelimits
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
+	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bradselph/CODStatusBot/services"
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxFields is Discord's limit on fields per embed.
+const maxFields = 25
+
+// actionLabels names the limits that are not keyed by a command name.
+var actionLabels = map[string]string{
+	"checknow.checks": "ratelimits.action.checks",
+	"notification":    "ratelimits.action.notification",
+	"verdansk.daily":  "ratelimits.action.verdansk_daily",
+}
+
+var Command = registry.Command{
+	Name:        "ratelimits",
+	Description: "See which rate limits you are waiting on",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Consulta qué límites de uso te hacen esperar",
+		discordgo.SpanishLATAM: "Consulta qué límites de uso te hacen esperar",
+		discordgo.PortugueseBR: "Veja quais limites de uso você está aguardando",
+	},
+	Handler: CommandRateLimits,
+}
+
+func CommandRateLimits(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
+	userID := middleware.Current(i).UserID
+
+	statuses, err := services.Limiter().Status(services.UserSubject(userID))
+	if err != nil {
+		logger.Log.WithError(err).Errorf("Error fetching rate limits for user %s", userID)
+		middleware.Reply(s, i, i18n.T(locale, "ratelimits.error"))
+		return
+	}
+
+	if len(statuses) == 0 {
+		middleware.Reply(s, i, i18n.T(locale, "ratelimits.none"))
+		return
+	}
+
+	titles := accountTitles(userID)
+	now := time.Now()
+	embed := &discordgo.MessageEmbed{
+		Title:       i18n.T(locale, "ratelimits.title"),
+		Description: i18n.T(locale, "ratelimits.description"),
+		Color:       0x00ff00,
+		Timestamp:   now.Format(time.RFC3339),
+	}
+
+	for _, status := range statuses {
+		if len(embed.Fields) == maxFields {
+			break
+		}
+
+		value := i18n.T(locale, "ratelimits.usage", status.Remaining, status.Limit, services.FormatDuration(status.Window))
+		if status.RetryAfter > 0 {
+			embed.Color = 0xFFA500
+			value += "\n" + i18n.T(locale, "ratelimits.waiting", relative(now, status.RetryAfter))
+		} else {
+			value += "\n" + i18n.T(locale, "ratelimits.full", relative(now, status.ResetAfter))
+		}
+
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  actionLabel(locale, status.Action, titles),
+			Value: value,
+		})
+	}
+
+	middleware.Reply(s, i, "", embed)
+}
+
+func actionLabel(locale, action string, titles map[uint]string) string {
+	if id, ok := strings.CutPrefix(action, "check_account."); ok {
+		accountID, _ := strconv.ParseUint(id, 10, 64)
+		title, known := titles[uint(accountID)]
+		if !known {
+			title = "#" + id
+		}
+		return i18n.T(locale, "ratelimits.action.account_checks", title)
+	}
+	if key, ok := actionLabels[action]; ok {
+		return i18n.T(locale, key)
+	}
+	return "/" + action
+}
+
+func accountTitles(userID string) map[uint]string {
+	var accounts []models.Account
+	if err := database.DB.Select("id", "title").Where("user_id = ?", userID).Find(&accounts).Error; err != nil {
+		logger.Log.WithError(err).Error("Error fetching account titles")
+	}
+
+	titles := make(map[uint]string, len(accounts))
+	for _, account := range accounts {
+		titles[account.ID] = account.Title
+	}
+	return titles
+}
+
+// relative renders the moment d from now as a Discord timestamp, which
+// each client shows in its own language.
+func relative(now time.Time, d time.Duration) string {
+	return fmt.Sprintf("<t:%d:R>", now.Add(d).Unix())
+}
@@ -0,0 +1,129 @@
+package registry
+
+import (
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/services"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Command declares everything the bot needs to know about one slash command:
+// its definition, the components and modals it owns, who may use it and how
+// often. Routing and registration are generated from these declarations.
+type Command struct {
+	Name        string
+	Description string
+	Options     []*discordgo.ApplicationCommandOption
+
+	// Localized names and descriptions, keyed by Discord locale.
+	NameLocalizations        map[discordgo.Locale]string
+	DescriptionLocalizations map[discordgo.Locale]string
+
+	// Permissions is the default member permission a server member needs to
+	// see the command; 0 shows it to everyone.
+	Permissions int64
+	// DeveloperOnly restricts the command and its components and modals to
+	// the configured developer.
+	DeveloperOnly bool
+	// AdminOnly restricts the command and its components and modals to the
+	// developer and the configured admins.
+	AdminOnly bool
+	// RateLimit throttles invocations of the slash command per user.
+	RateLimit middleware.Policy
+
+	Handler    services.InteractionHandler
+	Components []Route
+	Modals     []Route
+}
+
+// Route maps a component or modal custom ID to its handler.
+type Route struct {
+	ID      string
+	Prefix  bool
+	Handler services.InteractionHandler
+}
+
+func Exact(id string, handler services.InteractionHandler) Route {
+	return Route{ID: id, Handler: handler}
+}
+
+// Prefix routes every custom ID that starts with prefix, for IDs that carry
+// an account ID or a provider name.
+func Prefix(prefix string, handler services.InteractionHandler) Route {
+	return Route{ID: prefix, Prefix: true, Handler: handler}
+}
+
+// Definition is the command as sent to Discord.
+func (c Command) Definition() *discordgo.ApplicationCommand {
+	def := &discordgo.ApplicationCommand{
+		Type:         discordgo.ChatApplicationCommand,
+		Name:         c.Name,
+		Description:  c.Description,
+		Options:      c.Options,
+		DMPermission: boolPtr(true),
+	}
+	if len(c.NameLocalizations) > 0 {
+		names := c.NameLocalizations
+		def.NameLocalizations = &names
+	}
+	if len(c.DescriptionLocalizations) > 0 {
+		descriptions := c.DescriptionLocalizations
+		def.DescriptionLocalizations = &descriptions
+	}
+	if c.Permissions != 0 {
+		permissions := c.Permissions
+		def.DefaultMemberPermissions = &permissions
+	}
+	return def
+}
+
+// Pipeline is the middleware shared by every route, run ahead of a
+// command's own authorization and rate limit.
+type Pipeline struct {
+	Commands   []middleware.Middleware
+	Components []middleware.Middleware
+}
+
+// Install adds the command's slash, component and modal routes to r, each
+// wrapped in the pipeline.
+func Install(r *services.InteractionRouter, commands []Command, pipeline Pipeline) {
+	for _, cmd := range commands {
+		slash := append(append([]middleware.Middleware{}, pipeline.Commands...), cmd.guards(true)...)
+		r.Handle(discordgo.InteractionApplicationCommand, cmd.Name, middleware.Chain(cmd.Handler, slash...))
+
+		components := append(append([]middleware.Middleware{}, pipeline.Components...), cmd.guards(false)...)
+		for _, route := range cmd.Components {
+			route.install(r, discordgo.InteractionMessageComponent, middleware.Chain(route.Handler, components...))
+		}
+		for _, route := range cmd.Modals {
+			route.install(r, discordgo.InteractionModalSubmit, middleware.Chain(route.Handler, components...))
+		}
+	}
+}
+
+// guards are the command's own middleware. The rate limit counts slash
+// invocations only.
+func (c Command) guards(slash bool) []middleware.Middleware {
+	var guards []middleware.Middleware
+	if c.DeveloperOnly {
+		guards = append(guards, middleware.DeveloperOnly)
+	}
+	if c.AdminOnly {
+		guards = append(guards, middleware.AdminOnly)
+	}
+	if slash {
+		guards = append(guards, middleware.RateLimit(c.RateLimit))
+	}
+	return guards
+}
+
+func (route Route) install(r *services.InteractionRouter, kind discordgo.InteractionType, handler services.InteractionHandler) {
+	if route.Prefix {
+		r.HandlePrefix(kind, route.ID, handler)
+		return
+	}
+	r.Handle(kind, route.ID, handler)
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,171 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Sync makes the commands registered with Discord match commands. Global
+// commands are used when guildID is empty. Discord is only asked to
+// overwrite the set when a definition was added, removed or changed, so
+// restarts do not burn the command registration rate limit.
+func Sync(s *discordgo.Session, appID, guildID string, commands []Command) error {
+	scope := "global"
+	if guildID != "" {
+		scope = "guild " + guildID
+	}
+
+	existing, err := s.ApplicationCommands(appID, guildID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s commands: %w", scope, err)
+	}
+
+	current := make(map[string]string, len(existing))
+	for _, cmd := range existing {
+		current[cmd.Name] = fingerprint(cmd)
+	}
+
+	definitions := make([]*discordgo.ApplicationCommand, 0, len(commands))
+	var added, changed []string
+	for _, cmd := range commands {
+		def := cmd.Definition()
+		definitions = append(definitions, def)
+
+		previous, ok := current[def.Name]
+		switch {
+		case !ok:
+			added = append(added, def.Name)
+		case previous != fingerprint(def):
+			changed = append(changed, def.Name)
+		}
+		delete(current, def.Name)
+	}
+	removed := make([]string, 0, len(current))
+	for name := range current {
+		removed = append(removed, name)
+	}
+	sort.Strings(removed)
+
+	if len(added) == 0 && len(changed) == 0 && len(removed) == 0 {
+		logger.Log.Infof("All %d %s commands are up to date", len(definitions), scope)
+		return nil
+	}
+
+	logger.Log.Infof("Updating %s commands: added %v, changed %v, removed %v", scope, added, changed, removed)
+	if _, err := s.ApplicationCommandBulkOverwrite(appID, guildID, definitions); err != nil {
+		return fmt.Errorf("failed to register %s commands: %w", scope, err)
+	}
+	return nil
+}
+
+// commandShape is the part of a command definition we control. Discord adds
+// IDs and versions and drops empty fields, so definitions are compared
+// through this shape rather than as sent.
+type commandShape struct {
+	Type                     discordgo.ApplicationCommandType
+	Name                     string
+	Description              string
+	NameLocalizations        map[discordgo.Locale]string
+	DescriptionLocalizations map[discordgo.Locale]string
+	Permissions              int64
+	DMPermission             bool
+	NSFW                     bool
+	Options                  []optionShape
+}
+
+type optionShape struct {
+	Type                     discordgo.ApplicationCommandOptionType
+	Name                     string
+	Description              string
+	NameLocalizations        map[discordgo.Locale]string
+	DescriptionLocalizations map[discordgo.Locale]string
+	Required                 bool
+	Autocomplete             bool
+	Choices                  []choiceShape
+	ChannelTypes             []discordgo.ChannelType
+	MinValue                 *float64
+	MaxValue                 float64
+	MinLength                int
+	MaxLength                int
+	Options                  []optionShape
+}
+
+type choiceShape struct {
+	Name              string
+	NameLocalizations map[discordgo.Locale]string
+	Value             interface{}
+}
+
+func fingerprint(cmd *discordgo.ApplicationCommand) string {
+	shape := commandShape{
+		Type:         cmd.Type,
+		Name:         cmd.Name,
+		Description:  cmd.Description,
+		DMPermission: cmd.DMPermission == nil || *cmd.DMPermission,
+		NSFW:         cmd.NSFW != nil && *cmd.NSFW,
+		Options:      optionShapes(cmd.Options),
+	}
+	// Discord treats a command without a type as a slash command.
+	if shape.Type == 0 {
+		shape.Type = discordgo.ChatApplicationCommand
+	}
+	if cmd.NameLocalizations != nil && len(*cmd.NameLocalizations) > 0 {
+		shape.NameLocalizations = *cmd.NameLocalizations
+	}
+	if cmd.DescriptionLocalizations != nil && len(*cmd.DescriptionLocalizations) > 0 {
+		shape.DescriptionLocalizations = *cmd.DescriptionLocalizations
+	}
+	if cmd.DefaultMemberPermissions != nil {
+		shape.Permissions = *cmd.DefaultMemberPermissions
+	}
+
+	// encoding/json sorts map keys, so equal shapes encode identically.
+	encoded, _ := json.Marshal(shape)
+	return string(encoded)
+}
+
+func optionShapes(options []*discordgo.ApplicationCommandOption) []optionShape {
+	if len(options) == 0 {
+		return nil
+	}
+	shapes := make([]optionShape, 0, len(options))
+	for _, opt := range options {
+		shape := optionShape{
+			Type:         opt.Type,
+			Name:         opt.Name,
+			Description:  opt.Description,
+			Required:     opt.Required,
+			Autocomplete: opt.Autocomplete,
+			MinValue:     opt.MinValue,
+			MaxValue:     opt.MaxValue,
+			MaxLength:    opt.MaxLength,
+			Options:      optionShapes(opt.Options),
+		}
+		// Discord leaves out unset constraints, so nil and zero compare equal.
+		if opt.MinLength != nil {
+			shape.MinLength = *opt.MinLength
+		}
+		if len(opt.ChannelTypes) > 0 {
+			shape.ChannelTypes = opt.ChannelTypes
+		}
+		if len(opt.NameLocalizations) > 0 {
+			shape.NameLocalizations = opt.NameLocalizations
+		}
+		if len(opt.DescriptionLocalizations) > 0 {
+			shape.DescriptionLocalizations = opt.DescriptionLocalizations
+		}
+		for _, choice := range opt.Choices {
+			c := choiceShape{Name: choice.Name, Value: choice.Value}
+			if len(choice.NameLocalizations) > 0 {
+				c.NameLocalizations = choice.NameLocalizations
+			}
+			shape.Choices = append(shape.Choices, c)
+		}
+		shapes = append(shapes, shape)
+	}
+	return shapes
+}
@@ -0,0 +1,116 @@
+package registry
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func testCommand() Command {
+	minLength := 3
+	minValue := 1.0
+	return Command{
+		Name:                     "test",
+		Description:              "Test command",
+		NameLocalizations:        map[discordgo.Locale]string{discordgo.SpanishES: "prueba"},
+		DescriptionLocalizations: map[discordgo.Locale]string{discordgo.SpanishES: "Comando de prueba"},
+		Permissions:              discordgo.PermissionManageServer,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "audience",
+				Description: "Who to send to",
+				Required:    true,
+				MinLength:   &minLength,
+				MaxLength:   32,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Everyone", NameLocalizations: map[discordgo.Locale]string{discordgo.SpanishES: "Todos"}, Value: "all"},
+					{Name: "Admins", Value: "admins"},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "minutes",
+				Description: "Interval",
+				MinValue:    &minValue,
+				MaxValue:    1440,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Hourly", Value: 60},
+				},
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "channel",
+				Description:  "Where to post",
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+			},
+		},
+	}
+}
+
+// echo returns def as Discord sends it back: with IDs and a version, and
+// decoded from JSON so numbers are float64.
+func echo(t *testing.T, def *discordgo.ApplicationCommand) *discordgo.ApplicationCommand {
+	t.Helper()
+	encoded, err := json.Marshal(def)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		t.Fatal(err)
+	}
+	fields["id"] = "1100000000000000000"
+	fields["application_id"] = "1000000000000000000"
+	fields["version"] = "1200000000000000000"
+	fields["nsfw"] = false
+	if encoded, err = json.Marshal(fields); err != nil {
+		t.Fatal(err)
+	}
+
+	var cmd discordgo.ApplicationCommand
+	if err := json.Unmarshal(encoded, &cmd); err != nil {
+		t.Fatal(err)
+	}
+	return &cmd
+}
+
+func TestFingerprintMatchesDiscordEcho(t *testing.T) {
+	def := testCommand().Definition()
+	if got, want := fingerprint(echo(t, def)), fingerprint(def); got != want {
+		t.Errorf("echo fingerprint differs:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestFingerprintDetectsChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Command)
+	}{
+		{name: "description", change: func(c *Command) { c.Description = "Changed" }},
+		{name: "name localization", change: func(c *Command) { c.NameLocalizations[discordgo.PortugueseBR] = "teste" }},
+		{name: "permissions", change: func(c *Command) { c.Permissions = 0 }},
+		{name: "required", change: func(c *Command) { c.Options[0].Required = false }},
+		{name: "max length", change: func(c *Command) { c.Options[0].MaxLength = 64 }},
+		{name: "choice value", change: func(c *Command) { c.Options[0].Choices[1].Value = "mods" }},
+		{name: "choice localization", change: func(c *Command) {
+			c.Options[0].Choices[0].NameLocalizations[discordgo.SpanishES] = "Todo el mundo"
+		}},
+		{name: "max value", change: func(c *Command) { c.Options[1].MaxValue = 60 }},
+		{name: "channel types", change: func(c *Command) {
+			c.Options[2].ChannelTypes = []discordgo.ChannelType{discordgo.ChannelTypeGuildNews}
+		}},
+	}
+
+	registered := echo(t, testCommand().Definition())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := testCommand()
+			tt.change(&cmd)
+			if fingerprint(cmd.Definition()) == fingerprint(registered) {
+				t.Errorf("fingerprint unchanged after changing the %s", tt.name)
+			}
+		})
+	}
+}
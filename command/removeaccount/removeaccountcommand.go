@@ -5,35 +5,47 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
 	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bradselph/CODStatusBot/services"
 
 	"github.com/bwmarrin/discordgo"
 )
 
+var Command = registry.Command{
+	Name:        "removeaccount",
+	Description: "Remove a monitored account",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Elimina una cuenta vigilada",
+		discordgo.SpanishLATAM: "Elimina una cuenta vigilada",
+		discordgo.PortugueseBR: "Remova uma conta monitorada",
+	},
+	Handler: CommandRemoveAccount,
+	Components: []registry.Route{
+		registry.Prefix("remove_account_", HandleAccountSelection),
+		registry.Prefix("confirm_remove_", HandleConfirmation),
+		registry.Exact("cancel_remove", HandleConfirmation),
+	},
+}
+
 func CommandRemoveAccount(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	var userID string
-	if i.Member != nil {
-		userID = i.Member.User.ID
-	} else if i.User != nil {
-		userID = i.User.ID
-	} else {
-		logger.Log.Error("Interaction doesn't have Member or User")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
+	locale := services.InteractionLocale(i)
+	userID := middleware.Current(i).UserID
 
 	var accounts []models.Account
 	result := database.DB.Where("user_id = ?", userID).Find(&accounts)
 	if result.Error != nil {
 		logger.Log.WithError(result.Error).Error("Error fetching user accounts")
-		respondToInteraction(s, i, "Error fetching your accounts. Please try again.")
+		middleware.Update(s, i, i18n.T(locale, "error.fetch_accounts"))
 		return
 	}
 
 	if len(accounts) == 0 {
-		respondToInteraction(s, i, "You don't have any monitored accounts to remove.")
+		middleware.Update(s, i, i18n.T(locale, "removeaccount.none"))
 		return
 	}
 
@@ -58,10 +70,10 @@ func CommandRemoveAccount(s *discordgo.Session, i *discordgo.InteractionCreate)
 	if len(currentRow) > 0 {
 		components = append(components, discordgo.ActionsRow{Components: currentRow})
 	}
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err := middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content:    "Select an account to remove:",
+			Content:    i18n.T(locale, "removeaccount.select"),
 			Flags:      discordgo.MessageFlagsEphemeral,
 			Components: components,
 		},
@@ -72,11 +84,12 @@ func CommandRemoveAccount(s *discordgo.Session, i *discordgo.InteractionCreate)
 }
 
 func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 	customID := i.MessageComponentData().CustomID
 	accountID, err := strconv.Atoi(strings.TrimPrefix(customID, "remove_account_"))
 	if err != nil {
 		logger.Log.WithError(err).Error("Error parsing account ID")
-		respondToInteraction(s, i, "Error processing your selection. Please try again.")
+		middleware.Update(s, i, i18n.T(locale, "error.selection"))
 		return
 	}
 
@@ -84,24 +97,24 @@ func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate
 	result := database.DB.First(&account, accountID)
 	if result.Error != nil {
 		logger.Log.WithError(result.Error).Error("Error fetching account")
-		respondToInteraction(s, i, "Error: Account not found or you don't have permission to remove it.")
+		middleware.Update(s, i, i18n.T(locale, "removeaccount.not_found"))
 		return
 	}
 
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err = middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseUpdateMessage,
 		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("Are you sure you want to remove the account '%s'? This action is permanent and cannot be undone.", account.Title),
+			Content: i18n.T(locale, "removeaccount.confirm", account.Title),
 			Components: []discordgo.MessageComponent{
 				discordgo.ActionsRow{
 					Components: []discordgo.MessageComponent{
 						discordgo.Button{
-							Label:    "Delete",
+							Label:    i18n.T(locale, "common.delete"),
 							Style:    discordgo.DangerButton,
 							CustomID: fmt.Sprintf("confirm_remove_%d", account.ID),
 						},
 						discordgo.Button{
-							Label:    "Cancel",
+							Label:    i18n.T(locale, "common.cancel"),
 							Style:    discordgo.SecondaryButton,
 							CustomID: "cancel_remove",
 						},
@@ -112,21 +125,21 @@ func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate
 	})
 	if err != nil {
 		logger.Log.WithError(err).Error("Error showing confirmation buttons")
-		err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		err = middleware.Respond(s, i, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: fmt.Sprintf("Are you sure you want to remove the account '%s'? This action is permanent and cannot be undone.", account.Title),
+				Content: i18n.T(locale, "removeaccount.confirm", account.Title),
 				Flags:   discordgo.MessageFlagsEphemeral,
 				Components: []discordgo.MessageComponent{
 					discordgo.ActionsRow{
 						Components: []discordgo.MessageComponent{
 							discordgo.Button{
-								Label:    "Delete",
+								Label:    i18n.T(locale, "common.delete"),
 								Style:    discordgo.DangerButton,
 								CustomID: fmt.Sprintf("confirm_remove_%d", account.ID),
 							},
 							discordgo.Button{
-								Label:    "Cancel",
+								Label:    i18n.T(locale, "common.cancel"),
 								Style:    discordgo.SecondaryButton,
 								CustomID: "cancel_remove",
 							},
@@ -137,23 +150,24 @@ func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate
 		})
 		if err != nil {
 			logger.Log.WithError(err).Error("Error sending confirmation message")
-			respondToInteraction(s, i, "An error occurred. Please try again.")
+			middleware.Update(s, i, i18n.T(locale, "error.try_again"))
 		}
 	}
 }
 
 func HandleConfirmation(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 	customID := i.MessageComponentData().CustomID
 
 	if customID == "cancel_remove" {
-		respondToInteraction(s, i, "Account removal cancelled.")
+		middleware.Update(s, i, i18n.T(locale, "removeaccount.cancelled"))
 		return
 	}
 
 	accountID, err := strconv.Atoi(strings.TrimPrefix(customID, "confirm_remove_"))
 	if err != nil {
 		logger.Log.WithError(err).Error("Error parsing account ID")
-		respondToInteraction(s, i, "Error processing your confirmation. Please try again.")
+		middleware.Update(s, i, i18n.T(locale, "removeaccount.confirm_error"))
 		return
 	}
 
@@ -161,7 +175,7 @@ func HandleConfirmation(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	result := database.DB.First(&account, accountID)
 	if result.Error != nil {
 		logger.Log.WithError(result.Error).Error("Error fetching account")
-		respondToInteraction(s, i, "Error: Account not found or you don't have permission to remove it.")
+		middleware.Update(s, i, i18n.T(locale, "removeaccount.not_found"))
 		return
 	}
 
@@ -170,57 +184,22 @@ func HandleConfirmation(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if err := tx.Where("account_id = ?", account.ID).Delete(&models.Ban{}).Error; err != nil {
 		tx.Rollback()
 		logger.Log.WithError(err).Error("Error deleting associated bans")
-		respondToInteraction(s, i, "Error removing account. Please try again.")
+		middleware.Update(s, i, "", services.UserErrorEmbed(locale, err))
 		return
 	}
 
 	if err := tx.Delete(&account).Error; err != nil {
 		tx.Rollback()
 		logger.Log.WithError(err).Error("Error deleting account")
-		respondToInteraction(s, i, "Error removing account. Please try again.")
+		middleware.Update(s, i, "", services.UserErrorEmbed(locale, err))
 		return
 	}
 
 	if err := tx.Commit().Error; err != nil {
 		logger.Log.WithError(err).Error("Error committing transaction")
-		respondToInteraction(s, i, "Error removing account. Please try again.")
+		middleware.Update(s, i, "", services.UserErrorEmbed(locale, err))
 		return
 	}
 
-	respondToInteraction(s, i, fmt.Sprintf("Account '%s' has been successfully removed from the database.", account.Title))
-}
-
-func respondToInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseUpdateMessage,
-		Data: &discordgo.InteractionResponseData{
-			Content:    message,
-			Components: []discordgo.MessageComponent{},
-		},
-	})
-
-	if err != nil {
-		logger.Log.WithError(err).Error("Error updating message, trying to send new message")
-
-		err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: message,
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-
-		if err != nil {
-			logger.Log.WithError(err).Error("Error sending new message, trying followup")
-
-			_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-				Content: message,
-				Flags:   discordgo.MessageFlagsEphemeral,
-			})
-
-			if err != nil {
-				logger.Log.WithError(err).Error("All response methods failed")
-			}
-		}
-	}
+	middleware.Update(s, i, i18n.T(locale, "removeaccount.removed", account.Title))
 }
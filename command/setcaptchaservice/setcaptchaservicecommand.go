@@ -5,8 +5,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
 	"github.com/bradselph/CODStatusBot/configuration"
 	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
 	"github.com/bradselph/CODStatusBot/services"
@@ -14,6 +17,24 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
+var Command = registry.Command{
+	Name:        "setcaptchaservice",
+	Description: "Set your Captcha service provider and API key (EZCaptcha/2Captcha)",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Configura tu proveedor de captcha y su clave API (EZCaptcha/2Captcha)",
+		discordgo.SpanishLATAM: "Configura tu proveedor de captcha y su clave API (EZCaptcha/2Captcha)",
+		discordgo.PortugueseBR: "Configure seu provedor de captcha e a chave API (EZCaptcha/2Captcha)",
+	},
+	Handler: CommandSetCaptchaService,
+	Components: []registry.Route{
+		registry.Prefix("set_captcha_", HandleCaptchaServiceSelection),
+	},
+	Modals: []registry.Route{
+		registry.Exact("set_captcha_service_modal", HandleModalSubmit),
+		registry.Prefix("set_captcha_service_modal_", HandleModalSubmit),
+	},
+}
+
 var providerLabels = map[string]string{
 	"capsolver": "Capsolver",
 	"ezcaptcha": "EZCaptcha",
@@ -21,6 +42,7 @@ var providerLabels = map[string]string{
 }
 
 func CommandSetCaptchaService(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 	cfg := configuration.Get()
 	var components []discordgo.MessageComponent
 
@@ -37,20 +59,20 @@ func CommandSetCaptchaService(s *discordgo.Session, i *discordgo.InteractionCrea
 	}
 
 	components = append(components, discordgo.Button{
-		Label:    "Remove API Key",
+		Label:    i18n.T(locale, "setcaptcha.remove_key"),
 		Style:    discordgo.DangerButton,
 		CustomID: "set_captcha_remove",
 	})
 
 	if len(components) == 1 {
-		respondToInteraction(s, i, "No captcha services are currently enabled. Please contact the bot administrator.")
+		middleware.Reply(s, i, i18n.T(locale, "setcaptcha.none_enabled"))
 		return
 	}
 
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err := middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: "Select a captcha service provider:",
+			Content: i18n.T(locale, "setcaptcha.select"),
 			Components: []discordgo.MessageComponent{
 				discordgo.ActionsRow{Components: components},
 			},
@@ -63,6 +85,7 @@ func CommandSetCaptchaService(s *discordgo.Session, i *discordgo.InteractionCrea
 }
 
 func HandleCaptchaServiceSelection(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 	customID := i.MessageComponentData().CustomID
 
 	if customID == "set_captcha_remove" {
@@ -72,7 +95,7 @@ func HandleCaptchaServiceSelection(s *discordgo.Session, i *discordgo.Interactio
 
 	provider := strings.TrimPrefix(customID, "set_captcha_")
 	if _, ok := providerLabels[provider]; !ok {
-		respondToInteraction(s, i, "Invalid service selection")
+		middleware.Reply(s, i, i18n.T(locale, "setcaptcha.invalid_selection"))
 		return
 	}
 
@@ -80,18 +103,16 @@ func HandleCaptchaServiceSelection(s *discordgo.Session, i *discordgo.Interactio
 }
 
 func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 	data := i.ModalSubmitData()
 	provider := strings.TrimPrefix(data.CustomID, "set_captcha_service_modal_")
 
-	userID, err := services.GetUserID(i)
-	if err != nil {
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
+	userID := middleware.Current(i).UserID
 
 	apiKey := getAPIKeyFromModal(data)
 	if err := validateAndSaveAPIKey(s, i, userID, provider, apiKey); err != nil {
-		respondToInteraction(s, i, fmt.Sprintf("Error: %v", err))
+		logger.Log.WithError(err).Error("Error saving captcha API key")
+		middleware.Reply(s, i, "", services.UserErrorEmbed(locale, err))
 		return
 	}
 }
@@ -105,34 +126,32 @@ func createProviderButton(provider string) discordgo.Button {
 }
 
 func handleAPIKeyRemoval(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	userID, err := services.GetUserID(i)
-	if err != nil {
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
+	locale := services.InteractionLocale(i)
+	userID := middleware.Current(i).UserID
 
 	if err := services.RemoveCaptchaKey(userID); err != nil {
-		respondToInteraction(s, i, "Error removing API key. Please try again.")
+		middleware.Reply(s, i, i18n.T(locale, "setcaptcha.remove_error"))
 		return
 	}
 
-	respondToInteraction(s, i, "Your API key has been removed. The bot's default API key will be used. Your check interval and notification settings have been reset to default values.")
+	middleware.Reply(s, i, i18n.T(locale, "setcaptcha.removed"))
 }
 
 func showAPIKeyModal(s *discordgo.Session, i *discordgo.InteractionCreate, provider string) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	locale := services.InteractionLocale(i)
+	err := middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseModal,
 		Data: &discordgo.InteractionResponseData{
 			CustomID: fmt.Sprintf("set_captcha_service_modal_%s", provider),
-			Title:    fmt.Sprintf("Set %s API Key", providerLabels[provider]),
+			Title:    i18n.T(locale, "setcaptcha.modal.title", providerLabels[provider]),
 			Components: []discordgo.MessageComponent{
 				discordgo.ActionsRow{
 					Components: []discordgo.MessageComponent{
 						discordgo.TextInput{
 							CustomID:    "api_key",
-							Label:       fmt.Sprintf("Enter your %s API key", providerLabels[provider]),
+							Label:       i18n.T(locale, "setcaptcha.modal.label", providerLabels[provider]),
 							Style:       discordgo.TextInputShort,
-							Placeholder: "Enter your new API key",
+							Placeholder: i18n.T(locale, "setcaptcha.modal.placeholder"),
 							Required:    true,
 							MinLength:   32,
 							MaxLength:   90,
@@ -161,12 +180,13 @@ func getAPIKeyFromModal(data discordgo.ModalSubmitInteractionData) string {
 }
 
 func validateAndSaveAPIKey(s *discordgo.Session, i *discordgo.InteractionCreate, userID, provider, apiKey string) error {
+	locale := services.InteractionLocale(i)
 	isValid, balance, err := services.ValidateCaptchaKey(apiKey, provider)
 	if err != nil {
-		return fmt.Errorf("error validating the %s API key: %v", provider, err)
+		return fmt.Errorf("error validating the %s API key: %w", provider, err)
 	}
 	if !isValid {
-		return fmt.Errorf("the provided %s API key is invalid", provider)
+		return fmt.Errorf("%w: %s", services.ErrCaptchaKeyInvalid, provider)
 	}
 
 	settings := models.UserSettings{UserID: userID}
@@ -193,44 +213,44 @@ func validateAndSaveAPIKey(s *discordgo.Session, i *discordgo.InteractionCreate,
 		apiKey != cfg.CaptchaService.TwoCaptcha.ClientKey {
 
 		embed := &discordgo.MessageEmbed{
-			Title:       "API Key Configuration Updated",
-			Description: fmt.Sprintf("Your %s API key has been configured successfully!", providerLabels[provider]),
+			Title:       i18n.T(locale, "setcaptcha.updated.title"),
+			Description: i18n.T(locale, "setcaptcha.updated.description", providerLabels[provider]),
 			Color:       0x00ff00,
 			Fields: []*discordgo.MessageEmbedField{
 				{
-					Name:   "Premium Features Unlocked",
-					Value:  "• Faster check intervals\n• Increased account limits\n• Priority status updates",
+					Name:   i18n.T(locale, "setcaptcha.premium.name"),
+					Value:  i18n.T(locale, "setcaptcha.premium.value"),
 					Inline: false,
 				},
 				{
-					Name:   "Service Provider",
+					Name:   i18n.T(locale, "field.service_provider"),
 					Value:  providerLabels[provider],
 					Inline: true,
 				},
 				{
-					Name:   "Current Balance",
-					Value:  fmt.Sprintf("%.2f points", balance),
+					Name:   i18n.T(locale, "field.current_balance"),
+					Value:  i18n.T(locale, "setcaptcha.balance", balance),
 					Inline: true,
 				},
 			},
 			Timestamp: time.Now().Format(time.RFC3339),
 		}
-		respondToInteractionWithEmbed(s, i, "", embed)
+		middleware.Reply(s, i, "", embed)
 	} else {
 		embed := &discordgo.MessageEmbed{
-			Title:       "API Key Configuration Updated",
-			Description: fmt.Sprintf("Your %s API key has been configured successfully!", providerLabels[provider]),
+			Title:       i18n.T(locale, "setcaptcha.updated.title"),
+			Description: i18n.T(locale, "setcaptcha.updated.description", providerLabels[provider]),
 			Color:       0x00ff00,
 			Fields: []*discordgo.MessageEmbedField{
 				{
-					Name:   "Service Provider",
+					Name:   i18n.T(locale, "field.service_provider"),
 					Value:  providerLabels[provider],
 					Inline: true,
 				},
 			},
 			Timestamp: time.Now().Format(time.RFC3339),
 		}
-		respondToInteractionWithEmbed(s, i, "", embed)
+		middleware.Reply(s, i, "", embed)
 	}
 
 	return nil
@@ -250,37 +270,3 @@ func updateAPIKeys(settings *models.UserSettings, provider, apiKey string) {
 		settings.TwoCaptchaAPIKey = apiKey
 	}
 }
-
-func respondToInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: message,
-			Flags:   discordgo.MessageFlagsEphemeral,
-		},
-	})
-	if err != nil {
-		logger.Log.WithError(err).Error("Error responding to interaction")
-	}
-}
-
-func respondToInteractionWithEmbed(s *discordgo.Session, i *discordgo.InteractionCreate, message string, embed *discordgo.MessageEmbed) {
-	response := &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Flags: discordgo.MessageFlagsEphemeral,
-		},
-	}
-
-	if message != "" {
-		response.Data.Content = message
-	}
-	if embed != nil {
-		response.Data.Embeds = []*discordgo.MessageEmbed{embed}
-	}
-
-	err := s.InteractionRespond(i.Interaction, response)
-	if err != nil {
-		logger.Log.WithError(err).Error("Error responding to interaction with embed")
-	}
-}
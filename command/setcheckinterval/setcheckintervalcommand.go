@@ -6,7 +6,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
 	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/services"
 	"github.com/bradselph/CODStatusBot/utils"
@@ -14,53 +17,36 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
-func CommandSetCheckInterval(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	var userID string
-	if i.Member != nil {
-		userID = i.Member.User.ID
-	} else if i.User != nil {
-		userID = i.User.ID
-	} else {
-		logger.Log.Error("Interaction doesn't have Member or User")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
-
-	userSettings, err := services.GetUserSettings(userID)
-	if err != nil {
-		logger.Log.WithError(err).Error("Error fetching user settings")
-		respondToInteraction(s, i, "Error fetching your settings. Please try again.")
-		return
-	}
+var Command = registry.Command{
+	Name:        "setcheckinterval",
+	Description: "Set check interval, notification interval, and notification type",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Configura el intervalo de comprobación, de notificación y el tipo de notificación",
+		discordgo.SpanishLATAM: "Configura el intervalo de comprobación, de notificación y el tipo de notificación",
+		discordgo.PortugueseBR: "Configure o intervalo de verificação, de notificação e o tipo de notificação",
+	},
+	Handler: CommandSetCheckInterval,
+	Components: []registry.Route{
+		registry.Exact("show_interval_modal", HandleButton),
+	},
+	Modals: []registry.Route{
+		registry.Exact("set_check_interval_modal", HandleModalSubmit),
+	},
+}
 
-	if userSettings.CapSolverAPIKey == "" && userSettings.EZCaptchaAPIKey == "" && userSettings.TwoCaptchaAPIKey == "" {
-		respondToInteraction(s, i, "You need to set your own Capsolver or EZ-Captcha or 2captcha API key using the /setcaptchaservice command before you can modify these settings.")
-		return
-	}
+func CommandSetCheckInterval(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 
 	explanationEmbed := &discordgo.MessageEmbed{
-		Title: "Configure Check & Notification Settings",
-		Description: "You're about to configure the following settings:\n\n" +
-			"**• Check Interval (1-1440 minutes)**\n" +
-			"How often the bot checks your account status. Lower values mean more frequent checks but use more captcha credits.\n" +
-			"Recommended: 15-30 minutes for active monitoring.\n\n" +
-			"**• Notification Interval (1-24 hours)**\n" +
-			"How often you receive regular status updates, even when nothing changes.\n" +
-			"Recommended: 12-24 hours for daily updates.\n\n" +
-			"**• Cooldown Duration (1-24 hours)**\n" +
-			"Minimum time between repeated notifications of the same type to prevent spam.\n" +
-			"Recommended: 6-12 hours.\n\n" +
-			"**• Status Change Cooldown (1-24 hours)**\n" +
-			"Minimum time between notifications when your account status changes.\n" +
-			"Recommended: 1-2 hours.\n\n" +
-			"Click 'Configure' to set these values.",
-		Color: 0x00ff00,
+		Title:       i18n.T(locale, "setinterval.explain.title"),
+		Description: i18n.T(locale, "setinterval.explain.description"),
+		Color:       0x00ff00,
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Note: Lower intervals provide more frequent updates but use more captcha credits",
+			Text: i18n.T(locale, "setinterval.explain.footer"),
 		},
 	}
 
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err := middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
 			Embeds: []*discordgo.MessageEmbed{explanationEmbed},
@@ -68,7 +54,7 @@ func CommandSetCheckInterval(s *discordgo.Session, i *discordgo.InteractionCreat
 				discordgo.ActionsRow{
 					Components: []discordgo.MessageComponent{
 						discordgo.Button{
-							Label:    "Configure",
+							Label:    i18n.T(locale, "setinterval.configure"),
 							Style:    discordgo.PrimaryButton,
 							CustomID: "show_interval_modal",
 						},
@@ -87,32 +73,28 @@ func HandleButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if i.MessageComponentData().CustomID != "show_interval_modal" {
 		return
 	}
+	locale := services.InteractionLocale(i)
 
-	userID := ""
-	if i.Member != nil {
-		userID = i.Member.User.ID
-	} else if i.User != nil {
-		userID = i.User.ID
-	}
+	userID := middleware.Current(i).UserID
 
 	userSettings, err := services.GetUserSettings(userID)
 	if err != nil {
 		logger.Log.WithError(err).Error("Error fetching user settings")
-		respondToInteraction(s, i, "Error fetching your settings. Please try again.")
+		middleware.Reply(s, i, "", services.UserErrorEmbed(locale, err))
 		return
 	}
 
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err = middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseModal,
 		Data: &discordgo.InteractionResponseData{
 			CustomID: "set_check_interval_modal",
-			Title:    "Configure Settings",
+			Title:    i18n.T(locale, "setinterval.modal.title"),
 			Components: []discordgo.MessageComponent{
 				discordgo.ActionsRow{
 					Components: []discordgo.MessageComponent{
 						discordgo.TextInput{
 							CustomID:  "check_interval",
-							Label:     "Check Interval (minutes)",
+							Label:     i18n.T(locale, "setinterval.modal.check_interval"),
 							Style:     discordgo.TextInputShort,
 							Required:  false,
 							MinLength: 0,
@@ -125,7 +107,7 @@ func HandleButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
 					Components: []discordgo.MessageComponent{
 						discordgo.TextInput{
 							CustomID:  "notification_interval",
-							Label:     "Notification Interval (hours)",
+							Label:     i18n.T(locale, "setinterval.modal.notification_interval"),
 							Style:     discordgo.TextInputShort,
 							Required:  false,
 							MinLength: 0,
@@ -138,7 +120,7 @@ func HandleButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
 					Components: []discordgo.MessageComponent{
 						discordgo.TextInput{
 							CustomID:  "cooldown_duration",
-							Label:     "Cooldown Duration (hours)",
+							Label:     i18n.T(locale, "setinterval.modal.cooldown"),
 							Style:     discordgo.TextInputShort,
 							Required:  false,
 							MinLength: 0,
@@ -151,7 +133,7 @@ func HandleButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
 					Components: []discordgo.MessageComponent{
 						discordgo.TextInput{
 							CustomID:  "status_change_cooldown",
-							Label:     "Status Change Cooldown (hours)",
+							Label:     i18n.T(locale, "setinterval.modal.status_cooldown"),
 							Style:     discordgo.TextInputShort,
 							Required:  false,
 							MinLength: 0,
@@ -169,33 +151,28 @@ func HandleButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
 }
 
 func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 	data := i.ModalSubmitData()
 
-	var userID string
-	if i.Member != nil {
-		userID = i.Member.User.ID
-	} else if i.User != nil {
-		userID = i.User.ID
-	} else {
-		logger.Log.Error("Interaction doesn't have Member or User")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
+	userID := middleware.Current(i).UserID
 
 	userSettings, err := services.GetUserSettings(userID)
 	if err != nil {
 		logger.Log.WithError(err).Error("Error fetching user settings")
-		respondToInteraction(s, i, "Error fetching your settings. Please try again.")
+		middleware.Reply(s, i, "", services.UserErrorEmbed(locale, err))
 		return
 	}
 
 	defaultSettings, err := services.GetDefaultSettings()
 	if err != nil {
 		logger.Log.WithError(err).Error("Error fetching default settings")
-		respondToInteraction(s, i, "Error fetching default settings. Please try again.")
+		middleware.Reply(s, i, "", services.UserErrorEmbed(locale, err))
 		return
 	}
 
+	// The tier's minimum check interval, rounded up to whole minutes.
+	minInterval := int((services.UserQuotas(userSettings).MinCheckInterval + time.Minute - 1) / time.Minute)
+
 	var errors []string
 
 	for _, comp := range data.Components {
@@ -211,7 +188,11 @@ func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 						} else {
 							interval, err := strconv.Atoi(value)
 							if err != nil || interval < 1 || interval > 1440 {
-								errors = append(errors, "Check interval must be between 1 and 1440 minutes.")
+								errors = append(errors, i18n.T(locale, "setinterval.invalid.check_interval"))
+								continue
+							}
+							if interval < minInterval {
+								errors = append(errors, i18n.T(locale, "setinterval.invalid.check_interval_min", minInterval))
 								continue
 							}
 							userSettings.CheckInterval = interval
@@ -223,7 +204,7 @@ func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 						} else {
 							interval, err := strconv.ParseFloat(value, 64)
 							if err != nil || interval < 1 || interval > 24 {
-								errors = append(errors, "Notification interval must be between 1 and 24 hours.")
+								errors = append(errors, i18n.T(locale, "setinterval.invalid.notification_interval"))
 								continue
 							}
 							userSettings.NotificationInterval = interval
@@ -235,7 +216,7 @@ func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 						} else {
 							duration, err := strconv.ParseFloat(value, 64)
 							if err != nil || duration < 1 || duration > 24 {
-								errors = append(errors, "Cooldown duration must be between 1 and 24 hours.")
+								errors = append(errors, i18n.T(locale, "setinterval.invalid.cooldown"))
 								continue
 							}
 							userSettings.CooldownDuration = duration
@@ -247,7 +228,7 @@ func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 						} else {
 							cooldown, err := strconv.ParseFloat(value, 64)
 							if err != nil || cooldown < 1 || cooldown > 24 {
-								errors = append(errors, "Status change cooldown must be between 1 and 24 hours.")
+								errors = append(errors, i18n.T(locale, "setinterval.invalid.status_cooldown"))
 								continue
 							}
 							userSettings.StatusChangeCooldown = cooldown
@@ -259,23 +240,19 @@ func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	}
 
 	if len(errors) > 0 {
-		respondToInteraction(s, i, fmt.Sprintf("Error updating settings:\n%s", strings.Join(errors, "\n")))
+		middleware.Reply(s, i, i18n.T(locale, "setinterval.update_errors", strings.Join(errors, "\n")))
 		return
 	}
 
 	if err := database.DB.Save(&userSettings).Error; err != nil {
 		logger.Log.WithError(err).Error("Error saving user settings")
-		respondToInteraction(s, i, "Error updating your settings. Please try again.")
+		middleware.Reply(s, i, "", services.UserErrorEmbed(locale, err))
 		return
 	}
 
 	successEmbed := &discordgo.MessageEmbed{
-		Title: "Settings Updated Successfully",
-		Description: fmt.Sprintf("Your new settings:\n\n"+
-			"• Check Interval: %d minutes\n"+
-			"• Notification Interval: %.1f hours\n"+
-			"• Cooldown Duration: %.1f hours\n"+
-			"• Status Change Cooldown: %.1f hours",
+		Title: i18n.T(locale, "setinterval.updated.title"),
+		Description: i18n.T(locale, "setinterval.updated.description",
 			userSettings.CheckInterval,
 			userSettings.NotificationInterval,
 			userSettings.CooldownDuration,
@@ -284,26 +261,5 @@ func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
 
-	respondToInteraction(s, i, "", successEmbed)
-}
-
-func respondToInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, message string, embeds ...*discordgo.MessageEmbed) {
-	response := &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Flags: discordgo.MessageFlagsEphemeral,
-		},
-	}
-
-	if message != "" {
-		response.Data.Content = message
-	}
-	if len(embeds) > 0 {
-		response.Data.Embeds = embeds
-	}
-
-	err := s.InteractionRespond(i.Interaction, response)
-	if err != nil {
-		logger.Log.WithError(err).Error("Error responding to interaction")
-	}
+	middleware.Reply(s, i, "", successEmbed)
 }
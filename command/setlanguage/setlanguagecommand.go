@@ -0,0 +1,82 @@
+package setlanguage
+
+import (
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
+	"github.com/bradselph/CODStatusBot/i18n"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/services"
+	"github.com/bwmarrin/discordgo"
+)
+
+// followDiscord is the choice that clears the stored language.
+const followDiscord = "auto"
+
+// languageNames are shown in their own language, so they read the same
+// whichever locale the reply is in.
+var languageNames = map[string]string{
+	"en": "English",
+	"es": "Español",
+	"pt": "Português",
+}
+
+var Command = registry.Command{
+	Name:        "setlanguage",
+	Description: "Choose the language the bot replies and notifies you in",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Elige el idioma en el que el bot te responde y notifica",
+		discordgo.SpanishLATAM: "Elige el idioma en el que el bot te responde y notifica",
+		discordgo.PortugueseBR: "Escolha o idioma em que o bot responde e envia notificações",
+	},
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "language",
+			Description: "Language to use",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.SpanishES:    "Idioma a usar",
+				discordgo.SpanishLATAM: "Idioma a usar",
+				discordgo.PortugueseBR: "Idioma a usar",
+			},
+			Required: true,
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{
+					Name: "Discord client language",
+					NameLocalizations: map[discordgo.Locale]string{
+						discordgo.SpanishES:    "Idioma del cliente de Discord",
+						discordgo.SpanishLATAM: "Idioma del cliente de Discord",
+						discordgo.PortugueseBR: "Idioma do cliente Discord",
+					},
+					Value: followDiscord,
+				},
+				{Name: languageNames["en"], Value: "en"},
+				{Name: languageNames["es"], Value: "es"},
+				{Name: languageNames["pt"], Value: "pt"},
+			},
+		},
+	},
+	Handler: CommandSetLanguage,
+}
+
+func CommandSetLanguage(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := middleware.Current(i).UserID
+
+	choice := i.ApplicationCommandData().Options[0].StringValue()
+	locale := choice
+	if choice == followDiscord {
+		locale = ""
+	}
+
+	if err := services.SetUserLocale(userID, locale); err != nil {
+		logger.Log.WithError(err).Errorf("Failed to save locale for user %s", userID)
+		middleware.Reply(s, i, i18n.T(services.InteractionLocale(i), "setlanguage.error"))
+		return
+	}
+
+	reply := services.InteractionLocale(i)
+	if locale == "" {
+		middleware.Reply(s, i, i18n.T(reply, "setlanguage.auto"))
+		return
+	}
+	middleware.Reply(s, i, i18n.T(reply, "setlanguage.saved", languageNames[locale]))
+}
@@ -4,41 +4,55 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
 	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bradselph/CODStatusBot/services"
 	"github.com/bradselph/CODStatusBot/utils"
 	"github.com/bwmarrin/discordgo"
 )
 
+var Command = registry.Command{
+	Name:        "setnotifications",
+	Description: "Set your notification preferences (channel or DM)",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Configura tus preferencias de notificación (canal o MD)",
+		discordgo.SpanishLATAM: "Configura tus preferencias de notificación (canal o MD)",
+		discordgo.PortugueseBR: "Configure suas preferências de notificação (canal ou DM)",
+	},
+	Handler: CommandSetNotifications,
+	Modals: []registry.Route{
+		registry.Prefix("set_notifications_modal_", HandleModalSubmit),
+	},
+}
+
 func CommandSetNotifications(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	userID := getUserID(i)
-	if userID == "" {
-		logger.Log.Error("Could not determine user ID")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
+	locale := services.InteractionLocale(i)
+	userID := middleware.Current(i).UserID
 
 	var userSettings models.UserSettings
 	if err := database.DB.Where("user_id = ?", userID).FirstOrCreate(&userSettings).Error; err != nil {
 		logger.Log.WithError(err).Error("Error getting user settings")
-		respondToInteraction(s, i, "Error retrieving your current settings. Please try again.")
+		middleware.Update(s, i, i18n.T(locale, "error.retrieve_settings"))
 		return
 	}
 
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err := middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseModal,
 		Data: &discordgo.InteractionResponseData{
 			CustomID: fmt.Sprintf("set_notifications_modal_%s", userID),
-			Title:    "Set Notification Preferences",
+			Title:    i18n.T(locale, "setnotifications.modal.title"),
 			Components: []discordgo.MessageComponent{
 				discordgo.ActionsRow{
 					Components: []discordgo.MessageComponent{
 						discordgo.TextInput{
 							CustomID:    "notification_type",
-							Label:       "Notification Type (channel or dm)",
+							Label:       i18n.T(locale, "setnotifications.modal.label"),
 							Style:       discordgo.TextInputShort,
-							Placeholder: "Enter 'channel' or 'dm'",
+							Placeholder: i18n.T(locale, "setnotifications.modal.placeholder"),
 							Required:    true,
 							MinLength:   2,
 							MaxLength:   7,
@@ -55,20 +69,21 @@ func CommandSetNotifications(s *discordgo.Session, i *discordgo.InteractionCreat
 }
 
 func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 	data := i.ModalSubmitData()
 
 	parts := strings.Split(data.CustomID, "_")
 	if len(parts) < 4 {
 		logger.Log.Error("Invalid modal custom ID format")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
+		middleware.Update(s, i, i18n.T(locale, "error.processing"))
 		return
 	}
 	userID := parts[len(parts)-1]
 
-	interactionUserID := getUserID(i)
-	if interactionUserID == "" || interactionUserID != userID {
+	interactionUserID := middleware.Current(i).UserID
+	if interactionUserID != userID {
 		logger.Log.Error("User ID mismatch or not found")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
+		middleware.Update(s, i, i18n.T(locale, "error.processing"))
 		return
 	}
 
@@ -86,21 +101,21 @@ func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	}
 
 	if notificationType != "channel" && notificationType != "dm" {
-		respondToInteraction(s, i, "Invalid notification type. Please enter 'channel' or 'dm'.")
+		middleware.Update(s, i, i18n.T(locale, "setnotifications.invalid"))
 		return
 	}
 
 	var userSettings models.UserSettings
 	if err := database.DB.Where("user_id = ?", userID).FirstOrCreate(&userSettings).Error; err != nil {
 		logger.Log.WithError(err).Error("Error getting/creating user settings")
-		respondToInteraction(s, i, "Error updating settings. Please try again.")
+		middleware.Update(s, i, i18n.T(locale, "error.update_settings"))
 		return
 	}
 
 	userSettings.NotificationType = notificationType
 	if err := database.DB.Save(&userSettings).Error; err != nil {
 		logger.Log.WithError(err).Error("Error saving user settings")
-		respondToInteraction(s, i, "Error saving settings. Please try again.")
+		middleware.Update(s, i, i18n.T(locale, "error.save_settings"))
 		return
 	}
 
@@ -112,48 +127,11 @@ func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 	if result.Error != nil {
 		logger.Log.WithError(result.Error).Error("Error updating user accounts")
-		respondToInteraction(s, i, "Error updating accounts with new settings. Please try again.")
+		middleware.Update(s, i, i18n.T(locale, "setnotifications.accounts_error"))
 		return
 	}
 
 	logger.Log.Infof("Updated notification preferences for user %s to %s", userID, notificationType)
-	message := fmt.Sprintf("Your notification preferences have been updated. You will now receive notifications via %s.", notificationType)
-	respondToInteraction(s, i, message)
-}
-
-func getUserID(i *discordgo.InteractionCreate) string {
-	if i.Member != nil && i.Member.User != nil {
-		return i.Member.User.ID
-	}
-	if i.User != nil {
-
-		return i.User.ID
-	}
-	logger.Log.Error("Interaction doesn't have Member or User")
-	return ""
-}
-
-func respondToInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
-	var err error
-	if i.Type == discordgo.InteractionMessageComponent {
-		err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseUpdateMessage,
-			Data: &discordgo.InteractionResponseData{
-				Content:    message,
-				Components: []discordgo.MessageComponent{},
-				Flags:      discordgo.MessageFlagsEphemeral,
-			},
-		})
-	} else {
-		err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: message,
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-	}
-	if err != nil {
-		logger.Log.WithError(err).Error("Error responding to interaction")
-	}
+	message := i18n.T(locale, "setnotifications.updated", notificationType)
+	middleware.Update(s, i, message)
 }
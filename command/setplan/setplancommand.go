@@ -0,0 +1,142 @@
+package setplan
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/services"
+	"github.com/bwmarrin/discordgo"
+)
+
+// revoke is the tier choice that removes an assignment.
+const revoke = "revoke"
+
+var minDays = 0.0
+
+var Command = registry.Command{
+	Name:        "setplan",
+	Description: "Assign a plan to a user or guild (Developer only)",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Asigna un plan a un usuario o servidor (solo desarrollador)",
+		discordgo.SpanishLATAM: "Asigna un plan a un usuario o servidor (solo desarrollador)",
+		discordgo.PortugueseBR: "Atribua um plano a um usuário ou servidor (somente desenvolvedor)",
+	},
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "tier",
+			Description: "Plan to assign",
+			Required:    true,
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{Name: "Free", Value: string(services.TierFree)},
+				{Name: "Own captcha key", Value: string(services.TierOwnKey)},
+				{Name: "Supporter", Value: string(services.TierSupporter)},
+				{Name: "Granted", Value: string(services.TierGranted)},
+				{Name: "Revoke assignment", Value: revoke},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "user",
+			Description: "User to assign the plan to",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "guild",
+			Description: "ID of the guild to assign the plan to",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "days",
+			Description: "Days until the plan lapses (0 or empty never lapses)",
+			MinValue:    &minDays,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "note",
+			Description: "Why the plan was assigned",
+			MaxLength:   255,
+		},
+	},
+	Permissions:   discordgo.PermissionAdministrator,
+	DeveloperOnly: true,
+	Handler:       CommandSetPlan,
+}
+
+func CommandSetPlan(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var (
+		choice, userID, guildID, note string
+		days                          int64
+	)
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "tier":
+			choice = option.StringValue()
+		case "user":
+			userID = option.UserValue(nil).ID
+		case "guild":
+			guildID = option.StringValue()
+		case "days":
+			days = option.IntValue()
+		case "note":
+			note = option.StringValue()
+		}
+	}
+
+	if (userID == "") == (guildID == "") {
+		middleware.Reply(s, i, "Pick either a user or a guild.")
+		return
+	}
+	scope, subjectID := services.TierScopeUser, userID
+	if guildID != "" {
+		scope, subjectID = services.TierScopeGuild, guildID
+	}
+
+	if choice == revoke {
+		removed, err := services.RevokeTier(scope, subjectID)
+		switch {
+		case err != nil:
+			logger.Log.WithError(err).Errorf("Error revoking tier for %s %s", scope, subjectID)
+			middleware.Reply(s, i, "Error revoking the plan.")
+		case removed:
+			middleware.Reply(s, i, fmt.Sprintf("Revoked the plan assigned to %s. %s", subject(scope, subjectID), propagationNote()))
+		default:
+			middleware.Reply(s, i, fmt.Sprintf("%s has no assigned plan.", subject(scope, subjectID)))
+		}
+		return
+	}
+
+	tier, err := services.ParseTier(choice)
+	if err != nil {
+		middleware.Reply(s, i, err.Error())
+		return
+	}
+
+	assignment, err := services.AssignTier(scope, subjectID, tier, time.Duration(days)*24*time.Hour, middleware.Current(i).UserID, note)
+	if err != nil {
+		logger.Log.WithError(err).Errorf("Error assigning tier to %s %s", scope, subjectID)
+		middleware.Reply(s, i, "Error assigning the plan.")
+		return
+	}
+
+	message := fmt.Sprintf("Assigned the %s plan to %s", tier, subject(scope, subjectID))
+	if assignment.ExpiresAt != nil {
+		message += fmt.Sprintf(" until <t:%d:f>", assignment.ExpiresAt.Unix())
+	}
+	middleware.Reply(s, i, message+". "+propagationNote())
+}
+
+// propagationNote says when other bot processes pick up a plan change.
+func propagationNote() string {
+	return fmt.Sprintf("Other bot processes apply the change within %d minutes.", int(services.TierCacheTTL.Minutes()))
+}
+
+func subject(scope, subjectID string) string {
+	if scope == services.TierScopeUser {
+		return "<@" + subjectID + ">"
+	}
+	return "guild " + subjectID
+}
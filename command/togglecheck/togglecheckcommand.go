@@ -5,7 +5,10 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
 	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
 	"github.com/bradselph/CODStatusBot/services"
@@ -13,24 +16,36 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
+var Command = registry.Command{
+	Name:        "togglecheck",
+	Description: "Toggle checks on/off for a monitored account",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Activa o desactiva las comprobaciones de una cuenta vigilada",
+		discordgo.SpanishLATAM: "Activa o desactiva las comprobaciones de una cuenta vigilada",
+		discordgo.PortugueseBR: "Ative ou desative as verificações de uma conta monitorada",
+	},
+	Handler: CommandToggleCheck,
+	Components: []registry.Route{
+		registry.Prefix("toggle_check_", HandleAccountSelection),
+		registry.Prefix("confirm_reenable_", HandleConfirmation),
+		registry.Exact("cancel_reenable", HandleConfirmation),
+	},
+}
+
 func CommandToggleCheck(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	userID, err := services.GetUserID(i)
-	if err != nil {
-		logger.Log.WithError(err).Error("Failed to get user ID")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
+	locale := services.InteractionLocale(i)
+	userID := middleware.Current(i).UserID
 
 	var accounts []models.Account
 	result := database.DB.Where("user_id = ?", userID).Find(&accounts)
 	if result.Error != nil {
 		logger.Log.WithError(result.Error).Error("Error fetching user accounts")
-		respondToInteraction(s, i, "Error fetching your accounts. Please try again.")
+		middleware.Update(s, i, i18n.T(locale, "error.fetch_accounts"))
 		return
 	}
 
 	if len(accounts) == 0 {
-		respondToInteraction(s, i, "You don't have any monitored accounts.")
+		middleware.Update(s, i, i18n.T(locale, "accounts.none"))
 		return
 	}
 
@@ -40,7 +55,7 @@ func CommandToggleCheck(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	)
 
 	for _, account := range accounts {
-		label := fmt.Sprintf("%s (%s)", account.Title, services.GetCheckStatus(account.IsCheckDisabled))
+		label := fmt.Sprintf("%s (%s)", account.Title, services.GetCheckStatus(locale, account.IsCheckDisabled))
 		currentRow = append(currentRow, discordgo.Button{
 			Label:    label,
 			Style:    discordgo.PrimaryButton,
@@ -57,10 +72,10 @@ func CommandToggleCheck(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		components = append(components, discordgo.ActionsRow{Components: currentRow})
 	}
 
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err := middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content:    "Select an account to toggle auto check On/Off:",
+			Content:    i18n.T(locale, "togglecheck.select"),
 			Flags:      discordgo.MessageFlagsEphemeral,
 			Components: components,
 		},
@@ -71,29 +86,20 @@ func CommandToggleCheck(s *discordgo.Session, i *discordgo.InteractionCreate) {
 }
 
 func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Flags: discordgo.MessageFlagsEphemeral,
-		},
-	})
+	locale := services.InteractionLocale(i)
+	err := middleware.Defer(s, i)
 	if err != nil {
 		logger.Log.WithError(err).Error("Error acknowledging interaction")
 		return
 	}
 
-	userID, err := services.GetUserID(i)
-	if err != nil {
-		logger.Log.WithError(err).Error("Failed to get user ID")
-		sendFollowupMessage(s, i, "An error occurred while processing your request.")
-		return
-	}
+	userID := middleware.Current(i).UserID
 
 	customID := i.MessageComponentData().CustomID
 	accountIDParsed, err := strconv.ParseUint(strings.TrimPrefix(customID, "toggle_check_"), 10, 64)
 	if err != nil {
 		logger.Log.WithError(err).Error("Error parsing account ID")
-		sendFollowupMessage(s, i, "Error processing your selection. Please try again.")
+		middleware.Followup(s, i, i18n.T(locale, "error.selection"))
 		return
 	}
 	accountID := uint(accountIDParsed)
@@ -102,31 +108,32 @@ func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate
 	result := database.DB.First(&account, accountID)
 	if result.Error != nil {
 		logger.Log.WithError(result.Error).Error("Error fetching account")
-		sendFollowupMessage(s, i, "Error: Account not found or you don't have permission to modify it.")
+		middleware.Followup(s, i, i18n.T(locale, "error.not_found_modify"))
 		return
 	}
 
 	if account.UserID != userID {
-		sendFollowupMessage(s, i, "You don't have permission to modify this account.")
+		middleware.Followup(s, i, i18n.T(locale, "error.no_permission_modify"))
 		return
 	}
 
 	if account.IsCheckDisabled {
-		showConfirmationButtons(s, i, accountID, fmt.Sprintf("Are you sure you want to re-enable checks for account '%s'?", account.Title))
+		showConfirmationButtons(s, i, accountID, i18n.T(locale, "togglecheck.confirm", account.Title))
 	} else {
 		account.IsCheckDisabled = true
 		account.DisabledReason = "Manually disabled by user"
-		message := fmt.Sprintf("Checks for account '%s' have been disabled.", account.Title)
+		message := i18n.T(locale, "togglecheck.disabled", account.Title)
 		if err = database.DB.Save(&account).Error; err != nil {
 			logger.Log.WithError(err).Error("Failed to update account after toggling check")
-			sendFollowupMessage(s, i, "Error toggling account checks. Please try again.")
+			middleware.Followup(s, i, "", services.UserErrorEmbed(locale, err))
 			return
 		}
-		sendFollowupMessage(s, i, message)
+		middleware.Followup(s, i, message)
 	}
 }
 
 func showConfirmationButtons(s *discordgo.Session, i *discordgo.InteractionCreate, accountID uint, message string) {
+	locale := services.InteractionLocale(i)
 	logger.Log.Infof("Showing confirmation buttons for account %d", accountID)
 
 	_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
@@ -136,12 +143,12 @@ func showConfirmationButtons(s *discordgo.Session, i *discordgo.InteractionCreat
 			discordgo.ActionsRow{
 				Components: []discordgo.MessageComponent{
 					discordgo.Button{
-						Label:    "Confirm Re-enable",
+						Label:    i18n.T(locale, "togglecheck.confirm_button"),
 						Style:    discordgo.SuccessButton,
 						CustomID: fmt.Sprintf("confirm_reenable_%d", accountID),
 					},
 					discordgo.Button{
-						Label:    "Cancel",
+						Label:    i18n.T(locale, "common.cancel"),
 						Style:    discordgo.DangerButton,
 						CustomID: "cancel_reenable",
 					},
@@ -152,51 +159,32 @@ func showConfirmationButtons(s *discordgo.Session, i *discordgo.InteractionCreat
 
 	if err != nil {
 		logger.Log.WithError(err).Error("Error showing confirmation buttons")
-		sendFollowupMessage(s, i, "An error occurred. Please try again.")
+		middleware.Followup(s, i, i18n.T(locale, "error.try_again"))
 		return
 	}
 }
 
-func sendFollowupMessage(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
-	_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Content: message,
-		Flags:   discordgo.MessageFlagsEphemeral,
-	})
-	if err != nil {
-		logger.Log.WithError(err).Error("Error sending followup message")
-	}
-}
-
 func HandleConfirmation(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Flags: discordgo.MessageFlagsEphemeral,
-		},
-	})
+	locale := services.InteractionLocale(i)
+	err := middleware.Defer(s, i)
 	if err != nil {
 		logger.Log.WithError(err).Error("Failed to defer interaction response")
 		return
 	}
 
-	userID, err := services.GetUserID(i)
-	if err != nil {
-		logger.Log.WithError(err).Error("Failed to get user ID")
-		sendFollowupMessage(s, i, "An error occurred while processing your request.")
-		return
-	}
+	userID := middleware.Current(i).UserID
 
 	customID := i.MessageComponentData().CustomID
 
 	if customID == "cancel_reenable" {
-		sendFollowupMessage(s, i, "Re-enabling cancelled.")
+		middleware.Followup(s, i, i18n.T(locale, "togglecheck.cancelled"))
 		return
 	}
 
 	accountIDParsed, err := strconv.ParseUint(strings.TrimPrefix(customID, "confirm_reenable_"), 10, 64)
 	if err != nil {
 		logger.Log.WithError(err).Error("Error parsing account ID")
-		sendFollowupMessage(s, i, "Error processing your confirmation. Please try again.")
+		middleware.Followup(s, i, i18n.T(locale, "removeaccount.confirm_error"))
 		return
 	}
 	accountID := uint(accountIDParsed)
@@ -205,12 +193,12 @@ func HandleConfirmation(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	result := database.DB.First(&account, accountID)
 	if result.Error != nil {
 		logger.Log.WithError(result.Error).Error("Error fetching account")
-		sendFollowupMessage(s, i, "Error: Account not found or you don't have permission to modify it.")
+		middleware.Followup(s, i, i18n.T(locale, "error.not_found_modify"))
 		return
 	}
 
 	if account.UserID != userID {
-		sendFollowupMessage(s, i, "You don't have permission to modify this account.")
+		middleware.Followup(s, i, i18n.T(locale, "error.no_permission_modify"))
 		return
 	}
 
@@ -224,31 +212,19 @@ func HandleConfirmation(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 	account.IsCheckDisabled = false
 	account.DisabledReason = ""
+	account.DisabledCategory = ""
 	account.ConsecutiveErrors = 0
+	account.LastErrorCategory = ""
 	if err = database.DB.Save(&account).Error; err != nil {
 		logger.Log.WithError(err).Error("Error saving account changes")
-		sendFollowupMessage(s, i, "Error re-enabling account checks. Please try again.")
+		middleware.Followup(s, i, "", services.UserErrorEmbed(locale, err))
 		return
 	}
 
-	message := fmt.Sprintf("Checks for account '%s' have been re-enabled.", account.Title)
+	message := i18n.T(locale, "togglecheck.reenabled", account.Title)
 	if !cookieValid {
-		message += "\n Note: The account's SSO cookie may be expired. If checks fail, please update the cookie using /updateaccount."
+		message += "\n" + i18n.T(locale, "togglecheck.cookie_note")
 	}
 
-	sendFollowupMessage(s, i, message)
-}
-
-func respondToInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseUpdateMessage,
-		Data: &discordgo.InteractionResponseData{
-			Content:    message,
-			Components: []discordgo.MessageComponent{},
-		},
-	})
-
-	if err != nil {
-		logger.Log.WithError(err).Error("Error responding to interaction")
-	}
+	middleware.Followup(s, i, message)
 }
@@ -6,7 +6,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
 	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
 	"github.com/bradselph/CODStatusBot/services"
@@ -15,39 +18,43 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
+var Command = registry.Command{
+	Name:        "updateaccount",
+	Description: "Update a monitored account's information",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Actualiza los datos de una cuenta vigilada",
+		discordgo.SpanishLATAM: "Actualiza los datos de una cuenta vigilada",
+		discordgo.PortugueseBR: "Atualize os dados de uma conta monitorada",
+	},
+	Handler: CommandUpdateAccount,
+	Components: []registry.Route{
+		registry.Prefix("update_account_", HandleAccountSelection),
+	},
+	Modals: []registry.Route{
+		registry.Prefix("update_account_modal_", HandleModalSubmit),
+	},
+}
+
 func CommandUpdateAccount(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Flags: discordgo.MessageFlagsEphemeral,
-		},
-	})
+	locale := services.InteractionLocale(i)
+	err := middleware.Defer(s, i)
 	if err != nil {
 		logger.Log.WithError(err).Error("Error sending deferred response")
 		return
 	}
 
-	var userID string
-	if i.Member != nil {
-		userID = i.Member.User.ID
-	} else if i.User != nil {
-		userID = i.User.ID
-	} else {
-		logger.Log.Error("Interaction doesn't have Member or User")
-		sendFollowupMessage(s, i, "An error occurred while processing your request.")
-		return
-	}
+	userID := middleware.Current(i).UserID
 
 	var accounts []models.Account
 	result := database.DB.Where("user_id = ?", userID).Find(&accounts)
 	if result.Error != nil {
 		logger.Log.WithError(result.Error).Error("Error fetching user accounts")
-		sendFollowupMessage(s, i, "Error fetching your accounts. Please try again.")
+		middleware.Followup(s, i, i18n.T(locale, "error.fetch_accounts"))
 		return
 	}
 
 	if len(accounts) == 0 {
-		sendFollowupMessage(s, i, "You don't have any monitored accounts to update.")
+		middleware.Followup(s, i, i18n.T(locale, "updateaccount.none"))
 		return
 	}
 
@@ -63,7 +70,7 @@ func CommandUpdateAccount(s *discordgo.Session, i *discordgo.InteractionCreate)
 		}
 
 		if account.IsCheckDisabled {
-			label += " (Disabled)"
+			label += " " + i18n.T(locale, "updateaccount.disabled_suffix")
 			button := discordgo.Button{
 				Label:    label,
 				Style:    discordgo.SecondaryButton,
@@ -89,7 +96,7 @@ func CommandUpdateAccount(s *discordgo.Session, i *discordgo.InteractionCreate)
 		components = append(components, discordgo.ActionsRow{Components: currentRow})
 	}
 	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Content:    "Select an account to update:",
+		Content:    i18n.T(locale, "updateaccount.select"),
 		Flags:      discordgo.MessageFlagsEphemeral,
 		Components: components,
 	})
@@ -99,11 +106,12 @@ func CommandUpdateAccount(s *discordgo.Session, i *discordgo.InteractionCreate)
 }
 
 func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 	customID := i.MessageComponentData().CustomID
 	accountID, err := strconv.Atoi(strings.TrimPrefix(customID, "update_account_"))
 	if err != nil {
 		logger.Log.WithError(err).Error("Error parsing account ID")
-		respondToInteraction(s, i, "Error processing your selection. Please try again.")
+		middleware.Reply(s, i, i18n.T(locale, "error.selection"))
 		return
 	}
 
@@ -111,35 +119,30 @@ func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate
 	result := database.DB.First(&account, accountID)
 	if result.Error != nil {
 		logger.Log.WithError(result.Error).Error("Error fetching account")
-		respondToInteraction(s, i, "Error: Account not found or you don't have permission to update it.")
+		middleware.Reply(s, i, i18n.T(locale, "updateaccount.not_found"))
 		return
 	}
 
-	userID := ""
-	if i.Member != nil {
-		userID = i.Member.User.ID
-	} else if i.User != nil {
-		userID = i.User.ID
-	}
+	userID := middleware.Current(i).UserID
 
 	if account.UserID != userID {
-		respondToInteraction(s, i, "Error: You don't have permission to update this account.")
+		middleware.Reply(s, i, i18n.T(locale, "updateaccount.no_permission"))
 		return
 	}
 
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err = middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseModal,
 		Data: &discordgo.InteractionResponseData{
 			CustomID: fmt.Sprintf("update_account_modal_%d", accountID),
-			Title:    "Update Account",
+			Title:    i18n.T(locale, "updateaccount.modal.title"),
 			Components: []discordgo.MessageComponent{
 				discordgo.ActionsRow{
 					Components: []discordgo.MessageComponent{
 						discordgo.TextInput{
 							CustomID:    "new_sso_cookie",
-							Label:       "Enter the new SSO cookie",
+							Label:       i18n.T(locale, "updateaccount.modal.label"),
 							Style:       discordgo.TextInputParagraph,
-							Placeholder: "Enter the new SSO cookie for this account",
+							Placeholder: i18n.T(locale, "updateaccount.modal.placeholder"),
 							Required:    true,
 							MinLength:   1,
 							MaxLength:   100,
@@ -152,18 +155,14 @@ func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate
 
 	if err != nil {
 		logger.Log.WithError(err).Error("Error showing update modal")
-		respondToInteraction(s, i, "Error showing update form. Please try again.")
+		middleware.Reply(s, i, i18n.T(locale, "updateaccount.form_error"))
 		return
 	}
 }
 
 func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Flags: discordgo.MessageFlagsEphemeral,
-		},
-	})
+	locale := services.InteractionLocale(i)
+	err := middleware.Defer(s, i)
 	if err != nil {
 		logger.Log.WithError(err).Error("Error sending deferred response")
 		return
@@ -174,7 +173,7 @@ func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	accountID, err := strconv.Atoi(accountIDStr)
 	if err != nil {
 		logger.Log.WithError(err).Error("Error parsing account ID")
-		sendFollowupMessage(s, i, "Error processing your update. Please try again.")
+		middleware.Followup(s, i, i18n.T(locale, "updateaccount.process_error"))
 		return
 	}
 
@@ -190,7 +189,7 @@ func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	}
 
 	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Content: "Processing your account update... This may take a few moments.",
+		Content: i18n.T(locale, "updateaccount.processing"),
 		Flags:   discordgo.MessageFlagsEphemeral,
 	})
 	if err != nil {
@@ -199,30 +198,26 @@ func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	go processAccountUpdate(s, i, accountID, newSSOCookie)
 }
 func processAccountUpdate(s *discordgo.Session, i *discordgo.InteractionCreate, accountID int, newSSOCookie string) {
-	userID := ""
-	if i.Member != nil {
-		userID = i.Member.User.ID
-	} else if i.User != nil {
-		userID = i.User.ID
-	}
+	locale := services.InteractionLocale(i)
+	userID := middleware.Current(i).UserID
 
 	logger.Log.Infof("Processing account update for ID %d by user %s", accountID, userID)
 
 	if !services.VerifySSOCookie(newSSOCookie) {
 		logger.Log.Error("SSO cookie validation failed")
-		sendFollowupMessage(s, i, "Error: SSO cookie validation failed. Please check the cookie and try again.")
+		middleware.Followup(s, i, i18n.T(locale, "updateaccount.validation_failed"))
 		return
 	}
 
 	validationResult, err := services.ValidateAndGetAccountInfo(newSSOCookie)
 	if err != nil {
 		logger.Log.WithError(err).Error("Error validating new SSO cookie")
-		sendFollowupMessage(s, i, fmt.Sprintf("Error validating cookie: %v", err))
+		middleware.Followup(s, i, "", services.UserErrorEmbed(locale, err))
 		return
 	}
 
 	if !validationResult.IsValid {
-		sendFollowupMessage(s, i, "Error: The provided SSO cookie is invalid.")
+		middleware.Followup(s, i, i18n.T(locale, "updateaccount.invalid_cookie"))
 		return
 	}
 
@@ -230,29 +225,29 @@ func processAccountUpdate(s *discordgo.Session, i *discordgo.InteractionCreate,
 	result := database.DB.First(&account, accountID)
 	if result.Error != nil {
 		logger.Log.WithError(result.Error).Error("Error fetching account for update")
-		sendFollowupMessage(s, i, "Error: Account not found or you don't have permission to update it.")
+		middleware.Followup(s, i, i18n.T(locale, "updateaccount.not_found"))
 		return
 	}
 	if account.UserID != userID {
-		sendFollowupMessage(s, i, "Error: You don't have permission to update this account.")
+		middleware.Followup(s, i, i18n.T(locale, "updateaccount.no_permission"))
 		return
 	}
 
 	userSettings, err := services.GetUserSettings(userID)
 	if err != nil {
 		logger.Log.WithError(err).Error("Error fetching user settings")
-		sendFollowupMessage(s, i, "Error fetching user settings. Please try again.")
+		middleware.Followup(s, i, i18n.T(locale, "error.fetch_settings"))
 		return
 	}
 
 	if !services.IsServiceEnabled(userSettings.PreferredCaptchaProvider) {
-		msg := fmt.Sprintf("Your preferred captcha service (%s) is currently disabled. ", userSettings.PreferredCaptchaProvider)
+		msg := i18n.T(locale, "addaccount.provider_disabled", userSettings.PreferredCaptchaProvider)
 		if services.IsServiceEnabled("ezcaptcha") {
-			msg += "Please switch to EZCaptcha using /setcaptchaservice."
+			msg += " " + i18n.T(locale, "checknow.switch_provider", "EZCaptcha")
 		} else if services.IsServiceEnabled("2captcha") {
-			msg += "Please switch to 2Captcha using /setcaptchaservice."
+			msg += " " + i18n.T(locale, "checknow.switch_provider", "2Captcha")
 		}
-		sendFollowupMessage(s, i, msg)
+		middleware.Followup(s, i, msg)
 		return
 	}
 	services.DBMutex.Lock()
@@ -268,13 +263,15 @@ func processAccountUpdate(s *discordgo.Session, i *discordgo.InteractionCreate,
 	account.IsExpiredCookie = false
 	account.IsCheckDisabled = false
 	account.DisabledReason = ""
+	account.DisabledCategory = ""
 	account.ConsecutiveErrors = 0
+	account.LastErrorCategory = ""
 	account.LastSuccessfulCheck = time.Now()
 
 	if err := database.DB.Save(&account).Error; err != nil {
 		services.DBMutex.Unlock()
 		logger.Log.WithError(err).Error("Failed to update account")
-		sendFollowupMessage(s, i, "Error updating account. Please try again.")
+		middleware.Followup(s, i, i18n.T(locale, "updateaccount.update_error"))
 		return
 	}
 	services.DBMutex.Unlock()
@@ -296,20 +293,20 @@ func processAccountUpdate(s *discordgo.Session, i *discordgo.InteractionCreate,
 	newVIP := validationResult.IsVIP
 	if oldVIP != newVIP {
 		if newVIP {
-			vipStatusChange = "Your account is now a VIP account!"
+			vipStatusChange = i18n.T(locale, "updateaccount.vip_gained")
 		} else {
-			vipStatusChange = "Your account is no longer a VIP account"
+			vipStatusChange = i18n.T(locale, "updateaccount.vip_lost")
 		}
 	}
 
-	embed := createSuccessEmbed(&account, wasDisabled, vipStatusChange, validationResult.ExpiresAt, account.IsVIP)
-	sendFollowupMessageWithEmbed(s, i, "", embed)
+	embed := createSuccessEmbed(locale, &account, wasDisabled, vipStatusChange, validationResult.ExpiresAt, account.IsVIP)
+	middleware.Followup(s, i, "", embed)
 
 	go func() {
 		time.Sleep(2 * time.Second)
 		logger.Log.Infof("Performing status check for updated account %d", account.ID)
 
-		status, err := services.CheckAccount(newSSOCookie, userID, "")
+		status, err := services.CheckAccount(services.InteractionContext(i), newSSOCookie, userID, "")
 		if err != nil {
 			logger.Log.WithError(err).Error("Error performing status check after update")
 			return
@@ -319,20 +316,20 @@ func processAccountUpdate(s *discordgo.Session, i *discordgo.InteractionCreate,
 	}()
 }
 
-func createSuccessEmbed(account *models.Account, wasDisabled bool, vipStatusChange string, expirationTimestamp int64, isVIP bool) *discordgo.MessageEmbed {
+func createSuccessEmbed(locale string, account *models.Account, wasDisabled bool, vipStatusChange string, expirationTimestamp int64, isVIP bool) *discordgo.MessageEmbed {
 	embed := &discordgo.MessageEmbed{
-		Title:       "Account Update Successful",
-		Description: fmt.Sprintf("Account '%s' has been updated successfully.", account.Title),
+		Title:       i18n.T(locale, "updateaccount.success.title"),
+		Description: i18n.T(locale, "updateaccount.success.description", account.Title),
 		Color:       0x00ff00,
 		Fields: []*discordgo.MessageEmbedField{
 			{
-				Name:   "Cookie Expiration",
-				Value:  services.FormatExpirationTime(expirationTimestamp),
+				Name:   i18n.T(locale, "field.cookie_expiration"),
+				Value:  fmt.Sprintf("<t:%d:R>", expirationTimestamp),
 				Inline: true,
 			},
 			{
-				Name:   "VIP Status",
-				Value:  getVIPStatusText(isVIP),
+				Name:   i18n.T(locale, "field.vip_status"),
+				Value:  getVIPStatusText(locale, isVIP),
 				Inline: true,
 			},
 		},
@@ -341,98 +338,36 @@ func createSuccessEmbed(account *models.Account, wasDisabled bool, vipStatusChan
 
 	if wasDisabled {
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-			Name:   "Account Status",
-			Value:  "Account checks have been re-enabled",
+			Name:   i18n.T(locale, "field.account_status"),
+			Value:  i18n.T(locale, "updateaccount.reenabled"),
 			Inline: false,
 		})
 	}
 
 	if vipStatusChange != "" {
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-			Name:   "Status Change",
+			Name:   i18n.T(locale, "field.status_change"),
 			Value:  vipStatusChange,
 			Inline: false,
 		})
 	}
 
 	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-		Name:   "Notification Type",
+		Name:   i18n.T(locale, "field.notification_type"),
 		Value:  account.NotificationType,
 		Inline: true,
 	})
 
 	embed.Footer = &discordgo.MessageEmbedFooter{
-		Text: "Use /listaccounts to view all your monitored accounts",
+		Text: i18n.T(locale, "footer.listaccounts"),
 	}
 
 	return embed
 }
 
-func getVIPStatusText(isVIP bool) string {
+func getVIPStatusText(locale string, isVIP bool) string {
 	if isVIP {
-		return "VIP Account"
-	}
-	return "Regular Account"
-}
-
-func sendFollowupMessage(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
-	if i.Interaction == nil || i.Interaction.Token == "" {
-		logger.Log.Error("Cannot send followup: invalid interaction or token")
-		return
-	}
-
-	_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Content: message,
-		Flags:   discordgo.MessageFlagsEphemeral,
-	})
-	if err != nil {
-		logger.Log.WithError(err).Error("Error sending followup message")
-	}
-}
-
-func sendFollowupMessageWithEmbed(s *discordgo.Session, i *discordgo.InteractionCreate, message string, embed *discordgo.MessageEmbed) {
-	if i.Interaction == nil || i.Interaction.Token == "" {
-		logger.Log.Error("Cannot send followup with embed: invalid interaction or token")
-		return
-	}
-
-	params := &discordgo.WebhookParams{
-		Embeds: []*discordgo.MessageEmbed{embed},
-		Flags:  discordgo.MessageFlagsEphemeral,
-	}
-
-	if message != "" {
-		params.Content = message
-	}
-
-	_, err := s.FollowupMessageCreate(i.Interaction, true, params)
-	if err != nil {
-		logger.Log.WithError(err).Error("Error sending followup message with embed")
-	}
-}
-
-func respondToInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: message,
-			Flags:   discordgo.MessageFlagsEphemeral,
-		},
-	})
-	if err != nil {
-		logger.Log.WithError(err).Error("Error responding to interaction")
-	}
-}
-
-func respondToInteractionWithMessage(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: message,
-			Flags:   discordgo.MessageFlagsEphemeral,
-		},
-	})
-	if err != nil {
-		logger.Log.WithError(err).Error("Error responding to interaction with message")
+		return i18n.T(locale, "vip.yes")
 	}
+	return i18n.T(locale, "vip.no")
 }
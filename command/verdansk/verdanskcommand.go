@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image/jpeg"
 	"io"
@@ -20,8 +21,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/bradselph/CODStatusBot/command/middleware"
+	"github.com/bradselph/CODStatusBot/command/registry"
 	"github.com/bradselph/CODStatusBot/configuration"
 	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
 	"github.com/bradselph/CODStatusBot/services"
@@ -29,144 +33,79 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+var Command = registry.Command{
+	Name:        "verdansk",
+	Description: "Get your Verdansk Replay stats and images",
+	DescriptionLocalizations: map[discordgo.Locale]string{
+		discordgo.SpanishES:    "Obtén tus estadísticas e imágenes de Verdansk Replay",
+		discordgo.SpanishLATAM: "Obtén tus estadísticas e imágenes de Verdansk Replay",
+		discordgo.PortugueseBR: "Veja suas estatísticas e imagens do Verdansk Replay",
+	},
+	// A cooldown between requests plus the daily allowance of the user's
+	// tier.
+	RateLimit: middleware.Policy{
+		Dynamic: func(req *middleware.Request) []services.RateLimitPolicy {
+			return []services.RateLimitPolicy{
+				{Limit: 1, Window: configuration.Get().Verdansk.CommandCooldown},
+				{Action: "verdansk.daily", Limit: req.Tier().Quotas().VerdanskPerDay, Window: 24 * time.Hour},
+			}
+		},
+		Message: "verdansk.limited",
+	},
+	Handler: CommandVerdansk,
+	Components: []registry.Route{
+		registry.Exact("verdansk_provide_id", HandleMethodSelection),
+		registry.Exact("verdansk_select_account", HandleMethodSelection),
+		registry.Prefix("verdansk_account_", HandleAccountSelection),
+	},
+	Modals: []registry.Route{
+		registry.Exact("verdansk_activision_id_modal", HandleActivisionIDModal),
+	},
+}
+
 var verdanskRateLimits = struct {
 	sync.RWMutex
-	userLimits     map[string]userRateLimit
 	globalSettings verdanskLimitSettings
 }{
-	userLimits: make(map[string]userRateLimit),
 	globalSettings: verdanskLimitSettings{
-		CommandCooldown:    time.Hour * 1,    // 1 hour between commands per user
-		MaxRequestsPerDay:  3,                // Max 3 requests per day per user
 		CleanupTime:        time.Minute * 30, // 30 minutes before cleanup
 		MaxDownloadsPerDay: 10,               // Maximum stats downloads per day across all users
-		MaxConcurrentJobs:  3,                // Max concurrent jobs for a single user
 	},
 }
 
-type userRateLimit struct {
-	lastRequest     time.Time
-	todayRequests   int
-	todayDownloads  int
-	resetTime       time.Time
-	downloadHistory []time.Time
-}
-
 type verdanskLimitSettings struct {
-	CommandCooldown    time.Duration
-	MaxRequestsPerDay  int
 	CleanupTime        time.Duration
 	MaxDownloadsPerDay int
-	MaxConcurrentJobs  int
 }
 
-func canUserRunVerdanskCommand(userID string) (bool, string) {
-	verdanskRateLimits.RLock()
-	defer verdanskRateLimits.RUnlock()
-
-	now := time.Now()
-	settings := verdanskRateLimits.globalSettings
-	limit, exists := verdanskRateLimits.userLimits[userID]
-
-	if !exists {
-		return true, ""
-	}
-
-	if now.Sub(limit.lastRequest) < settings.CommandCooldown {
-		remaining := limit.lastRequest.Add(settings.CommandCooldown).Sub(now).Round(time.Second)
-		return false, fmt.Sprintf("Command cooldown in effect. Please try again in %s", remaining)
-	}
-
-	if now.Day() == limit.lastRequest.Day() &&
-		now.Month() == limit.lastRequest.Month() &&
-		now.Year() == limit.lastRequest.Year() {
-		if limit.todayRequests >= settings.MaxRequestsPerDay {
-			nextDay := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
-			return false, fmt.Sprintf("Daily limit reached (%d). Reset at midnight (%s remaining)",
-				settings.MaxRequestsPerDay, time.Until(nextDay).Round(time.Minute))
-		}
-	}
-
-	return true, ""
-}
-
-func updateUserRateLimit(userID string) {
-	verdanskRateLimits.Lock()
-	defer verdanskRateLimits.Unlock()
-
-	now := time.Now()
-	limit, exists := verdanskRateLimits.userLimits[userID]
-
-	if !exists {
-		verdanskRateLimits.userLimits[userID] = userRateLimit{
-			lastRequest:   now,
-			todayRequests: 1,
-			resetTime:     time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location()),
-		}
-		return
-	}
-
-	// Reset counters if it's a new day
-	if now.Day() != limit.lastRequest.Day() ||
-		now.Month() != limit.lastRequest.Month() ||
-		now.Year() != limit.lastRequest.Year() {
-		limit.todayRequests = 0
-		limit.todayDownloads = 0
-		limit.resetTime = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
-	}
-
-	limit.lastRequest = now
-	limit.todayRequests++
-
-	verdanskRateLimits.userLimits[userID] = limit
-}
-
-func trackVerdanskDownload(userID string) {
-	verdanskRateLimits.Lock()
-	defer verdanskRateLimits.Unlock()
-
-	now := time.Now()
-	limit, exists := verdanskRateLimits.userLimits[userID]
+// verdanskDownloads counts stat downloads across all users for the current
+// day, against MaxDownloadsPerDay.
+var verdanskDownloads = struct {
+	sync.Mutex
+	day   string
+	count int
+}{}
 
-	if !exists {
-		verdanskRateLimits.userLimits[userID] = userRateLimit{
-			lastRequest:     now,
-			todayRequests:   1,
-			todayDownloads:  1,
-			downloadHistory: []time.Time{now},
-		}
-		return
-	}
+func trackVerdanskDownload() {
+	verdanskDownloads.Lock()
+	defer verdanskDownloads.Unlock()
 
-	var recentDownloads []time.Time
-	for _, t := range limit.downloadHistory {
-		if now.Sub(t) < 24*time.Hour {
-			recentDownloads = append(recentDownloads, t)
-		}
+	today := time.Now().Format(time.DateOnly)
+	if verdanskDownloads.day != today {
+		verdanskDownloads.day = today
+		verdanskDownloads.count = 0
 	}
-
-	limit.todayDownloads++
-	limit.downloadHistory = append(recentDownloads, now)
-
-	verdanskRateLimits.userLimits[userID] = limit
+	verdanskDownloads.count++
 }
 
 func getTotalDownloadsToday() int {
-	verdanskRateLimits.RLock()
-	defer verdanskRateLimits.RUnlock()
-
-	total := 0
-	now := time.Now()
+	verdanskDownloads.Lock()
+	defer verdanskDownloads.Unlock()
 
-	for _, limit := range verdanskRateLimits.userLimits {
-		if now.Day() == limit.lastRequest.Day() &&
-			now.Month() == limit.lastRequest.Month() &&
-			now.Year() == limit.lastRequest.Year() {
-			total += limit.todayDownloads
-		}
+	if verdanskDownloads.day != time.Now().Format(time.DateOnly) {
+		return 0
 	}
-
-	return total
+	return verdanskDownloads.count
 }
 
 type PlayerPreferences struct {
@@ -203,6 +142,7 @@ const (
 )
 
 func CommandVerdansk(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 	cfg := configuration.Get()
 	X_APIKey = cfg.Verdansk.APIKey
 
@@ -213,35 +153,21 @@ func CommandVerdansk(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 	log.Info("Verdansk command initiated")
 
-	userID, err := services.GetUserID(i)
-	if err != nil {
-		log.WithError(err).Error("Failed to get user ID")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
+	userID := middleware.Current(i).UserID
 
 	log = log.WithField("userID", userID)
 
-	canRun, message := canUserRunVerdanskCommand(userID)
-	if !canRun {
-		log.Infof("Rate limit applied: %s", message)
-		respondToInteraction(s, i, fmt.Sprintf("⏳ **Rate Limited**: %s", message))
-		return
-	}
-
 	if getTotalDownloadsToday() >= verdanskRateLimits.globalSettings.MaxDownloadsPerDay {
 		log.Warn("Global download limit reached")
-		respondToInteraction(s, i, "🛑 **Service Unavailable**: Verdansk stats service has reached its daily limit. Please try again tomorrow.")
+		middleware.Reply(s, i, i18n.T(locale, "verdansk.service_unavailable"))
 		return
 	}
 
-	updateUserRateLimit(userID)
-
 	var accounts []models.Account
 	result := database.DB.Where("user_id = ?", userID).Find(&accounts)
 	if result.Error != nil {
 		log.WithError(result.Error).Error("Error fetching user accounts")
-		respondToInteraction(s, i, "Error fetching your accounts. Please try again.")
+		middleware.Reply(s, i, i18n.T(locale, "error.fetch_accounts"))
 		return
 	}
 
@@ -249,7 +175,7 @@ func CommandVerdansk(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 	components := []discordgo.MessageComponent{
 		discordgo.Button{
-			Label:    "Provide Activision ID",
+			Label:    i18n.T(locale, "verdansk.provide_id"),
 			Style:    discordgo.PrimaryButton,
 			CustomID: "verdansk_provide_id",
 		},
@@ -257,16 +183,16 @@ func CommandVerdansk(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 	if len(accounts) > 0 {
 		components = append(components, discordgo.Button{
-			Label:    "Select Account",
+			Label:    i18n.T(locale, "verdansk.select_account"),
 			Style:    discordgo.SuccessButton,
 			CustomID: "verdansk_select_account",
 		})
 	}
 
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err := middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: "How would you like to check Verdansk Replay stats?",
+			Content: i18n.T(locale, "verdansk.method_prompt"),
 			Flags:   discordgo.MessageFlagsEphemeral,
 			Components: []discordgo.MessageComponent{
 				discordgo.ActionsRow{
@@ -277,16 +203,16 @@ func CommandVerdansk(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	})
 	if err != nil {
 		log.WithError(err).Error("Error responding with method selection")
+		middleware.Current(i).Fail("Error responding with method selection")
 	} else {
 		log.Info("Successfully displayed method selection options")
 	}
-
-	services.LogCommandExecution("verdansk", userID, i.GuildID, err == nil, time.Now().UnixMilli(), "")
 }
 
 func HandleMethodSelection(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 	customID := i.MessageComponentData().CustomID
-	userID, _ := services.GetUserID(i)
+	userID := middleware.Current(i).UserID
 	log := logger.Log.WithFields(logrus.Fields{
 		"command":  "verdansk",
 		"action":   "method_selection",
@@ -305,12 +231,13 @@ func HandleMethodSelection(s *discordgo.Session, i *discordgo.InteractionCreate)
 		showAccountSelection(s, i)
 	default:
 		log.Warn("Invalid selection")
-		respondToInteraction(s, i, "Invalid selection.")
+		middleware.Reply(s, i, i18n.T(locale, "verdansk.invalid_selection"))
 	}
 }
 
 func showActivisionIDModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	userID, _ := services.GetUserID(i)
+	locale := services.InteractionLocale(i)
+	userID := middleware.Current(i).UserID
 	log := logger.Log.WithFields(logrus.Fields{
 		"command": "verdansk",
 		"action":  "show_activision_id_modal",
@@ -319,19 +246,19 @@ func showActivisionIDModal(s *discordgo.Session, i *discordgo.InteractionCreate)
 
 	log.Info("Showing Activision ID entry modal")
 
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err := middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseModal,
 		Data: &discordgo.InteractionResponseData{
 			CustomID: "verdansk_activision_id_modal",
-			Title:    "Enter Activision ID",
+			Title:    i18n.T(locale, "verdansk.modal.title"),
 			Components: []discordgo.MessageComponent{
 				discordgo.ActionsRow{
 					Components: []discordgo.MessageComponent{
 						discordgo.TextInput{
 							CustomID:    "activision_id",
-							Label:       "Activision ID (e.g. Username#1234)",
+							Label:       i18n.T(locale, "verdansk.modal.label"),
 							Style:       discordgo.TextInputShort,
-							Placeholder: "Enter Activision ID",
+							Placeholder: i18n.T(locale, "verdansk.modal.placeholder"),
 							Required:    true,
 							MinLength:   3,
 							MaxLength:   32,
@@ -349,7 +276,8 @@ func showActivisionIDModal(s *discordgo.Session, i *discordgo.InteractionCreate)
 }
 
 func showAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	userID, err := services.GetUserID(i)
+	locale := services.InteractionLocale(i)
+	userID := middleware.Current(i).UserID
 	log := logger.Log.WithFields(logrus.Fields{
 		"command": "verdansk",
 		"action":  "show_account_selection",
@@ -358,23 +286,17 @@ func showAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate)
 
 	log.Info("Preparing account selection")
 
-	if err != nil {
-		log.WithError(err).Error("Failed to get user ID")
-		respondToInteraction(s, i, "An error occurred while processing your request.")
-		return
-	}
-
 	var accounts []models.Account
 	result := database.DB.Where("user_id = ?", userID).Find(&accounts)
 	if result.Error != nil {
 		log.WithError(result.Error).Error("Error fetching user accounts")
-		respondToInteraction(s, i, "Error fetching your accounts. Please try again.")
+		middleware.Reply(s, i, i18n.T(locale, "error.fetch_accounts"))
 		return
 	}
 
 	if len(accounts) == 0 {
 		log.Warn("No accounts found")
-		respondToInteraction(s, i, "You don't have any monitored accounts.")
+		middleware.Reply(s, i, i18n.T(locale, "accounts.none"))
 		return
 	}
 
@@ -411,10 +333,10 @@ func showAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate)
 
 	log.WithField("ogVerdanskAccounts", ogVerdanskAccounts).Info("Displaying account selection buttons")
 
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err := middleware.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseUpdateMessage,
 		Data: &discordgo.InteractionResponseData{
-			Content:    "Select an account to check Verdansk Replay stats:\n(Green buttons indicate accounts with confirmed Verdansk stats)",
+			Content:    i18n.T(locale, "verdansk.account_prompt"),
 			Flags:      discordgo.MessageFlagsEphemeral,
 			Components: components,
 		},
@@ -427,8 +349,9 @@ func showAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate)
 }
 
 func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 	customID := i.MessageComponentData().CustomID
-	userID, _ := services.GetUserID(i)
+	userID := middleware.Current(i).UserID
 
 	log := logger.Log.WithFields(logrus.Fields{
 		"command":  "verdansk",
@@ -450,18 +373,13 @@ func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate
 	var account models.Account
 	if err := database.DB.First(&account, accountID).Error; err != nil {
 		log.WithError(err).Error("Error fetching account")
-		respondToInteraction(s, i, "Error: Account not found or you don't have permission to check its Verdansk stats.")
+		middleware.Reply(s, i, i18n.T(locale, "verdansk.not_found"))
 		return
 	}
 
 	log.WithField("accountTitle", account.Title).Info("Account found, deferring response")
 
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Flags: discordgo.MessageFlagsEphemeral,
-		},
-	})
+	err := middleware.Defer(s, i)
 	if err != nil {
 		log.WithError(err).Error("Error sending deferred response")
 		return
@@ -471,7 +389,7 @@ func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate
 	activisionID, err := getActivisionIDFromAccount(account)
 	if err != nil {
 		log.WithError(err).Error("Error getting Activision ID from account")
-		sendFollowupMessage(s, i, "Error: Could not determine Activision ID from account. Please ensure your cookie is valid.")
+		middleware.Followup(s, i, i18n.T(locale, "verdansk.no_activision_id"))
 		return
 	}
 
@@ -480,8 +398,9 @@ func HandleAccountSelection(s *discordgo.Session, i *discordgo.InteractionCreate
 }
 
 func HandleActivisionIDModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := services.InteractionLocale(i)
 	data := i.ModalSubmitData()
-	userID, _ := services.GetUserID(i)
+	userID := middleware.Current(i).UserID
 
 	log := logger.Log.WithFields(logrus.Fields{
 		"command": "verdansk",
@@ -506,17 +425,12 @@ func HandleActivisionIDModal(s *discordgo.Session, i *discordgo.InteractionCreat
 
 	if activisionID == "" {
 		log.Warn("Empty Activision ID provided")
-		respondToInteraction(s, i, "Error: Activision ID is required.")
+		middleware.Reply(s, i, i18n.T(locale, "verdansk.id_required"))
 		return
 	}
 
 	log.Info("Deferring response")
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Flags: discordgo.MessageFlagsEphemeral,
-		},
-	})
+	err := middleware.Defer(s, i)
 	if err != nil {
 		log.WithError(err).Error("Error sending deferred response")
 		return
@@ -603,7 +517,8 @@ func getActivisionIDFromAccount(account models.Account) (string, error) {
 }
 
 func processVerdanskStats(s *discordgo.Session, i *discordgo.InteractionCreate, activisionID string, account *models.Account) {
-	userID, _ := services.GetUserID(i)
+	locale := services.InteractionLocale(i)
+	userID := middleware.Current(i).UserID
 	log := logger.Log.WithFields(logrus.Fields{
 		"function":     "processVerdanskStats",
 		"userID":       userID,
@@ -611,7 +526,7 @@ func processVerdanskStats(s *discordgo.Session, i *discordgo.InteractionCreate,
 	})
 
 	log.Info("Processing Verdansk stats")
-	trackVerdanskDownload(userID)
+	trackVerdanskDownload()
 
 	cfg := configuration.Get()
 	tempDir := cfg.Verdansk.TempDir
@@ -626,7 +541,7 @@ func processVerdanskStats(s *discordgo.Session, i *discordgo.InteractionCreate,
 
 	if err := os.MkdirAll(tempDir, 0755); err != nil {
 		log.WithError(err).Error("Error creating temp directory")
-		sendFollowupMessage(s, i, "Error: Failed to create temporary directory for stats.")
+		middleware.Followup(s, i, i18n.T(locale, "verdansk.temp_dir_error"))
 		return
 	}
 
@@ -640,39 +555,35 @@ func processVerdanskStats(s *discordgo.Session, i *discordgo.InteractionCreate,
 	if err != nil {
 		log.WithError(err).Error("Error fetching player preferences")
 
-		errorReason := "An unknown error occurred."
-		remedyMessage := "Please try again later."
-
-		if strings.Contains(err.Error(), "404") || strings.Contains(err.Error(), "not found") {
-			errorReason = "Account not found in Verdansk records."
-			remedyMessage = "This account either did not play during the Verdansk era or was created after Verdansk ended."
-		} else if strings.Contains(err.Error(), "403") || strings.Contains(err.Error(), "forbidden") {
-			errorReason = "Access to this account's Verdansk data is restricted."
-			remedyMessage = "Check your privacy settings at https://profile.callofduty.com/cod/login and ensure game data is set to 'visible'."
-		} else if strings.Contains(err.Error(), "429") || strings.Contains(err.Error(), "too many requests") {
-			errorReason = "Rate limit reached for Activision's API."
-			remedyMessage = "The service is experiencing high traffic. Please try again in 15-30 minutes."
-		} else if strings.Contains(err.Error(), "500") || strings.Contains(err.Error(), "503") {
-			errorReason = "Activision's API is currently experiencing issues."
-			remedyMessage = "The Verdansk Replay service may be temporarily unavailable. Please try again later."
+		var apiErr *verdanskAPIError
+		if !errors.As(err, &apiErr) || (apiErr.StatusCode != http.StatusNotFound && apiErr.StatusCode != http.StatusForbidden) {
+			middleware.Followup(s, i, "", services.UserErrorEmbed(locale, err))
+			return
+		}
+
+		errorReason := i18n.T(locale, "verdansk.reason.not_found")
+		remedyMessage := i18n.T(locale, "verdansk.remedy.not_found")
+		if apiErr.StatusCode == http.StatusForbidden {
+			errorReason = i18n.T(locale, "verdansk.reason.forbidden")
+			remedyMessage = i18n.T(locale, "verdansk.remedy.forbidden")
 		}
 
 		errorEmbed := &discordgo.MessageEmbed{
-			Title:       "Verdansk Stats Unavailable",
-			Description: fmt.Sprintf("Unable to retrieve Verdansk Replay stats for **%s**", activisionID),
+			Title:       i18n.T(locale, "verdansk.unavailable.title"),
+			Description: i18n.T(locale, "verdansk.unavailable.description", activisionID),
 			Color:       0xFF0000,
 			Fields: []*discordgo.MessageEmbedField{
 				{
-					Name:  "Error Reason",
+					Name:  i18n.T(locale, "verdansk.field.error_reason"),
 					Value: errorReason,
 				},
 				{
-					Name:  "What You Can Do",
+					Name:  i18n.T(locale, "verdansk.field.what_you_can_do"),
 					Value: remedyMessage,
 				},
 			},
 			Footer: &discordgo.MessageEmbedFooter{
-				Text: "COD Status Bot - Verdansk Replay",
+				Text: i18n.T(locale, "verdansk.footer"),
 			},
 		}
 
@@ -682,7 +593,7 @@ func processVerdanskStats(s *discordgo.Session, i *discordgo.InteractionCreate,
 		})
 
 		if err != nil {
-			sendFollowupMessage(s, i, fmt.Sprintf("Error: Failed to fetch preferences for %s. %v", activisionID, err))
+			middleware.Followup(s, i, i18n.T(locale, "verdansk.fetch_preferences_error", activisionID, err))
 		}
 		return
 	}
@@ -691,27 +602,21 @@ func processVerdanskStats(s *discordgo.Session, i *discordgo.InteractionCreate,
 		log.Info("Verdansk stats not available for this account")
 
 		unavailableEmbed := &discordgo.MessageEmbed{
-			Title:       "Verdansk Stats Not Available",
-			Description: fmt.Sprintf("Unfortunately, Verdansk Replay stats are not available for **%s**.", activisionID),
+			Title:       i18n.T(locale, "verdansk.private.title"),
+			Description: i18n.T(locale, "verdansk.private.description", activisionID),
 			Color:       0xFF9900,
 			Fields: []*discordgo.MessageEmbedField{
 				{
-					Name: "Possible Reasons",
-					Value: "• Not enough Verdansk gameplay (at least 5 matches required)\n" +
-						"• Your Game Data settings are set to private\n" +
-						"• The account was created after Verdansk ended",
+					Name:  i18n.T(locale, "verdansk.field.possible_reasons"),
+					Value: i18n.T(locale, "verdansk.private.reasons"),
 				},
 				{
-					Name: "How to Fix",
-					Value: "1. Go to [callofduty.com/profile](https://profile.callofduty.com/cod/login)\n" +
-						"2. Log in with your Activision account\n" +
-						"3. Go to Privacy & Security settings\n" +
-						"4. Make sure Game Data is set to visible\n" +
-						"5. Try again in 24 hours as changes may take time to apply",
+					Name:  i18n.T(locale, "verdansk.field.how_to_fix"),
+					Value: i18n.T(locale, "verdansk.private.fix"),
 				},
 			},
 			Footer: &discordgo.MessageEmbedFooter{
-				Text: "COD Status Bot - Verdansk Replay",
+				Text: i18n.T(locale, "verdansk.footer"),
 			},
 		}
 
@@ -721,7 +626,7 @@ func processVerdanskStats(s *discordgo.Session, i *discordgo.InteractionCreate,
 		})
 
 		if err != nil {
-			sendFollowupMessage(s, i, fmt.Sprintf("Verdansk stats for %s are not available. This could be because:\n- You haven't played enough in Verdansk (at least 5 deployments required)\n- Your Game Player Data settings need to be updated at https://profile.callofduty.com/cod/login", activisionID))
+			middleware.Followup(s, i, i18n.T(locale, "verdansk.private.fallback", activisionID))
 		}
 		return
 	}
@@ -738,13 +643,13 @@ func processVerdanskStats(s *discordgo.Session, i *discordgo.InteractionCreate,
 	stats, err := fetchPlayerStats(client, encodedID)
 	if err != nil {
 		log.WithError(err).Error("Error fetching player stats")
-		sendFollowupMessage(s, i, fmt.Sprintf("Error: Failed to fetch stats for %s. %v", activisionID, err))
+		middleware.Followup(s, i, "", services.UserErrorEmbed(locale, err))
 		return
 	}
 
 	if len(stats) == 0 {
 		log.Warn("No stats found for player")
-		sendFollowupMessage(s, i, fmt.Sprintf("No Verdansk stats found for %s.", activisionID))
+		middleware.Followup(s, i, i18n.T(locale, "verdansk.no_stats", activisionID))
 		return
 	}
 
@@ -762,7 +667,7 @@ func processVerdanskStats(s *discordgo.Session, i *discordgo.InteractionCreate,
 	images, err := downloadImages(client, stats, outputDir, 3)
 	if err != nil {
 		log.WithError(err).Error("Error downloading stat images")
-		sendFollowupMessage(s, i, fmt.Sprintf("Error: Failed to download stat images for %s. %v", activisionID, err))
+		middleware.Followup(s, i, "", services.UserErrorEmbed(locale, err))
 		return
 	}
 
@@ -773,7 +678,7 @@ func processVerdanskStats(s *discordgo.Session, i *discordgo.InteractionCreate,
 	log.Info("Creating zip file")
 	if err := createZip(images, zipFilename); err != nil {
 		log.WithError(err).Error("Error creating zip file")
-		sendFollowupMessage(s, i, fmt.Sprintf("Error: Failed to create zip file for %s. %v", activisionID, err))
+		middleware.Followup(s, i, "", services.UserErrorEmbed(locale, err))
 		return
 	}
 
@@ -789,7 +694,7 @@ func processVerdanskStats(s *discordgo.Session, i *discordgo.InteractionCreate,
 	zipFile, err := os.Open(zipFilename)
 	if err != nil {
 		log.WithError(err).Error("Error opening zip file")
-		sendFollowupMessage(s, i, fmt.Sprintf("Error: Failed to prepare zip file for %s.", activisionID))
+		middleware.Followup(s, i, i18n.T(locale, "verdansk.zip_prepare_error", activisionID))
 		return
 	}
 	defer zipFile.Close()
@@ -800,7 +705,7 @@ func processVerdanskStats(s *discordgo.Session, i *discordgo.InteractionCreate,
 
 	log.WithField("imageCount", len(images)).Info("Downloaded images successfully")
 	images = enrichVerdanskFilenames(images)
-	summaryEmbed := generateVerdanskStatsEmbed(activisionID, stats)
+	summaryEmbed := generateVerdanskStatsEmbed(locale, activisionID, stats)
 	embeds = append(embeds, summaryEmbed)
 
 	var displayedImages int
@@ -812,7 +717,7 @@ func processVerdanskStats(s *discordgo.Session, i *discordgo.InteractionCreate,
 		formattedName := formatStatName(img.Name)
 		embed := &discordgo.MessageEmbed{
 			Title:       formattedName,
-			Description: fmt.Sprintf("Verdansk Replay Stat %d/%d", displayedImages+1, len(images)),
+			Description: i18n.T(locale, "verdansk.image_description", displayedImages+1, len(images)),
 			Image: &discordgo.MessageEmbedImage{
 				URL: fmt.Sprintf("attachment://%s.jpg", img.Name),
 			},
@@ -826,23 +731,22 @@ func processVerdanskStats(s *discordgo.Session, i *discordgo.InteractionCreate,
 		displayedImages++
 	}
 
-	contentMsg := fmt.Sprintf("Verdansk Replay Stats for %s", activisionID)
+	contentMsg := i18n.T(locale, "verdansk.content", activisionID)
 	if len(images) > maxImages {
-		contentMsg += fmt.Sprintf(" (Showing %d/%d images)\n\nDownload the zip file for all %d images. The data will expire after 30 minutes.",
-			maxImages, len(images), len(images))
+		contentMsg += " " + i18n.T(locale, "verdansk.content.truncated", maxImages, len(images), len(images))
 	} else {
-		contentMsg += "\n\nThe images will expire after 30 minutes. Download the zip file for permanent access."
+		contentMsg += "\n\n" + i18n.T(locale, "verdansk.content.expiry")
 	}
 
 	if account != nil {
-		contentMsg += "\n\nThese stats have been associated with your account and marked with the Verdansk flag."
+		contentMsg += "\n\n" + i18n.T(locale, "verdansk.content.flagged")
 	}
 
 	if account != nil {
 		if err := storeVerdanskStatsWithAccount(account, stats); err != nil {
 			log.WithError(err).Warn("Failed to store Verdansk stats with account")
 		} else {
-			contentMsg += "\n\nYour Verdansk stats have been saved to your account for future reference."
+			contentMsg += "\n\n" + i18n.T(locale, "verdansk.content.saved")
 		}
 	}
 
@@ -857,9 +761,9 @@ func processVerdanskStats(s *discordgo.Session, i *discordgo.InteractionCreate,
 	if err != nil {
 		log.WithError(err).Error("Error sending stat images")
 		if strings.Contains(err.Error(), "Maximum number of allowed attachments") {
-			sendFollowupMessage(s, i, fmt.Sprintf("Error: Discord's attachment limit prevents showing all images. Please download the zip file for the complete set of %d images.", len(images)))
+			middleware.Followup(s, i, i18n.T(locale, "verdansk.attachment_limit", len(images)))
 		} else {
-			sendFollowupMessage(s, i, fmt.Sprintf("Error: Failed to send stat images for %s. Please try again later.", activisionID))
+			middleware.Followup(s, i, i18n.T(locale, "verdansk.send_error", activisionID))
 		}
 		return
 	}
@@ -914,7 +818,7 @@ func fetchPlayerPreferences(client *http.Client, encodedGamerTag string) (*Playe
 	resp, err := client.Do(req)
 	if err != nil {
 		log.WithError(err).Error("Error sending request")
-		return nil, fmt.Errorf("error sending request: %w", err)
+		return nil, fmt.Errorf("%w: error sending request: %w", services.ErrUpstreamUnavailable, err)
 	}
 	defer resp.Body.Close()
 
@@ -925,7 +829,7 @@ func fetchPlayerPreferences(client *http.Client, encodedGamerTag string) (*Playe
 			"statusCode": resp.StatusCode,
 			"body":       string(body),
 		}).Error("API returned non-OK status")
-		return nil, fmt.Errorf("API returned status code %d: %s", resp.StatusCode, string(body))
+		return nil, &verdanskAPIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	body, err := readResponseBody(resp)
@@ -981,7 +885,7 @@ func fetchPlayerStats(client *http.Client, encodedGamerTag string) (map[string]S
 	resp, err := client.Do(req)
 	if err != nil {
 		log.WithError(err).Error("Error sending request")
-		return nil, fmt.Errorf("error sending request: %w", err)
+		return nil, fmt.Errorf("%w: error sending request: %w", services.ErrUpstreamUnavailable, err)
 	}
 	defer resp.Body.Close()
 
@@ -992,7 +896,7 @@ func fetchPlayerStats(client *http.Client, encodedGamerTag string) (map[string]S
 			"statusCode": resp.StatusCode,
 			"body":       string(body),
 		}).Error("API returned non-OK status")
-		return nil, fmt.Errorf("API returned status code %d: %s", resp.StatusCode, string(body))
+		return nil, &verdanskAPIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	body, err := readResponseBody(resp)
@@ -1418,6 +1322,29 @@ func doPreflightRequest(client *http.Client, targetURL string) error {
 	return nil
 }
 
+// verdanskAPIError is a non-OK response from the Verdansk API. It matches
+// services.ErrRateLimited and services.ErrUpstreamUnavailable so the user
+// error catalog can explain it.
+type verdanskAPIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *verdanskAPIError) Error() string {
+	return fmt.Sprintf("API returned status code %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *verdanskAPIError) Is(target error) bool {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return target == services.ErrRateLimited
+	case e.StatusCode >= 500:
+		return target == services.ErrUpstreamUnavailable
+	default:
+		return false
+	}
+}
+
 func readResponseBody(resp *http.Response) ([]byte, error) {
 	log := logger.Log.WithFields(logrus.Fields{
 		"function":    "readResponseBody",
@@ -1464,29 +1391,6 @@ func formatStatName(name string) string {
 	return strings.Join(words, " ")
 }
 
-func respondToInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: message,
-			Flags:   discordgo.MessageFlagsEphemeral,
-		},
-	})
-	if err != nil {
-		logger.Log.WithError(err).Error("Error responding to interaction")
-	}
-}
-
-func sendFollowupMessage(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
-	_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Content: message,
-		Flags:   discordgo.MessageFlagsEphemeral,
-	})
-	if err != nil {
-		logger.Log.WithError(err).Error("Error sending followup message")
-	}
-}
-
 func storeVerdanskStatsWithAccount(account *models.Account, stats map[string]StatValue) error {
 	if account == nil {
 		return fmt.Errorf("no account provided")
@@ -1682,22 +1586,23 @@ func organizeImagesForDisplay(images []ImageDownload, stats map[string]StatValue
 	return selectedImages
 }
 
-func generateVerdanskStatsEmbed(activisionID string, stats map[string]StatValue) *discordgo.MessageEmbed {
+func generateVerdanskStatsEmbed(locale, activisionID string, stats map[string]StatValue) *discordgo.MessageEmbed {
 	var fields []*discordgo.MessageEmbedField
 
-	keyStats := map[string]string{
-		"total_kills":            "Total Kills",
-		"total_deaths":           "Total Deaths",
-		"kd_ratio":               "K/D Ratio",
-		"matches_played":         "Matches Played",
-		"total_wins":             "Total Wins",
-		"win_percentage":         "Win Percentage",
-		"hours_played":           "Hours Played",
-		"favorite_weapon":        "Favorite Weapon",
-		"favorite_drop_location": "Favorite Drop Location",
+	keyStats := []string{
+		"total_kills",
+		"total_deaths",
+		"kd_ratio",
+		"matches_played",
+		"total_wins",
+		"win_percentage",
+		"hours_played",
+		"favorite_weapon",
+		"favorite_drop_location",
 	}
 
-	for statKey, displayName := range keyStats {
+	for _, statKey := range keyStats {
+		displayName := i18n.T(locale, "verdansk.stat."+statKey)
 		possibleKeys := []string{
 			statKey,
 			strings.ReplaceAll(statKey, "_", ""),
@@ -1718,12 +1623,12 @@ func generateVerdanskStatsEmbed(activisionID string, stats map[string]StatValue)
 	}
 
 	embed := &discordgo.MessageEmbed{
-		Title:       fmt.Sprintf("Verdansk Replay Stats Summary for %s", activisionID),
-		Description: "Here's a summary of your Verdansk career stats. Download the images or zip file to see detailed visualizations.",
+		Title:       i18n.T(locale, "verdansk.summary.title", activisionID),
+		Description: i18n.T(locale, "verdansk.summary.description"),
 		Color:       0x00BFFF,
 		Fields:      fields,
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Data provided by Call of Duty Verdansk Replay",
+			Text: i18n.T(locale, "verdansk.summary.footer"),
 		},
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
@@ -2345,15 +2250,13 @@ func getStatIntValue(stats map[string]StatValue, keys ...string) int {
 
 func HandleVerdanskCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if !isVerdanskAPIAvailable() {
-		respondToInteraction(s, i, "The Verdansk Replay API is currently unavailable. Please try again later.")
+		middleware.Reply(s, i, "The Verdansk Replay API is currently unavailable. Please try again later.")
 		return
 	}
 
 	cfg := configuration.Get()
 
 	verdanskRateLimits.Lock()
-	verdanskRateLimits.globalSettings.CommandCooldown = cfg.Verdansk.CommandCooldown
-	verdanskRateLimits.globalSettings.MaxRequestsPerDay = cfg.Verdansk.MaxRequestsPerDay
 	verdanskRateLimits.globalSettings.CleanupTime = cfg.Verdansk.CleanupTime
 	verdanskRateLimits.Unlock()
 
@@ -0,0 +1,137 @@
+package configuration
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildWithFile builds a configuration from a config file holding contents
+// and the current environment.
+func buildWithFile(t *testing.T, name, contents string) (*Config, *loadState) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	SetFile(path)
+	t.Cleanup(func() { SetFile("") })
+
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	return build()
+}
+
+func TestValidationReportsEveryProblem(t *testing.T) {
+	t.Setenv("CHECK_INTERVAL", "soon")
+	t.Setenv("ROLE", "boss")
+
+	_, state := buildWithFile(t, "config.yaml", `
+bogus: true
+admin:
+  port: eighty
+  nope: 1
+logging:
+  compress: "yes"
+`)
+
+	want := []string{
+		`unknown key "bogus"`,
+		`unknown key "admin.nope"`,
+		"admin.port: expected integer",
+		"logging.compress: expected boolean",
+		`CHECK_INTERVAL: expected integer, got "soon"`,
+		`unknown ROLE "boss"`,
+		"DISCORD_TOKEN (discord.token) is required",
+	}
+
+	var got []string
+	for _, problem := range state.problems {
+		got = append(got, problem.Error())
+	}
+	for _, fragment := range want {
+		found := false
+		for _, problem := range got {
+			if strings.Contains(problem, fragment) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("no problem mentions %q; got:\n%s", fragment, strings.Join(got, "\n"))
+		}
+	}
+	if err := problemsError(state.problems); err == nil {
+		t.Error("problemsError() = nil with problems reported")
+	}
+}
+
+func TestEnvironmentOverridesFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		contents string
+	}{
+		{
+			name: "yaml",
+			file: "config.yaml",
+			contents: `
+admin:
+  port: 9000
+  stats_rate_limit: 5
+intervals:
+  check_minutes: 30
+`,
+		},
+		{
+			name: "toml",
+			file: "config.toml",
+			contents: `
+[admin]
+port = 9000
+stats_rate_limit = 5
+
+[intervals]
+check_minutes = 30
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ADMIN_PORT", "9100")
+			t.Setenv("CHECK_INTERVAL", "")
+
+			cfg, state := buildWithFile(t, tt.file, tt.contents)
+
+			if cfg.Admin.Port != 9100 || state.values["ADMIN_PORT"].Source != "env" {
+				t.Errorf("ADMIN_PORT = %d from %q, want 9100 from env", cfg.Admin.Port, state.values["ADMIN_PORT"].Source)
+			}
+			if cfg.Admin.StatsRateLimit != 5 || state.values["ADMIN_STATS_RATE_LIMIT"].Source != state.file {
+				t.Errorf("ADMIN_STATS_RATE_LIMIT = %v from %q, want 5 from the file", cfg.Admin.StatsRateLimit, state.values["ADMIN_STATS_RATE_LIMIT"].Source)
+			}
+			if cfg.Intervals.Check != 30 {
+				t.Errorf("CHECK_INTERVAL = %d, want 30 from the file", cfg.Intervals.Check)
+			}
+			if got := state.values["ADMIN_API_ENABLED"].Source; got != "default" {
+				t.Errorf("ADMIN_API_ENABLED source = %q, want default", got)
+			}
+		})
+	}
+}
+
+func TestSchemaCoversEverySetting(t *testing.T) {
+	_, state := buildWithFile(t, "config.yaml", "environment: test\n")
+
+	for _, problem := range state.problems {
+		if strings.Contains(problem.Error(), "missing from the settings schema") {
+			t.Error(problem)
+		}
+	}
+	for _, s := range settings {
+		if _, ok := state.values[s.Env]; !ok {
+			t.Errorf("%s is in the schema but never read", s.Env)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package configuration
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultFiles are tried in order when CONFIG_FILE is not set.
+var defaultFiles = []string{"config.yaml", "config.yml", "config.toml"}
+
+var configFile string
+
+// SetFile selects the config file used by Load and Reload. An empty path
+// falls back to CONFIG_FILE and then to the default file names.
+func SetFile(path string) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	configFile = path
+}
+
+// File reports the config file in use, or "" when settings only come from
+// the environment.
+func File() string {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	return resolveFile()
+}
+
+func resolveFile() string {
+	if configFile != "" {
+		return configFile
+	}
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		return path
+	}
+	for _, name := range defaultFiles {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// readFile decodes a YAML or TOML config file into raw string values keyed by
+// environment variable name, so the file and the environment go through the
+// same parsing. Every unknown key and mistyped value is reported.
+func readFile(path string) (map[string]string, []error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s: %w", path, err)}
+	}
+
+	raw := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, []error{fmt.Errorf("%s: unsupported config file type (want .yaml, .yml or .toml)", path)}
+	}
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s: %w", path, err)}
+	}
+
+	flat := make(map[string]interface{})
+	flatten("", raw, flat)
+
+	paths := make([]string, 0, len(flat))
+	for p := range flat {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	values := make(map[string]string, len(flat))
+	var problems []error
+	for _, p := range paths {
+		s, ok := settingsByPath[p]
+		if !ok {
+			problems = append(problems, fmt.Errorf("%s: unknown key %q", path, p))
+			continue
+		}
+		value, ok := fileValueString(flat[p], s.Kind)
+		if !ok {
+			problems = append(problems, fmt.Errorf("%s: %s: expected %s, got %v", path, p, s.Kind, flat[p]))
+			continue
+		}
+		values[s.Env] = value
+	}
+	return values, problems
+}
+
+func flatten(prefix string, in map[string]interface{}, out map[string]interface{}) {
+	for key, value := range in {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			flatten(path, nested, out)
+			continue
+		}
+		out[path] = value
+	}
+}
+
+func fileValueString(value interface{}, kind settingKind) (string, bool) {
+	switch kind {
+	case kindString:
+		switch v := value.(type) {
+		case string:
+			return v, true
+		case int, int64, float64:
+			// Ports and IDs are often written unquoted.
+			return fmt.Sprint(v), true
+		}
+	case kindInt:
+		switch v := value.(type) {
+		case int:
+			return strconv.Itoa(v), true
+		case int64:
+			return strconv.FormatInt(v, 10), true
+		case float64:
+			if v == math.Trunc(v) {
+				return strconv.FormatInt(int64(v), 10), true
+			}
+		}
+	case kindFloat:
+		switch v := value.(type) {
+		case int:
+			return strconv.Itoa(v), true
+		case int64:
+			return strconv.FormatInt(v, 10), true
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), true
+		}
+	case kindBool:
+		if v, ok := value.(bool); ok {
+			return strconv.FormatBool(v), true
+		}
+	case kindList:
+		items, ok := value.([]interface{})
+		if !ok {
+			return "", false
+		}
+		parts := make([]string, 0, len(items))
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok {
+				return "", false
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, ","), true
+	}
+	return "", false
+}
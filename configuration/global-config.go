@@ -1,24 +1,49 @@
 package configuration
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/sirupsen/logrus"
 )
 
+// TierQuotas are the limits of one plan tier. A zero quota means no limit.
+type TierQuotas struct {
+	MaxAccounts          int           // Accounts a user may monitor
+	MinCheckInterval     time.Duration // Shortest time between scheduled checks of an account
+	DailyManualChecks    int           // Checks a user may run with /checknow per day
+	NotificationsPerHour int           // Notifications a user may receive per hour
+	VerdanskPerDay       int           // Verdansk stat downloads per day
+}
+
 type Config struct {
 	// Admin API Endpoints
 	Admin struct {
-		Port           int
-		APIKey         string
-		Enabled        bool
-		BasePath       string
-		StatsRateLimit float64
-		RetentionDays  int
+		Port             int
+		APIKey           string
+		APIKeyFullAccess bool
+		Enabled          bool
+		BasePath         string
+		StatsRateLimit   float64
+		RetentionDays    int
+		MetricsEnabled   bool
+		AllowedOrigins   []string
+	}
+
+	// User Portal Settings
+	Portal struct {
+		Enabled      bool
+		ClientSecret string
+		RedirectURL  string
+		SessionTTL   time.Duration
+		CookieSecure bool
 	}
 
 	// Database Performance
@@ -30,6 +55,35 @@ type Config struct {
 	// Environment
 	Environment string
 	LogDir      string
+	LogFormat   string // "text" or "json"
+
+	// Logging Settings
+	Logging struct {
+		Level         string // Default level
+		PackageLevels string // e.g. "services=debug,command/checknow=warn"
+		Output        string // "file", "stdout" or "syslog"
+		MaxSizeMB     int
+		MaxAgeDays    int
+		Compress      bool
+		SyslogAddress string
+		SyslogTag     string
+	}
+
+	// Process Settings
+	Process struct {
+		Role              string // "all", "gateway", "worker" or "api"
+		InstanceID        string
+		WorkerConcurrency int
+		JobLease          time.Duration
+		LeaderLease       time.Duration
+	}
+
+	// Tracing Settings
+	Tracing struct {
+		Exporter    string // "none", "otlp" or "sentry"
+		SampleRate  float64
+		ServiceName string
+	}
 
 	// Database Settings
 	Database struct {
@@ -47,6 +101,11 @@ type Config struct {
 		DeveloperID string
 		ClientID    string
 		PublicKey   string
+		// Commands are registered to this guild instead of globally in
+		// development, where guild commands update instantly.
+		DevGuildID string
+		// AdminIDs may use the /admin commands alongside the developer.
+		AdminIDs []string
 	}
 
 	// Captcha Service Settings
@@ -99,6 +158,16 @@ type Config struct {
 		ProfileEndpoint    string
 		CheckVIPEndpoint   string
 		RedeemCodeEndpoint string
+		RecordResponses    bool   // Store sanitized Activision responses for replay
+		RecordDir          string // Directory for recorded Activision responses
+		DriftThreshold     int    // Schema drift events before alerting the developer
+		DriftPauseChecks   bool   // Pause account checks until drift is acknowledged
+	}
+
+	// Circuit Breaker Settings
+	CircuitBreaker struct {
+		FailureThreshold int           // Consecutive upstream failures before opening
+		OpenDuration     time.Duration // How long a breaker stays open before probing
 	}
 
 	// Rate Limits and Intervals
@@ -107,6 +176,15 @@ type Config struct {
 		Default            time.Duration
 		DefaultMaxAccounts int
 		PremiumMaxAccounts int
+		Store              string // Where rate limiter state lives: database or memory
+	}
+
+	// Quotas of each plan tier
+	Tiers struct {
+		Free      TierQuotas
+		OwnKey    TierQuotas
+		Supporter TierQuotas
+		Granted   TierQuotas
 	}
 
 	// Intervals
@@ -161,45 +239,69 @@ type Config struct {
 	}
 }
 
-var AppConfig Config
+// current is the active configuration. Reloads publish a new Config
+// instead of changing this one, so readers never see a half-applied reload.
+var current atomic.Pointer[Config]
+
+func init() {
+	current.Store(&Config{})
+}
 
+var (
+	loadMu sync.Mutex
+	// active holds the state of the load in progress; the getters below
+	// record into it.
+	active *loadState
+	// lastValues are the raw settings behind the active configuration, used to tell which
+	// settings a reload changed.
+	lastValues map[string]settingValue
+)
+
+type settingValue struct {
+	Value  string
+	Source string // "env", "default" or the config file name
+}
+
+type loadState struct {
+	file     string
+	fileVals map[string]string
+	values   map[string]settingValue
+	problems []error
+}
+
+// Load reads the config file (if any) and the environment and makes the
+// result the active configuration.
+// Environment variables override the file. Every invalid or unknown setting
+// is reported, not just the first.
 func Load() error {
 	logger.Log.Info("Loading configuration...")
 
-	AppConfig.Environment = getEnvWithDefault("ENVIRONMENT", "development")
-	AppConfig.LogDir = getEnvWithDefault("LOG_DIR", "logs")
+	loadMu.Lock()
+	defer loadMu.Unlock()
 
-	requiredDbFields := map[string]*string{
-		"DB_USER":     &AppConfig.Database.User,
-		"DB_PASSWORD": &AppConfig.Database.Password,
-		"DB_NAME":     &AppConfig.Database.Name,
-		"DB_HOST":     &AppConfig.Database.Host,
-		"DB_PORT":     &AppConfig.Database.Port,
-		"DB_VAR":      &AppConfig.Database.Var,
-	}
+	cfg, state := build()
 
-	for env, field := range requiredDbFields {
-		*field = os.Getenv(env)
+	if err := configureLogging(cfg); err != nil {
+		return fmt.Errorf("failed to configure logging: %w", err)
 	}
+	logger.RegisterSecrets(
+		cfg.Discord.Token,
+		cfg.Portal.ClientSecret,
+		cfg.Admin.APIKey,
+		cfg.Database.Password,
+		cfg.CaptchaService.Capsolver.ClientKey,
+		cfg.CaptchaService.EZCaptcha.ClientKey,
+		cfg.CaptchaService.TwoCaptcha.ClientKey,
+	)
 
-	AppConfig.Discord.Token = os.Getenv("DISCORD_TOKEN")
-	AppConfig.Discord.DeveloperID = os.Getenv("DEVELOPER_ID")
-	AppConfig.Discord.ClientID = os.Getenv("DISCORD_CLIENT_ID")
-	AppConfig.Discord.PublicKey = os.Getenv("DISCORD_PUBLIC_KEY")
-
-	loadAdminConfig()
-	loadCaptchaConfig()
-	loadAPIEndpoints()
-	loadUserSettings()
-	loadNotificationSettings()
-	loadRateLimits()
-	loadIntervals()
-	loadEmojiConfig()
-	loadPerformanceConfig()
-	loadVerdanskConfig()
+	if err := problemsError(state.problems); err != nil {
+		return err
+	}
 
-	if err := validate(); err != nil {
-		return fmt.Errorf("configuration validation failed: %w", err)
+	current.Store(cfg)
+	lastValues = state.values
+	if state.file != "" {
+		logger.Log.Infof("Loaded configuration file %s", state.file)
 	}
 
 	logConfigurationValues()
@@ -207,199 +309,437 @@ func Load() error {
 	return nil
 }
 
-func loadAdminConfig() {
-	AppConfig.Admin.Enabled = getEnvAsBool("ADMIN_API_ENABLED", true)
-	AppConfig.Admin.Port = getEnvAsInt("ADMIN_PORT", 8080)
-	AppConfig.Admin.APIKey = os.Getenv("ADMIN_API_KEY")
-	AppConfig.Admin.BasePath = getEnvWithDefault("ADMIN_API_BASE_PATH", "/api")
-	AppConfig.Admin.StatsRateLimit = getEnvAsFloat("ADMIN_STATS_RATE_LIMIT", 25.0)
-	AppConfig.Admin.RetentionDays = getEnvAsInt("ANALYTICS_RETENTION_DAYS", 90)
+func build() (*Config, *loadState) {
+	state := &loadState{
+		file:   resolveFile(),
+		values: make(map[string]settingValue, len(settings)),
+	}
+	if state.file != "" {
+		state.fileVals, state.problems = readFile(state.file)
+	}
+
+	active = state
+	defer func() { active = nil }()
+
+	cfg := &Config{}
+	cfg.Environment = getEnvWithDefault("ENVIRONMENT", "development")
+	cfg.LogDir = getEnvWithDefault("LOG_DIR", "logs")
+	cfg.LogFormat = getEnvWithDefault("LOG_FORMAT", "text")
+	loadLoggingConfig(cfg)
+	loadTracingConfig(cfg)
+	loadProcessConfig(cfg)
+
+	cfg.Database.User = getEnv("DB_USER")
+	cfg.Database.Password = getEnv("DB_PASSWORD")
+	cfg.Database.Name = getEnv("DB_NAME")
+	cfg.Database.Host = getEnv("DB_HOST")
+	cfg.Database.Port = getEnv("DB_PORT")
+	cfg.Database.Var = getEnv("DB_VAR")
+
+	cfg.Discord.Token = getEnv("DISCORD_TOKEN")
+	cfg.Discord.DeveloperID = getEnv("DEVELOPER_ID")
+	cfg.Discord.ClientID = getEnv("DISCORD_CLIENT_ID")
+	cfg.Discord.PublicKey = getEnv("DISCORD_PUBLIC_KEY")
+	cfg.Discord.DevGuildID = getEnv("DISCORD_DEV_GUILD_ID")
+	cfg.Discord.AdminIDs = nil
+	for _, id := range strings.Split(getEnv("ADMIN_USER_IDS"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			cfg.Discord.AdminIDs = append(cfg.Discord.AdminIDs, id)
+		}
+	}
+
+	loadAdminConfig(cfg)
+	loadPortalConfig(cfg)
+	loadCaptchaConfig(cfg)
+	loadAPIEndpoints(cfg)
+	loadUserSettings(cfg)
+	loadNotificationSettings(cfg)
+	loadRateLimits(cfg)
+	loadCircuitBreakerConfig(cfg)
+	loadIntervals(cfg)
+	loadEmojiConfig(cfg)
+	loadPerformanceConfig(cfg)
+	loadVerdanskConfig(cfg)
+	loadTiers(cfg)
+
+	state.problems = append(state.problems, validate(cfg)...)
+	return cfg, state
 }
 
-func loadUserSettings() {
-	AppConfig.Users.MaxMessageFailures = getEnvAsInt("MAX_MESSAGE_FAILURES", 25)
+func problemsError(problems []error) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("configuration validation failed with %d problem(s):\n%w", len(problems), errors.Join(problems...))
+}
+
+func loadAdminConfig(cfg *Config) {
+	cfg.Admin.Enabled = getEnvAsBool("ADMIN_API_ENABLED", true)
+	cfg.Admin.Port = getEnvAsInt("ADMIN_PORT", 8080)
+	cfg.Admin.APIKey = getEnv("ADMIN_API_KEY")
+	cfg.Admin.APIKeyFullAccess = getEnvAsBool("ADMIN_API_KEY_FULL_ACCESS", false)
+	cfg.Admin.BasePath = getEnvWithDefault("ADMIN_API_BASE_PATH", "/api")
+	cfg.Admin.StatsRateLimit = getEnvAsFloat("ADMIN_STATS_RATE_LIMIT", 25.0)
+	cfg.Admin.RetentionDays = getEnvAsInt("ANALYTICS_RETENTION_DAYS", 90)
+	cfg.Admin.MetricsEnabled = getEnvAsBool("METRICS_ENABLED", true)
+	cfg.Admin.AllowedOrigins = nil
+	for _, origin := range strings.Split(getEnv("ADMIN_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			cfg.Admin.AllowedOrigins = append(cfg.Admin.AllowedOrigins, origin)
+		}
+	}
+}
+
+func loadLoggingConfig(cfg *Config) {
+	cfg.Logging.Level = getEnvWithDefault("LOG_LEVEL", "info")
+	cfg.Logging.PackageLevels = getEnv("LOG_PACKAGE_LEVELS")
+	cfg.Logging.Output = getEnvWithDefault("LOG_OUTPUT", "file")
+	cfg.Logging.MaxSizeMB = getEnvAsInt("LOG_MAX_SIZE_MB", 100)
+	cfg.Logging.MaxAgeDays = getEnvAsInt("LOG_MAX_AGE_DAYS", 30)
+	cfg.Logging.Compress = getEnvAsBool("LOG_COMPRESS", true)
+	cfg.Logging.SyslogAddress = getEnv("LOG_SYSLOG_ADDRESS")
+	cfg.Logging.SyslogTag = getEnvWithDefault("LOG_SYSLOG_TAG", "codstatusbot")
+}
+
+// The OTLP endpoint and headers are read by the exporter itself from the
+// standard OTEL_EXPORTER_OTLP_* variables.
+func loadTracingConfig(cfg *Config) {
+	cfg.Tracing.Exporter = strings.ToLower(getEnvWithDefault("TRACING_EXPORTER", "none"))
+	cfg.Tracing.SampleRate = getEnvAsFloat("TRACING_SAMPLE_RATE", 0.1)
+	cfg.Tracing.ServiceName = getEnvWithDefault("TRACING_SERVICE_NAME", "codstatusbot")
+}
+
+func loadProcessConfig(cfg *Config) {
+	cfg.Process.Role = strings.ToLower(getEnvWithDefault("ROLE", "all"))
+	cfg.Process.InstanceID = getEnv("INSTANCE_ID")
+	cfg.Process.WorkerConcurrency = getEnvAsInt("WORKER_CONCURRENCY", 2)
+	cfg.Process.JobLease = time.Duration(getEnvAsInt("JOB_LEASE_SECONDS", 120)) * time.Second
+	cfg.Process.LeaderLease = time.Duration(getEnvAsInt("LEADER_LEASE_SECONDS", 30)) * time.Second
+}
+
+func configureLogging(cfg *Config) error {
+	level, err := logrus.ParseLevel(cfg.Logging.Level)
+	if err != nil {
+		return fmt.Errorf("invalid LOG_LEVEL: %w", err)
+	}
+	packageLevels, err := logger.ParsePackageLevels(cfg.Logging.PackageLevels)
+	if err != nil {
+		return fmt.Errorf("invalid LOG_PACKAGE_LEVELS: %w", err)
+	}
+	return logger.Configure(logger.Options{
+		Dir:           cfg.LogDir,
+		Format:        cfg.LogFormat,
+		Output:        cfg.Logging.Output,
+		Level:         level,
+		PackageLevels: packageLevels,
+		MaxSizeMB:     cfg.Logging.MaxSizeMB,
+		MaxAgeDays:    cfg.Logging.MaxAgeDays,
+		Compress:      cfg.Logging.Compress,
+		SyslogAddress: cfg.Logging.SyslogAddress,
+		SyslogTag:     cfg.Logging.SyslogTag,
+	})
+}
+
+func loadPortalConfig(cfg *Config) {
+	cfg.Portal.Enabled = getEnvAsBool("PORTAL_ENABLED", false)
+	cfg.Portal.ClientSecret = getEnv("DISCORD_CLIENT_SECRET")
+	cfg.Portal.RedirectURL = getEnv("PORTAL_REDIRECT_URL")
+	sessionHours := getEnvAsInt("PORTAL_SESSION_HOURS", 24)
+	cfg.Portal.SessionTTL = time.Duration(sessionHours) * time.Hour
+	cfg.Portal.CookieSecure = getEnvAsBool("PORTAL_COOKIE_SECURE", true)
+}
+
+func loadUserSettings(cfg *Config) {
+	cfg.Users.MaxMessageFailures = getEnvAsInt("MAX_MESSAGE_FAILURES", 25)
 	inactiveDays := getEnvAsInt("INACTIVE_USER_DAYS", 90)
-	AppConfig.Users.InactiveUserPeriod = time.Duration(inactiveDays) * 24 * time.Hour
+	cfg.Users.InactiveUserPeriod = time.Duration(inactiveDays) * 24 * time.Hour
 	unreachableDays := getEnvAsInt("UNREACHABLE_RESET_DAYS", 30)
-	AppConfig.Users.UnreachableResetPeriod = time.Duration(unreachableDays) * 24 * time.Hour
+	cfg.Users.UnreachableResetPeriod = time.Duration(unreachableDays) * 24 * time.Hour
 }
 
-func loadNotificationSettings() {
+func loadNotificationSettings(cfg *Config) {
 	cooldownMinutes := getEnvAsInt("NOTIFICATION_DEFAULT_COOLDOWN_MINUTES", 60)
-	AppConfig.Notifications.DefaultCooldown = time.Duration(cooldownMinutes) * time.Minute
-	AppConfig.Notifications.MaxPerHour = getEnvAsInt("NOTIFICATION_MAX_PER_HOUR", 4)
-	AppConfig.Notifications.MaxPerDay = getEnvAsInt("NOTIFICATION_MAX_PER_DAY", 10)
+	cfg.Notifications.DefaultCooldown = time.Duration(cooldownMinutes) * time.Minute
+	cfg.Notifications.MaxPerHour = getEnvAsInt("NOTIFICATION_MAX_PER_HOUR", 4)
+	cfg.Notifications.MaxPerDay = getEnvAsInt("NOTIFICATION_MAX_PER_DAY", 10)
 	minIntervalMinutes := getEnvAsInt("NOTIFICATION_MIN_INTERVAL_MINUTES", 5)
-	AppConfig.Notifications.MinInterval = time.Duration(minIntervalMinutes) * time.Minute
+	cfg.Notifications.MinInterval = time.Duration(minIntervalMinutes) * time.Minute
 	baseIntervalMinutes := getEnvAsInt("NOTIFICATION_BACKOFF_BASE_MINUTES", 5)
-	AppConfig.Notifications.BackoffBaseInterval = time.Duration(baseIntervalMinutes) * time.Minute
-	AppConfig.Notifications.BackoffMaxMultiplier = getEnvAsFloat("NOTIFICATION_BACKOFF_MAX_MULTIPLIER", 6.0)
+	cfg.Notifications.BackoffBaseInterval = time.Duration(baseIntervalMinutes) * time.Minute
+	cfg.Notifications.BackoffMaxMultiplier = getEnvAsFloat("NOTIFICATION_BACKOFF_MAX_MULTIPLIER", 6.0)
 	historyHours := getEnvAsInt("NOTIFICATION_HISTORY_WINDOW_HOURS", 24)
-	AppConfig.Notifications.BackoffHistoryWindow = time.Duration(historyHours) * time.Hour
+	cfg.Notifications.BackoffHistoryWindow = time.Duration(historyHours) * time.Hour
 }
 
-func loadCaptchaConfig() {
+func loadCaptchaConfig(cfg *Config) {
 	// Capsolver
-	AppConfig.CaptchaService.Capsolver.Enabled = os.Getenv("CAPSOLVER_ENABLED") == "true"
-	AppConfig.CaptchaService.Capsolver.ClientKey = os.Getenv("CAPSOLVER_CLIENT_KEY")
-	AppConfig.CaptchaService.Capsolver.AppID = os.Getenv("CAPSOLVER_APP_ID")
-	AppConfig.CaptchaService.Capsolver.BalanceMin = getEnvAsFloat("CAPSOLVER_BALANCE_MIN", 0.10)
-	AppConfig.CaptchaService.Capsolver.MaxRetries = getEnvAsInt("CAPSOLVER_MAX_RETRIES", 6)                                     // TODO: Merge with MAX_RETRIES
-	AppConfig.CaptchaService.Capsolver.RetryInterval = time.Duration(getEnvAsInt("CAPSOLVER_RETRY_INTERVAL", 10)) * time.Second // TODO: Merge with RETRY_INTERVAL
+	cfg.CaptchaService.Capsolver.Enabled = getEnvAsBool("CAPSOLVER_ENABLED", false)
+	cfg.CaptchaService.Capsolver.ClientKey = getEnv("CAPSOLVER_CLIENT_KEY")
+	cfg.CaptchaService.Capsolver.AppID = getEnv("CAPSOLVER_APP_ID")
+	cfg.CaptchaService.Capsolver.BalanceMin = getEnvAsFloat("CAPSOLVER_BALANCE_MIN", 0.10)
+	cfg.CaptchaService.Capsolver.MaxRetries = getEnvAsInt("CAPSOLVER_MAX_RETRIES", 6)                                     // TODO: Merge with MAX_RETRIES
+	cfg.CaptchaService.Capsolver.RetryInterval = time.Duration(getEnvAsInt("CAPSOLVER_RETRY_INTERVAL", 10)) * time.Second // TODO: Merge with RETRY_INTERVAL
 
 	// EZCaptcha
-	AppConfig.CaptchaService.EZCaptcha.Enabled = os.Getenv("EZCAPTCHA_ENABLED") == "true"
-	AppConfig.CaptchaService.EZCaptcha.ClientKey = os.Getenv("EZCAPTCHA_CLIENT_KEY")
-	AppConfig.CaptchaService.EZCaptcha.AppID = os.Getenv("EZAPPID")
-	AppConfig.CaptchaService.EZCaptcha.BalanceMin = getEnvAsFloat("EZCAPBALMIN", 50)
+	cfg.CaptchaService.EZCaptcha.Enabled = getEnvAsBool("EZCAPTCHA_ENABLED", false)
+	cfg.CaptchaService.EZCaptcha.ClientKey = getEnv("EZCAPTCHA_CLIENT_KEY")
+	cfg.CaptchaService.EZCaptcha.AppID = getEnv("EZAPPID")
+	cfg.CaptchaService.EZCaptcha.BalanceMin = getEnvAsFloat("EZCAPBALMIN", 50)
 
 	// 2Captcha
-	AppConfig.CaptchaService.TwoCaptcha.Enabled = os.Getenv("TWOCAPTCHA_ENABLED") == "true"
-	AppConfig.CaptchaService.TwoCaptcha.SoftID = os.Getenv("SOFT_ID")
-	AppConfig.CaptchaService.TwoCaptcha.BalanceMin = getEnvAsFloat("TWOCAPBALMIN", 0.10)
+	cfg.CaptchaService.TwoCaptcha.Enabled = getEnvAsBool("TWOCAPTCHA_ENABLED", false)
+	cfg.CaptchaService.TwoCaptcha.SoftID = getEnv("SOFT_ID")
+	cfg.CaptchaService.TwoCaptcha.BalanceMin = getEnvAsFloat("TWOCAPBALMIN", 0.10)
 
 	// Common Captcha Settings
-	AppConfig.CaptchaService.RecaptchaSiteKey = os.Getenv("RECAPTCHA_SITE_KEY")
-	AppConfig.CaptchaService.RecaptchaURL = os.Getenv("RECAPTCHA_URL")
-	AppConfig.CaptchaService.MaxRetries = getEnvAsInt("MAX_RETRIES", 3)
-}
-
-func loadAPIEndpoints() {
-	AppConfig.API.CheckEndpoint = os.Getenv("CHECK_ENDPOINT")
-	AppConfig.API.ProfileEndpoint = os.Getenv("PROFILE_ENDPOINT")
-	AppConfig.API.CheckVIPEndpoint = os.Getenv("CHECK_VIP_ENDPOINT")
-	AppConfig.API.RedeemCodeEndpoint = os.Getenv("REDEEM_CODE_ENDPOINT")
-}
-
-func loadRateLimits() {
-	AppConfig.RateLimits.CheckNow = time.Duration(getEnvAsInt("CHECK_NOW_RATE_LIMIT", 3600)) * time.Second
-	AppConfig.RateLimits.Default = time.Duration(getEnvAsInt("DEFAULT_RATE_LIMIT", 180)) * time.Minute
-	AppConfig.RateLimits.DefaultMaxAccounts = getEnvAsInt("DEFAULT_USER_MAXACCOUNTS", 3)
-	AppConfig.RateLimits.PremiumMaxAccounts = getEnvAsInt("PREM_USER_MAXACCOUNTS", 15)
-}
-
-func loadIntervals() {
-	AppConfig.Intervals.Check = getEnvAsInt("CHECK_INTERVAL", 15)
-	AppConfig.Intervals.Notification = getEnvAsFloat("NOTIFICATION_INTERVAL", 24)
-	AppConfig.Intervals.Cooldown = getEnvAsFloat("COOLDOWN_DURATION", 6)
-	AppConfig.Intervals.Sleep = getEnvAsInt("SLEEP_DURATION", 1)
-	AppConfig.Intervals.PermaBanCheck = getEnvAsFloat("COOKIE_CHECK_INTERVAL_PERMABAN", 24)
-	AppConfig.Intervals.StatusChange = getEnvAsFloat("STATUS_CHANGE_COOLDOWN", 1)
-	AppConfig.Intervals.GlobalNotification = getEnvAsFloat("GLOBAL_NOTIFICATION_COOLDOWN", 2)
-	AppConfig.Intervals.CookieExpiration = getEnvAsFloat("COOKIE_EXPIRATION_WARNING", 24)
-	AppConfig.Intervals.TempBanUpdate = getEnvAsFloat("TEMP_BAN_UPDATE_INTERVAL", 24)
-}
-
-func loadEmojiConfig() {
-	AppConfig.Emojis.CheckCircle = os.Getenv("CHECKCIRCLE")
-	AppConfig.Emojis.BanCircle = os.Getenv("BANCIRCLE")
-	AppConfig.Emojis.InfoCircle = os.Getenv("INFOCIRCLE")
-	AppConfig.Emojis.StopWatch = os.Getenv("STOPWATCH")
-	AppConfig.Emojis.QuestionCircle = os.Getenv("QUESTIONCIRCLE")
-}
-
-func loadPerformanceConfig() {
-	AppConfig.Performance.DbMaxIdleConns = getEnvAsInt("DB_MAX_IDLE_CONNS", 10)
-	AppConfig.Performance.DbMaxOpenConns = getEnvAsInt("DB_MAX_OPEN_CONNS", 100)
-}
-
-func validate() error {
-	var missingVars []string
-
-	requiredVars := map[string]string{
-		"DISCORD_TOKEN":      AppConfig.Discord.Token,
-		"DEVELOPER_ID":       AppConfig.Discord.DeveloperID,
-		"DB_USER":            AppConfig.Database.User,
-		"DB_PASSWORD":        AppConfig.Database.Password,
-		"DB_NAME":            AppConfig.Database.Name,
-		"DB_HOST":            AppConfig.Database.Host,
-		"DB_PORT":            AppConfig.Database.Port,
-		"DB_VAR":             AppConfig.Database.Var,
-		"PROFILE_ENDPOINT":   AppConfig.API.ProfileEndpoint,
-		"CHECK_VIP_ENDPOINT": AppConfig.API.CheckVIPEndpoint,
-		"CHECK_ENDPOINT":     AppConfig.API.CheckEndpoint,
-	}
-
-	for key, value := range requiredVars {
-		if value == "" {
-			missingVars = append(missingVars, key)
-		}
+	cfg.CaptchaService.RecaptchaSiteKey = getEnv("RECAPTCHA_SITE_KEY")
+	cfg.CaptchaService.RecaptchaURL = getEnv("RECAPTCHA_URL")
+	cfg.CaptchaService.MaxRetries = getEnvAsInt("MAX_RETRIES", 3)
+}
+
+func loadAPIEndpoints(cfg *Config) {
+	cfg.API.CheckEndpoint = getEnv("CHECK_ENDPOINT")
+	cfg.API.ProfileEndpoint = getEnv("PROFILE_ENDPOINT")
+	cfg.API.CheckVIPEndpoint = getEnv("CHECK_VIP_ENDPOINT")
+	cfg.API.RedeemCodeEndpoint = getEnv("REDEEM_CODE_ENDPOINT")
+	cfg.API.RecordResponses = getEnvAsBool("ACTIVISION_RECORD_RESPONSES", false)
+	cfg.API.RecordDir = getEnvWithDefault("ACTIVISION_RECORD_DIR", "recordings/activision")
+	cfg.API.DriftThreshold = getEnvAsInt("SCHEMA_DRIFT_THRESHOLD", 5)
+	cfg.API.DriftPauseChecks = getEnvAsBool("SCHEMA_DRIFT_PAUSE_CHECKS", true)
+}
+
+func loadRateLimits(cfg *Config) {
+	cfg.RateLimits.CheckNow = time.Duration(getEnvAsInt("CHECK_NOW_RATE_LIMIT", 3600)) * time.Second
+	cfg.RateLimits.Default = time.Duration(getEnvAsInt("DEFAULT_RATE_LIMIT", 180)) * time.Minute
+	cfg.RateLimits.DefaultMaxAccounts = getEnvAsInt("DEFAULT_USER_MAXACCOUNTS", 3)
+	cfg.RateLimits.PremiumMaxAccounts = getEnvAsInt("PREM_USER_MAXACCOUNTS", 15)
+	cfg.RateLimits.Store = strings.ToLower(getEnvWithDefault("RATE_LIMIT_STORE", "database"))
+}
+
+// loadTiers reads the quotas of each tier. The free and own-key tiers
+// default to the older per-key settings so existing deployments keep their
+// limits.
+func loadTiers(cfg *Config) {
+	cfg.Tiers.Free = loadTierQuotas("FREE", TierQuotas{
+		MaxAccounts:          cfg.RateLimits.DefaultMaxAccounts,
+		MinCheckInterval:     cfg.RateLimits.Default,
+		DailyManualChecks:    10,
+		NotificationsPerHour: 10,
+		VerdanskPerDay:       cfg.Verdansk.MaxRequestsPerDay,
+	})
+	cfg.Tiers.OwnKey = loadTierQuotas("OWN_KEY", TierQuotas{
+		MaxAccounts:    cfg.RateLimits.PremiumMaxAccounts,
+		VerdanskPerDay: cfg.Verdansk.MaxRequestsPerDay,
+	})
+	cfg.Tiers.Supporter = loadTierQuotas("SUPPORTER", TierQuotas{
+		MaxAccounts:       25,
+		MinCheckInterval:  30 * time.Minute,
+		DailyManualChecks: 50,
+		VerdanskPerDay:    10,
+	})
+	cfg.Tiers.Granted = loadTierQuotas("GRANTED", TierQuotas{})
+}
+
+func loadTierQuotas(tier string, defaults TierQuotas) TierQuotas {
+	prefix := "TIER_" + tier + "_"
+	return TierQuotas{
+		MaxAccounts:          getEnvAsInt(prefix+"MAX_ACCOUNTS", defaults.MaxAccounts),
+		MinCheckInterval:     time.Duration(getEnvAsInt(prefix+"MIN_CHECK_MINUTES", int(defaults.MinCheckInterval/time.Minute))) * time.Minute,
+		DailyManualChecks:    getEnvAsInt(prefix+"DAILY_CHECKS", defaults.DailyManualChecks),
+		NotificationsPerHour: getEnvAsInt(prefix+"NOTIFICATIONS_PER_HOUR", defaults.NotificationsPerHour),
+		VerdanskPerDay:       getEnvAsInt(prefix+"VERDANSK_PER_DAY", defaults.VerdanskPerDay),
 	}
+}
 
-	if len(missingVars) > 0 {
-		return fmt.Errorf("missing required environment variables: %v", missingVars)
+func loadCircuitBreakerConfig(cfg *Config) {
+	cfg.CircuitBreaker.FailureThreshold = getEnvAsInt("CIRCUIT_BREAKER_FAILURE_THRESHOLD", 5)
+	cfg.CircuitBreaker.OpenDuration = time.Duration(getEnvAsInt("CIRCUIT_BREAKER_OPEN_SECONDS", 300)) * time.Second
+}
+
+func loadIntervals(cfg *Config) {
+	cfg.Intervals.Check = getEnvAsInt("CHECK_INTERVAL", 15)
+	cfg.Intervals.Notification = getEnvAsFloat("NOTIFICATION_INTERVAL", 24)
+	cfg.Intervals.Cooldown = getEnvAsFloat("COOLDOWN_DURATION", 6)
+	cfg.Intervals.Sleep = getEnvAsInt("SLEEP_DURATION", 1)
+	cfg.Intervals.PermaBanCheck = getEnvAsFloat("COOKIE_CHECK_INTERVAL_PERMABAN", 24)
+	cfg.Intervals.StatusChange = getEnvAsFloat("STATUS_CHANGE_COOLDOWN", 1)
+	cfg.Intervals.GlobalNotification = getEnvAsFloat("GLOBAL_NOTIFICATION_COOLDOWN", 2)
+	cfg.Intervals.CookieExpiration = getEnvAsFloat("COOKIE_EXPIRATION_WARNING", 24)
+	cfg.Intervals.TempBanUpdate = getEnvAsFloat("TEMP_BAN_UPDATE_INTERVAL", 24)
+}
+
+func loadEmojiConfig(cfg *Config) {
+	cfg.Emojis.CheckCircle = getEnv("CHECKCIRCLE")
+	cfg.Emojis.BanCircle = getEnv("BANCIRCLE")
+	cfg.Emojis.InfoCircle = getEnv("INFOCIRCLE")
+	cfg.Emojis.StopWatch = getEnv("STOPWATCH")
+	cfg.Emojis.QuestionCircle = getEnv("QUESTIONCIRCLE")
+}
+
+func loadPerformanceConfig(cfg *Config) {
+	cfg.Performance.DbMaxIdleConns = getEnvAsInt("DB_MAX_IDLE_CONNS", 10)
+	cfg.Performance.DbMaxOpenConns = getEnvAsInt("DB_MAX_OPEN_CONNS", 100)
+}
+
+func validate(cfg *Config) []error {
+	var problems []error
+
+	requiredVars := []struct {
+		key   string
+		value string
+	}{
+		{"DISCORD_TOKEN", cfg.Discord.Token},
+		{"DEVELOPER_ID", cfg.Discord.DeveloperID},
+		{"DB_USER", cfg.Database.User},
+		{"DB_PASSWORD", cfg.Database.Password},
+		{"DB_NAME", cfg.Database.Name},
+		{"DB_HOST", cfg.Database.Host},
+		{"DB_PORT", cfg.Database.Port},
+		{"DB_VAR", cfg.Database.Var},
+		{"PROFILE_ENDPOINT", cfg.API.ProfileEndpoint},
+		{"CHECK_VIP_ENDPOINT", cfg.API.CheckVIPEndpoint},
+		{"CHECK_ENDPOINT", cfg.API.CheckEndpoint},
+	}
+
+	for _, required := range requiredVars {
+		if required.value == "" {
+			problems = append(problems, fmt.Errorf("%s (%s) is required", required.key, settingsByEnv[required.key].Path))
+		}
 	}
 
-	if AppConfig.Discord.ClientID == "" {
+	if cfg.Discord.ClientID == "" {
 		logger.Log.Warn("DISCORD_CLIENT_ID not set")
 	}
 
-	if !AppConfig.CaptchaService.Capsolver.Enabled &&
-		!AppConfig.CaptchaService.EZCaptcha.Enabled &&
-		!AppConfig.CaptchaService.TwoCaptcha.Enabled {
+	if cfg.Portal.Enabled {
+		if cfg.Discord.ClientID == "" || cfg.Portal.ClientSecret == "" || cfg.Portal.RedirectURL == "" {
+			problems = append(problems, fmt.Errorf("portal is enabled but DISCORD_CLIENT_ID, DISCORD_CLIENT_SECRET and PORTAL_REDIRECT_URL are required"))
+		}
+	}
+
+	switch cfg.Tracing.Exporter {
+	case "none", "otlp", "sentry":
+	default:
+		problems = append(problems, fmt.Errorf("unknown TRACING_EXPORTER %q (want none, otlp or sentry)", cfg.Tracing.Exporter))
+	}
+	switch cfg.Process.Role {
+	case "all", "gateway", "worker", "api":
+	default:
+		problems = append(problems, fmt.Errorf("unknown ROLE %q (want all, gateway, worker or api)", cfg.Process.Role))
+	}
+	switch cfg.RateLimits.Store {
+	case "database", "memory":
+	default:
+		problems = append(problems, fmt.Errorf("unknown RATE_LIMIT_STORE %q (want database or memory)", cfg.RateLimits.Store))
+	}
+
+	for tier, quotas := range map[string]TierQuotas{
+		"FREE":      cfg.Tiers.Free,
+		"OWN_KEY":   cfg.Tiers.OwnKey,
+		"SUPPORTER": cfg.Tiers.Supporter,
+		"GRANTED":   cfg.Tiers.Granted,
+	} {
+		if quotas.MaxAccounts < 0 || quotas.MinCheckInterval < 0 || quotas.DailyManualChecks < 0 ||
+			quotas.NotificationsPerHour < 0 || quotas.VerdanskPerDay < 0 {
+			problems = append(problems, fmt.Errorf("TIER_%s_* quotas must not be negative", tier))
+		}
+	}
+
+	if cfg.Tracing.SampleRate < 0 || cfg.Tracing.SampleRate > 1 {
+		problems = append(problems, fmt.Errorf("TRACING_SAMPLE_RATE must be between 0 and 1"))
+	}
+
+	positive := []struct {
+		key   string
+		value float64
+	}{
+		{"CHECK_INTERVAL", float64(cfg.Intervals.Check)},
+		{"NOTIFICATION_INTERVAL", cfg.Intervals.Notification},
+		{"COOKIE_CHECK_INTERVAL_PERMABAN", cfg.Intervals.PermaBanCheck},
+		{"TEMP_BAN_UPDATE_INTERVAL", cfg.Intervals.TempBanUpdate},
+		{"NOTIFICATION_MAX_PER_HOUR", float64(cfg.Notifications.MaxPerHour)},
+		{"NOTIFICATION_MAX_PER_DAY", float64(cfg.Notifications.MaxPerDay)},
+		{"DEFAULT_USER_MAXACCOUNTS", float64(cfg.RateLimits.DefaultMaxAccounts)},
+		{"PREM_USER_MAXACCOUNTS", float64(cfg.RateLimits.PremiumMaxAccounts)},
+		{"ADMIN_PORT", float64(cfg.Admin.Port)},
+		{"WORKER_CONCURRENCY", float64(cfg.Process.WorkerConcurrency)},
+		{"JOB_LEASE_SECONDS", cfg.Process.JobLease.Seconds()},
+		{"LEADER_LEASE_SECONDS", cfg.Process.LeaderLease.Seconds()},
+	}
+	for _, p := range positive {
+		if p.value <= 0 {
+			problems = append(problems, fmt.Errorf("%s (%s) must be greater than zero", p.key, settingsByEnv[p.key].Path))
+		}
+	}
+
+	if !cfg.CaptchaService.Capsolver.Enabled &&
+		!cfg.CaptchaService.EZCaptcha.Enabled &&
+		!cfg.CaptchaService.TwoCaptcha.Enabled {
 		logger.Log.Warn("No captcha services are enabled - functionality will be limited")
 	}
 
-	if AppConfig.CaptchaService.Capsolver.Enabled && AppConfig.CaptchaService.Capsolver.ClientKey == "" {
-		return fmt.Errorf("Capsolver is enabled but no client key provided")
+	if cfg.CaptchaService.Capsolver.Enabled && cfg.CaptchaService.Capsolver.ClientKey == "" {
+		problems = append(problems, fmt.Errorf("Capsolver is enabled but no client key provided"))
 	}
-	if AppConfig.CaptchaService.EZCaptcha.Enabled && AppConfig.CaptchaService.EZCaptcha.ClientKey == "" {
-		return fmt.Errorf("EZCaptcha is enabled but no client key provided")
+	if cfg.CaptchaService.EZCaptcha.Enabled && cfg.CaptchaService.EZCaptcha.ClientKey == "" {
+		problems = append(problems, fmt.Errorf("EZCaptcha is enabled but no client key provided"))
 	}
-	if AppConfig.CaptchaService.TwoCaptcha.Enabled && AppConfig.CaptchaService.TwoCaptcha.ClientKey == "" {
-		return fmt.Errorf("2Captcha is enabled but no client key provided")
+	if cfg.CaptchaService.TwoCaptcha.Enabled && cfg.CaptchaService.TwoCaptcha.ClientKey == "" {
+		problems = append(problems, fmt.Errorf("2Captcha is enabled but no client key provided"))
 	}
-	return nil
+	return problems
 }
+
 func logConfigurationValues() {
+	cfg := Get()
 	logger.Log.Infof("Loaded rate limits and intervals: CHECK_INTERVAL=%d, NOTIFICATION_INTERVAL=%.2f, "+
 		"COOLDOWN_DURATION=%.2f, SLEEP_DURATION=%d, COOKIE_CHECK_INTERVAL_PERMABAN=%.2f, "+
 		"STATUS_CHANGE_COOLDOWN=%.2f, GLOBAL_NOTIFICATION_COOLDOWN=%.2f, COOKIE_EXPIRATION_WARNING=%.2f, "+
 		"TEMP_BAN_UPDATE_INTERVAL=%.2f, CHECK_NOW_RATE_LIMIT=%v, DEFAULT_RATE_LIMIT=%v",
-		AppConfig.Intervals.Check,
-		AppConfig.Intervals.Notification,
-		AppConfig.Intervals.Cooldown,
-		AppConfig.Intervals.Sleep,
-		AppConfig.Intervals.PermaBanCheck,
-		AppConfig.Intervals.StatusChange,
-		AppConfig.Intervals.GlobalNotification,
-		AppConfig.Intervals.CookieExpiration,
-		AppConfig.Intervals.TempBanUpdate,
-		AppConfig.RateLimits.CheckNow,
-		AppConfig.RateLimits.Default)
+		cfg.Intervals.Check,
+		cfg.Intervals.Notification,
+		cfg.Intervals.Cooldown,
+		cfg.Intervals.Sleep,
+		cfg.Intervals.PermaBanCheck,
+		cfg.Intervals.StatusChange,
+		cfg.Intervals.GlobalNotification,
+		cfg.Intervals.CookieExpiration,
+		cfg.Intervals.TempBanUpdate,
+		cfg.RateLimits.CheckNow,
+		cfg.RateLimits.Default)
 
 	// Log user management settings
 	logger.Log.Infof("Loaded user management settings: MAX_MESSAGE_FAILURES=%d, INACTIVE_USER_DAYS=%.2f, "+
 		"UNREACHABLE_RESET_DAYS=%.2f",
-		AppConfig.Users.MaxMessageFailures,
-		AppConfig.Users.InactiveUserPeriod.Hours()/24,
-		AppConfig.Users.UnreachableResetPeriod.Hours()/24)
+		cfg.Users.MaxMessageFailures,
+		cfg.Users.InactiveUserPeriod.Hours()/24,
+		cfg.Users.UnreachableResetPeriod.Hours()/24)
 
 	// Log notification settings
 	logger.Log.Infof("Loaded notification settings: DEFAULT_COOLDOWN=%v, MAX_PER_HOUR=%d, "+
 		"MAX_PER_DAY=%d, MIN_INTERVAL=%v",
-		AppConfig.Notifications.DefaultCooldown,
-		AppConfig.Notifications.MaxPerHour,
-		AppConfig.Notifications.MaxPerDay,
-		AppConfig.Notifications.MinInterval)
+		cfg.Notifications.DefaultCooldown,
+		cfg.Notifications.MaxPerHour,
+		cfg.Notifications.MaxPerDay,
+		cfg.Notifications.MinInterval)
 
 	// Log admin API settings
 	logger.Log.Infof("Loaded admin API settings: ENABLED=%v, PORT=%d, STATS_RATE_LIMIT=%.2f, "+
 		"RETENTION_DAYS=%d",
-		AppConfig.Admin.Enabled,
-		AppConfig.Admin.Port,
-		AppConfig.Admin.StatsRateLimit,
-		AppConfig.Admin.RetentionDays)
+		cfg.Admin.Enabled,
+		cfg.Admin.Port,
+		cfg.Admin.StatsRateLimit,
+		cfg.Admin.RetentionDays)
 
 	// Log enabled captcha services
 	var enabledServices []string
-	if AppConfig.CaptchaService.Capsolver.Enabled {
+	if cfg.CaptchaService.Capsolver.Enabled {
 		enabledServices = append(enabledServices, "Capsolver")
 	}
-	if AppConfig.CaptchaService.EZCaptcha.Enabled {
+	if cfg.CaptchaService.EZCaptcha.Enabled {
 		enabledServices = append(enabledServices, "EZCaptcha")
 	}
-	if AppConfig.CaptchaService.TwoCaptcha.Enabled {
+	if cfg.CaptchaService.TwoCaptcha.Enabled {
 		enabledServices = append(enabledServices, "2Captcha")
 	}
 
@@ -409,49 +749,111 @@ func logConfigurationValues() {
 		logger.Log.Warn("No captcha services are enabled")
 	}
 
-	if AppConfig.Discord.ClientID != "" {
+	if cfg.Discord.ClientID != "" {
 		logger.Log.Info("OAuth2 configuration loaded successfully")
 	}
 }
 
-func getEnvWithDefault(key, defaultValue string) string {
+// The getters below resolve a setting from the environment first and the
+// config file second, record the value used and report anything that does
+// not parse instead of quietly falling back to the default.
+
+func lookup(key string) (value, source string, ok bool) {
 	if value := os.Getenv(key); value != "" {
+		return value, "env", true
+	}
+	if active != nil {
+		if value, ok := active.fileVals[key]; ok {
+			return value, active.file, true
+		}
+	}
+	return "", "", false
+}
+
+func record(key, value, source string) {
+	if active == nil {
+		return
+	}
+	if _, ok := settingsByEnv[key]; !ok {
+		active.problems = append(active.problems, fmt.Errorf("%s is read but missing from the settings schema", key))
+	}
+	active.values[key] = settingValue{Value: value, Source: source}
+}
+
+func invalid(key, source, value, want string) {
+	if active == nil {
+		return
+	}
+	where := key
+	if source != "env" {
+		where = source + ": " + settingsByEnv[key].Path
+	}
+	active.problems = append(active.problems, fmt.Errorf("%s: expected %s, got %q", where, want, value))
+}
+
+func getEnv(key string) string {
+	return getEnvWithDefault(key, "")
+}
+
+func getEnvWithDefault(key, defaultValue string) string {
+	if value, source, ok := lookup(key); ok {
+		record(key, value, source)
 		return value
 	}
+	record(key, defaultValue, "default")
 	return defaultValue
 }
 
 func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+	if value, source, ok := lookup(key); ok {
 		if intValue, err := strconv.Atoi(value); err == nil {
+			record(key, value, source)
 			return intValue
 		}
-		logger.Log.WithField("key", key).WithField("default", defaultValue).
-			Error("Failed to parse integer from environment variable")
+		invalid(key, source, value, "integer")
 	}
+	record(key, strconv.Itoa(defaultValue), "default")
 	return defaultValue
 }
 
 func getEnvAsFloat(key string, defaultValue float64) float64 {
-	if value := os.Getenv(key); value != "" {
+	if value, source, ok := lookup(key); ok {
 		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			record(key, value, source)
 			return floatValue
 		}
-		logger.Log.WithField("key", key).WithField("default", defaultValue).
-			Error("Failed to parse float from environment variable")
+		invalid(key, source, value, "number")
 	}
+	record(key, strconv.FormatFloat(defaultValue, 'f', -1, 64), "default")
 	return defaultValue
 }
 
 func getEnvAsBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
-		return strings.ToLower(value) == "true" || value == "1"
+	if value, source, ok := lookup(key); ok {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			record(key, value, source)
+			return boolValue
+		}
+		invalid(key, source, value, "boolean")
 	}
+	record(key, strconv.FormatBool(defaultValue), "default")
 	return defaultValue
 }
 
+// Get returns the active configuration. Treat it as read-only; use Update to
+// change settings at runtime.
 func Get() *Config {
-	return &AppConfig
+	return current.Load()
+}
+
+// Update applies fn to a copy of the active configuration and publishes the
+// copy.
+func Update(fn func(cfg *Config)) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	next := *current.Load()
+	fn(&next)
+	current.Store(&next)
 }
 
 func GetDefaultSettings() struct {
@@ -460,25 +862,26 @@ func GetDefaultSettings() struct {
 	CooldownDuration     float64
 	StatusChangeCooldown float64
 } {
+	cfg := Get()
 	return struct {
 		CheckInterval        int
 		NotificationInterval float64
 		CooldownDuration     float64
 		StatusChangeCooldown float64
 	}{
-		CheckInterval:        AppConfig.Intervals.Check,
-		NotificationInterval: AppConfig.Intervals.Notification,
-		CooldownDuration:     AppConfig.Intervals.Cooldown,
-		StatusChangeCooldown: AppConfig.Intervals.StatusChange,
+		CheckInterval:        cfg.Intervals.Check,
+		NotificationInterval: cfg.Intervals.Notification,
+		CooldownDuration:     cfg.Intervals.Cooldown,
+		StatusChangeCooldown: cfg.Intervals.StatusChange,
 	}
 }
 
-func loadVerdanskConfig() {
-	AppConfig.Verdansk.PreferencesEndpoint = getEnvWithDefault("VERDANSK_PREFERENCES", "https://pd.callofduty.com/api/x/v1/campaign/warzonewrapped/preferences/gamer/{encodedGamerTag}")
-	AppConfig.Verdansk.StatsEndpoint = getEnvWithDefault("VERDANSK_STATS", "https://pd.callofduty.com/api/x/v1/campaign/warzonewrapped/stats/gamer/{encodedGamerTag}")
-	AppConfig.Verdansk.APIKey = getEnvWithDefault("X_API_KEY", "a855a770-cf8a-4ae8-9f30-b787d676e608")
-	AppConfig.Verdansk.TempDir = getEnvWithDefault("VERDANSK_TEMP_DIR", "verdansk_temp")
-	AppConfig.Verdansk.CleanupTime = time.Duration(getEnvAsInt("VERDANSK_CLEANUP_MINUTES", 30)) * time.Minute
-	AppConfig.Verdansk.CommandCooldown = time.Duration(getEnvAsInt("VERDANSK_COMMAND_COOLDOWN_MINUTES", 60)) * time.Minute
-	AppConfig.Verdansk.MaxRequestsPerDay = getEnvAsInt("VERDANSK_MAX_REQUESTS_PER_DAY", 3)
+func loadVerdanskConfig(cfg *Config) {
+	cfg.Verdansk.PreferencesEndpoint = getEnvWithDefault("VERDANSK_PREFERENCES", "https://pd.callofduty.com/api/x/v1/campaign/warzonewrapped/preferences/gamer/{encodedGamerTag}")
+	cfg.Verdansk.StatsEndpoint = getEnvWithDefault("VERDANSK_STATS", "https://pd.callofduty.com/api/x/v1/campaign/warzonewrapped/stats/gamer/{encodedGamerTag}")
+	cfg.Verdansk.APIKey = getEnvWithDefault("X_API_KEY", "a855a770-cf8a-4ae8-9f30-b787d676e608")
+	cfg.Verdansk.TempDir = getEnvWithDefault("VERDANSK_TEMP_DIR", "verdansk_temp")
+	cfg.Verdansk.CleanupTime = time.Duration(getEnvAsInt("VERDANSK_CLEANUP_MINUTES", 30)) * time.Minute
+	cfg.Verdansk.CommandCooldown = time.Duration(getEnvAsInt("VERDANSK_COMMAND_COOLDOWN_MINUTES", 60)) * time.Minute
+	cfg.Verdansk.MaxRequestsPerDay = getEnvAsInt("VERDANSK_MAX_REQUESTS_PER_DAY", 3)
 }
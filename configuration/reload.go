@@ -0,0 +1,173 @@
+package configuration
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/logger"
+)
+
+const watchInterval = 10 * time.Second
+
+var reloadHooks []func()
+
+// OnReload registers fn to run after a reload has changed the configuration,
+// for code that caches values derived from the configuration.
+func OnReload(fn func()) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	reloadHooks = append(reloadHooks, fn)
+}
+
+// Reload re-reads the config file and environment. Only the sections that
+// are safe to change at runtime are applied: intervals, rate limits,
+// notification limits, the captcha provider switches and the admin list. Changes to any
+// other setting are logged and wait for a restart. An invalid configuration
+// is rejected as a whole and the running values are kept.
+func Reload() error {
+	loadMu.Lock()
+	cfg, state := build()
+	if err := problemsError(state.problems); err != nil {
+		loadMu.Unlock()
+		return err
+	}
+
+	var applied, pending []string
+	for _, s := range settings {
+		if state.values[s.Env] == lastValues[s.Env] {
+			continue
+		}
+		if s.Reloadable {
+			applied = append(applied, s.Path)
+		} else {
+			pending = append(pending, s.Path)
+		}
+	}
+
+	// Readers hold on to the Config they got from Get, so the reloadable
+	// sections are applied to a copy that replaces it.
+	next := *current.Load()
+	next.Intervals = cfg.Intervals
+	store := next.RateLimits.Store
+	next.RateLimits = cfg.RateLimits
+	next.RateLimits.Store = store
+	next.Tiers = cfg.Tiers
+	next.Notifications = cfg.Notifications
+	next.CaptchaService.Capsolver.Enabled = cfg.CaptchaService.Capsolver.Enabled
+	next.CaptchaService.EZCaptcha.Enabled = cfg.CaptchaService.EZCaptcha.Enabled
+	next.CaptchaService.TwoCaptcha.Enabled = cfg.CaptchaService.TwoCaptcha.Enabled
+	next.Discord.AdminIDs = cfg.Discord.AdminIDs
+	current.Store(&next)
+
+	// Keep the old values for restart-only settings so they are reported
+	// again on the next reload until the process restarts.
+	for _, s := range settings {
+		if !s.Reloadable {
+			if previous, ok := lastValues[s.Env]; ok {
+				state.values[s.Env] = previous
+			}
+		}
+	}
+	lastValues = state.values
+	hooks := append([]func(){}, reloadHooks...)
+	loadMu.Unlock()
+
+	if len(applied) == 0 {
+		logger.Log.Info("Configuration reloaded, no runtime settings changed")
+	} else {
+		logger.Log.Infof("Configuration reloaded, applied: %s", strings.Join(applied, ", "))
+		for _, fn := range hooks {
+			fn()
+		}
+	}
+	if len(pending) > 0 {
+		logger.Log.Warnf("Configuration changes that need a restart: %s", strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// Watch reloads the configuration on SIGHUP and whenever the config file's
+// modification time changes, until ctx is cancelled.
+func Watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hup)
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+
+		lastMod := fileModTime(File())
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				logger.Log.Info("Received SIGHUP, reloading configuration")
+				lastMod = fileModTime(File())
+				reloadAndLog()
+			case <-ticker.C:
+				mod := fileModTime(File())
+				if mod.Equal(lastMod) {
+					continue
+				}
+				lastMod = mod
+				logger.Log.Info("Configuration file changed, reloading")
+				reloadAndLog()
+			}
+		}
+	}()
+}
+
+func reloadAndLog() {
+	if err := Reload(); err != nil {
+		logger.Log.WithError(err).Error("Configuration reload rejected, keeping current settings")
+	}
+}
+
+func fileModTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// Setting describes one resolved setting for `config print`.
+type Setting struct {
+	Key        string
+	Env        string
+	Value      string
+	Source     string
+	Secret     bool
+	Reloadable bool
+}
+
+// Check resolves the configuration without applying it and returns every
+// setting, in schema order, along with every problem found.
+func Check() ([]Setting, []error) {
+	loadMu.Lock()
+	_, state := build()
+	loadMu.Unlock()
+
+	resolved := make([]Setting, 0, len(settings))
+	for _, s := range settings {
+		value := state.values[s.Env]
+		resolved = append(resolved, Setting{
+			Key:        s.Path,
+			Env:        s.Env,
+			Value:      value.Value,
+			Source:     value.Source,
+			Secret:     s.Secret,
+			Reloadable: s.Reloadable,
+		})
+	}
+	return resolved, state.problems
+}
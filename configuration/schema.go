@@ -0,0 +1,198 @@
+package configuration
+
+type settingKind int
+
+const (
+	kindString settingKind = iota
+	kindInt
+	kindFloat
+	kindBool
+	kindList // comma-separated in the environment, a list in the file
+)
+
+func (k settingKind) String() string {
+	switch k {
+	case kindInt:
+		return "integer"
+	case kindFloat:
+		return "number"
+	case kindBool:
+		return "boolean"
+	case kindList:
+		return "list of strings"
+	default:
+		return "string"
+	}
+}
+
+// setting ties an environment variable to its key in the config file.
+// Reloadable settings are applied on SIGHUP or when the file changes; the
+// rest only take effect after a restart.
+type setting struct {
+	Env        string
+	Path       string
+	Kind       settingKind
+	Secret     bool
+	Reloadable bool
+}
+
+var settings = []setting{
+	{Env: "ENVIRONMENT", Path: "environment"},
+	{Env: "LOG_DIR", Path: "log_dir"},
+	{Env: "LOG_FORMAT", Path: "log_format"},
+
+	{Env: "LOG_LEVEL", Path: "logging.level"},
+	{Env: "LOG_PACKAGE_LEVELS", Path: "logging.package_levels"},
+	{Env: "LOG_OUTPUT", Path: "logging.output"},
+	{Env: "LOG_MAX_SIZE_MB", Path: "logging.max_size_mb", Kind: kindInt},
+	{Env: "LOG_MAX_AGE_DAYS", Path: "logging.max_age_days", Kind: kindInt},
+	{Env: "LOG_COMPRESS", Path: "logging.compress", Kind: kindBool},
+	{Env: "LOG_SYSLOG_ADDRESS", Path: "logging.syslog_address"},
+	{Env: "LOG_SYSLOG_TAG", Path: "logging.syslog_tag"},
+
+	{Env: "ROLE", Path: "process.role"},
+	{Env: "INSTANCE_ID", Path: "process.instance_id"},
+	{Env: "WORKER_CONCURRENCY", Path: "process.worker_concurrency", Kind: kindInt},
+	{Env: "JOB_LEASE_SECONDS", Path: "process.job_lease_seconds", Kind: kindInt},
+	{Env: "LEADER_LEASE_SECONDS", Path: "process.leader_lease_seconds", Kind: kindInt},
+
+	{Env: "TRACING_EXPORTER", Path: "tracing.exporter"},
+	{Env: "TRACING_SAMPLE_RATE", Path: "tracing.sample_rate", Kind: kindFloat},
+	{Env: "TRACING_SERVICE_NAME", Path: "tracing.service_name"},
+
+	{Env: "DB_USER", Path: "database.user"},
+	{Env: "DB_PASSWORD", Path: "database.password", Secret: true},
+	{Env: "DB_NAME", Path: "database.name"},
+	{Env: "DB_HOST", Path: "database.host"},
+	{Env: "DB_PORT", Path: "database.port"},
+	{Env: "DB_VAR", Path: "database.var"},
+	{Env: "DB_MAX_IDLE_CONNS", Path: "database.max_idle_conns", Kind: kindInt},
+	{Env: "DB_MAX_OPEN_CONNS", Path: "database.max_open_conns", Kind: kindInt},
+
+	{Env: "DISCORD_TOKEN", Path: "discord.token", Secret: true},
+	{Env: "DEVELOPER_ID", Path: "discord.developer_id"},
+	{Env: "ADMIN_USER_IDS", Path: "discord.admin_ids", Kind: kindList, Reloadable: true},
+	{Env: "DISCORD_CLIENT_ID", Path: "discord.client_id"},
+	{Env: "DISCORD_CLIENT_SECRET", Path: "discord.client_secret", Secret: true},
+	{Env: "DISCORD_PUBLIC_KEY", Path: "discord.public_key"},
+	{Env: "DISCORD_DEV_GUILD_ID", Path: "discord.dev_guild_id"},
+
+	{Env: "ADMIN_API_ENABLED", Path: "admin.enabled", Kind: kindBool},
+	{Env: "ADMIN_PORT", Path: "admin.port", Kind: kindInt},
+	{Env: "ADMIN_API_KEY", Path: "admin.api_key", Secret: true},
+	{Env: "ADMIN_API_KEY_FULL_ACCESS", Path: "admin.api_key_full_access", Kind: kindBool},
+	{Env: "ADMIN_API_BASE_PATH", Path: "admin.base_path"},
+	{Env: "ADMIN_STATS_RATE_LIMIT", Path: "admin.stats_rate_limit", Kind: kindFloat},
+	{Env: "ANALYTICS_RETENTION_DAYS", Path: "admin.retention_days", Kind: kindInt},
+	{Env: "METRICS_ENABLED", Path: "admin.metrics_enabled", Kind: kindBool},
+	{Env: "ADMIN_ALLOWED_ORIGINS", Path: "admin.allowed_origins", Kind: kindList},
+
+	{Env: "PORTAL_ENABLED", Path: "portal.enabled", Kind: kindBool},
+	{Env: "PORTAL_REDIRECT_URL", Path: "portal.redirect_url"},
+	{Env: "PORTAL_SESSION_HOURS", Path: "portal.session_hours", Kind: kindInt},
+	{Env: "PORTAL_COOKIE_SECURE", Path: "portal.cookie_secure", Kind: kindBool},
+
+	{Env: "CAPSOLVER_ENABLED", Path: "captcha.capsolver.enabled", Kind: kindBool, Reloadable: true},
+	{Env: "CAPSOLVER_CLIENT_KEY", Path: "captcha.capsolver.client_key", Secret: true},
+	{Env: "CAPSOLVER_APP_ID", Path: "captcha.capsolver.app_id"},
+	{Env: "CAPSOLVER_BALANCE_MIN", Path: "captcha.capsolver.balance_min", Kind: kindFloat},
+	{Env: "CAPSOLVER_MAX_RETRIES", Path: "captcha.capsolver.max_retries", Kind: kindInt},
+	{Env: "CAPSOLVER_RETRY_INTERVAL", Path: "captcha.capsolver.retry_interval_seconds", Kind: kindInt},
+	{Env: "EZCAPTCHA_ENABLED", Path: "captcha.ezcaptcha.enabled", Kind: kindBool, Reloadable: true},
+	{Env: "EZCAPTCHA_CLIENT_KEY", Path: "captcha.ezcaptcha.client_key", Secret: true},
+	{Env: "EZAPPID", Path: "captcha.ezcaptcha.app_id"},
+	{Env: "EZCAPBALMIN", Path: "captcha.ezcaptcha.balance_min", Kind: kindFloat},
+	{Env: "TWOCAPTCHA_ENABLED", Path: "captcha.twocaptcha.enabled", Kind: kindBool, Reloadable: true},
+	{Env: "SOFT_ID", Path: "captcha.twocaptcha.soft_id"},
+	{Env: "TWOCAPBALMIN", Path: "captcha.twocaptcha.balance_min", Kind: kindFloat},
+	{Env: "RECAPTCHA_SITE_KEY", Path: "captcha.recaptcha_site_key"},
+	{Env: "RECAPTCHA_URL", Path: "captcha.recaptcha_url"},
+	{Env: "MAX_RETRIES", Path: "captcha.max_retries", Kind: kindInt},
+
+	{Env: "CHECK_ENDPOINT", Path: "api.check_endpoint"},
+	{Env: "PROFILE_ENDPOINT", Path: "api.profile_endpoint"},
+	{Env: "CHECK_VIP_ENDPOINT", Path: "api.check_vip_endpoint"},
+	{Env: "REDEEM_CODE_ENDPOINT", Path: "api.redeem_code_endpoint"},
+	{Env: "ACTIVISION_RECORD_RESPONSES", Path: "api.record_responses", Kind: kindBool},
+	{Env: "ACTIVISION_RECORD_DIR", Path: "api.record_dir"},
+	{Env: "SCHEMA_DRIFT_THRESHOLD", Path: "api.drift_threshold", Kind: kindInt},
+	{Env: "SCHEMA_DRIFT_PAUSE_CHECKS", Path: "api.drift_pause_checks", Kind: kindBool},
+
+	{Env: "CHECK_NOW_RATE_LIMIT", Path: "rate_limits.check_now_seconds", Kind: kindInt, Reloadable: true},
+	{Env: "DEFAULT_RATE_LIMIT", Path: "rate_limits.default_minutes", Kind: kindInt, Reloadable: true},
+	{Env: "DEFAULT_USER_MAXACCOUNTS", Path: "rate_limits.default_max_accounts", Kind: kindInt, Reloadable: true},
+	{Env: "PREM_USER_MAXACCOUNTS", Path: "rate_limits.premium_max_accounts", Kind: kindInt, Reloadable: true},
+	{Env: "RATE_LIMIT_STORE", Path: "rate_limits.store"},
+
+	{Env: "TIER_FREE_MAX_ACCOUNTS", Path: "tiers.free.max_accounts", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_FREE_MIN_CHECK_MINUTES", Path: "tiers.free.min_check_minutes", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_FREE_DAILY_CHECKS", Path: "tiers.free.daily_checks", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_FREE_NOTIFICATIONS_PER_HOUR", Path: "tiers.free.notifications_per_hour", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_FREE_VERDANSK_PER_DAY", Path: "tiers.free.verdansk_per_day", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_OWN_KEY_MAX_ACCOUNTS", Path: "tiers.own_key.max_accounts", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_OWN_KEY_MIN_CHECK_MINUTES", Path: "tiers.own_key.min_check_minutes", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_OWN_KEY_DAILY_CHECKS", Path: "tiers.own_key.daily_checks", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_OWN_KEY_NOTIFICATIONS_PER_HOUR", Path: "tiers.own_key.notifications_per_hour", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_OWN_KEY_VERDANSK_PER_DAY", Path: "tiers.own_key.verdansk_per_day", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_SUPPORTER_MAX_ACCOUNTS", Path: "tiers.supporter.max_accounts", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_SUPPORTER_MIN_CHECK_MINUTES", Path: "tiers.supporter.min_check_minutes", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_SUPPORTER_DAILY_CHECKS", Path: "tiers.supporter.daily_checks", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_SUPPORTER_NOTIFICATIONS_PER_HOUR", Path: "tiers.supporter.notifications_per_hour", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_SUPPORTER_VERDANSK_PER_DAY", Path: "tiers.supporter.verdansk_per_day", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_GRANTED_MAX_ACCOUNTS", Path: "tiers.granted.max_accounts", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_GRANTED_MIN_CHECK_MINUTES", Path: "tiers.granted.min_check_minutes", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_GRANTED_DAILY_CHECKS", Path: "tiers.granted.daily_checks", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_GRANTED_NOTIFICATIONS_PER_HOUR", Path: "tiers.granted.notifications_per_hour", Kind: kindInt, Reloadable: true},
+	{Env: "TIER_GRANTED_VERDANSK_PER_DAY", Path: "tiers.granted.verdansk_per_day", Kind: kindInt, Reloadable: true},
+
+	{Env: "CIRCUIT_BREAKER_FAILURE_THRESHOLD", Path: "circuit_breaker.failure_threshold", Kind: kindInt},
+	{Env: "CIRCUIT_BREAKER_OPEN_SECONDS", Path: "circuit_breaker.open_seconds", Kind: kindInt},
+
+	{Env: "CHECK_INTERVAL", Path: "intervals.check_minutes", Kind: kindInt, Reloadable: true},
+	{Env: "NOTIFICATION_INTERVAL", Path: "intervals.notification_hours", Kind: kindFloat, Reloadable: true},
+	{Env: "COOLDOWN_DURATION", Path: "intervals.cooldown_hours", Kind: kindFloat, Reloadable: true},
+	{Env: "SLEEP_DURATION", Path: "intervals.sleep_minutes", Kind: kindInt, Reloadable: true},
+	{Env: "COOKIE_CHECK_INTERVAL_PERMABAN", Path: "intervals.permaban_check_hours", Kind: kindFloat, Reloadable: true},
+	{Env: "STATUS_CHANGE_COOLDOWN", Path: "intervals.status_change_hours", Kind: kindFloat, Reloadable: true},
+	{Env: "GLOBAL_NOTIFICATION_COOLDOWN", Path: "intervals.global_notification_hours", Kind: kindFloat, Reloadable: true},
+	{Env: "COOKIE_EXPIRATION_WARNING", Path: "intervals.cookie_expiration_warning_hours", Kind: kindFloat, Reloadable: true},
+	{Env: "TEMP_BAN_UPDATE_INTERVAL", Path: "intervals.temp_ban_update_hours", Kind: kindFloat, Reloadable: true},
+
+	{Env: "MAX_MESSAGE_FAILURES", Path: "users.max_message_failures", Kind: kindInt},
+	{Env: "INACTIVE_USER_DAYS", Path: "users.inactive_days", Kind: kindInt},
+	{Env: "UNREACHABLE_RESET_DAYS", Path: "users.unreachable_reset_days", Kind: kindInt},
+
+	{Env: "NOTIFICATION_DEFAULT_COOLDOWN_MINUTES", Path: "notifications.default_cooldown_minutes", Kind: kindInt, Reloadable: true},
+	{Env: "NOTIFICATION_MAX_PER_HOUR", Path: "notifications.max_per_hour", Kind: kindInt, Reloadable: true},
+	{Env: "NOTIFICATION_MAX_PER_DAY", Path: "notifications.max_per_day", Kind: kindInt, Reloadable: true},
+	{Env: "NOTIFICATION_MIN_INTERVAL_MINUTES", Path: "notifications.min_interval_minutes", Kind: kindInt, Reloadable: true},
+	{Env: "NOTIFICATION_BACKOFF_BASE_MINUTES", Path: "notifications.backoff_base_minutes", Kind: kindInt, Reloadable: true},
+	{Env: "NOTIFICATION_BACKOFF_MAX_MULTIPLIER", Path: "notifications.backoff_max_multiplier", Kind: kindFloat, Reloadable: true},
+	{Env: "NOTIFICATION_HISTORY_WINDOW_HOURS", Path: "notifications.history_window_hours", Kind: kindInt, Reloadable: true},
+
+	{Env: "CHECKCIRCLE", Path: "emojis.check_circle"},
+	{Env: "BANCIRCLE", Path: "emojis.ban_circle"},
+	{Env: "INFOCIRCLE", Path: "emojis.info_circle"},
+	{Env: "STOPWATCH", Path: "emojis.stop_watch"},
+	{Env: "QUESTIONCIRCLE", Path: "emojis.question_circle"},
+
+	{Env: "VERDANSK_PREFERENCES", Path: "verdansk.preferences_endpoint"},
+	{Env: "VERDANSK_STATS", Path: "verdansk.stats_endpoint"},
+	{Env: "X_API_KEY", Path: "verdansk.api_key", Secret: true},
+	{Env: "VERDANSK_TEMP_DIR", Path: "verdansk.temp_dir"},
+	{Env: "VERDANSK_CLEANUP_MINUTES", Path: "verdansk.cleanup_minutes", Kind: kindInt},
+	{Env: "VERDANSK_COMMAND_COOLDOWN_MINUTES", Path: "verdansk.command_cooldown_minutes", Kind: kindInt},
+	{Env: "VERDANSK_MAX_REQUESTS_PER_DAY", Path: "verdansk.max_requests_per_day", Kind: kindInt},
+}
+
+var (
+	settingsByEnv  = make(map[string]setting, len(settings))
+	settingsByPath = make(map[string]setting, len(settings))
+)
+
+func init() {
+	for _, s := range settings {
+		settingsByEnv[s.Env] = s
+		settingsByPath[s.Path] = s
+	}
+}
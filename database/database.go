@@ -9,6 +9,7 @@ import (
 	"github.com/bradselph/CODStatusBot/configuration"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bradselph/CODStatusBot/tracing"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -59,6 +60,10 @@ func Databaselogin() error {
 	}
 
 	DB = db
+	if err := tracing.InstrumentGorm(DB); err != nil {
+		logger.Log.WithError(err).Error("Failed to register database tracing callbacks")
+		return err
+	}
 
 	sqlDB, err := DB.DB()
 	if err != nil {
@@ -77,6 +82,18 @@ func Databaselogin() error {
 		&models.Analytics{},
 		&models.BotStatistics{},
 		&models.CommandStatistics{},
+		&models.AdminAPIKey{},
+		&models.AdminAuditLog{},
+		&models.PortalSession{},
+		&models.CheckJob{},
+		&models.OutboundMessage{},
+		&models.LeaderLease{},
+		&models.RateLimitState{},
+		&models.TierAssignment{},
+		&models.SchedulerPause{},
+		&models.SchemaDriftState{},
+		&models.Announcement{},
+		&models.AnnouncementDelivery{},
 	)
 	if err != nil {
 		logger.Log.WithError(err).WithField("Bot Startup ", "Database Models Problem ").Error()
@@ -84,6 +101,7 @@ func Databaselogin() error {
 	}
 
 	CleanupInvalidTimestamps()
+	DropLegacyRateLimitColumns()
 
 	return nil
 }
@@ -44,3 +44,17 @@ func CleanupInvalidTimestamps() {
 		logger.Log.Infof("Fixed %d invalid Account last_check timestamps", result.RowsAffected)
 	}
 }
+
+// DropLegacyRateLimitColumns removes the per-user rate limit maps that
+// UserSettings kept before rate limits moved to their own table.
+func DropLegacyRateLimitColumns() {
+	for _, column := range []string{"action_counts", "last_action_times", "rate_limit_expiration"} {
+		if !DB.Migrator().HasColumn(&models.UserSettings{}, column) {
+			continue
+		}
+		logger.Log.Infof("Dropping %s column from UserSettings table", column)
+		if err := DB.Migrator().DropColumn(&models.UserSettings{}, column); err != nil {
+			logger.Log.WithError(err).Errorf("Failed to drop %s column from UserSettings table", column)
+		}
+	}
+}
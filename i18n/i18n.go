@@ -0,0 +1,163 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bradselph/CODStatusBot/logger"
+)
+
+// DefaultLocale is the last entry of every fallback chain; its catalog has
+// every message.
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// message is one catalog entry. Messages without plural forms only set
+// Other.
+type message struct {
+	One   string
+	Other string
+}
+
+func (m *message) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		m.Other = text
+		return nil
+	}
+	var forms struct {
+		One   string `json:"one"`
+		Other string `json:"other"`
+	}
+	if err := json.Unmarshal(data, &forms); err != nil {
+		return err
+	}
+	m.One, m.Other = forms.One, forms.Other
+	return nil
+}
+
+var (
+	catalogs map[string]map[string]message
+	loadOnce sync.Once
+	missing  sync.Map
+)
+
+func load() {
+	catalogs = make(map[string]map[string]message)
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("i18n: reading catalogs: %v", err))
+	}
+	for _, file := range files {
+		data, err := localeFiles.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: reading %s: %v", file.Name(), err))
+		}
+		catalog := make(map[string]message)
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("i18n: parsing %s: %v", file.Name(), err))
+		}
+		catalogs[Normalize(strings.TrimSuffix(file.Name(), ".json"))] = catalog
+	}
+}
+
+// Normalize lower-cases a locale tag, so Discord's "pt-BR" and a stored
+// "pt-br" match the same catalog.
+func Normalize(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// Chain is the order catalogs are searched for locale: the locale itself,
+// then its language without the region, then DefaultLocale. "es-419" falls
+// back to "es" and then "en".
+func Chain(locale string) []string {
+	locale = Normalize(locale)
+	var chain []string
+	for locale != "" {
+		chain = append(chain, locale)
+		cut := strings.LastIndex(locale, "-")
+		if cut < 0 {
+			break
+		}
+		locale = locale[:cut]
+	}
+	if len(chain) == 0 || chain[len(chain)-1] != DefaultLocale {
+		chain = append(chain, DefaultLocale)
+	}
+	return chain
+}
+
+// Supported lists the locales that have a catalog.
+func Supported() []string {
+	loadOnce.Do(load)
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+func lookup(locale, id string) (message, string) {
+	loadOnce.Do(load)
+	for _, candidate := range Chain(locale) {
+		if msg, ok := catalogs[candidate][id]; ok {
+			return msg, candidate
+		}
+	}
+	if _, logged := missing.LoadOrStore(id, true); !logged {
+		logger.Log.Warnf("i18n: no message %q in any catalog", id)
+	}
+	return message{Other: id}, DefaultLocale
+}
+
+// T renders message id in locale. Messages are fmt formats, so translations
+// may reorder arguments with explicit indexes such as %[2]s.
+func T(locale, id string, args ...interface{}) string {
+	msg, _ := lookup(locale, id)
+	return format(msg.Other, args)
+}
+
+// N renders the plural form of message id that matches count. count is
+// passed to the format as the first argument, ahead of args.
+func N(locale, id string, count int, args ...interface{}) string {
+	msg, found := lookup(locale, id)
+	text := msg.Other
+	if msg.One != "" && pluralOne(found, count) {
+		text = msg.One
+	}
+	if !strings.Contains(text, "%") {
+		// Forms such as "the last entry" leave the count out.
+		return text
+	}
+	return format(text, append([]interface{}{count}, args...))
+}
+
+func format(text string, args []interface{}) string {
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// pluralOne applies the CLDR "one" rule of the catalog's language. English
+// and Spanish use "one" for exactly 1; Portuguese also for 0.
+func pluralOne(locale string, count int) bool {
+	language := Normalize(locale)
+	if cut := strings.Index(language, "-"); cut >= 0 {
+		language = language[:cut]
+	}
+	switch language {
+	case "pt":
+		return count == 0 || count == 1
+	default:
+		return count == 1
+	}
+}
@@ -0,0 +1,130 @@
+package i18n
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   []string
+	}{
+		{locale: "", want: []string{"en"}},
+		{locale: "en", want: []string{"en"}},
+		{locale: "en-US", want: []string{"en-us", "en"}},
+		{locale: "es-419", want: []string{"es-419", "es", "en"}},
+		{locale: "pt_BR", want: []string{"pt-br", "pt", "en"}},
+		{locale: " FR ", want: []string{"fr", "en"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			if got := Chain(tt.locale); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Chain(%q) = %v, want %v", tt.locale, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFallback(t *testing.T) {
+	tests := []struct {
+		name   string
+		locale string
+		id     string
+		want   string
+	}{
+		{name: "exact catalog", locale: "es", id: "common.yes", want: "Sí"},
+		{name: "regional locale", locale: "es-419", id: "common.yes", want: "Sí"},
+		{name: "discord locale", locale: "pt-BR", id: "common.yes", want: "Sim"},
+		{name: "unsupported locale", locale: "fr", id: "common.yes", want: "Yes"},
+		{name: "empty locale", locale: "", id: "common.yes", want: "Yes"},
+		{name: "missing message", locale: "es", id: "test.no_such_message", want: "test.no_such_message"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := T(tt.locale, tt.id); got != tt.want {
+				t.Errorf("T(%q, %q) = %q, want %q", tt.locale, tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlurals(t *testing.T) {
+	tests := []struct {
+		locale string
+		count  int
+		id     string
+		want   string
+	}{
+		{locale: "en", count: 0, id: "age.days", want: "0 days"},
+		{locale: "en", count: 1, id: "age.days", want: "1 day"},
+		{locale: "en", count: 2, id: "age.days", want: "2 days"},
+		{locale: "es", count: 0, id: "age.days", want: "0 días"},
+		{locale: "es", count: 1, id: "age.days", want: "1 día"},
+		{locale: "pt-BR", count: 0, id: "age.days", want: "0 dia"},
+		{locale: "pt-BR", count: 1, id: "age.days", want: "1 dia"},
+		{locale: "pt-BR", count: 2, id: "age.days", want: "2 dias"},
+		{locale: "fr", count: 1, id: "age.days", want: "1 day"},
+		{locale: "en", count: 1, id: "accountlogs.description", want: "Recent account history (showing the last entry)"},
+		{locale: "en", count: 5, id: "accountlogs.description", want: "Recent account history (showing last 5 entries)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale+"/"+tt.id, func(t *testing.T) {
+			if got := N(tt.locale, tt.id, tt.count); got != tt.want {
+				t.Errorf("N(%q, %q, %d) = %q, want %q", tt.locale, tt.id, tt.count, got, tt.want)
+			}
+		})
+	}
+}
+
+var formatVerb = regexp.MustCompile(`%(\[\d+\])?[-+# 0]*\d*(\.\d+)?[a-zA-Z%]`)
+
+// verbs lists the format verbs of text, ignoring escaped percent signs.
+func verbs(text string) []string {
+	var found []string
+	for _, verb := range formatVerb.FindAllString(text, -1) {
+		if verb != "%%" {
+			found = append(found, verb)
+		}
+	}
+	return found
+}
+
+func TestCatalogParity(t *testing.T) {
+	loadOnce.Do(load)
+	base := catalogs[DefaultLocale]
+	if len(base) == 0 {
+		t.Fatalf("no %s catalog", DefaultLocale)
+	}
+
+	for _, locale := range Supported() {
+		if locale == DefaultLocale {
+			continue
+		}
+		catalog := catalogs[locale]
+		t.Run(locale, func(t *testing.T) {
+			for id, want := range base {
+				got, ok := catalog[id]
+				if !ok {
+					t.Errorf("missing %q", id)
+					continue
+				}
+				if (got.One == "") != (want.One == "") {
+					t.Errorf("%q: plural forms differ from %s", id, DefaultLocale)
+				}
+				if len(verbs(got.Other)) != len(verbs(want.Other)) {
+					t.Errorf("%q: format verbs %v, %s has %v", id, verbs(got.Other), DefaultLocale, verbs(want.Other))
+				}
+			}
+			for id := range catalog {
+				if _, ok := base[id]; !ok {
+					t.Errorf("%q is not in the %s catalog", id, DefaultLocale)
+				}
+			}
+		})
+	}
+}
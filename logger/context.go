@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FieldCorrelationID is the log field that ties together every line written
+// while handling one interaction or one scheduled check.
+const FieldCorrelationID = "correlation_id"
+
+type correlationKey struct{}
+
+// NewCorrelationID returns a short random ID for work that has no natural one.
+func NewCorrelationID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(buf)
+}
+
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+func CorrelationID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// FromContext returns an entry that carries the context's correlation ID.
+func FromContext(ctx context.Context) *logrus.Entry {
+	return Log.WithContext(ctx)
+}
+
+// correlationHook copies the correlation ID from the entry's context into its
+// fields, so callers only need Log.WithContext(ctx).
+type correlationHook struct{}
+
+func (correlationHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (correlationHook) Fire(entry *logrus.Entry) error {
+	if id := CorrelationID(entry.Context); id != "" {
+		if _, ok := entry.Data[FieldCorrelationID]; !ok {
+			entry.Data[FieldCorrelationID] = id
+		}
+	}
+	return nil
+}
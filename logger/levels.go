@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const modulePath = "github.com/bradselph/CODStatusBot/"
+
+var (
+	levelMu       sync.RWMutex
+	defaultLevel  = logrus.InfoLevel
+	packageLevels = map[string]logrus.Level{}
+)
+
+// ParsePackageLevels reads "services=debug,command/checknow=warn" into a map
+// keyed by package path relative to the module.
+func ParsePackageLevels(raw string) (map[string]logrus.Level, error) {
+	levels := make(map[string]logrus.Level)
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid package level %q, want package=level", pair)
+		}
+		level, err := logrus.ParseLevel(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid level for %s: %w", parts[0], err)
+		}
+		levels[strings.Trim(strings.TrimSpace(parts[0]), "/")] = level
+	}
+	return levels, nil
+}
+
+// SetLevel changes the default level at runtime.
+func SetLevel(level logrus.Level) {
+	levelMu.Lock()
+	defaultLevel = level
+	levelMu.Unlock()
+	applyLevels()
+}
+
+// SetPackageLevel overrides the level for one package and everything below
+// it. Passing a nil level removes the override.
+func SetPackageLevel(pkg string, level *logrus.Level) {
+	pkg = strings.Trim(pkg, "/")
+	levelMu.Lock()
+	if level == nil {
+		delete(packageLevels, pkg)
+	} else {
+		packageLevels[pkg] = *level
+	}
+	levelMu.Unlock()
+	applyLevels()
+}
+
+// Levels returns the default level and a copy of the package overrides.
+func Levels() (logrus.Level, map[string]logrus.Level) {
+	levelMu.RLock()
+	defer levelMu.RUnlock()
+	overrides := make(map[string]logrus.Level, len(packageLevels))
+	for pkg, level := range packageLevels {
+		overrides[pkg] = level
+	}
+	return defaultLevel, overrides
+}
+
+// applyLevels sets the logger to the most verbose level anyone asked for and
+// turns on caller reporting only while package overrides exist, since that
+// is what levelFilterFormatter needs to tell packages apart.
+func applyLevels() {
+	levelMu.RLock()
+	max := defaultLevel
+	for _, level := range packageLevels {
+		if level > max {
+			max = level
+		}
+	}
+	hasOverrides := len(packageLevels) > 0
+	levelMu.RUnlock()
+
+	Log.SetLevel(max)
+	Log.SetReportCaller(hasOverrides)
+}
+
+func callerPackage(frame *runtime.Frame) string {
+	if frame == nil {
+		return ""
+	}
+	fn := strings.TrimPrefix(frame.Function, modulePath)
+	// Function looks like "services.CheckAccount" or
+	// "command/checknow.CommandCheckNow.func1".
+	slash := strings.LastIndex(fn, "/")
+	if dot := strings.Index(fn[slash+1:], "."); dot >= 0 {
+		return fn[:slash+1+dot]
+	}
+	return fn
+}
+
+func levelFor(pkg string) logrus.Level {
+	levelMu.RLock()
+	defer levelMu.RUnlock()
+
+	best := ""
+	level := defaultLevel
+	for prefix, l := range packageLevels {
+		if (pkg == prefix || strings.HasPrefix(pkg, prefix+"/")) && len(prefix) > len(best) {
+			best = prefix
+			level = l
+		}
+	}
+	return level
+}
+
+// levelFilterFormatter drops entries below their package's level. Dropped
+// entries still reach hooks, which only act on warnings and errors.
+type levelFilterFormatter struct {
+	logrus.Formatter
+}
+
+func (f levelFilterFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if entry.HasCaller() && entry.Level > levelFor(callerPackage(entry.Caller)) {
+		return nil, nil
+	}
+	return f.Formatter.Format(entry)
+}
+
+// DescribeLevels renders the current levels as "info, services=debug".
+func DescribeLevels() string {
+	def, overrides := Levels()
+	parts := []string{def.String()}
+	pkgs := make([]string, 0, len(overrides))
+	for pkg := range overrides {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		parts = append(parts, pkg+"="+overrides[pkg].String())
+	}
+	return strings.Join(parts, ", ")
+}
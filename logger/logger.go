@@ -1,10 +1,12 @@
 package logger
 
 import (
+	"fmt"
 	"io"
 	"os"
-	"path/filepath"
+	"runtime"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,50 +15,169 @@ import (
 )
 
 var (
-	Log           *logrus.Logger
-	logFile       *os.File
-	lastRotation  time.Time
-	rotationMutex sync.Mutex
+	Log *logrus.Logger
+
+	outputMu         sync.Mutex
+	logFile          *rotatingFile
+	activeSyslogHook logrus.Hook
+	formatName       string
+	colorOutput      = true
 )
 
+// Options controls where logs go and how long they are kept.
+type Options struct {
+	Dir           string
+	Format        string                  // "text" or "json"
+	Output        string                  // "file" (stdout and files), "stdout" or "syslog"
+	Level         logrus.Level            // Default level
+	PackageLevels map[string]logrus.Level // Overrides keyed by package path, e.g. "services"
+	MaxSizeMB     int                     // Rotate the day's file once it reaches this size; 0 disables
+	MaxAgeDays    int                     // Delete rotated files older than this; 0 keeps them forever
+	Compress      bool                    // Gzip rotated files
+	SyslogAddress string                  // Empty for the local socket, or e.g. udp://host:514
+	SyslogTag     string
+}
+
 func init() {
-	dsn := os.Getenv("SENTRY_DSN")
-	debug := os.Getenv("SENTRY_DEBUG") == "true"
+	if err := InitSentry(false); err != nil {
+		panic("sentry.Init: " + err.Error())
+	}
+
+	// Until Configure runs, log to stdout only so nothing is written to a
+	// directory the configuration has not chosen yet.
+	Log = logrus.New()
+	Log.SetOutput(os.Stdout)
+	SetFormat(os.Getenv("LOG_FORMAT"))
+
+	Log.AddHook(correlationHook{})
+	Log.AddHook(redactionHook{})
+	Log.AddHook(NewSentryHook())
+}
+
+// InitSentry (re)initializes the Sentry client from SENTRY_* variables.
+// Tracing is only enabled when Sentry is the configured trace exporter, so
+// the sample rate is not spent on transactions nobody reads.
+func InitSentry(enableTracing bool) error {
 	traceRate := 1.0
 	if rate, err := strconv.ParseFloat(os.Getenv("SENTRY_TRACES_SAMPLE_RATE"), 64); err == nil {
 		traceRate = rate
 	}
 
-	err := sentry.Init(sentry.ClientOptions{
-		Dsn:              dsn,
+	return sentry.Init(sentry.ClientOptions{
+		Dsn:              os.Getenv("SENTRY_DSN"),
+		EnableTracing:    enableTracing,
 		TracesSampleRate: traceRate,
-		Debug:            debug,
+		Debug:            os.Getenv("SENTRY_DEBUG") == "true",
 	})
-	if err != nil {
-		panic("sentry.Init: " + err.Error())
+}
+
+var fieldMap = logrus.FieldMap{
+	logrus.FieldKeyTime:  "timestamp",
+	logrus.FieldKeyLevel: "level",
+	logrus.FieldKeyMsg:   "message",
+}
+
+// hideCaller keeps output unchanged when caller reporting is switched on for
+// per-package levels.
+func hideCaller(*runtime.Frame) (string, string) {
+	return "", ""
+}
+
+// SetFormat switches between the text output and one JSON object per line
+// ("json"), which log shippers can parse without a grok pattern.
+func SetFormat(format string) {
+	outputMu.Lock()
+	formatName = format
+	colors := colorOutput
+	outputMu.Unlock()
+
+	var formatter logrus.Formatter
+	if strings.EqualFold(format, "json") {
+		formatter = &logrus.JSONFormatter{
+			TimestampFormat:  time.RFC3339Nano,
+			FieldMap:         fieldMap,
+			CallerPrettyfier: hideCaller,
+		}
+	} else {
+		formatter = &logrus.TextFormatter{
+			ForceColors:      colors,
+			DisableColors:    !colors,
+			FieldMap:         fieldMap,
+			CallerPrettyfier: hideCaller,
+		}
 	}
+	Log.SetFormatter(levelFilterFormatter{Formatter: formatter})
+}
 
-	Log = logrus.New()
-	Log.SetFormatter(&logrus.TextFormatter{
-		ForceColors: true,
-		FieldMap: logrus.FieldMap{
-			logrus.FieldKeyTime:  "timestamp",
-			logrus.FieldKeyLevel: "level",
-			logrus.FieldKeyMsg:   "message",
-		},
-	})
+// Configure applies the logging configuration. It can be called again, for
+// example after a config reload; the previous outputs are closed.
+func Configure(opts Options) error {
+	var (
+		out  io.Writer
+		file *rotatingFile
+		hook logrus.Hook
+		err  error
+	)
 
-	logDir := "logs/"
-	err = os.MkdirAll(logDir, 0755)
-	if err != nil {
-		Log.WithError(err).Fatal("Failed to create log directory")
+	switch strings.ToLower(opts.Output) {
+	case "", "file":
+		file, err = newRotatingFile(opts.Dir, opts.MaxSizeMB, opts.MaxAgeDays, opts.Compress)
+		if err != nil {
+			return err
+		}
+		out = io.MultiWriter(os.Stdout, file)
+	case "stdout":
+		out = os.Stdout
+	case "syslog":
+		hook, err = newSyslogHook(opts.SyslogAddress, opts.SyslogTag)
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+		out = io.Discard
+	default:
+		return fmt.Errorf("unknown log output %q (want file, stdout or syslog)", opts.Output)
 	}
 
-	rotateLog()
+	outputMu.Lock()
+	previousFile, previousHook := logFile, activeSyslogHook
+	logFile, activeSyslogHook = file, hook
+	colorOutput = hook == nil
+	format := formatName
+	if opts.Format != "" {
+		format = opts.Format
+	}
+	outputMu.Unlock()
 
-	go checkRotation()
-	Log.AddHook(NewSentryHook())
+	hooks := make(logrus.LevelHooks)
+	for level, levelHooks := range Log.Hooks {
+		for _, h := range levelHooks {
+			if h != previousHook {
+				hooks[level] = append(hooks[level], h)
+			}
+		}
+	}
+	if hook != nil {
+		hooks.Add(hook)
+	}
+	Log.ReplaceHooks(hooks)
+	Log.SetOutput(out)
+	SetFormat(format)
+
+	levelMu.Lock()
+	defaultLevel = opts.Level
+	packageLevels = make(map[string]logrus.Level, len(opts.PackageLevels))
+	for pkg, level := range opts.PackageLevels {
+		packageLevels[strings.Trim(pkg, "/")] = level
+	}
+	levelMu.Unlock()
+	applyLevels()
+
+	if previousFile != nil {
+		previousFile.Close()
+	}
 
+	Log.Infof("Logging configured: output=%s dir=%s levels=%s", strings.ToLower(opts.Output), opts.Dir, DescribeLevels())
+	return nil
 }
 
 type SentryHook struct {
@@ -89,6 +210,17 @@ func (hook *SentryHook) Fire(entry *logrus.Entry) error {
 	event := sentry.Event{
 		Message: entry.Message,
 		Level:   sentryLevel(entry.Level),
+		Extra:   make(map[string]interface{}, len(entry.Data)),
+		Tags:    make(map[string]string),
+	}
+	for key, value := range entry.Data {
+		if key == logrus.ErrorKey {
+			continue
+		}
+		event.Extra[key] = value
+	}
+	if id, ok := entry.Data[FieldCorrelationID].(string); ok {
+		event.Tags[FieldCorrelationID] = id
 	}
 
 	if err, ok := entry.Data[logrus.ErrorKey]; ok {
@@ -126,42 +258,6 @@ func sentryLevel(level logrus.Level) sentry.Level {
 
 func LogAndCapture(message string, args ...interface{}) {
 	Log.Info(message)
-	sentry.CaptureMessage(message)
+	sentry.CaptureMessage(RedactString(message))
 	sentry.Flush(2 * time.Second)
 }
-
-func rotateLog() {
-	rotationMutex.Lock()
-	defer rotationMutex.Unlock()
-
-	if logFile != nil {
-		err := logFile.Close()
-		if err != nil {
-			Log.WithError(err).Error("Failed to close log file")
-		}
-	}
-
-	logDir := "logs/"
-	logFileName := filepath.Join(logDir, time.Now().Format("2006-01-02")+".txt")
-	newLogFile, err := os.OpenFile(logFileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		Log.WithError(err).Fatal("Failed to open new log file")
-	}
-
-	logFile = newLogFile
-	mw := io.MultiWriter(os.Stdout, logFile)
-	Log.SetOutput(mw)
-	lastRotation = time.Now()
-}
-
-func checkRotation() {
-	for {
-		time.Sleep(1 * time.Hour)
-
-		now := time.Now()
-		if now.YearDay() != lastRotation.YearDay() {
-			rotateLog()
-			Log.Info("Log file rotated")
-		}
-	}
-}
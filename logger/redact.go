@@ -0,0 +1,191 @@
+package logger
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveFieldNames mark a field as secret when its lowercased name
+// contains any of them.
+var sensitiveFieldNames = []string{
+	"cookie", "token", "secret", "password", "authorization",
+	"apikey", "api_key", "clientkey", "client_key", "g-cc", "recaptcha",
+}
+
+var redactionPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	// Cookie headers and cookie assignments.
+	{regexp.MustCompile(`(?i)\b(ACT_SSO_COOKIE|ACT_SSO_REMEMBER_ME|ACT_SSO_COOKIE_EXPIRY|atkn|sso_cookie|ssoCookie)=[^;\s&"]+`), "$1=" + redacted},
+	// Query string and form values.
+	{regexp.MustCompile(`(?i)\b(g-cc|access_token|refresh_token|token|client_secret|clientKey|api_key|apikey|key)=[^&\s";]+`), "$1=" + redacted},
+	// JSON members.
+	{regexp.MustCompile(`(?i)"(clientKey|client_key|gRecaptchaResponse|token|access_token|api_key|apiKey|ssoCookie|sso_cookie|cookie|secret)"\s*:\s*"[^"]*"`), `"$1":"` + redacted + `"`},
+	// Authorization headers.
+	{regexp.MustCompile(`(?i)\b(Bearer|Bot|Basic)\s+[A-Za-z0-9._~+/=-]{16,}`), "$1 " + redacted},
+	// Prose such as "for cookie: <value>" or "API key: <value>".
+	{regexp.MustCompile(`(?i)\b(cookie|token|key|secret)(\s*:\s*)[^\s,;]{8,}`), "$1$2" + redacted},
+	// Admin API keys.
+	{regexp.MustCompile(`\bcsb_[0-9a-f]{16,}`), "csb_" + redacted},
+	// Discord bot tokens.
+	{regexp.MustCompile(`\b[MNO][A-Za-z\d_-]{23,27}\.[A-Za-z\d_-]{6}\.[A-Za-z\d_-]{27,}`), redacted},
+}
+
+var (
+	knownSecrets   []string
+	knownSecretsMu sync.RWMutex
+)
+
+// RegisterSecrets adds configured secrets that must never appear in logs,
+// whatever field or message they end up in. Short values are ignored to avoid
+// masking ordinary words.
+func RegisterSecrets(secrets ...string) {
+	knownSecretsMu.Lock()
+	defer knownSecretsMu.Unlock()
+
+	seen := make(map[string]bool, len(knownSecrets))
+	for _, s := range knownSecrets {
+		seen[s] = true
+	}
+	for _, s := range secrets {
+		if len(s) < 8 || seen[s] {
+			continue
+		}
+		seen[s] = true
+		knownSecrets = append(knownSecrets, s)
+	}
+	// Replace longer secrets first so one that contains another is fully masked.
+	sort.Slice(knownSecrets, func(i, j int) bool { return len(knownSecrets[i]) > len(knownSecrets[j]) })
+}
+
+// RedactString masks known secrets and secret-looking values in s.
+func RedactString(s string) string {
+	if s == "" {
+		return s
+	}
+
+	knownSecretsMu.RLock()
+	for _, secret := range knownSecrets {
+		if strings.Contains(s, secret) {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+	knownSecretsMu.RUnlock()
+
+	for _, p := range redactionPatterns {
+		s = p.pattern.ReplaceAllString(s, p.replacement)
+	}
+	return s
+}
+
+func isSensitiveField(name string) bool {
+	lower := strings.ToLower(name)
+	for _, s := range sensitiveFieldNames {
+		if strings.Contains(lower, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactedError keeps an error in the entry, so hooks still see an error
+// value, while only exposing its redacted text.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return RedactString(v)
+	case []byte:
+		return RedactString(string(v))
+	case error:
+		msg := v.Error()
+		if clean := RedactString(msg); clean != msg {
+			return &redactedError{msg: clean, err: v}
+		}
+		return v
+	case http.Header:
+		clean := make(http.Header, len(v))
+		for name, values := range v {
+			if isSensitiveField(name) {
+				clean[name] = []string{redacted}
+				continue
+			}
+			for _, value := range values {
+				clean.Add(name, RedactString(value))
+			}
+		}
+		return clean
+	case map[string]string:
+		clean := make(map[string]string, len(v))
+		for name, value := range v {
+			if isSensitiveField(name) {
+				clean[name] = redacted
+			} else {
+				clean[name] = RedactString(value)
+			}
+		}
+		return clean
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	case fmt.Stringer:
+		text := v.String()
+		if clean := RedactString(text); clean != text {
+			return clean
+		}
+		return v
+	default:
+		text := fmt.Sprintf("%+v", v)
+		if clean := RedactString(text); clean != text {
+			return clean
+		}
+		return v
+	}
+}
+
+// redactionHook rewrites the message and every field of an entry before it
+// is formatted or sent to Sentry. It must be added before any hook that
+// ships entries elsewhere.
+type redactionHook struct{}
+
+func (redactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (redactionHook) Fire(entry *logrus.Entry) error {
+	entry.Message = RedactString(entry.Message)
+	for name, value := range entry.Data {
+		if isSensitiveField(name) {
+			switch v := value.(type) {
+			case bool, int, int64, float64:
+				// Lengths and flags about a secret are safe to keep.
+			case string:
+				if v != "" {
+					entry.Data[name] = redacted
+				}
+			default:
+				entry.Data[name] = redacted
+			}
+			continue
+		}
+		entry.Data[name] = redactValue(value)
+	}
+	return nil
+}
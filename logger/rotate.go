@@ -0,0 +1,203 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const logFileExt = ".txt"
+
+// rotatingFile writes to <dir>/<date>.txt and starts a new file when the day
+// changes or the current file reaches maxSize. Rotated files are optionally
+// gzipped, and files older than maxAge are deleted.
+type rotatingFile struct {
+	mu        sync.Mutex
+	cleanupMu sync.Mutex
+	dir       string
+	maxSize   int64
+	maxAge    time.Duration
+	compress  bool
+
+	file *os.File
+	day  string
+	size int64
+}
+
+func newRotatingFile(dir string, maxSizeMB, maxAgeDays int, compress bool) (*rotatingFile, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	r := &rotatingFile{
+		dir:      dir,
+		maxSize:  int64(maxSizeMB) * 1024 * 1024,
+		maxAge:   time.Duration(maxAgeDays) * 24 * time.Hour,
+		compress: compress,
+	}
+	if err := r.open(time.Now()); err != nil {
+		return nil, err
+	}
+	go r.cleanup()
+	return r, nil
+}
+
+func (r *rotatingFile) currentPath() string {
+	return filepath.Join(r.dir, r.day+logFileExt)
+}
+
+func (r *rotatingFile) open(now time.Time) error {
+	r.day = now.Format("2006-01-02")
+	file, err := os.OpenFile(r.currentPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if r.file == nil || now.Format("2006-01-02") != r.day || (r.maxSize > 0 && r.size+int64(len(p)) > r.maxSize && r.size > 0) {
+		if err := r.rotate(now); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate closes the current file and opens a fresh one. Must be called with
+// mu held.
+func (r *rotatingFile) rotate(now time.Time) error {
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+
+		// A new day simply opens a new file; a size rotation within the same
+		// day moves the full file aside under a timestamped name.
+		if now.Format("2006-01-02") == r.day {
+			rotated := filepath.Join(r.dir, fmt.Sprintf("%s.%s%s", r.day, now.Format("150405.000"), logFileExt))
+			if err := os.Rename(r.currentPath(), rotated); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to rotate log file: %v\n", err)
+			}
+		}
+		go r.cleanup()
+	}
+	return r.open(now)
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// cleanup compresses rotated files left uncompressed by an earlier run and
+// deletes anything older than maxAge.
+func (r *rotatingFile) cleanup() {
+	r.cleanupMu.Lock()
+	defer r.cleanupMu.Unlock()
+
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	current := filepath.Base(r.currentPath())
+	r.mu.Unlock()
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == current || !(strings.HasSuffix(name, logFileExt) || strings.HasSuffix(name, logFileExt+".gz")) {
+			continue
+		}
+		path := filepath.Join(r.dir, name)
+
+		if r.maxAge > 0 {
+			if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > r.maxAge {
+				if err := os.Remove(path); err != nil {
+					fmt.Fprintf(os.Stderr, "failed to remove old log file %s: %v\n", name, err)
+				}
+				continue
+			}
+		}
+
+		if r.compress && strings.HasSuffix(name, logFileExt) {
+			compressLogFile(path)
+		}
+	}
+}
+
+func compressLogFile(path string) {
+	if err := gzipFile(path); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to compress log file %s: %v\n", path, err)
+	}
+}
+
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	zw.Name = filepath.Base(path)
+	zw.ModTime = info.ModTime()
+	if _, err := io.Copy(zw, src); err != nil {
+		zw.Close()
+		dst.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	// Keep the original's age so max-age cleanup treats both the same.
+	os.Chtimes(path+".gz", info.ModTime(), info.ModTime())
+	return os.Remove(path)
+}
@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package logger
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newSyslogHook(address, tag string) (logrus.Hook, error) {
+	return nil, errors.New("syslog output is not supported on this platform")
+}
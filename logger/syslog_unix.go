@@ -0,0 +1,64 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"fmt"
+	"log/syslog"
+	"net/url"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// syslogHook forwards entries to syslog/journald with a priority matching
+// their level. It runs after redactionHook, so secrets never leave the
+// process.
+type syslogHook struct {
+	writer *syslog.Writer
+}
+
+// newSyslogHook connects to the local syslog socket, or to a remote daemon
+// when address is a URL such as udp://host:514.
+func newSyslogHook(address, tag string) (logrus.Hook, error) {
+	network, raddr := "", ""
+	if address != "" {
+		u, err := url.Parse(address)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid syslog address %q, want e.g. udp://host:514", address)
+		}
+		network, raddr = u.Scheme, u.Host
+	}
+	writer, err := syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogHook{writer: writer}, nil
+}
+
+func (h *syslogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *syslogHook) Fire(entry *logrus.Entry) error {
+	line, err := Log.Formatter.Format(entry)
+	if err != nil || len(line) == 0 {
+		return err
+	}
+	msg := strings.TrimRight(string(line), "\n")
+
+	switch entry.Level {
+	case logrus.PanicLevel:
+		return h.writer.Crit(msg)
+	case logrus.FatalLevel:
+		return h.writer.Crit(msg)
+	case logrus.ErrorLevel:
+		return h.writer.Err(msg)
+	case logrus.WarnLevel:
+		return h.writer.Warning(msg)
+	case logrus.InfoLevel:
+		return h.writer.Info(msg)
+	default:
+		return h.writer.Debug(msg)
+	}
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"os/signal"
@@ -18,13 +20,18 @@ import (
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
 	"github.com/bradselph/CODStatusBot/services"
+	"github.com/bradselph/CODStatusBot/tracing"
 	"github.com/bwmarrin/discordgo"
 )
 
-var discord *discordgo.Session
-
+// loadEnv copies KEY=value lines from filename into the environment. A
+// missing file is not an error, since settings may come from a YAML or TOML
+// config file instead.
 func loadEnv(filename string) error {
 	file, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("error opening config file: %w", err)
 	}
@@ -71,13 +78,20 @@ func main() {
 		}
 	}()
 
-	if err := run(); err != nil {
+	if handled, code := runSubcommand(os.Args[1:]); handled {
+		os.Exit(code)
+	}
+
+	role := flag.String("role", "", "parts of the bot to run: all, gateway, worker or api (overrides ROLE)")
+	flag.Parse()
+
+	if err := run(*role); err != nil {
 		logger.Log.WithError(err).Error("Bot encountered an error and is shutting down")
 		logger.Log.Fatal("Exiting due to error")
 	}
 }
 
-func run() error {
+func run(roleFlag string) error {
 	logger.Log.Info("Starting COD Status Bot...")
 
 	if err := loadEnv("config.env"); err != nil {
@@ -88,68 +102,93 @@ func run() error {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	services.InitializeServices()
 	cfg := configuration.Get()
-
-	if !cfg.CaptchaService.Capsolver.Enabled && !cfg.CaptchaService.EZCaptcha.Enabled && !cfg.CaptchaService.TwoCaptcha.Enabled {
-		logger.Log.Warn("No captcha services are enabled - functionality will be limited")
-	} else {
-		var enabledServices []string
-		if cfg.CaptchaService.Capsolver.Enabled && cfg.CaptchaService.Capsolver.ClientKey != "" {
-			enabledServices = append(enabledServices, "Capsolver")
-			if err := services.ValidateDefaultCapsolverConfig(); err != nil {
-				logger.Log.WithError(err).Error("Capsolver service enabled but configuration is invalid")
-				cfg.CaptchaService.Capsolver.Enabled = false
-			} else {
-				logger.Log.Info("Capsolver service enabled and configured correctly")
-			}
-		}
-		if cfg.CaptchaService.EZCaptcha.Enabled && cfg.CaptchaService.EZCaptcha.ClientKey != "" {
-			enabledServices = append(enabledServices, "EZCaptcha")
-			if services.VerifyEZCaptchaConfig() {
-				logger.Log.Info("EZCaptcha service enabled and configured correctly")
-			} else {
-				logger.Log.Error("EZCaptcha service enabled but configuration is invalid")
-				cfg.CaptchaService.EZCaptcha.Enabled = false
-			}
-		}
-		if cfg.CaptchaService.TwoCaptcha.Enabled && cfg.CaptchaService.TwoCaptcha.ClientKey != "" {
-			enabledServices = append(enabledServices, "2Captcha")
-			logger.Log.Info("2Captcha service enabled and configured correctly")
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		ServiceName: cfg.Tracing.ServiceName,
+		Environment: cfg.Environment,
+		SampleRate:  cfg.Tracing.SampleRate,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Log.WithError(err).Error("Error flushing traces")
 		}
+	}()
 
-		if len(enabledServices) == 0 {
-			logger.Log.Error("No properly configured captcha services found")
-		} else {
-			logger.Log.Infof("Enabled captcha services: %s", strings.Join(enabledServices, ", "))
-		}
+	roleName := cfg.Process.Role
+	if roleFlag != "" {
+		roleName = roleFlag
+	}
+	role, err := services.ParseRole(roleName)
+	if err != nil {
+		return err
+	}
+	services.SetRole(role)
+	logger.Log.Infof("Running with role %s", role)
+
+	services.InitializeServices()
+
+	if role != services.RoleAPI {
+		checkCaptchaServices(cfg)
 	}
 
 	if err := database.Databaselogin(); err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 	logger.Log.Info("Database connection established successfully")
+	services.InitRateLimiter()
 
-	services.StartAdminAPI()
-	logger.Log.Info("Admin API started successfully")
-
-	var err error
-	discord, err = bot.StartBot()
-	if err != nil {
-		return fmt.Errorf("failed to start Discord bot: %w", err)
+	if role.ServesAPI() {
+		services.StartAdminAPI()
+		logger.Log.Info("Admin API started successfully")
 	}
-	logger.Log.Info("Discord bot started successfully")
-
-	services.StartNotificationProcessor(discord)
-	logger.Log.Info("Notification processor started successfully")
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	periodicTasksCtx, cancelPeriodicTasks := context.WithCancel(ctx)
-	go startPeriodicTasks(periodicTasksCtx, discord)
+	configuration.Watch(ctx)
+
+	var shards *services.ShardManager
+	var elector *services.LeaderElector
+	if role.ConnectsToDiscord() {
+		shards, err = bot.StartBot()
+		if err != nil {
+			return fmt.Errorf("failed to start Discord bot: %w", err)
+		}
+		discord := shards.Primary()
+		logger.Log.Info("Discord bot started successfully")
+
+		services.RegisterDiscordMetrics(discord)
+		services.SetDiscordSession(discord)
+
+		services.StartNotificationProcessor(discord)
+		logger.Log.Info("Notification processor started successfully")
 
-	verdansk.InitCleanupRoutine()
+		elector = services.NewLeaderElector(services.SchedulerElection, services.InstanceID(), cfg.Process.LeaderLease)
+		services.SetLeaderElector(elector)
+		elector.Start(ctx)
+
+		services.StartOutboxDispatcher(ctx, discord)
+
+		go startPeriodicTasks(ctx, discord, role)
+
+		verdansk.InitCleanupRoutine()
+	}
+
+	workersDone := make(chan struct{})
+	if role == services.RoleWorker {
+		go func() {
+			defer close(workersDone)
+			services.RunCheckWorker(ctx, services.InstanceID(), cfg.Process.WorkerConcurrency, cfg.Process.JobLease)
+		}()
+	} else {
+		close(workersDone)
+	}
 
 	logger.Log.Info("COD Status Bot startup complete")
 
@@ -159,26 +198,26 @@ func run() error {
 
 	logger.Log.Info("Shutting down COD Status Bot...")
 
-	cancelPeriodicTasks()
+	cancel()
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer shutdownCancel()
-	done := make(chan struct{})
-	go func() {
-		//TODO: add WaitGroup to track goroutines
-		// wg.Wait()
-		close(done)
-	}()
 
 	select {
-	case <-done:
+	case <-workersDone:
 		logger.Log.Info("All goroutines terminated gracefully")
 	case <-shutdownCtx.Done():
 		logger.Log.Warn("Shutdown timed out, forcing exit")
 	}
 
-	if err := discord.Close(); err != nil {
-		logger.Log.WithError(err).Error("Error closing Discord session")
+	if elector != nil {
+		elector.Release()
+	}
+
+	if shards != nil {
+		if err := shards.Close(); err != nil {
+			logger.Log.WithError(err).Error("Error closing Discord session")
+		}
 	}
 
 	if err := database.CloseConnection(); err != nil {
@@ -189,16 +228,66 @@ func run() error {
 	return nil
 }
 
-func startPeriodicTasks(ctx context.Context, s *discordgo.Session) {
-	cfg := configuration.Get()
+func checkCaptchaServices(cfg *configuration.Config) {
+	if !cfg.CaptchaService.Capsolver.Enabled && !cfg.CaptchaService.EZCaptcha.Enabled && !cfg.CaptchaService.TwoCaptcha.Enabled {
+		logger.Log.Warn("No captcha services are enabled - functionality will be limited")
+	} else {
+		var enabledServices []string
+		if cfg.CaptchaService.Capsolver.Enabled && cfg.CaptchaService.Capsolver.ClientKey != "" {
+			enabledServices = append(enabledServices, "Capsolver")
+			if err := services.ValidateDefaultCapsolverConfig(); err != nil {
+				logger.Log.WithError(err).Error("Capsolver service enabled but configuration is invalid")
+				configuration.Update(func(cfg *configuration.Config) { cfg.CaptchaService.Capsolver.Enabled = false })
+			} else {
+				logger.Log.Info("Capsolver service enabled and configured correctly")
+			}
+		}
+		if cfg.CaptchaService.EZCaptcha.Enabled && cfg.CaptchaService.EZCaptcha.ClientKey != "" {
+			enabledServices = append(enabledServices, "EZCaptcha")
+			if services.VerifyEZCaptchaConfig() {
+				logger.Log.Info("EZCaptcha service enabled and configured correctly")
+			} else {
+				logger.Log.Error("EZCaptcha service enabled but configuration is invalid")
+				configuration.Update(func(cfg *configuration.Config) { cfg.CaptchaService.EZCaptcha.Enabled = false })
+			}
+		}
+		if cfg.CaptchaService.TwoCaptcha.Enabled && cfg.CaptchaService.TwoCaptcha.ClientKey != "" {
+			enabledServices = append(enabledServices, "2Captcha")
+			logger.Log.Info("2Captcha service enabled and configured correctly")
+		}
+
+		if len(enabledServices) == 0 {
+			logger.Log.Error("No properly configured captcha services found")
+		} else {
+			logger.Log.Infof("Enabled captcha services: %s", strings.Join(enabledServices, ", "))
+		}
+	}
+}
+
+// leaderPollInterval is how often a standby checks whether it has become
+// leader, so a failover does not wait out a full job interval.
+const leaderPollInterval = 30 * time.Second
+
+// startPeriodicTasks runs on every gateway. Jobs that must not run twice
+// only run on the scheduler leader; presence and in-memory cleanup run
+// everywhere.
+func startPeriodicTasks(ctx context.Context, s *discordgo.Session, role services.Role) {
 	go func() {
 		for {
 			select {
 			case <-ctx.Done():
 				return
 			default:
-				services.CheckAccounts(s)
-				time.Sleep(time.Duration(cfg.Intervals.Sleep) * time.Minute)
+				if !services.IsLeader() {
+					time.Sleep(leaderPollInterval)
+					continue
+				}
+				if role == services.RoleGateway {
+					services.EnqueueCheckJobs(s)
+				} else {
+					services.CheckAccounts(s)
+				}
+				time.Sleep(time.Duration(configuration.Get().Intervals.Sleep) * time.Minute)
 			}
 		}
 	}()
@@ -208,6 +297,11 @@ func startPeriodicTasks(ctx context.Context, s *discordgo.Session) {
 			case <-ctx.Done():
 				return
 			default:
+				if !services.IsLeader() {
+					time.Sleep(leaderPollInterval)
+					continue
+				}
+
 				var users []models.UserSettings
 				if err := database.DB.Find(&users).Error; err != nil {
 					logger.Log.WithError(err).Error("Failed to fetch users for consolidated updates")
@@ -216,6 +310,10 @@ func startPeriodicTasks(ctx context.Context, s *discordgo.Session) {
 				}
 
 				for _, user := range users {
+					if !services.StillLeader() {
+						break
+					}
+
 					var accounts []models.Account
 					if err := database.DB.Where("user_id = ? AND is_check_disabled = ? AND is_expired_cookie = ?",
 						user.UserID, false, false).Find(&accounts).Error; err != nil {
@@ -224,7 +322,7 @@ func startPeriodicTasks(ctx context.Context, s *discordgo.Session) {
 					}
 
 					if time.Since(user.LastDailyUpdateNotification) >=
-						time.Duration(cfg.Intervals.Notification)*time.Hour {
+						time.Duration(configuration.Get().Intervals.Notification)*time.Hour {
 						services.SendConsolidatedDailyUpdate(s, user.UserID, user, accounts)
 					}
 				}
@@ -242,24 +340,26 @@ func startPeriodicTasks(ctx context.Context, s *discordgo.Session) {
 			case <-ctx.Done():
 				return
 			default:
-				if err := services.SendAnnouncementToAllUsers(s); err != nil {
-					logger.Log.WithError(err).Error("Failed to send global announcement")
+				if err := bot.RefreshPresence(); err != nil {
+					logger.Log.WithError(err).Error("Failed to refresh presence status")
 				}
-				time.Sleep(24 * time.Hour)
+				time.Sleep(60 * time.Minute)
 			}
 		}
 	}()
 
 	go func() {
+		ticker := time.NewTicker(services.AnnouncementPollInterval)
+		defer ticker.Stop()
+
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			default:
-				if err := s.UpdateWatchStatus(0, bot.BotStatusMessage); err != nil {
-					logger.Log.WithError(err).Error("Failed to refresh presence status")
+			case <-ticker.C:
+				if services.IsLeader() {
+					services.RunAnnouncements(s)
 				}
-				time.Sleep(60 * time.Minute)
 			}
 		}
 	}()
@@ -273,7 +373,10 @@ func startPeriodicTasks(ctx context.Context, s *discordgo.Session) {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				services.CleanupOldRateLimitData()
+				if services.IsLeader() {
+					services.CleanupOldRateLimitData()
+				}
+				services.CleanupNotificationBackoffs()
 			}
 		}
 	}()
@@ -287,9 +390,14 @@ func startPeriodicTasks(ctx context.Context, s *discordgo.Session) {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				services.CleanupInactiveUsers()
-				logger.Log.Info("Ran inactive users cleanup")
-				services.LogInstallationStats(s)
+				if services.IsLeader() {
+					services.CleanupInactiveUsers()
+					logger.Log.Info("Ran inactive users cleanup")
+					services.CleanupExpiredPortalSessions()
+					services.CleanupJobQueue()
+					services.CleanupExpiredTiers()
+					services.LogInstallationStats(s)
+				}
 			}
 		}
 	}()
@@ -300,7 +408,7 @@ func startPeriodicTasks(ctx context.Context, s *discordgo.Session) {
 			case <-ctx.Done():
 				return
 			default:
-				if err := s.UpdateWatchStatus(0, bot.BotStatusMessage); err != nil {
+				if err := bot.RefreshPresence(); err != nil {
 					logger.Log.WithError(err).Error("Failed to refresh presence status")
 				}
 				time.Sleep(60 * time.Minute)
@@ -317,6 +425,9 @@ func startPeriodicTasks(ctx context.Context, s *discordgo.Session) {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
+				if !services.IsLeader() {
+					continue
+				}
 				cfg := configuration.Get()
 				if err := services.CleanupOldAnalyticsData(cfg.Admin.RetentionDays); err != nil {
 					logger.Log.WithError(err).Error("Failed to clean up old analytics data")
@@ -30,6 +30,8 @@ type Account struct { // The accounts table
 	LastStatusChange       int64     `gorm:"default:0"`       // The timestamp of the last status change
 	IsCheckDisabled        bool      `gorm:"default:false"`   // A flag indicating if checks are disabled for this account
 	DisabledReason         string    // Reason for disabling checks
+	DisabledCategory       string    `gorm:"default:''"` // Error category that caused checks to be disabled
+	LastErrorCategory      string    `gorm:"default:''"` // Category of the most recent check error
 	SSOCookieExpiration    int64     // The timestamp of the SSO cookie expiration
 	ConsecutiveErrors      int       `gorm:"default:0"` // The number of consecutive errors encountered while checking the account
 	LastSuccessfulCheck    time.Time // The timestamp of the last successful check
@@ -55,8 +57,6 @@ type UserSettings struct { // User settings for the bot
 	HasSeenAnnouncement          bool                 `gorm:"default:false"`   // Flag to track if the user has seen the global announcement.
 	NotificationType             string               `gorm:"default:channel"` // User preference for location of notifications either channel or dm
 	NotificationTimes            map[string]time.Time `gorm:"serializer:json"` // For all notification cooldowns
-	ActionCounts                 map[string]int       `gorm:"serializer:json"` // For counting actions within time windows
-	LastActionTimes              map[string]time.Time `gorm:"serializer:json"` // For tracking when actions were last performed
 	LastNotification             time.Time            // Timestamp of the last notification
 	LastDisabledNotification     time.Time            // Timestamp of the last disabled notification
 	LastStatusChangeNotification time.Time            // Timestamp of the last status change notification
@@ -66,7 +66,6 @@ type UserSettings struct { // User settings for the bot
 	LastErrorNotification        time.Time            // Timestamp of the last error notification
 	CustomSettings               bool                 `gorm:"default:false"`   // Flag to indicate if user has custom settings
 	LastCommandTimes             map[string]time.Time `gorm:"serializer:json"` // Map of command names to their last execution time
-	RateLimitExpiration          map[string]time.Time `gorm:"serializer:json"` // Map of command names to their rate limit expiration time
 	InstallationType             string               `gorm:"default:''"`      // 'server' or 'direct' - how the user installed the bot
 	InstallationGuildID          string               `gorm:"default:''"`      // The guild ID where the bot was installed (if server)
 	InstallationTime             time.Time            // When the user first interacted with the bot
@@ -77,6 +76,8 @@ type UserSettings struct { // User settings for the bot
 	LastMessageFailure           time.Time            // Timestamp of the last failed message
 	IsUnreachable                bool                 `gorm:"default:false"` // Flag to indicate if the account is unreachable
 	UnreachableSince             time.Time            // Timestamp when the account became unreachable
+	Locale                       string               `gorm:"size:16;default:''"` // Language chosen with /setlanguage; empty follows the Discord client
+	DiscordLocale                string               `gorm:"size:16;default:''"` // Discord client locale seen on the user's last command
 }
 type Ban struct { // Define the Ban struct
 	gorm.Model
@@ -116,6 +117,139 @@ type Analytics struct { // The analytics table
 	Timestamp       time.Time `gorm:"index"` // When this log entry was created
 	Day             string    `gorm:"index"` // YYYY-MM-DD format for easy querying
 }
+type AdminAPIKey struct { // The admin API keys table
+	gorm.Model
+	Name       string     `gorm:"type:varchar(100);uniqueIndex"` // Human readable key name, shown in the audit log.
+	KeyHash    string     `gorm:"type:char(64);uniqueIndex"`     // SHA-256 of the key; the key itself is never stored.
+	Prefix     string     // First characters of the key, to help identify it.
+	Scopes     string     // Comma-separated scopes granted to the key.
+	ExpiresAt  *time.Time // When the key stops working, if set.
+	LastUsedAt *time.Time // When the key was last accepted.
+	RevokedAt  *time.Time // When the key was revoked, if ever.
+}
+type AdminAuditLog struct { // The admin API audit table
+	gorm.Model
+	KeyName         string `gorm:"index"` // The API key used for the request.
+	Actor           string `gorm:"index"` // Who made the call, as reported by the caller.
+	RemoteAddr      string // The address the request came from.
+	Method          string // The HTTP method.
+	Route           string `gorm:"index"`     // The request path.
+	Parameters      string `gorm:"type:text"` // Query parameters with secrets redacted.
+	Body            string `gorm:"type:text"` // Form or JSON body of a mutating request with secrets redacted.
+	StatusCode      int    // The HTTP status returned.
+	Action          string `gorm:"index"`     // The management action performed.
+	TargetUserID    string `gorm:"index"`     // The user the action applied to.
+	TargetAccountID uint   `gorm:"index"`     // The account the action applied to, if any.
+	Details         string `gorm:"type:text"` // Outcome of the action.
+	Success         bool   // Whether the request succeeded.
+}
+type PortalSession struct { // The web portal sessions table
+	gorm.Model
+	TokenHash string    `gorm:"type:char(64);uniqueIndex"` // SHA-256 of the session cookie; the token itself is never stored.
+	UserID    string    `gorm:"index"`                     // The Discord user who logged in.
+	Username  string    // The Discord username at login time.
+	CSRFToken string    // Token the browser must echo on state-changing requests.
+	ExpiresAt time.Time `gorm:"index"` // When the session stops working.
+}
+type CheckJob struct { // The queue of scheduled checks, one job per user
+	gorm.Model
+	UserID         string    `gorm:"index"`                         // The user whose accounts are checked.
+	Status         string    `gorm:"index;size:16;default:pending"` // pending, leased, done or failed.
+	LeaseOwner     string    `gorm:"index"`                         // The worker holding the lease.
+	LeaseExpiresAt time.Time `gorm:"index"`                         // When another worker may take the job over.
+	HeartbeatAt    time.Time // The last time the lease owner reported progress.
+	Attempts       int       // How many times the job has been claimed.
+	LastError      string    `gorm:"type:text"` // Why the last attempt failed.
+}
+type OutboundMessage struct { // Messages produced by workers for the gateway to deliver
+	gorm.Model
+	UserID        string     `gorm:"index"` // The user the message is for.
+	ChannelID     string     // The guild channel to post in, empty for DMs.
+	GuildID       string     // The guild owning ChannelID, used to pick a shard.
+	DirectMessage bool       // Whether to deliver by DM to UserID.
+	Payload       string     `gorm:"type:text"`                     // The discordgo.MessageSend as JSON.
+	Status        string     `gorm:"index;size:16;default:pending"` // pending, sent or failed.
+	Attempts      int        // How many deliveries were tried.
+	LastError     string     `gorm:"type:text"` // Why the last delivery failed.
+	SentAt        *time.Time // When the gateway delivered the message.
+}
+type LeaderLease struct { // Leader election leases, one row per election
+	gorm.Model
+	Name      string    `gorm:"type:varchar(64);uniqueIndex"` // The election, e.g. "scheduler".
+	Holder    string    // The instance holding the lease, empty when released.
+	Token     uint64    // Fencing token, incremented on every change of leader.
+	ExpiresAt time.Time // When the lease lapses unless renewed.
+	RenewedAt time.Time // The last successful renewal.
+}
+type Announcement struct { // Announcements sent by admins, one row per message
+	gorm.Model
+	Title       string     `gorm:"size:256"`  // The embed title.
+	Content     string     `gorm:"type:text"` // The embed body.
+	Audience    string     `gorm:"size:16"`   // all, server, direct, own_key, banned or guild.
+	GuildID     string     // The guild targeted by the guild audience.
+	Status      string     `gorm:"index;size:16"` // scheduled, sending, editing, sent, recalling or recalled.
+	Revision    int        `gorm:"default:1"`     // Incremented on every edit.
+	ScheduledAt time.Time  `gorm:"index"`         // When sending starts.
+	CreatedBy   string     // Who created it: a Discord user ID or an admin API actor.
+	StartedAt   *time.Time // When recipients were selected and sending began.
+	FinishedAt  *time.Time // When every recipient was attempted.
+	RecalledAt  *time.Time // When the announcement was recalled.
+}
+type AnnouncementDelivery struct { // Delivery of an announcement to one recipient
+	gorm.Model
+	AnnouncementID uint       `gorm:"uniqueIndex:idx_announcement_recipient;index:idx_announcement_status"` // The announcement delivered.
+	UserID         string     `gorm:"type:varchar(32);uniqueIndex:idx_announcement_recipient"`              // The recipient.
+	Status         string     `gorm:"size:16;index:idx_announcement_status"`                                // pending, sent, failed, unreachable or recalled.
+	Attempts       int        // How many sends were tried.
+	NextAttemptAt  time.Time  `gorm:"index"`     // When the next send may be tried.
+	LastError      string     `gorm:"type:text"` // Why the last send failed.
+	ChannelID      string     // Where the message was posted.
+	GuildID        string     // The guild owning ChannelID, empty for DMs.
+	MessageID      string     // The posted message, for edits and recalls.
+	Revision       int        // The announcement revision the message shows.
+	SentAt         *time.Time // When the message was posted.
+}
+type SchedulerPause struct { // Admin pauses of the check scheduler, one row per scheduler
+	gorm.Model
+	Name      string    `gorm:"type:varchar(64);uniqueIndex"` // The scheduler, e.g. "checks".
+	Paused    bool      // Whether scheduled checks are held back.
+	Reason    string    // Why the scheduler was paused.
+	ChangedBy string    // The Discord user who last paused or resumed it.
+	ChangedAt time.Time // When it was last paused or resumed.
+}
+type SchemaDriftState struct { // Activision schema drift shared by every process, one row per upstream
+	gorm.Model
+	Name           string     `gorm:"type:varchar(64);uniqueIndex"` // The upstream, e.g. "activision".
+	Events         int        // Drift events since the last acknowledgement.
+	Endpoints      string     `gorm:"type:text"` // JSON counts and samples per endpoint.
+	Tripped        bool       // Whether the alert threshold was crossed.
+	TrippedAt      *time.Time // When the threshold was crossed.
+	ChecksPaused   bool       // Whether account checks are held back until acknowledged.
+	AcknowledgedBy string     // Who last acknowledged the drift.
+	AcknowledgedAt *time.Time // When the drift was last acknowledged.
+}
+type TierAssignment struct { // Plan tiers granted by admins, one row per user or guild
+	gorm.Model
+	Scope     string     `gorm:"type:varchar(8);uniqueIndex:idx_tier_scope_subject"`  // user or guild.
+	SubjectID string     `gorm:"type:varchar(32);uniqueIndex:idx_tier_scope_subject"` // The Discord user or guild ID.
+	Tier      string     `gorm:"size:16"`                                             // supporter, granted, etc.
+	GrantedBy string     // Who assigned the tier: a Discord user ID or an admin API key name.
+	Note      string     // Why the tier was granted.
+	ExpiresAt *time.Time `gorm:"index"` // When the tier lapses, nil for never.
+}
+type RateLimitState struct { // Rate limiter usage, one row per subject and action
+	ID         uint          `gorm:"primarykey"`
+	Subject    string        `gorm:"type:varchar(128);uniqueIndex:idx_rate_limit_subject_action"` // Who is limited, e.g. "user:<discord id>".
+	Action     string        `gorm:"type:varchar(64);uniqueIndex:idx_rate_limit_subject_action"`  // What is limited, e.g. "checknow".
+	Algorithm  string        `gorm:"size:16"`                                                     // token_bucket or sliding_window.
+	Capacity   int           // Uses allowed per window.
+	Window     time.Duration // The period the capacity applies to.
+	Tokens     float64       // Tokens left in a bucket as of RefilledAt.
+	RefilledAt time.Time     // When Tokens was last brought up to date.
+	Hits       []time.Time   `gorm:"type:text;serializer:json"` // Uses still inside a sliding window.
+	ExpiresAt  time.Time     `gorm:"index"`                     // When the state is back to unused and can be dropped.
+	UpdatedAt  time.Time
+}
 type Status string // The status of the account.
 
 const (
@@ -139,18 +273,9 @@ func (u *UserSettings) EnsureMapsInitialized() {
 	if u.NotificationTimes == nil {
 		u.NotificationTimes = make(map[string]time.Time)
 	}
-	if u.ActionCounts == nil {
-		u.ActionCounts = make(map[string]int)
-	}
-	if u.LastActionTimes == nil {
-		u.LastActionTimes = make(map[string]time.Time)
-	}
 	if u.LastCommandTimes == nil {
 		u.LastCommandTimes = make(map[string]time.Time)
 	}
-	if u.RateLimitExpiration == nil {
-		u.RateLimitExpiration = make(map[string]time.Time)
-	}
 }
 
 func (u *UserSettings) BeforeCreate(tx *gorm.DB) error {
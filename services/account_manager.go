@@ -1,92 +1,19 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/bradselph/CODStatusBot/configuration"
 	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
 	"github.com/bwmarrin/discordgo"
 )
 
-func validateRateLimit(userID, action string, duration time.Duration) bool {
-	var userSettings models.UserSettings
-	if err := database.DB.Where("user_id = ?", userID).First(&userSettings).Error; err != nil {
-		logger.Log.WithError(err).Error("Error fetching user settings")
-		return false
-	}
-
-	userSettings.EnsureMapsInitialized()
-	now := time.Now()
-	lastAction := userSettings.LastCommandTimes[action]
-
-	config, exists := notificationConfigs[action]
-	if !exists {
-		config.Cooldown = duration
-		config.MaxPerHour = 4
-	}
-
-	if !lastAction.IsZero() && now.Sub(lastAction) < config.Cooldown {
-		return false
-	}
-
-	userSettings.LastCommandTimes[action] = now
-	if err := database.DB.Save(&userSettings).Error; err != nil {
-		logger.Log.WithError(err).Error("Error saving rate limit")
-		return false
-	}
-
-	return true
-}
-
-func checkActionRateLimit(userID, action string, duration time.Duration) bool {
-	var userSettings models.UserSettings
-	if err := database.DB.Where("user_id = ?", userID).First(&userSettings).Error; err != nil {
-		logger.Log.WithError(err).Error("Error fetching user settings")
-		return false
-	}
-
-	userSettings.EnsureMapsInitialized()
-
-	now := time.Now()
-	lastAction := userSettings.LastActionTimes[action]
-	count := userSettings.ActionCounts[action]
-
-	if now.Sub(lastAction) > duration {
-		count = 0
-	}
-
-	if count >= getActionLimit(action) {
-		return false
-	}
-
-	tx := database.DB.Begin()
-	userSettings.LastActionTimes[action] = now
-	userSettings.ActionCounts[action] = count + 1
-	if err := tx.Save(&userSettings).Error; err != nil {
-		tx.Rollback()
-		logger.Log.WithError(err).Error("Error saving user settings")
-		return false
-	}
-	tx.Commit()
-
-	return true
-}
-
-func getActionLimit(action string) int {
-	switch action {
-	case "check_account":
-		return 25
-	case "notification":
-		return 30
-	default:
-		return 10
-	}
-}
-
 func processUserAccounts(s *discordgo.Session, userID string, accounts []models.Account) {
 	if len(accounts) == 0 {
 		return
@@ -102,7 +29,7 @@ func processUserAccounts(s *discordgo.Session, userID string, accounts []models.
 	if err := validateUserCaptchaService(userID, userSettings); err != nil {
 		logger.Log.WithError(err).Errorf("Captcha service validation failed for user %s", userID)
 		notifyUserOfServiceIssue(s, userID, err)
-		if strings.Contains(err.Error(), "insufficient balance") {
+		if errors.Is(err, ErrInsufficientBalance) {
 			return
 		}
 	}
@@ -116,30 +43,51 @@ func processUserAccounts(s *discordgo.Session, userID string, accounts []models.
 
 	var accountsToUpdate, accountsToNotify, accountsForDailyUpdate []models.Account
 
+	// An open breaker on the user's captcha provider holds back only this
+	// user's checks; the daily update still goes out.
+	captchaBreaker := captchaBreakerForProvider(userSettings.PreferredCaptchaProvider)
+	captchaOpen := getCircuitBreaker(captchaBreaker).Blocked()
+	if captchaOpen {
+		logger.Log.Warnf("Skipping checks for user %s: %s circuit breaker open", userID, captchaBreaker)
+	}
+
 	for _, account := range accounts {
 		if !account.IsCheckDisabled && !account.IsExpiredCookie {
 			accountsForDailyUpdate = append(accountsForDailyUpdate, account)
 		}
 
-		if !shouldCheckAccount(account, userSettings) {
+		if captchaOpen || !shouldCheckAccount(account, userSettings) {
 			continue
 		}
 
-		if !checkActionRateLimit(userID, fmt.Sprintf("check_account_%d", account.ID), time.Hour) {
+		if IsCheckingPausedForDrift() {
+			logger.Log.Warn("Account checks paused for schema drift, stopping batch")
+			break
+		}
+
+		if !allowAction(UserSubject(userID), RateLimitPolicy{Action: fmt.Sprintf("check_account.%d", account.ID), Limit: 25, Window: time.Hour}) {
 			logger.Log.Infof("Rate limit reached for account %s", account.Title)
 			continue
 		}
 
-		result, err := CheckAccount(account.SSOCookie, userID, "")
+		ctx := logger.WithCorrelationID(context.Background(), logger.NewCorrelationID())
+		logger.FromContext(ctx).WithField("account_id", account.ID).Info("Starting scheduled account check")
+		result, err := CheckAccount(ctx, account.SSOCookie, userID, "")
 		if err != nil {
+			if errors.Is(err, ErrCircuitOpen) {
+				logger.FromContext(ctx).WithError(err).Warn("Upstream circuit breaker open, stopping batch without penalizing account")
+				break
+			}
 			handleCheckError(s, &account, err)
 			continue
 		}
 
+		markScheduledCheckSuccess()
 		now := time.Now()
 		account.LastCheck = now.Unix()
 		account.LastSuccessfulCheck = now
 		account.ConsecutiveErrors = 0
+		account.LastErrorCategory = ""
 
 		if hasStatusChanged(account, result) {
 			account.LastStatus = result
@@ -168,14 +116,7 @@ func processUserAccounts(s *discordgo.Session, userID string, accounts []models.
 }
 
 func notifyUserOfServiceIssue(s *discordgo.Session, userID string, err error) {
-	cfg := configuration.Get()
-	if userID != cfg.Discord.DeveloperID {
-		return
-	}
-
-	channel, err := s.UserChannelCreate(userID)
-	if err != nil {
-		logger.Log.WithError(err).Error("Failed to create DM channel for service issue")
+	if !IsBotAdmin(userID) {
 		return
 	}
 
@@ -198,7 +139,8 @@ func notifyUserOfServiceIssue(s *discordgo.Session, userID string, err error) {
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
 
-	if _, err = s.ChannelMessageSendEmbed(channel.ID, embed); err != nil {
+	target := messageTarget{UserID: userID, DM: true}
+	if err := deliverMessage(s, target, &discordgo.MessageSend{Embed: embed}); err != nil {
 		logger.Log.WithError(err).Error("Failed to send admin service issue notification")
 	}
 }
@@ -227,9 +169,9 @@ func shouldCheckAccount(account models.Account, settings models.UserSettings) bo
 
 	lastCheckTime := time.Unix(account.LastCheck, 0)
 	checkInterval := time.Duration(settings.CheckInterval) * time.Minute
-	hasCustomKey := settings.CapSolverAPIKey != "" || settings.EZCaptchaAPIKey != "" || settings.TwoCaptchaAPIKey != ""
+	minInterval := UserQuotas(settings).MinCheckInterval
 
-	if !hasCustomKey && time.Since(lastCheckTime) < cfg.RateLimits.Default {
+	if time.Since(lastCheckTime) < minInterval {
 		return false
 	}
 
@@ -255,11 +197,7 @@ func shouldCheckAccount(account models.Account, settings models.UserSettings) bo
 		nextCheckTime = time.Unix(account.LastCheck, 0).Add(time.Duration(checkInterval) * time.Minute)
 	}
 
-	if settings.CapSolverAPIKey != "" || settings.EZCaptchaAPIKey != "" || settings.TwoCaptchaAPIKey != "" {
-		return now.After(nextCheckTime)
-	}
-
-	return now.After(nextCheckTime) && time.Since(time.Unix(account.LastCheck, 0)) >= cfg.RateLimits.Default
+	return now.After(nextCheckTime)
 }
 
 func hasStatusChanged(account models.Account, newStatus models.Status) bool {
@@ -271,23 +209,31 @@ func hasStatusChanged(account models.Account, newStatus models.Status) bool {
 
 func handleCheckError(s *discordgo.Session, account *models.Account, err error) {
 	cfg := configuration.Get()
+	category := ClassifyCheckError(err)
 	account.ConsecutiveErrors++
 	account.LastErrorTime = time.Now()
+	account.LastErrorCategory = string(category)
 
 	if err := database.DB.Save(account).Error; err != nil {
 		logger.Log.WithError(err).Errorf("Failed to update account error status: %s", account.Title)
 		return
 	}
 
+	if !category.AccountAttributable() {
+		logger.Log.WithError(err).Infof("Check error for account %s is not account-attributable (%s), not disabling", account.Title, category)
+		return
+	}
+
 	if account.ConsecutiveErrors >= cfg.CaptchaService.MaxRetries {
-		disableAccount(s, *account, fmt.Sprintf("Max consecutive errors reached (%d). Last error: %v",
-			cfg.CaptchaService.MaxRetries, err))
+		account.DisabledCategory = string(category)
+		disableAccount(s, *account, fmt.Sprintf("%d consecutive errors. Last error: %v",
+			account.ConsecutiveErrors, err))
 	}
 }
 
 func processNotifications(s *discordgo.Session, accounts []models.Account, userSettings models.UserSettings) {
 	for _, account := range accounts {
-		if !validateRateLimit(account.UserID, "notification", time.Hour) {
+		if !allowAction(UserSubject(account.UserID), RateLimitPolicy{Action: "notification", Limit: 1, Window: time.Hour}) {
 			logger.Log.Infof("Notification rate limit reached for user %s", account.UserID)
 			continue
 		}
@@ -342,13 +288,13 @@ func validateUserCaptchaService(userID string, userSettings models.UserSettings)
 		return fmt.Errorf("captcha service %s is disabled", userSettings.PreferredCaptchaProvider)
 	}
 
-	if userSettings.CapSolverAPIKey != "" || userSettings.EZCaptchaAPIKey != "" || userSettings.TwoCaptchaAPIKey != "" {
+	if HasOwnCaptchaKey(userSettings) {
 		_, balance, err := GetUserCaptchaKey(userID)
 		if err != nil {
 			return fmt.Errorf("failed to validate captcha key: %w", err)
 		}
 		if balance <= 0 {
-			return fmt.Errorf("insufficient captcha balance: %.2f", balance)
+			return fmt.Errorf("%w: %.2f", ErrInsufficientBalance, balance)
 		}
 	}
 
@@ -375,9 +321,9 @@ func ValidateDefaultCapsolverConfig() error {
 	return nil
 }
 
-func GetCheckStatus(isCheckDisabled bool) string {
+func GetCheckStatus(locale string, isCheckDisabled bool) string {
 	if isCheckDisabled {
-		return "Disabled"
+		return i18n.T(locale, "checkstatus.disabled")
 	}
-	return "Enabled"
+	return i18n.T(locale, "checkstatus.enabled")
 }
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/models"
+)
+
+const (
+	EndpointBanCheck   = "ban_check"
+	EndpointAccountAge = "account_age"
+	EndpointVIPStatus  = "vip_status"
+	EndpointProfile    = "profile"
+)
+
+// activisionSchema describes the fields each endpoint parser relies on.
+// Open schemas tolerate fields we do not consume.
+type activisionSchema struct {
+	Known    []string
+	Required []string
+	Open     bool
+}
+
+var activisionSchemas = map[string]activisionSchema{
+	EndpointBanCheck: {
+		Known: []string{
+			"error", "success", "canAppeal", "bans",
+			"bans[].enforcement", "bans[].title", "bans[].canAppeal", "bans[].bar",
+			"timestamp", "path", "status", "requestId", "exception", "message",
+		},
+	},
+	EndpointVIPStatus: {
+		Known:    []string{"vip"},
+		Required: []string{"vip"},
+	},
+	EndpointAccountAge: {
+		Known:    []string{"created"},
+		Required: []string{"created"},
+		Open:     true,
+	},
+	EndpointProfile: {
+		Known:    []string{"created"},
+		Required: []string{"created"},
+		Open:     true,
+	},
+}
+
+// opaqueActivisionFields are objects whose contents are never inspected.
+var opaqueActivisionFields = map[string]bool{
+	"bans[].bar": true,
+}
+
+type banCheckResult struct {
+	Status              models.Status
+	CaptchaRejected     bool
+	UnknownEnforcements []string
+}
+
+func parseBanCheckResponse(statusCode int, body []byte) (banCheckResult, error) {
+	var errorResponse struct {
+		Timestamp string `json:"timestamp"`
+		Path      string `json:"path"`
+		Status    int    `json:"status"`
+		Error     string `json:"error"`
+		RequestId string `json:"requestId"`
+		Exception string `json:"exception"`
+	}
+
+	if err := json.Unmarshal(body, &errorResponse); err == nil {
+		if errorResponse.Status == 400 && errorResponse.Path == "/api/bans/v2/appeal" {
+			return banCheckResult{Status: models.StatusUnknown}, newCheckError(CheckErrorCookieInvalid, fmt.Errorf("%w: invalid request to endpoint: %s", ErrCookieInvalid, errorResponse.Error))
+		}
+	}
+
+	var data struct {
+		Error     string `json:"error"`
+		Success   string `json:"success"`
+		CanAppeal bool   `json:"canAppeal"`
+		Bans      []struct {
+			Enforcement string   `json:"enforcement"`
+			Title       string   `json:"title"`
+			CanAppeal   bool     `json:"canAppeal"`
+			Bar         struct{} `json:"bar,omitempty"`
+		} `json:"bans"`
+	}
+
+	if err := json.Unmarshal(body, &data); err != nil {
+		return banCheckResult{Status: models.StatusUnknown}, newCheckError(CheckErrorParse, fmt.Errorf("failed to parse response: %w", err))
+	}
+
+	if strings.Contains(string(body), "InvalidCaptchaException") || statusCode == 400 {
+		return banCheckResult{Status: models.StatusUnknown, CaptchaRejected: true}, newCheckError(CheckErrorCaptcha, fmt.Errorf("invalid captcha response: %w", ErrCaptchaRejected))
+	}
+
+	if data.Success == "true" && len(data.Bans) == 0 {
+		return banCheckResult{Status: models.StatusGood}, nil
+	}
+
+	var unknownEnforcements []string
+	for _, ban := range data.Bans {
+		switch ban.Enforcement {
+		case "PERMANENT":
+			return banCheckResult{Status: models.StatusPermaban}, nil
+		case "UNDER_REVIEW":
+			return banCheckResult{Status: models.StatusShadowban}, nil
+		case "TEMPORARY":
+			return banCheckResult{Status: models.StatusTempban}, nil
+		default:
+			unknownEnforcements = append(unknownEnforcements, ban.Enforcement)
+		}
+	}
+
+	return banCheckResult{Status: models.StatusUnknown, UnknownEnforcements: unknownEnforcements}, nil
+}
+
+func parseAccountAgeResponse(body []byte) (time.Time, error) {
+	var data struct {
+		Created string `json:"created"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode JSON response from check account age request: %w", err)
+	}
+
+	created, err := time.Parse(time.RFC3339, data.Created)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse created date in check account age request: %w", err)
+	}
+	return created.UTC(), nil
+}
+
+func parseVIPResponse(body []byte) (bool, error) {
+	var data struct {
+		VIP bool `json:"vip"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return false, fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	return data.VIP, nil
+}
+
+func parseProfileResponse(body []byte) (map[string]interface{}, time.Time, error) {
+	var profileData map[string]interface{}
+	if err := json.Unmarshal(body, &profileData); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to decode profile response: %w", err)
+	}
+
+	createdStr, ok := profileData["created"].(string)
+	if !ok {
+		return nil, time.Time{}, fmt.Errorf("invalid creation date format")
+	}
+
+	created, err := time.Parse(time.RFC3339, createdStr)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to parse creation date: %w", err)
+	}
+	return profileData, created, nil
+}
+
+// activisionSchemaDiff reports fields in body that the endpoint schema does
+// not know about and required fields that are absent.
+func activisionSchemaDiff(endpoint string, body []byte) (unknown []string, missing []string) {
+	schema, ok := activisionSchemas[endpoint]
+	if !ok {
+		return nil, nil
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return nil, nil
+	}
+
+	paths := make(map[string]struct{})
+	collectJSONPaths(decoded, "", paths)
+
+	known := make(map[string]bool, len(schema.Known))
+	for _, field := range schema.Known {
+		known[field] = true
+	}
+
+	if !schema.Open {
+		for path := range paths {
+			if !known[path] {
+				unknown = append(unknown, path)
+			}
+		}
+		sort.Strings(unknown)
+	}
+
+	for _, field := range schema.Required {
+		if _, ok := paths[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+
+	return unknown, missing
+}
+
+func collectJSONPaths(value interface{}, prefix string, paths map[string]struct{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			paths[path] = struct{}{}
+			if opaqueActivisionFields[path] {
+				continue
+			}
+			collectJSONPaths(child, path, paths)
+		}
+	case []interface{}:
+		for _, child := range v {
+			collectJSONPaths(child, prefix+"[]", paths)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/configuration"
+	"github.com/bradselph/CODStatusBot/logger"
+)
+
+const redactedValue = "[REDACTED]"
+
+var sensitiveHeaderNames = map[string]bool{
+	"cookie":        true,
+	"set-cookie":    true,
+	"authorization": true,
+	"x-xsrf-token":  true,
+	"x-api-key":     true,
+}
+
+var sensitiveQueryParams = map[string]bool{
+	"g-cc":  true,
+	"token": true,
+	"sso":   true,
+}
+
+var sensitiveBodyKeys = []string{
+	"token", "cookie", "sso", "password", "secret", "email", "phone",
+}
+
+type RecordedRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+}
+
+type ActivisionRecording struct {
+	Endpoint   string          `json:"endpoint"`
+	RecordedAt time.Time       `json:"recorded_at"`
+	Request    RecordedRequest `json:"request"`
+	StatusCode int             `json:"status_code"`
+	Body       string          `json:"body"`
+	Expected   ReplayOutcome   `json:"expected"`
+}
+
+// recordActivisionExchange stores a sanitized copy of an Activision request
+// and response when ACTIVISION_RECORD_RESPONSES is enabled. Secrets are
+// scrubbed from the URL, headers and body before anything touches disk.
+func recordActivisionExchange(endpoint string, req *http.Request, statusCode int, body []byte, secrets ...string) {
+	cfg := configuration.Get()
+	if !cfg.API.RecordResponses || req == nil {
+		return
+	}
+
+	recording := ActivisionRecording{
+		Endpoint:   endpoint,
+		RecordedAt: time.Now().UTC(),
+		Request: RecordedRequest{
+			Method:  req.Method,
+			URL:     redactURL(req.URL, secrets),
+			Headers: redactHeaders(req.Header, secrets),
+		},
+		StatusCode: statusCode,
+		Body:       redactBody(body, secrets),
+	}
+	recording.Expected = replayRecording(recording)
+
+	dir := filepath.Join(cfg.API.RecordDir, endpoint)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logger.Log.WithError(err).Error("Failed to create Activision recording directory")
+		return
+	}
+
+	data, err := json.MarshalIndent(recording, "", "  ")
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to encode Activision recording")
+		return
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		logger.Log.WithError(err).Error("Failed to generate Activision recording name")
+		return
+	}
+
+	name := fmt.Sprintf("%s-%s.json", recording.RecordedAt.Format("20060102T150405"), hex.EncodeToString(suffix))
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+		logger.Log.WithError(err).Error("Failed to write Activision recording")
+	}
+}
+
+func redactSecrets(value string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		value = strings.ReplaceAll(value, secret, redactedValue)
+		value = strings.ReplaceAll(value, url.QueryEscape(secret), redactedValue)
+		value = strings.ReplaceAll(value, url.PathEscape(secret), redactedValue)
+	}
+	return value
+}
+
+func redactURL(u *url.URL, secrets []string) string {
+	if u == nil {
+		return ""
+	}
+	clean := *u
+	clean.User = nil
+
+	query := clean.Query()
+	for key := range query {
+		if sensitiveQueryParams[strings.ToLower(key)] {
+			query.Set(key, redactedValue)
+		}
+	}
+	clean.RawQuery = query.Encode()
+
+	return redactSecrets(clean.String(), secrets)
+}
+
+func redactHeaders(headers http.Header, secrets []string) map[string]string {
+	redacted := make(map[string]string, len(headers))
+	for key, values := range headers {
+		if sensitiveHeaderNames[strings.ToLower(key)] {
+			redacted[key] = redactedValue
+			continue
+		}
+		redacted[key] = redactSecrets(strings.Join(values, ", "), secrets)
+	}
+	return redacted
+}
+
+// redactBody masks sensitive string values while keeping the JSON shape
+// intact so the replay harness can still detect structural drift.
+func redactBody(body []byte, secrets []string) string {
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return redactSecrets(string(body), secrets)
+	}
+
+	encoded, err := json.Marshal(redactJSONValue(decoded, false, secrets))
+	if err != nil {
+		return redactSecrets(string(body), secrets)
+	}
+	return string(encoded)
+}
+
+func redactJSONValue(value interface{}, sensitive bool, secrets []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = redactJSONValue(child, sensitive || isSensitiveBodyKey(key), secrets)
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactJSONValue(child, sensitive, secrets)
+		}
+		return v
+	case string:
+		if sensitive {
+			return redactedValue
+		}
+		return redactSecrets(v, secrets)
+	default:
+		return v
+	}
+}
+
+func isSensitiveBodyKey(key string) bool {
+	lower := strings.ToLower(key)
+	for _, fragment := range sensitiveBodyKeys {
+		if strings.Contains(lower, fragment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type ReplayOutcome struct {
+	Status          string `json:"status,omitempty"`
+	CaptchaRejected bool   `json:"captcha_rejected,omitempty"`
+	VIP             *bool  `json:"vip,omitempty"`
+	Created         string `json:"created,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+type ReplayResult struct {
+	File          string
+	Endpoint      string
+	Expected      ReplayOutcome
+	Actual        ReplayOutcome
+	UnknownFields []string
+	MissingFields []string
+	LoadError     error
+}
+
+// Drifted reports whether the recording no longer matches what the parser
+// produces today or the payload shape has moved away from the known schema.
+func (r ReplayResult) Drifted() bool {
+	return r.LoadError != nil ||
+		!reflect.DeepEqual(r.Expected, r.Actual) ||
+		len(r.UnknownFields) > 0 ||
+		len(r.MissingFields) > 0
+}
+
+func (r ReplayResult) Summary() string {
+	var problems []string
+	if r.LoadError != nil {
+		problems = append(problems, fmt.Sprintf("load error: %v", r.LoadError))
+	}
+	if !reflect.DeepEqual(r.Expected, r.Actual) {
+		problems = append(problems, fmt.Sprintf("outcome changed: expected %s, got %s", formatOutcome(r.Expected), formatOutcome(r.Actual)))
+	}
+	if len(r.UnknownFields) > 0 {
+		problems = append(problems, fmt.Sprintf("unknown fields: %s", strings.Join(r.UnknownFields, ", ")))
+	}
+	if len(r.MissingFields) > 0 {
+		problems = append(problems, fmt.Sprintf("missing fields: %s", strings.Join(r.MissingFields, ", ")))
+	}
+	if len(problems) == 0 {
+		return "ok"
+	}
+	return strings.Join(problems, "; ")
+}
+
+func formatOutcome(outcome ReplayOutcome) string {
+	data, err := json.Marshal(outcome)
+	if err != nil {
+		return fmt.Sprintf("%+v", outcome)
+	}
+	return string(data)
+}
+
+// replayRecording runs the parser for the recorded endpoint against the
+// stored body without touching the network or the database.
+func replayRecording(recording ActivisionRecording) ReplayOutcome {
+	body := []byte(recording.Body)
+	var outcome ReplayOutcome
+
+	switch recording.Endpoint {
+	case EndpointBanCheck:
+		result, err := parseBanCheckResponse(recording.StatusCode, body)
+		outcome.Status = string(result.Status)
+		outcome.CaptchaRejected = result.CaptchaRejected
+		if err != nil {
+			outcome.Error = err.Error()
+		}
+	case EndpointAccountAge:
+		created, err := parseAccountAgeResponse(body)
+		if err != nil {
+			outcome.Error = err.Error()
+		} else {
+			outcome.Created = created.Format(time.RFC3339)
+		}
+	case EndpointVIPStatus:
+		if recording.StatusCode != 200 {
+			outcome.Error = fmt.Sprintf("invalid response status code: %d", recording.StatusCode)
+			break
+		}
+		vip, err := parseVIPResponse(body)
+		if err != nil {
+			outcome.Error = err.Error()
+		} else {
+			outcome.VIP = &vip
+		}
+	case EndpointProfile:
+		if recording.StatusCode != 200 {
+			outcome.Status = "invalid"
+			break
+		}
+		_, created, err := parseProfileResponse(body)
+		if err != nil {
+			outcome.Error = err.Error()
+		} else {
+			outcome.Created = created.UTC().Format(time.RFC3339)
+		}
+	default:
+		outcome.Error = fmt.Sprintf("unknown endpoint: %s", recording.Endpoint)
+	}
+
+	return outcome
+}
+
+// ReplayActivisionCorpus replays every recording under dir and returns one
+// result per file.
+func ReplayActivisionCorpus(dir string) ([]ReplayResult, error) {
+	var results []ReplayResult
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		result := ReplayResult{File: path}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			result.LoadError = fmt.Errorf("failed to read recording: %w", err)
+			results = append(results, result)
+			return nil
+		}
+
+		var recording ActivisionRecording
+		if err := json.Unmarshal(data, &recording); err != nil {
+			result.LoadError = fmt.Errorf("failed to decode recording: %w", err)
+			results = append(results, result)
+			return nil
+		}
+
+		result.Endpoint = recording.Endpoint
+		result.Expected = recording.Expected
+		result.Actual = replayRecording(recording)
+		if recording.StatusCode == 200 {
+			result.UnknownFields, result.MissingFields = activisionSchemaDiff(recording.Endpoint, []byte(recording.Body))
+		}
+
+		results = append(results, result)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk recording directory: %w", err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,19 @@
+package services
+
+import "testing"
+
+func TestReplayActivisionCorpus(t *testing.T) {
+	results, err := ReplayActivisionCorpus("../testdata/activision")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) == 0 {
+		t.Fatal("no recordings found under testdata/activision")
+	}
+
+	for _, result := range results {
+		if result.Drifted() {
+			t.Errorf("%s (%s): %s", result.File, result.Endpoint, result.Summary())
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/configuration"
+	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bwmarrin/discordgo"
+	"gorm.io/gorm"
+)
+
+// discordAdminKeyName is the key name recorded in the audit log for actions
+// taken with the /admin commands.
+const discordAdminKeyName = "discord"
+
+// IsBotAdmin reports whether userID may use the /admin commands: the
+// developer or one of the configured admins.
+func IsBotAdmin(userID string) bool {
+	if userID == "" {
+		return false
+	}
+	cfg := configuration.Get()
+	if userID == cfg.Discord.DeveloperID {
+		return true
+	}
+	for _, id := range cfg.Discord.AdminIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// ForceCheck checks account right away and applies the result the way a
+// scheduled check would. A failed check is returned as is, for the caller
+// to classify.
+func ForceCheck(ctx context.Context, s *discordgo.Session, account models.Account, userSettings models.UserSettings) (models.Status, error) {
+	result, err := CheckAccount(ctx, account.SSOCookie, account.UserID, "")
+	if err != nil {
+		return "", err
+	}
+
+	HandleStatusChange(s, account, result, userSettings)
+
+	now := time.Now()
+	DBMutex.Lock()
+	err = database.DB.Model(&models.Account{}).Where("id = ?", account.ID).Updates(map[string]interface{}{
+		"last_check":            now.Unix(),
+		"last_successful_check": now,
+		"consecutive_errors":    0,
+		"last_error_category":   "",
+	}).Error
+	DBMutex.Unlock()
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to update account after forced check")
+	}
+	return result, nil
+}
+
+// SetAccountChecks enables or disables checks on account and logs the
+// change to its history. It returns the logged message.
+func SetAccountChecks(account *models.Account, enabled bool, reason, initiator string) (string, error) {
+	logType := "check_enabled"
+	message := "Checks enabled by admin"
+	if enabled {
+		account.IsCheckDisabled = false
+		account.DisabledReason = ""
+		account.DisabledCategory = ""
+		account.ConsecutiveErrors = 0
+		account.LastErrorCategory = ""
+	} else {
+		logType = "check_disabled"
+		message = "Checks disabled by admin"
+		account.IsCheckDisabled = true
+		account.DisabledReason = reason
+		account.DisabledCategory = ""
+	}
+	if reason != "" {
+		message += ": " + reason
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(account).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.Ban{
+			AccountID: account.ID,
+			Status:    account.LastStatus,
+			LogType:   logType,
+			Message:   message,
+			Timestamp: time.Now(),
+			Initiator: initiator,
+		}).Error
+	})
+	return message, err
+}
+
+// ResetUserRateLimits clears every rate limit the user is waiting on and
+// returns how many limiter entries were dropped.
+func ResetUserRateLimits(userID string) (int64, error) {
+	cleared, err := Limiter().Reset(UserSubject(userID))
+	if err != nil {
+		return 0, err
+	}
+
+	err = database.DB.Model(&models.Account{}).Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"last_check_now_time":   time.Time{},
+			"last_add_account_time": time.Time{},
+		}).Error
+	if err != nil {
+		return cleared, err
+	}
+
+	adaptiveRateLimits.Lock()
+	delete(adaptiveRateLimits.UserBackoffs, userID)
+	adaptiveRateLimits.Unlock()
+	return cleared, nil
+}
+
+// NotificationBacklog returns the notifications waiting in this instance's
+// queue and the outbound messages no gateway has delivered yet.
+func NotificationBacklog(ctx context.Context) (queued int, undelivered int64, err error) {
+	notificationQueue.mutex.RLock()
+	queued = len(notificationQueue.items)
+	notificationQueue.mutex.RUnlock()
+
+	err = database.DB.WithContext(ctx).Model(&models.OutboundMessage{}).Where("status = ?", outboxPending).Count(&undelivered).Error
+	return queued, undelivered, err
+}
+
+// CaptchaProviderHealth reports the status and balance of every enabled
+// default captcha provider, led by a summary. Results are cached briefly.
+func CaptchaProviderHealth() []ComponentHealth {
+	return checkCaptchaHealth()
+}
+
+// RecordAdminCommand writes an /admin command to the admin audit log, next
+// to the admin API requests.
+func RecordAdminCommand(actorID, action, targetUserID string, targetAccountID uint, details string, err error) {
+	entry := models.AdminAuditLog{
+		KeyName:         discordAdminKeyName,
+		Actor:           actorID,
+		Method:          "COMMAND",
+		Route:           "/admin",
+		Action:          action,
+		TargetUserID:    targetUserID,
+		TargetAccountID: targetAccountID,
+		Details:         details,
+		Success:         err == nil,
+	}
+	if err != nil {
+		entry.Details = strings.TrimSpace(details + " error: " + err.Error())
+	}
+
+	logger.Log.WithField("actor", actorID).Infof("Admin command %s", action)
+	if dbErr := database.DB.Create(&entry).Error; dbErr != nil {
+		logger.Log.WithError(dbErr).Error("Failed to write admin audit log")
+	}
+}
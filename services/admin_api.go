@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
-	"sync"
 	"time"
 
 	"github.com/bradselph/CODStatusBot/configuration"
@@ -14,23 +13,32 @@ import (
 	"github.com/bradselph/CODStatusBot/models"
 )
 
-var allowedAPIKeys []string
-
 func StartAdminAPI() {
 	cfg := configuration.Get()
 
-	if cfg.Admin.APIKey != "" {
-		allowedAPIKeys = append(allowedAPIKeys, cfg.Admin.APIKey)
+	warnIfNoAdminKeys()
+
+	http.HandleFunc("/api/stats/daily", authMiddleware(ScopeStatsRead, getDailyStats))
+	http.HandleFunc("/api/stats/users", authMiddleware(ScopeStatsRead, getUserStats))
+	http.HandleFunc("/api/stats/accounts", authMiddleware(ScopeStatsRead, getAccountStats))
+	http.HandleFunc("/api/stats/commands", authMiddleware(ScopeStatsRead, getCommandStats))
+	http.HandleFunc("/api/stats/status", authMiddleware(ScopeStatsRead, getStatusStats))
+	http.HandleFunc("/api/stats/trends", authMiddleware(ScopeStatsRead, getTrendStats))
+	http.HandleFunc("/api/health", corsMiddleware(getHealthStatus))
+	http.HandleFunc("/api/health/ready", corsMiddleware(getReadiness))
+	http.HandleFunc("/api/health/report", authMiddleware(ScopeStatsRead, getHealthReport))
+	http.HandleFunc("/api/drift", authMiddleware(ScopeStatsRead, getSchemaDrift))
+	http.HandleFunc("/api/drift/acknowledge", authMiddleware(ScopeAccountsWrite, acknowledgeSchemaDrift))
+	registerAdminManagementRoutes()
+	registerAdminLoggingRoutes()
+	registerDashboard()
+	registerPortalRoutes()
+
+	if cfg.Admin.MetricsEnabled {
+		registerDatabaseMetrics()
+		http.Handle("/metrics", metricsHandler())
 	}
 
-	http.HandleFunc("/api/stats/daily", authMiddleware(getDailyStats))
-	http.HandleFunc("/api/stats/users", authMiddleware(getUserStats))
-	http.HandleFunc("/api/stats/accounts", authMiddleware(getAccountStats))
-	http.HandleFunc("/api/stats/commands", authMiddleware(getCommandStats))
-	http.HandleFunc("/api/stats/status", authMiddleware(getStatusStats))
-	http.HandleFunc("/api/stats/trends", authMiddleware(getTrendStats))
-	http.HandleFunc("/api/health", getHealthStatus)
-
 	go func() {
 		addr := ":" + strconv.Itoa(cfg.Admin.Port)
 		if addr == ":" || addr == ":0" {
@@ -44,76 +52,6 @@ func StartAdminAPI() {
 	}()
 }
 
-func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if len(allowedAPIKeys) == 0 {
-			next(w, r)
-			return
-		}
-
-		apiKey := r.Header.Get("X-API-Key")
-		if apiKey == "" {
-			http.Error(w, "API key required", http.StatusUnauthorized)
-			return
-		}
-
-		authorized := false
-		for _, key := range allowedAPIKeys {
-			if apiKey == key {
-				authorized = true
-				break
-			}
-		}
-
-		if !authorized {
-			http.Error(w, "Invalid API key", http.StatusUnauthorized)
-			return
-		}
-
-		cfg := configuration.Get()
-		if !checkAPIRateLimit(r.RemoteAddr, cfg.Admin.StatsRateLimit) {
-			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
-			return
-		}
-
-		next(w, r)
-	}
-}
-
-var apiRateLimiter = struct {
-	sync.RWMutex
-	requests map[string][]time.Time
-}{
-	requests: make(map[string][]time.Time),
-}
-
-func checkAPIRateLimit(ipAddress string, rateLimit float64) bool {
-	apiRateLimiter.Lock()
-	defer apiRateLimiter.Unlock()
-
-	now := time.Now()
-	minuteWindow := now.Add(-time.Minute)
-
-	if _, exists := apiRateLimiter.requests[ipAddress]; !exists {
-		apiRateLimiter.requests[ipAddress] = []time.Time{now}
-		return true
-	}
-
-	var recentRequests []time.Time
-	for _, t := range apiRateLimiter.requests[ipAddress] {
-		if t.After(minuteWindow) {
-			recentRequests = append(recentRequests, t)
-		}
-	}
-
-	if float64(len(recentRequests)) >= rateLimit {
-		return false
-	}
-
-	apiRateLimiter.requests[ipAddress] = append(recentRequests, now)
-	return true
-}
-
 func parseTimeRange(r *http.Request) (startTime, endTime time.Time) {
 	endTime = time.Now()
 	startTime = endTime.AddDate(0, 0, -7)
@@ -134,14 +72,18 @@ func parseTimeRange(r *http.Request) (startTime, endTime time.Time) {
 }
 
 func enableCORS(w http.ResponseWriter) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-API-Key")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-API-Key, X-Admin-Actor")
 }
 
 func writeJSONResponse(w http.ResponseWriter, data interface{}) {
+	writeJSONResponseWithStatus(w, http.StatusOK, data)
+}
+
+func writeJSONResponseWithStatus(w http.ResponseWriter, statusCode int, data interface{}) {
 	enableCORS(w)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
 
 	if err := json.NewEncoder(w).Encode(data); err != nil {
 		logger.Log.WithError(err).Error("Failed to encode JSON response")
@@ -155,13 +97,81 @@ func getHealthStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	status := "ok"
+	if len(OpenCircuitBreakers()) > 0 {
+		status = "degraded"
+	}
+
 	enableCORS(w)
-	writeJSONResponse(w, map[string]string{
-		"status": "ok",
-		"time":   time.Now().Format(time.RFC3339),
+	writeJSONResponse(w, map[string]interface{}{
+		"status":           status,
+		"time":             time.Now().Format(time.RFC3339),
+		"circuit_breakers": GetCircuitBreakerStates(),
 	})
 }
 
+func getReadiness(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		enableCORS(w)
+		return
+	}
+
+	report := RunHealthChecks(r.Context(), nil)
+	writeJSONResponseWithStatus(w, readinessStatusCode(report), map[string]HealthState{"status": report.Status})
+}
+
+// getHealthReport returns the component details behind /api/health/ready:
+// captcha balances, the database pool, the leader and raw check errors.
+func getHealthReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		enableCORS(w)
+		return
+	}
+
+	report := RunHealthChecks(r.Context(), nil)
+	writeJSONResponseWithStatus(w, readinessStatusCode(report), report)
+}
+
+func readinessStatusCode(report HealthReport) int {
+	if !report.Ready() {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+func getSchemaDrift(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		enableCORS(w)
+		return
+	}
+
+	writeJSONResponse(w, GetSchemaDriftStatus())
+}
+
+func acknowledgeSchemaDrift(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		enableCORS(w)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	acknowledgedBy := r.URL.Query().Get("by")
+	if acknowledgedBy == "" {
+		acknowledgedBy = "admin_api"
+	}
+
+	if err := AcknowledgeSchemaDrift(acknowledgedBy); err != nil {
+		logger.Log.WithError(err).Error("Failed to acknowledge schema drift")
+		http.Error(w, "Failed to acknowledge schema drift", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResponse(w, GetSchemaDriftStatus())
+}
+
 func getDailyStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodOptions {
 		enableCORS(w)
@@ -196,6 +206,7 @@ func getUserStats(w http.ResponseWriter, r *http.Request) {
 		CommandCount int64  `json:"command_count"`
 		AccountCount int64  `json:"account_count"`
 		IsCustomKey  bool   `json:"is_custom_key"`
+		Tier         Tier   `json:"tier"`
 		LastActive   string `json:"last_active"`
 		InstallType  string `json:"install_type"`
 	}
@@ -231,22 +242,20 @@ func getUserStats(w http.ResponseWriter, r *http.Request) {
 			lastActive = userSettings.LastDirectInteraction
 		}
 
-		hasCustomKey := userSettings.CapSolverAPIKey != "" ||
-			userSettings.EZCaptchaAPIKey != "" ||
-			userSettings.TwoCaptchaAPIKey != ""
-
 		users = append(users, struct {
 			UserID       string `json:"user_id"`
 			CommandCount int64  `json:"command_count"`
 			AccountCount int64  `json:"account_count"`
 			IsCustomKey  bool   `json:"is_custom_key"`
+			Tier         Tier   `json:"tier"`
 			LastActive   string `json:"last_active"`
 			InstallType  string `json:"install_type"`
 		}{
 			UserID:       user.UserID,
 			CommandCount: user.CommandCount,
 			AccountCount: accountCount,
-			IsCustomKey:  hasCustomKey,
+			IsCustomKey:  HasOwnCaptchaKey(userSettings),
+			Tier:         UserTier(userSettings),
 			LastActive:   lastActive.Format(time.RFC3339),
 			InstallType:  userSettings.InstallationType,
 		})
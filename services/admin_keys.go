@@ -0,0 +1,489 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/configuration"
+	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	ScopeStatsRead         = "stats:read"
+	ScopeAccountsWrite     = "accounts:write"
+	ScopeAnnouncementsSend = "announcements:send"
+	ScopeLogsWrite         = "logs:write"
+)
+
+// AdminScopes lists every scope a key can be granted.
+var AdminScopes = []string{ScopeStatsRead, ScopeAccountsWrite, ScopeAnnouncementsSend, ScopeLogsWrite}
+
+const (
+	adminKeyPrefix = "csb_"
+	// legacyAdminKeyName is the key name recorded for requests made with
+	// ADMIN_API_KEY.
+	legacyAdminKeyName = "env"
+	// maxAuditBodyBytes caps how much of a request body the audit log keeps.
+	maxAuditBodyBytes = 16 << 10
+	// failedAuthAuditWindow is how often a failed authentication from one
+	// address is written to the audit log; failures in between are counted.
+	failedAuthAuditWindow = time.Minute
+)
+
+var (
+	ErrAdminKeyInvalid = errors.New("invalid API key")
+	ErrAdminKeyExpired = errors.New("API key expired")
+	ErrAdminKeyRevoked = errors.New("API key revoked")
+	ErrAdminKeyScope   = errors.New("API key lacks required scope")
+)
+
+type adminPrincipal struct {
+	Name   string
+	Scopes []string
+}
+
+func (p adminPrincipal) hasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type adminAuditContextKey struct{}
+
+func hashAdminKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseAdminScopes splits a comma-separated scope list and rejects unknown
+// scopes.
+func ParseAdminScopes(raw string) ([]string, error) {
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, scope := range strings.Split(raw, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || seen[scope] {
+			continue
+		}
+		known := false
+		for _, valid := range AdminScopes {
+			if scope == valid {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown scope %q (valid: %s)", scope, strings.Join(AdminScopes, ", "))
+		}
+		seen[scope] = true
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	sort.Strings(scopes)
+	return scopes, nil
+}
+
+// CreateAdminAPIKey generates a key, stores its hash and returns the
+// plaintext key. The plaintext cannot be recovered later.
+func CreateAdminAPIKey(name string, scopes []string, ttl time.Duration) (string, models.AdminAPIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", models.AdminAPIKey{}, errors.New("key name is required")
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", models.AdminAPIKey{}, fmt.Errorf("failed to generate key: %w", err)
+	}
+	key := adminKeyPrefix + hex.EncodeToString(buf)
+
+	record := models.AdminAPIKey{
+		Name:    name,
+		KeyHash: hashAdminKey(key),
+		Prefix:  key[:len(adminKeyPrefix)+6],
+		Scopes:  strings.Join(scopes, ","),
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		record.ExpiresAt = &expiresAt
+	}
+
+	if err := database.DB.Create(&record).Error; err != nil {
+		return "", models.AdminAPIKey{}, fmt.Errorf("failed to store key: %w", err)
+	}
+	return key, record, nil
+}
+
+func ListAdminAPIKeys() ([]models.AdminAPIKey, error) {
+	var keys []models.AdminAPIKey
+	if err := database.DB.Order("name").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func RevokeAdminAPIKey(name string) error {
+	now := time.Now()
+	result := database.DB.Model(&models.AdminAPIKey{}).
+		Where("name = ? AND revoked_at IS NULL", name).
+		Update("revoked_at", &now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("no active key named %q", name)
+	}
+	return nil
+}
+
+// authenticateAdminKey resolves a presented key to a principal. An empty key
+// or an empty key table never authenticates.
+func authenticateAdminKey(key string) (adminPrincipal, error) {
+	if key == "" {
+		return adminPrincipal{}, ErrAdminKeyInvalid
+	}
+
+	cfg := configuration.Get()
+	if cfg.Admin.APIKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(cfg.Admin.APIKey)) == 1 {
+		return adminPrincipal{Name: legacyAdminKeyName, Scopes: legacyAdminScopes(cfg)}, nil
+	}
+
+	var record models.AdminAPIKey
+	if err := database.DB.Where("key_hash = ?", hashAdminKey(key)).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return adminPrincipal{}, ErrAdminKeyInvalid
+		}
+		return adminPrincipal{}, err
+	}
+
+	principal := adminPrincipal{Name: record.Name, Scopes: strings.Split(record.Scopes, ",")}
+	if record.RevokedAt != nil {
+		return principal, ErrAdminKeyRevoked
+	}
+	if record.ExpiresAt != nil && time.Now().After(*record.ExpiresAt) {
+		return principal, ErrAdminKeyExpired
+	}
+
+	now := time.Now()
+	if err := database.DB.Model(&record).UpdateColumn("last_used_at", &now).Error; err != nil {
+		logger.Log.WithError(err).Warn("Failed to update admin key last use")
+	}
+	return principal, nil
+}
+
+// legacyAdminScopes is what ADMIN_API_KEY may do: read only, unless
+// ADMIN_API_KEY_FULL_ACCESS opts back in to every scope.
+func legacyAdminScopes(cfg *configuration.Config) []string {
+	if cfg.Admin.APIKeyFullAccess {
+		return AdminScopes
+	}
+	return []string{ScopeStatsRead}
+}
+
+// warnIfNoAdminKeys logs once at startup when the API will reject every
+// authenticated request, and when the deprecated ADMIN_API_KEY is in use.
+func warnIfNoAdminKeys() {
+	cfg := configuration.Get()
+	if cfg.Admin.APIKey != "" {
+		logger.Log.Warnf("ADMIN_API_KEY is deprecated and grants %s; create named keys with the keys subcommand",
+			strings.Join(legacyAdminScopes(cfg), ", "))
+		if !cfg.Admin.APIKeyFullAccess {
+			logger.Log.Warn("ADMIN_API_KEY is limited to read access; set ADMIN_API_KEY_FULL_ACCESS=true to restore every scope")
+		}
+		return
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.AdminAPIKey{}).Where("revoked_at IS NULL").Count(&count).Error; err != nil {
+		logger.Log.WithError(err).Warn("Failed to count admin API keys")
+		return
+	}
+	if count == 0 {
+		logger.Log.Warn("No admin API keys configured; authenticated admin endpoints will reject all requests")
+	}
+}
+
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *auditResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func authMiddleware(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		cfg := configuration.Get()
+		policy := RateLimitPolicy{Action: "admin_api", Algorithm: TokenBucket, Limit: int(cfg.Admin.StatsRateLimit), Window: time.Minute}
+		remote := remoteHost(r.RemoteAddr)
+		if !allowAction(RemoteSubject(remote), policy) {
+			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		entry := &models.AdminAuditLog{
+			Actor:      adminActor(r),
+			RemoteAddr: r.RemoteAddr,
+			Method:     r.Method,
+			Route:      r.URL.Path,
+			Parameters: redactAdminQuery(r.URL.Query()),
+		}
+		correlationID := logger.NewCorrelationID()
+		w.Header().Set("X-Correlation-ID", correlationID)
+		ctx := logger.WithCorrelationID(r.Context(), correlationID)
+
+		recorder := &auditResponseWriter{ResponseWriter: w}
+
+		principal, err := authenticateAdminKey(r.Header.Get("X-API-Key"))
+		entry.KeyName = principal.Name
+		if err != nil {
+			status := http.StatusUnauthorized
+			if !errors.Is(err, ErrAdminKeyInvalid) && !errors.Is(err, ErrAdminKeyExpired) && !errors.Is(err, ErrAdminKeyRevoked) {
+				logger.Log.WithError(err).Error("Admin API key lookup failed")
+				status = http.StatusInternalServerError
+				entry.Details = err.Error()
+				err = errors.New("authentication unavailable")
+			} else {
+				audit, suppressed := sampleFailedAuth(remote, time.Now())
+				if !audit {
+					http.Error(recorder, err.Error(), status)
+					return
+				}
+				entry.Details = err.Error()
+				if suppressed > 0 {
+					entry.Details += fmt.Sprintf(" (%d earlier failures from this address not logged)", suppressed)
+				}
+			}
+			http.Error(recorder, err.Error(), status)
+			writeAdminAudit(ctx, entry, recorder)
+			return
+		}
+
+		if !allowAction(APISubject(principal.Name), policy) {
+			http.Error(recorder, "Rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		defer writeAdminAudit(ctx, entry, recorder)
+
+		if !principal.hasScope(scope) {
+			entry.Details = fmt.Sprintf("missing scope %s", scope)
+			http.Error(recorder, ErrAdminKeyScope.Error(), http.StatusForbidden)
+			return
+		}
+
+		entry.Body = readAdminAuditBody(r)
+
+		next(recorder, r.WithContext(context.WithValue(ctx, adminAuditContextKey{}, entry)))
+	})
+}
+
+// sampleFailedAuth reports whether a failed authentication from host should
+// be written to the audit log, and how many failures from host were skipped
+// since the last one that was.
+func sampleFailedAuth(host string, now time.Time) (bool, int) {
+	failedAdminAuth.Lock()
+	defer failedAdminAuth.Unlock()
+
+	window, ok := failedAdminAuth.windows[host]
+	if ok && now.Sub(window.start) < failedAuthAuditWindow {
+		window.suppressed++
+		return false, 0
+	}
+
+	suppressed := 0
+	if ok {
+		suppressed = window.suppressed
+	} else {
+		for other, w := range failedAdminAuth.windows {
+			if now.Sub(w.start) >= failedAuthAuditWindow && w.suppressed == 0 {
+				delete(failedAdminAuth.windows, other)
+			}
+		}
+	}
+	failedAdminAuth.windows[host] = &failedAuthWindow{start: now}
+	return true, suppressed
+}
+
+type failedAuthWindow struct {
+	start      time.Time
+	suppressed int
+}
+
+var failedAdminAuth = struct {
+	sync.Mutex
+	windows map[string]*failedAuthWindow
+}{windows: make(map[string]*failedAuthWindow)}
+
+func remoteHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin != "" && adminOriginAllowed(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+			enableCORS(w)
+		}
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func adminOriginAllowed(origin string) bool {
+	cfg := configuration.Get()
+	for _, allowed := range cfg.Admin.AllowedOrigins {
+		if strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactAdminQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	clean := make(url.Values, len(query))
+	for key, values := range query {
+		if isSensitiveAdminParam(key) {
+			clean.Set(key, redactedValue)
+			continue
+		}
+		clean[key] = values
+	}
+	return clean.Encode()
+}
+
+func isSensitiveAdminParam(name string) bool {
+	lower := strings.ToLower(name)
+	return sensitiveQueryParams[lower] || strings.Contains(lower, "key") || isSensitiveBodyKey(lower)
+}
+
+// readAdminAuditBody returns the form or JSON body of a mutating request
+// with secrets redacted, and leaves r.Body readable for the handler.
+func readAdminAuditBody(r *http.Request) string {
+	if r.Body == nil || r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxAuditBodyBytes+1))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	if err != nil || len(body) == 0 {
+		return ""
+	}
+	if len(body) > maxAuditBodyBytes {
+		return fmt.Sprintf("[body over %d bytes not recorded]", maxAuditBodyBytes)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		var decoded interface{}
+		if err := json.Unmarshal(body, &decoded); err != nil {
+			return "[invalid JSON body]"
+		}
+		encoded, err := json.Marshal(redactAdminJSON(decoded, false))
+		if err != nil {
+			return ""
+		}
+		return string(encoded)
+	case "application/x-www-form-urlencoded", "":
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "[invalid form body]"
+		}
+		return redactAdminQuery(form)
+	default:
+		return fmt.Sprintf("[%s body not recorded]", mediaType)
+	}
+}
+
+func redactAdminJSON(value interface{}, sensitive bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = redactAdminJSON(child, sensitive || isSensitiveAdminParam(key))
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactAdminJSON(child, sensitive)
+		}
+		return v
+	default:
+		if sensitive {
+			return redactedValue
+		}
+		return v
+	}
+}
+
+func writeAdminAudit(ctx context.Context, entry *models.AdminAuditLog, recorder *auditResponseWriter) {
+	entry.StatusCode = recorder.status
+	if entry.StatusCode == 0 {
+		entry.StatusCode = http.StatusOK
+	}
+	if entry.Action == "" {
+		entry.Success = entry.StatusCode < http.StatusBadRequest
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"key":        entry.KeyName,
+		"actor":      entry.Actor,
+		"method":     entry.Method,
+		"route":      entry.Route,
+		"status":     entry.StatusCode,
+		"action":     entry.Action,
+		"user_id":    entry.TargetUserID,
+		"account_id": entry.TargetAccountID,
+	}).Info("Admin API request")
+
+	if err := database.DB.Create(entry).Error; err != nil {
+		logger.Log.WithError(err).Error("Failed to write admin audit log")
+	}
+}
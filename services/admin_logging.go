@@ -0,0 +1,76 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/sirupsen/logrus"
+)
+
+func registerAdminLoggingRoutes() {
+	http.HandleFunc("/api/admin/log-level", authMiddleware(ScopeLogsWrite, adminLogLevel))
+}
+
+func logLevelsView() map[string]interface{} {
+	def, overrides := logger.Levels()
+	packages := make(map[string]string, len(overrides))
+	for pkg, level := range overrides {
+		packages[pkg] = level.String()
+	}
+	return map[string]interface{}{
+		"level":    def.String(),
+		"packages": packages,
+	}
+}
+
+// adminLogLevel reports the current levels on GET. On POST it sets the
+// default level, or with package= the level for one package; level=default
+// removes a package override.
+func adminLogLevel(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		enableCORS(w)
+		return
+	}
+	if r.Method == http.MethodGet {
+		writeJSONResponse(w, logLevelsView())
+		return
+	}
+	if !requireAdminPost(w, r) {
+		return
+	}
+
+	pkg := strings.Trim(strings.TrimSpace(r.URL.Query().Get("package")), "/")
+	rawLevel := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("level")))
+	if rawLevel == "" {
+		http.Error(w, "level is required", http.StatusBadRequest)
+		return
+	}
+
+	if pkg != "" && rawLevel == "default" {
+		logger.SetPackageLevel(pkg, nil)
+		recordAdminAudit(r, "set_log_level", "", 0, fmt.Sprintf("cleared override for %s", pkg), nil)
+		writeJSONResponse(w, logLevelsView())
+		return
+	}
+
+	level, err := logrus.ParseLevel(rawLevel)
+	if err != nil {
+		recordAdminAudit(r, "set_log_level", "", 0, "", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	before, _ := logger.Levels()
+	if pkg == "" {
+		logger.SetLevel(level)
+		recordAdminAudit(r, "set_log_level", "", 0, fmt.Sprintf("default %s -> %s", before, level), nil)
+	} else {
+		logger.SetPackageLevel(pkg, &level)
+		recordAdminAudit(r, "set_log_level", "", 0, fmt.Sprintf("%s -> %s", pkg, level), nil)
+	}
+	logger.Log.Warnf("Log level changed via admin API: %s", logger.DescribeLevels())
+
+	writeJSONResponse(w, logLevelsView())
+}
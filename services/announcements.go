@@ -0,0 +1,576 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bwmarrin/discordgo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Announcement audiences.
+const (
+	AudienceAll    = "all"     // Every user.
+	AudienceServer = "server"  // Users who installed the bot in a server.
+	AudienceDirect = "direct"  // Users who installed the bot for themselves.
+	AudienceOwnKey = "own_key" // Users with a captcha key of their own.
+	AudienceBanned = "banned"  // Users with a banned account.
+	AudienceGuild  = "guild"   // Users who installed the bot in, or monitor accounts from, one guild.
+)
+
+var Audiences = []string{AudienceAll, AudienceServer, AudienceDirect, AudienceOwnKey, AudienceBanned, AudienceGuild}
+
+const (
+	announcementScheduled = "scheduled"
+	announcementSending   = "sending"
+	announcementEditing   = "editing"
+	announcementSent      = "sent"
+	announcementRecalling = "recalling"
+	announcementRecalled  = "recalled"
+
+	deliveryPending     = "pending"
+	deliverySent        = "sent"
+	deliveryFailed      = "failed"
+	deliveryUnreachable = "unreachable"
+	deliveryRecalled    = "recalled"
+
+	// AnnouncementPollInterval is how often the leader sends, edits and
+	// recalls announcement messages.
+	AnnouncementPollInterval = 30 * time.Second
+
+	announcementBatchSize    = 50
+	announcementMaxAttempts  = 3
+	announcementRetryBackoff = 5 * time.Minute
+	announcementTitleMax     = 256
+	announcementContentMax   = 4000
+)
+
+var (
+	ErrUnknownAudience      = errors.New("unknown audience (want all, server, direct, own_key, banned or guild)")
+	ErrAnnouncementRecalled = errors.New("announcement was recalled")
+)
+
+// AnnouncementProgress counts an announcement's recipients by delivery
+// status.
+type AnnouncementProgress struct {
+	Total       int64 `json:"total"`
+	Pending     int64 `json:"pending"`
+	Sent        int64 `json:"sent"`
+	Failed      int64 `json:"failed"`
+	Unreachable int64 `json:"unreachable"`
+	Recalled    int64 `json:"recalled"`
+}
+
+// ParseAnnouncementTime reads a send time given as RFC 3339 or as
+// "2006-01-02 15:04" in UTC. An empty value means now.
+func ParseAnnouncementTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Now(), nil
+	}
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+	at, err := time.ParseInLocation("2006-01-02 15:04", value, time.UTC)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid send time %q (want 2006-01-02 15:04 in UTC, or RFC 3339)", value)
+	}
+	return at, nil
+}
+
+// CreateAnnouncement stores an announcement for the leader to send at
+// scheduledAt. Recipients are picked when sending starts, so users who join
+// in between are included.
+func CreateAnnouncement(title, content, audience, guildID string, scheduledAt time.Time, createdBy string) (models.Announcement, error) {
+	if err := validateAnnouncement(title, content); err != nil {
+		return models.Announcement{}, err
+	}
+	if !validAudience(audience) {
+		return models.Announcement{}, ErrUnknownAudience
+	}
+	if audience == AudienceGuild && guildID == "" {
+		return models.Announcement{}, errors.New("the guild audience needs a guild ID")
+	}
+	if audience != AudienceGuild {
+		guildID = ""
+	}
+	if scheduledAt.IsZero() {
+		scheduledAt = time.Now()
+	}
+
+	announcement := models.Announcement{
+		Title:       title,
+		Content:     content,
+		Audience:    audience,
+		GuildID:     guildID,
+		Status:      announcementScheduled,
+		Revision:    1,
+		ScheduledAt: scheduledAt,
+		CreatedBy:   createdBy,
+	}
+	if err := database.DB.Create(&announcement).Error; err != nil {
+		return models.Announcement{}, err
+	}
+	logger.Log.Infof("Announcement %d for %s scheduled at %s by %s", announcement.ID, audience, scheduledAt.Format(time.RFC3339), createdBy)
+	return announcement, nil
+}
+
+func GetAnnouncement(id uint) (models.Announcement, error) {
+	var announcement models.Announcement
+	err := database.DB.First(&announcement, id).Error
+	return announcement, err
+}
+
+// ListAnnouncements returns the latest announcements, newest first.
+func ListAnnouncements(limit int) ([]models.Announcement, error) {
+	var announcements []models.Announcement
+	err := database.DB.Order("id DESC").Limit(limit).Find(&announcements).Error
+	return announcements, err
+}
+
+func GetAnnouncementProgress(id uint) (AnnouncementProgress, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := database.DB.Model(&models.AnnouncementDelivery{}).
+		Select("status, COUNT(*) AS count").
+		Where("announcement_id = ?", id).
+		Group("status").Scan(&rows).Error
+	if err != nil {
+		return AnnouncementProgress{}, err
+	}
+
+	var progress AnnouncementProgress
+	for _, row := range rows {
+		progress.Total += row.Count
+		switch row.Status {
+		case deliveryPending:
+			progress.Pending = row.Count
+		case deliverySent:
+			progress.Sent = row.Count
+		case deliveryFailed:
+			progress.Failed = row.Count
+		case deliveryUnreachable:
+			progress.Unreachable = row.Count
+		case deliveryRecalled:
+			progress.Recalled = row.Count
+		}
+	}
+	return progress, nil
+}
+
+// EditAnnouncement changes the text of an announcement. Messages already
+// sent are edited in place by the leader; recipients still pending get the
+// new text.
+func EditAnnouncement(id uint, title, content string) (models.Announcement, error) {
+	if err := validateAnnouncement(title, content); err != nil {
+		return models.Announcement{}, err
+	}
+
+	var announcement models.Announcement
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&announcement, id).Error; err != nil {
+			return err
+		}
+		if announcement.Status == announcementRecalling || announcement.Status == announcementRecalled {
+			return ErrAnnouncementRecalled
+		}
+
+		announcement.Title = title
+		announcement.Content = content
+		announcement.Revision++
+		if announcement.Status == announcementSent {
+			announcement.Status = announcementEditing
+		}
+		return tx.Save(&announcement).Error
+	})
+	if err != nil {
+		return models.Announcement{}, err
+	}
+	logger.Log.Infof("Announcement %d edited, now at revision %d", announcement.ID, announcement.Revision)
+	return announcement, nil
+}
+
+// RecallAnnouncement stops an announcement. Pending recipients are skipped
+// and the leader deletes the messages already sent.
+func RecallAnnouncement(id uint) (models.Announcement, error) {
+	var announcement models.Announcement
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&announcement, id).Error; err != nil {
+			return err
+		}
+		if announcement.Status == announcementRecalling || announcement.Status == announcementRecalled {
+			return nil
+		}
+
+		now := time.Now()
+		announcement.RecalledAt = &now
+		if announcement.Status == announcementScheduled {
+			announcement.Status = announcementRecalled
+		} else {
+			announcement.Status = announcementRecalling
+		}
+		return tx.Save(&announcement).Error
+	})
+	if err != nil {
+		return models.Announcement{}, err
+	}
+	logger.Log.Infof("Announcement %d recalled", announcement.ID)
+	return announcement, nil
+}
+
+// AnnouncementEmbed renders an announcement as recipients see it.
+func AnnouncementEmbed(announcement models.Announcement) *discordgo.MessageEmbed {
+	sentAt := announcement.ScheduledAt
+	if announcement.StartedAt != nil {
+		sentAt = *announcement.StartedAt
+	}
+	return &discordgo.MessageEmbed{
+		Title:       announcement.Title,
+		Description: announcement.Content,
+		Color:       0xFFD700,
+		Timestamp:   sentAt.Format(time.RFC3339),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "COD Status Bot Announcement",
+		},
+	}
+}
+
+// RunAnnouncements starts the announcements that are due and works through
+// a batch of sends, edits and recalls of each active one. It runs on the
+// leader.
+func RunAnnouncements(s *discordgo.Session) {
+	startDueAnnouncements()
+
+	var active []models.Announcement
+	err := database.DB.Where("status IN ?", []string{announcementSending, announcementEditing, announcementRecalling}).
+		Order("id").Find(&active).Error
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to load active announcements")
+		return
+	}
+
+	for _, announcement := range active {
+		if !StillLeader() {
+			logger.Log.Warn("Lost leadership, stopping announcement run")
+			return
+		}
+		if announcement.Status == announcementRecalling {
+			recallDeliveries(s, announcement)
+			continue
+		}
+		sendDueDeliveries(s, announcement)
+		editStaleDeliveries(s, announcement)
+		finishAnnouncement(announcement)
+	}
+}
+
+func startDueAnnouncements() {
+	var due []models.Announcement
+	if err := database.DB.Where("status = ? AND scheduled_at <= ?", announcementScheduled, time.Now()).Find(&due).Error; err != nil {
+		logger.Log.WithError(err).Error("Failed to load due announcements")
+		return
+	}
+
+	for _, announcement := range due {
+		var recipients int
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			now := time.Now()
+			result := tx.Model(&models.Announcement{}).
+				Where("id = ? AND status = ?", announcement.ID, announcementScheduled).
+				Updates(map[string]interface{}{"status": announcementSending, "started_at": now})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+
+			var userIDs []string
+			if err := audienceQuery(tx, announcement).Pluck("user_id", &userIDs).Error; err != nil {
+				return err
+			}
+			deliveries := make([]models.AnnouncementDelivery, 0, len(userIDs))
+			for _, userID := range userIDs {
+				deliveries = append(deliveries, models.AnnouncementDelivery{
+					AnnouncementID: announcement.ID,
+					UserID:         userID,
+					Status:         deliveryPending,
+					NextAttemptAt:  now,
+				})
+			}
+			recipients = len(deliveries)
+			if recipients == 0 {
+				return nil
+			}
+			return tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(deliveries, 500).Error
+		})
+		if err != nil {
+			logger.Log.WithError(err).Errorf("Failed to start announcement %d", announcement.ID)
+			continue
+		}
+		logger.Log.Infof("Started announcement %d to %d recipients", announcement.ID, recipients)
+	}
+}
+
+// audienceQuery selects the user settings of an announcement's recipients.
+func audienceQuery(db *gorm.DB, announcement models.Announcement) *gorm.DB {
+	query := db.Model(&models.UserSettings{})
+	switch announcement.Audience {
+	case AudienceServer, AudienceDirect:
+		query = query.Where("installation_type = ?", announcement.Audience)
+	case AudienceOwnKey:
+		query = query.Where("cap_solver_api_key <> '' OR ez_captcha_api_key <> '' OR two_captcha_api_key <> ''")
+	case AudienceBanned:
+		banned := db.Model(&models.Account{}).Select("user_id").
+			Where("is_permabanned = ? OR is_tempbanned = ? OR is_shadowbanned = ?", true, true, true)
+		query = query.Where("user_id IN (?)", banned)
+	case AudienceGuild:
+		members := db.Model(&models.Account{}).Select("user_id").Where("guild_id = ?", announcement.GuildID)
+		query = query.Where("installation_guild_id = ? OR user_id IN (?)", announcement.GuildID, members)
+	}
+	return query
+}
+
+func sendDueDeliveries(s *discordgo.Session, announcement models.Announcement) {
+	var deliveries []models.AnnouncementDelivery
+	err := database.DB.Where("announcement_id = ? AND status = ? AND next_attempt_at <= ?", announcement.ID, deliveryPending, time.Now()).
+		Order("id").Limit(announcementBatchSize).Find(&deliveries).Error
+	if err != nil {
+		logger.Log.WithError(err).Errorf("Failed to load deliveries of announcement %d", announcement.ID)
+		return
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+
+	userIDs := make([]string, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		userIDs = append(userIDs, delivery.UserID)
+	}
+	var users []models.UserSettings
+	if err := database.DB.Where("user_id IN ?", userIDs).Find(&users).Error; err != nil {
+		logger.Log.WithError(err).Errorf("Failed to load recipients of announcement %d", announcement.ID)
+		return
+	}
+	settings := make(map[string]models.UserSettings, len(users))
+	for _, user := range users {
+		settings[user.UserID] = user
+	}
+
+	embed := AnnouncementEmbed(announcement)
+	for _, delivery := range deliveries {
+		userSettings, ok := settings[delivery.UserID]
+		switch {
+		case !ok:
+			delivery.Status = deliveryFailed
+			delivery.LastError = "user settings not found"
+		case userSettings.IsUnreachable:
+			delivery.Status = deliveryUnreachable
+		default:
+			deliverAnnouncement(s, &delivery, userSettings, embed, announcement.Revision)
+		}
+		if err := database.DB.Save(&delivery).Error; err != nil {
+			logger.Log.WithError(err).Errorf("Failed to save delivery of announcement %d to %s", announcement.ID, delivery.UserID)
+		}
+	}
+}
+
+func deliverAnnouncement(s *discordgo.Session, delivery *models.AnnouncementDelivery, userSettings models.UserSettings, embed *discordgo.MessageEmbed, revision int) {
+	delivery.Attempts++
+	target := announcementTarget(delivery.UserID, userSettings)
+	message, err := postAnnouncement(s, &target, embed)
+	if err == nil {
+		now := time.Now()
+		delivery.Status = deliverySent
+		delivery.ChannelID = target.ChannelID
+		delivery.GuildID = target.GuildID
+		delivery.MessageID = message.ID
+		delivery.Revision = revision
+		delivery.SentAt = &now
+		delivery.LastError = ""
+		return
+	}
+
+	TrackMessageFailure(delivery.UserID, err.Error())
+	delivery.LastError = err.Error()
+	var unreachable int64
+	database.DB.Model(&models.UserSettings{}).Where("user_id = ? AND is_unreachable = ?", delivery.UserID, true).Count(&unreachable)
+	switch {
+	case unreachable > 0:
+		delivery.Status = deliveryUnreachable
+	case delivery.Attempts >= announcementMaxAttempts:
+		delivery.Status = deliveryFailed
+	default:
+		delivery.NextAttemptAt = time.Now().Add(time.Duration(delivery.Attempts) * announcementRetryBackoff)
+	}
+}
+
+// postAnnouncement sends embed to target and fills in the DM channel it
+// used.
+func postAnnouncement(s *discordgo.Session, target *messageTarget, embed *discordgo.MessageEmbed) (*discordgo.Message, error) {
+	session := target.session(s)
+	if session == nil {
+		return nil, errors.New("no Discord session for the target")
+	}
+	if target.DM {
+		channel, err := session.UserChannelCreate(target.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create DM channel: %w", err)
+		}
+		target.ChannelID = channel.ID
+	}
+	return session.ChannelMessageSendEmbed(target.ChannelID, embed)
+}
+
+// announcementTarget picks where userID gets announcements: their DMs if
+// they asked for them or monitor no accounts, otherwise the channel of the
+// account they updated last.
+func announcementTarget(userID string, userSettings models.UserSettings) messageTarget {
+	if userSettings.NotificationType == "dm" {
+		return messageTarget{UserID: userID, DM: true}
+	}
+
+	var account models.Account
+	if err := database.DB.Where("user_id = ?", userID).Order("updated_at DESC").First(&account).Error; err != nil {
+		return messageTarget{UserID: userID, DM: true}
+	}
+	return messageTarget{UserID: userID, ChannelID: account.ChannelID, GuildID: account.GuildID}
+}
+
+func editStaleDeliveries(s *discordgo.Session, announcement models.Announcement) {
+	var deliveries []models.AnnouncementDelivery
+	err := database.DB.Where("announcement_id = ? AND status = ? AND revision < ?", announcement.ID, deliverySent, announcement.Revision).
+		Order("id").Limit(announcementBatchSize).Find(&deliveries).Error
+	if err != nil {
+		logger.Log.WithError(err).Errorf("Failed to load sent deliveries of announcement %d", announcement.ID)
+		return
+	}
+
+	embed := AnnouncementEmbed(announcement)
+	for _, delivery := range deliveries {
+		session := sentMessageSession(s, delivery)
+		if session == nil {
+			return
+		}
+		_, err := session.ChannelMessageEditEmbed(delivery.ChannelID, delivery.MessageID, embed)
+		if err != nil && !messageGone(err) {
+			logger.Log.WithError(err).Warnf("Failed to edit announcement %d for %s, will retry", announcement.ID, delivery.UserID)
+			continue
+		}
+		if err := database.DB.Model(&delivery).Update("revision", announcement.Revision).Error; err != nil {
+			logger.Log.WithError(err).Error("Failed to save announcement edit")
+		}
+	}
+}
+
+func recallDeliveries(s *discordgo.Session, announcement models.Announcement) {
+	err := database.DB.Model(&models.AnnouncementDelivery{}).
+		Where("announcement_id = ? AND status = ?", announcement.ID, deliveryPending).
+		Update("status", deliveryRecalled).Error
+	if err != nil {
+		logger.Log.WithError(err).Errorf("Failed to cancel pending deliveries of announcement %d", announcement.ID)
+		return
+	}
+
+	var deliveries []models.AnnouncementDelivery
+	err = database.DB.Where("announcement_id = ? AND status = ?", announcement.ID, deliverySent).
+		Order("id").Limit(announcementBatchSize).Find(&deliveries).Error
+	if err != nil {
+		logger.Log.WithError(err).Errorf("Failed to load sent deliveries of announcement %d", announcement.ID)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		session := sentMessageSession(s, delivery)
+		if session == nil {
+			return
+		}
+		if err := session.ChannelMessageDelete(delivery.ChannelID, delivery.MessageID); err != nil && !messageGone(err) {
+			logger.Log.WithError(err).Warnf("Failed to delete announcement %d for %s, will retry", announcement.ID, delivery.UserID)
+			continue
+		}
+		if err := database.DB.Model(&delivery).Update("status", deliveryRecalled).Error; err != nil {
+			logger.Log.WithError(err).Error("Failed to save announcement recall")
+		}
+	}
+
+	if len(deliveries) < announcementBatchSize {
+		err := database.DB.Model(&models.Announcement{}).
+			Where("id = ? AND status = ?", announcement.ID, announcementRecalling).
+			Update("status", announcementRecalled).Error
+		if err != nil {
+			logger.Log.WithError(err).Errorf("Failed to finish recall of announcement %d", announcement.ID)
+		}
+	}
+}
+
+// finishAnnouncement marks an announcement sent once no recipient is
+// pending and every sent message shows the latest revision.
+func finishAnnouncement(announcement models.Announcement) {
+	var open int64
+	err := database.DB.Model(&models.AnnouncementDelivery{}).
+		Where("announcement_id = ? AND (status = ? OR (status = ? AND revision < ?))",
+			announcement.ID, deliveryPending, deliverySent, announcement.Revision).
+		Count(&open).Error
+	if err != nil || open > 0 {
+		return
+	}
+
+	updates := map[string]interface{}{"status": announcementSent}
+	if announcement.FinishedAt == nil {
+		updates["finished_at"] = time.Now()
+	}
+	// Matching the revision keeps an edit made meanwhile from being lost.
+	result := database.DB.Model(&models.Announcement{}).
+		Where("id = ? AND status IN ? AND revision = ?", announcement.ID, []string{announcementSending, announcementEditing}, announcement.Revision).
+		Updates(updates)
+	if result.Error != nil {
+		logger.Log.WithError(result.Error).Errorf("Failed to finish announcement %d", announcement.ID)
+		return
+	}
+	if result.RowsAffected > 0 && announcement.Status == announcementSending {
+		logger.Log.Infof("Announcement %d delivered", announcement.ID)
+	}
+}
+
+func sentMessageSession(s *discordgo.Session, delivery models.AnnouncementDelivery) *discordgo.Session {
+	return messageTarget{GuildID: delivery.GuildID, DM: delivery.GuildID == ""}.session(s)
+}
+
+// messageGone reports whether Discord refused because the message or its
+// channel is gone or out of reach, so retrying cannot help.
+func messageGone(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Response == nil {
+		return false
+	}
+	return restErr.Response.StatusCode == http.StatusNotFound || restErr.Response.StatusCode == http.StatusForbidden
+}
+
+func validateAnnouncement(title, content string) error {
+	switch {
+	case strings.TrimSpace(title) == "" || strings.TrimSpace(content) == "":
+		return errors.New("title and content are required")
+	case utf8.RuneCountInString(title) > announcementTitleMax:
+		return fmt.Errorf("title is longer than %d characters", announcementTitleMax)
+	case utf8.RuneCountInString(content) > announcementContentMax:
+		return fmt.Errorf("content is longer than %d characters", announcementContentMax)
+	}
+	return nil
+}
+
+func validAudience(audience string) bool {
+	for _, known := range Audiences {
+		if known == audience {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,6 +15,8 @@ import (
 
 	"github.com/bradselph/CODStatusBot/configuration"
 	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -29,7 +32,7 @@ const (
 )
 
 type CaptchaSolver interface {
-	SolveReCaptchaV2(siteKey, pageURL string) (string, error)
+	SolveReCaptchaV2(ctx context.Context, siteKey, pageURL string) (string, error)
 }
 type CapsolverSolver struct {
 	APIKey string
@@ -248,16 +251,15 @@ func NewCaptchaSolver(apiKey, provider string) (CaptchaSolver, error) {
 	}
 }
 
-func (s *CapsolverSolver) SolveReCaptchaV2(siteKey, pageURL string) (string, error) {
-	taskID, err := s.createTask(siteKey, pageURL)
+func (s *CapsolverSolver) SolveReCaptchaV2(ctx context.Context, siteKey, pageURL string) (string, error) {
+	taskID, err := s.createTask(ctx, siteKey, pageURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to create capsolver task: %w", err)
 	}
 
-	response, err := s.getTaskResult(taskID)
+	response, err := s.getTaskResult(ctx, taskID)
 	if err != nil {
-		if !strings.Contains(err.Error(), "failed to send request") &&
-			!strings.Contains(err.Error(), "failed to read response") {
+		if !errors.Is(err, ErrCaptchaTransport) {
 			logger.Log.Infof("Reporting Capsolver task failure for task %s", taskID)
 			reportErr := reportCapsolverTaskResult(s.APIKey, s.AppID, taskID, false, 1001, err.Error())
 			if reportErr != nil {
@@ -272,24 +274,26 @@ func (s *CapsolverSolver) SolveReCaptchaV2(siteKey, pageURL string) (string, err
 	return response, nil
 }
 
-func (s *EZCaptchaSolver) SolveReCaptchaV2(siteKey, pageURL string) (string, error) {
+func (s *EZCaptchaSolver) SolveReCaptchaV2(ctx context.Context, siteKey, pageURL string) (string, error) {
 
-	taskID, err := s.createTask(siteKey, pageURL)
+	taskID, err := s.createTask(ctx, siteKey, pageURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to create captcha task: %w", err)
 	}
-	return s.getTaskResult(taskID)
+	return s.getTaskResult(ctx, taskID)
 }
 
-func (s *TwoCaptchaSolver) SolveReCaptchaV2(siteKey, pageURL string) (string, error) {
-	taskID, err := s.createTask(siteKey, pageURL)
+func (s *TwoCaptchaSolver) SolveReCaptchaV2(ctx context.Context, siteKey, pageURL string) (string, error) {
+	taskID, err := s.createTask(ctx, siteKey, pageURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to create captcha task: %w", err)
 	}
-	return s.getTaskResult(taskID)
+	return s.getTaskResult(ctx, taskID)
 }
 
-func (s *CapsolverSolver) createTask(siteKey, pageURL string) (string, error) {
+func (s *CapsolverSolver) createTask(ctx context.Context, siteKey, pageURL string) (taskID string, err error) {
+	ctx, span := tracing.Start(ctx, "captcha.create_task", attribute.String("captcha.provider", "capsolver"))
+	defer func() { tracing.End(span, err) }()
 
 	if s.AppID == "" {
 		return "", fmt.Errorf("AppID is not configured")
@@ -306,7 +310,7 @@ func (s *CapsolverSolver) createTask(siteKey, pageURL string) (string, error) {
 		},
 	}
 
-	resp, err := sendRequest(CapsolverCreateEndpoint, payload)
+	resp, err := sendRequestContext(ctx, CapsolverCreateEndpoint, payload)
 	if err != nil {
 		return "", err
 	}
@@ -329,7 +333,9 @@ func (s *CapsolverSolver) createTask(siteKey, pageURL string) (string, error) {
 	return result.TaskId, nil
 }
 
-func (s *EZCaptchaSolver) createTask(siteKey, pageURL string) (string, error) {
+func (s *EZCaptchaSolver) createTask(ctx context.Context, siteKey, pageURL string) (taskID string, err error) {
+	ctx, span := tracing.Start(ctx, "captcha.create_task", attribute.String("captcha.provider", "ezcaptcha"))
+	defer func() { tracing.End(span, err) }()
 
 	if s.EzappID == "" {
 		return "", fmt.Errorf("EzappID is not configured")
@@ -346,7 +352,7 @@ func (s *EZCaptchaSolver) createTask(siteKey, pageURL string) (string, error) {
 		},
 	}
 
-	resp, err := sendRequest(EZCaptchaCreateEndpoint, payload)
+	resp, err := sendRequestContext(ctx, EZCaptchaCreateEndpoint, payload)
 	if err != nil {
 		return "", err
 	}
@@ -369,7 +375,9 @@ func (s *EZCaptchaSolver) createTask(siteKey, pageURL string) (string, error) {
 	return result.TaskId, nil
 }
 
-func (s *TwoCaptchaSolver) createTask(siteKey, pageURL string) (string, error) {
+func (s *TwoCaptchaSolver) createTask(ctx context.Context, siteKey, pageURL string) (taskID string, err error) {
+	ctx, span := tracing.Start(ctx, "captcha.create_task", attribute.String("captcha.provider", "2captcha"))
+	defer func() { tracing.End(span, err) }()
 
 	if s.SoftID == "" {
 		return "", fmt.Errorf("SoftID is not configured")
@@ -385,7 +393,7 @@ func (s *TwoCaptchaSolver) createTask(siteKey, pageURL string) (string, error) {
 		},
 	}
 
-	resp, err := sendRequest(TwoCaptchaCreateEndpoint, payload)
+	resp, err := sendRequestContext(ctx, TwoCaptchaCreateEndpoint, payload)
 	if err != nil {
 		return "", err
 	}
@@ -404,13 +412,15 @@ func (s *TwoCaptchaSolver) createTask(siteKey, pageURL string) (string, error) {
 	}
 
 	if result.ErrorId != 0 {
-		return "", fmt.Errorf("API error creating task: %s - %s", result.ErrorCode, result.ErrorDescription)
+		return "", twoCaptchaError("creating task", result.ErrorCode, result.ErrorDescription)
 	}
 
 	return fmt.Sprintf("%d", result.TaskId), nil
 }
 
-func (s *CapsolverSolver) getTaskResult(taskID string) (string, error) {
+func (s *CapsolverSolver) getTaskResult(ctx context.Context, taskID string) (response string, err error) {
+	ctx, span := tracing.Start(ctx, "captcha.poll", attribute.String("captcha.provider", "capsolver"))
+	defer func() { tracing.End(span, err) }()
 	cfg := configuration.Get()
 	MaxRetries := cfg.CaptchaService.Capsolver.MaxRetries
 	RetryInterval := cfg.CaptchaService.Capsolver.RetryInterval
@@ -421,7 +431,7 @@ func (s *CapsolverSolver) getTaskResult(taskID string) (string, error) {
 			"taskId":    taskID,
 		}
 
-		resp, err := sendRequest(CapsolverResultEndpoint, payload)
+		resp, err := sendRequestContext(ctx, CapsolverResultEndpoint, payload)
 		if err != nil {
 			return "", err
 		}
@@ -442,7 +452,7 @@ func (s *CapsolverSolver) getTaskResult(taskID string) (string, error) {
 
 		if result.ErrorId != 0 {
 			if strings.Contains(result.ErrorDescription, "insufficient balance") {
-				return "", fmt.Errorf("insufficient balance")
+				return "", ErrInsufficientBalance
 			}
 			if i == MaxRetries-1 {
 				return "", fmt.Errorf("capsolver API error after %d retries: %s - %s", MaxRetries, result.ErrorCode, result.ErrorDescription)
@@ -465,14 +475,16 @@ func (s *CapsolverSolver) getTaskResult(taskID string) (string, error) {
 
 }
 
-func (s *EZCaptchaSolver) getTaskResult(taskID string) (string, error) {
+func (s *EZCaptchaSolver) getTaskResult(ctx context.Context, taskID string) (response string, err error) {
+	ctx, span := tracing.Start(ctx, "captcha.poll", attribute.String("captcha.provider", "ezcaptcha"))
+	defer func() { tracing.End(span, err) }()
 	for i := 0; i < MaxRetries; i++ {
 		payload := map[string]interface{}{
 			"clientKey": s.APIKey,
 			"taskId":    taskID,
 		}
 
-		resp, err := sendRequest(EZCaptchaResultEndpoint, payload)
+		resp, err := sendRequestContext(ctx, EZCaptchaResultEndpoint, payload)
 		if err != nil {
 			return "", err
 		}
@@ -492,7 +504,7 @@ func (s *EZCaptchaSolver) getTaskResult(taskID string) (string, error) {
 
 		if result.ErrorId != 0 {
 			if strings.Contains(result.ErrorDescription, "insufficient balance") {
-				return "", fmt.Errorf("insufficient balance")
+				return "", ErrInsufficientBalance
 			}
 			if i == MaxRetries-1 {
 				return "", fmt.Errorf("API error after %d retries: %s - %s", MaxRetries, result.ErrorCode, result.ErrorDescription)
@@ -514,22 +526,26 @@ func (s *EZCaptchaSolver) getTaskResult(taskID string) (string, error) {
 	return "", errors.New("max retries reached waiting for result")
 }
 
-func (s *TwoCaptchaSolver) getTaskResult(taskID string) (string, error) {
+func (s *TwoCaptchaSolver) getTaskResult(ctx context.Context, taskID string) (response string, err error) {
+	ctx, span := tracing.Start(ctx, "captcha.poll", attribute.String("captcha.provider", "2captcha"))
+	defer func() { tracing.End(span, err) }()
 	for i := 0; i < MaxRetries; i++ {
 		payload := map[string]interface{}{
 			"clientKey": s.APIKey,
 			"taskId":    taskID,
 		}
 
-		resp, err := sendRequest(TwoCaptchaResultEndpoint, payload)
+		resp, err := sendRequestContext(ctx, TwoCaptchaResultEndpoint, payload)
 		if err != nil {
 			return "", err
 		}
 
 		var result struct {
-			ErrorId  int    `json:"errorId"`
-			Status   string `json:"status"`
-			Solution struct {
+			ErrorId          int    `json:"errorId"`
+			ErrorCode        string `json:"errorCode"`
+			ErrorDescription string `json:"errorDescription"`
+			Status           string `json:"status"`
+			Solution         struct {
 				GRecaptchaResponse string `json:"gRecaptchaResponse"`
 			} `json:"solution"`
 		}
@@ -539,7 +555,7 @@ func (s *TwoCaptchaSolver) getTaskResult(taskID string) (string, error) {
 		}
 
 		if result.ErrorId != 0 {
-			return "", fmt.Errorf("API error getting result")
+			return "", twoCaptchaError("getting result", result.ErrorCode, result.ErrorDescription)
 		}
 
 		if result.Status == "ready" {
@@ -552,15 +568,47 @@ func (s *TwoCaptchaSolver) getTaskResult(taskID string) (string, error) {
 	return "", errors.New("max retries reached waiting for result")
 }
 
+// twoCaptchaError maps the 2captcha error codes users can fix themselves to
+// their sentinels.
+func twoCaptchaError(op, code, description string) error {
+	switch code {
+	case "ERROR_ZERO_BALANCE":
+		return fmt.Errorf("%w: 2captcha", ErrInsufficientBalance)
+	case "ERROR_KEY_DOES_NOT_EXIST", "ERROR_WRONG_USER_KEY":
+		return fmt.Errorf("%w: 2captcha", ErrCaptchaKeyInvalid)
+	default:
+		return fmt.Errorf("API error %s: %s - %s", op, code, description)
+	}
+}
+
 func sendRequest(url string, payload interface{}) ([]byte, error) {
+	return sendRequestContext(context.Background(), url, payload)
+}
+
+func sendRequestContext(ctx context.Context, url string, payload interface{}) (body []byte, err error) {
+	ctx, span := tracing.Start(ctx, "captcha.request", attribute.String("http.url", url))
+	defer func() { tracing.End(span, err) }()
+
 	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonPayload))
+	breaker := getCircuitBreaker(captchaBreakerForURL(url))
+	if err := breaker.Allow(); err != nil {
+		return nil, fmt.Errorf("%w: failed to send request: %w", ErrCaptchaTransport, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := GetDefaultHTTPClient().Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		breaker.RecordFailure(err)
+		return nil, fmt.Errorf("%w: failed to send request: %w", ErrCaptchaTransport, err)
 	}
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
@@ -569,9 +617,18 @@ func sendRequest(url string, payload interface{}) ([]byte, error) {
 		}
 	}(resp.Body)
 
-	body, err := io.ReadAll(resp.Body)
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+
+	body, err = io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		breaker.RecordFailure(err)
+		return nil, fmt.Errorf("%w: failed to read response: %w", ErrCaptchaTransport, err)
+	}
+
+	if isUpstreamFailureStatus(resp.StatusCode) {
+		breaker.RecordFailure(fmt.Errorf("upstream returned status %d", resp.StatusCode))
+	} else {
+		breaker.RecordSuccess()
 	}
 
 	return body, nil
@@ -617,10 +674,10 @@ func validateCapsolverKey(apiKey string) (bool, float64, error) {
 	resp, err := sendRequest(url, payload)
 	if err != nil {
 		if strings.Contains(err.Error(), "invalid token format") {
-			return false, 0, errors.New("invalid capsolver API key format")
+			return false, 0, fmt.Errorf("%w: capsolver key format", ErrCaptchaKeyInvalid)
 		}
 		if strings.Contains(err.Error(), "invalid key") {
-			return false, 0, errors.New("invalid capsolver API key")
+			return false, 0, fmt.Errorf("%w: capsolver", ErrCaptchaKeyInvalid)
 		}
 		return false, 0, fmt.Errorf("capsolver balance check failed: %w", err)
 	}
@@ -638,10 +695,10 @@ func validateCapsolverKey(apiKey string) (bool, float64, error) {
 
 	if result.ErrorId != 0 {
 		if strings.Contains(result.ErrorDescription, "Invalid token format") {
-			return false, 0, errors.New("invalid capsolver API key format")
+			return false, 0, fmt.Errorf("%w: capsolver key format", ErrCaptchaKeyInvalid)
 		}
 		if strings.Contains(result.ErrorDescription, "Invalid key") {
-			return false, 0, errors.New("invalid capsolver API key")
+			return false, 0, fmt.Errorf("%w: capsolver", ErrCaptchaKeyInvalid)
 		}
 		return false, 0, fmt.Errorf("capsolver API error: %s - %s", result.ErrorCode, result.ErrorDescription)
 	}
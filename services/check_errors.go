@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bwmarrin/discordgo"
+)
+
+type CheckErrorCategory string
+
+const (
+	CheckErrorCaptcha       CheckErrorCategory = "captcha_failure"
+	CheckErrorUpstream      CheckErrorCategory = "upstream_outage"
+	CheckErrorCookieInvalid CheckErrorCategory = "cookie_invalid"
+	CheckErrorRateLimited   CheckErrorCategory = "rate_limited"
+	CheckErrorParse         CheckErrorCategory = "parse_error"
+	CheckErrorUnknown       CheckErrorCategory = "unknown"
+)
+
+// legacyDisabledReasonPrefix matches accounts disabled by the old
+// error-count rule before errors were categorized.
+const legacyDisabledReasonPrefix = "Max consecutive errors reached"
+
+type CheckError struct {
+	Category CheckErrorCategory
+	Err      error
+}
+
+func (e *CheckError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CheckError) Unwrap() error {
+	return e.Err
+}
+
+func newCheckError(category CheckErrorCategory, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &CheckError{Category: category, Err: err}
+}
+
+// ClassifyCheckError returns the category attached to err by CheckAccount.
+func ClassifyCheckError(err error) CheckErrorCategory {
+	if err == nil {
+		return ""
+	}
+
+	var checkErr *CheckError
+	if errors.As(err, &checkErr) {
+		return checkErr.Category
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return CheckErrorUpstream
+	}
+	return CheckErrorUnknown
+}
+
+// AccountAttributable reports whether the error points at the account itself
+// rather than the bot, a captcha provider or Activision.
+func (c CheckErrorCategory) AccountAttributable() bool {
+	return c == CheckErrorCookieInvalid
+}
+
+func (c CheckErrorCategory) Label() string {
+	switch c {
+	case CheckErrorCaptcha:
+		return "Captcha failure"
+	case CheckErrorUpstream:
+		return "Activision outage"
+	case CheckErrorCookieInvalid:
+		return "Cookie invalid"
+	case CheckErrorRateLimited:
+		return "Rate limited"
+	case CheckErrorParse:
+		return "Unexpected response"
+	case CheckErrorUnknown:
+		return "Unknown error"
+	default:
+		return ""
+	}
+}
+
+// LocalizedLabel is Label in locale, for messages shown to users. Like
+// Label, it is empty for categories without one.
+func (c CheckErrorCategory) LocalizedLabel(locale string) string {
+	if c.Label() == "" {
+		return ""
+	}
+	return i18n.T(locale, "checkerror."+string(c))
+}
+
+// AccountDisabledReason is why checks of account are disabled, in locale.
+// Accounts disabled for check errors show their category; others show the
+// reason given when they were disabled.
+func AccountDisabledReason(locale string, account models.Account) string {
+	if label := CheckErrorCategory(account.DisabledCategory).LocalizedLabel(locale); label != "" {
+		return i18n.T(locale, "checkerror.disabled_reason", label)
+	}
+	return account.DisabledReason
+}
+
+// ReenableOutageDisabledAccounts turns checks back on for accounts that were
+// disabled for reasons outside the account's control once upstream
+// services have recovered. It needs a session to tell users.
+func ReenableOutageDisabledAccounts(s *discordgo.Session) {
+	if s == nil || len(OpenCircuitBreakers()) > 0 {
+		return
+	}
+
+	var accounts []models.Account
+	if err := database.DB.Where("is_check_disabled = ?", true).
+		Where("(disabled_category <> '' AND disabled_category <> ?) OR (disabled_category = '' AND disabled_reason LIKE ? AND is_expired_cookie = ?)",
+			string(CheckErrorCookieInvalid), legacyDisabledReasonPrefix+"%", false).
+		Find(&accounts).Error; err != nil {
+		logger.Log.WithError(err).Error("Failed to fetch accounts disabled during outages")
+		return
+	}
+
+	for _, account := range accounts {
+		category := CheckErrorCategory(account.DisabledCategory)
+		if category == "" {
+			// Legacy rows do not say why they were disabled, so only
+			// re-enable them once the cookie is known to still work.
+			if !legacyAccountRecovered(&account) {
+				continue
+			}
+			category = CheckErrorUnknown
+		}
+
+		account.IsCheckDisabled = false
+		account.DisabledReason = ""
+		account.DisabledCategory = ""
+		account.ConsecutiveErrors = 0
+
+		if err := database.DB.Save(&account).Error; err != nil {
+			logger.Log.WithError(err).Errorf("Failed to re-enable account %s", account.Title)
+			continue
+		}
+
+		logEntry := models.Ban{
+			AccountID: account.ID,
+			Status:    account.LastStatus,
+			LogType:   "check_enabled",
+			Message:   fmt.Sprintf("Checks re-enabled automatically after %s", category.Label()),
+			Timestamp: time.Now(),
+			Initiator: "system",
+		}
+		if err := database.DB.Create(&logEntry).Error; err != nil {
+			logger.Log.WithError(err).Error("Failed to log automatic re-enable")
+		}
+
+		logger.Log.Infof("Re-enabled checks for account %s (disabled for %s)", account.Title, category)
+
+		locale := UserLocale(account.UserID)
+		embed := &discordgo.MessageEmbed{
+			Title:       i18n.T(locale, "notify.reenabled.title", account.Title),
+			Description: i18n.T(locale, "notify.reenabled.description", category.LocalizedLabel(locale)),
+			Color:       0x00ff00,
+			Timestamp:   time.Now().Format(time.RFC3339),
+		}
+		if err := SendNotification(s, account, embed, "", "check_reenabled"); err != nil {
+			logger.Log.WithError(err).Error("Failed to send re-enable notification")
+		}
+	}
+}
+
+// legacyAccountRecovered verifies the cookie of an account disabled by the
+// old error-count rule. An invalid cookie is recorded as the account's
+// category so the account is not verified again; an outage leaves it for
+// the next run.
+func legacyAccountRecovered(account *models.Account) bool {
+	err := verifySSOCookie(context.Background(), account.SSOCookie)
+	if err == nil {
+		return true
+	}
+	if ClassifyCheckError(err) == CheckErrorCookieInvalid {
+		account.DisabledCategory = string(CheckErrorCookieInvalid)
+		if err := database.DB.Model(account).Update("disabled_category", account.DisabledCategory).Error; err != nil {
+			logger.Log.WithError(err).Errorf("Failed to record invalid cookie for account %s", account.Title)
+		}
+	}
+	return false
+}
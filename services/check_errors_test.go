@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/configuration"
+	"github.com/bradselph/CODStatusBot/models"
+)
+
+func TestVerifySSOCookieStatusCodes(t *testing.T) {
+	previous := configuration.Get()
+	previousDelay := verifyRetryDelay
+	t.Cleanup(func() {
+		verifyRetryDelay = previousDelay
+		configuration.Update(func(cfg *configuration.Config) { cfg.API.ProfileEndpoint = previous.API.ProfileEndpoint })
+	})
+	verifyRetryDelay = time.Millisecond
+	setBreakerConfig(t, 10)
+
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		closed bool
+		want   CheckErrorCategory
+	}{
+		{name: "ok", status: http.StatusOK, body: `{"username":"test"}`},
+		{name: "unauthorized", status: http.StatusUnauthorized, want: CheckErrorCookieInvalid},
+		{name: "forbidden", status: http.StatusForbidden, want: CheckErrorCookieInvalid},
+		{name: "server error", status: http.StatusServiceUnavailable, want: CheckErrorUpstream},
+		{name: "too many requests", status: http.StatusTooManyRequests, want: CheckErrorUpstream},
+		{name: "connection refused", closed: true, want: CheckErrorUpstream},
+		{name: "empty body", status: http.StatusOK, want: CheckErrorParse},
+		{name: "not found", status: http.StatusNotFound, want: CheckErrorUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getCircuitBreaker(BreakerActivisionProfile).RecordSuccess()
+			t.Cleanup(getCircuitBreaker(BreakerActivisionProfile).RecordSuccess)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			if tt.closed {
+				server.Close()
+			} else {
+				defer server.Close()
+			}
+			configuration.Update(func(cfg *configuration.Config) { cfg.API.ProfileEndpoint = server.URL })
+
+			err := verifySSOCookie(context.Background(), "cookie")
+			if got := ClassifyCheckError(err); got != tt.want {
+				t.Errorf("verifySSOCookie() = %v, category %q, want %q", err, got, tt.want)
+			}
+			if got := VerifySSOCookie("cookie"); got != (tt.want == "") {
+				t.Errorf("VerifySSOCookie() = %v, want %v", got, tt.want == "")
+			}
+		})
+	}
+}
+
+func TestClassifyCheckError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want CheckErrorCategory
+	}{
+		{name: "nil", err: nil, want: ""},
+		{name: "check error", err: newCheckError(CheckErrorCookieInvalid, errors.New("rejected")), want: CheckErrorCookieInvalid},
+		{name: "wrapped check error", err: fmt.Errorf("check failed: %w", newCheckError(CheckErrorCaptcha, errors.New("unsolvable"))), want: CheckErrorCaptcha},
+		{name: "open breaker", err: fmt.Errorf("%w: %s", ErrCircuitOpen, BreakerActivisionCheck), want: CheckErrorUpstream},
+		{name: "plain error", err: errors.New("boom"), want: CheckErrorUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyCheckError(tt.err); got != tt.want {
+				t.Errorf("ClassifyCheckError() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckErrorSentinels(t *testing.T) {
+	sentinels := []error{ErrCookieInvalid, ErrUpstreamUnavailable, ErrRateLimited}
+
+	tests := []struct {
+		category CheckErrorCategory
+		want     error
+	}{
+		{category: CheckErrorCookieInvalid, want: ErrCookieInvalid},
+		{category: CheckErrorUpstream, want: ErrUpstreamUnavailable},
+		{category: CheckErrorRateLimited, want: ErrRateLimited},
+		{category: CheckErrorCaptcha},
+		{category: CheckErrorParse},
+		{category: CheckErrorUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.category), func(t *testing.T) {
+			err := fmt.Errorf("check failed: %w", newCheckError(tt.category, errors.New("cause")))
+			for _, sentinel := range sentinels {
+				if got := errors.Is(err, sentinel); got != (sentinel == tt.want) {
+					t.Errorf("errors.Is(%v) = %v", sentinel, got)
+				}
+			}
+		})
+	}
+}
+
+func TestAccountAttributable(t *testing.T) {
+	tests := []struct {
+		category CheckErrorCategory
+		want     bool
+	}{
+		{category: CheckErrorCookieInvalid, want: true},
+		{category: CheckErrorUpstream},
+		{category: CheckErrorCaptcha},
+		{category: CheckErrorRateLimited},
+		{category: CheckErrorParse},
+		{category: CheckErrorUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.category), func(t *testing.T) {
+			if got := tt.category.AccountAttributable(); got != tt.want {
+				t.Errorf("AccountAttributable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccountDisabledReason(t *testing.T) {
+	tests := []struct {
+		name    string
+		account models.Account
+		want    string
+	}{
+		{
+			name:    "categorized",
+			account: models.Account{DisabledCategory: string(CheckErrorUpstream), DisabledReason: "ignored"},
+			want:    "Checks stopped after repeated errors: Activision outage",
+		},
+		{
+			name:    "manual",
+			account: models.Account{DisabledReason: "Manually disabled by user"},
+			want:    "Manually disabled by user",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AccountDisabledReason("en", tt.account); got != tt.want {
+				t.Errorf("AccountDisabledReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
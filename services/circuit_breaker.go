@@ -0,0 +1,293 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/configuration"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+const (
+	BreakerActivisionProfile = "activision_profile"
+	BreakerActivisionCheck   = "activision_check"
+	BreakerActivisionVIP     = "activision_vip"
+	BreakerCapsolver         = "capsolver"
+	BreakerEZCaptcha         = "ezcaptcha"
+	BreakerTwoCaptcha        = "2captcha"
+)
+
+var ErrCircuitOpen = fmt.Errorf("circuit breaker open: %w", ErrUpstreamUnavailable)
+
+type CircuitBreaker struct {
+	mu              sync.Mutex
+	name            string
+	state           BreakerState
+	failures        int
+	openedAt        time.Time
+	probeStartedAt  time.Time
+	lastFailure     string
+	lastFailureTime time.Time
+	lastStateChange time.Time
+}
+
+type BreakerSnapshot struct {
+	Name            string       `json:"name"`
+	State           BreakerState `json:"state"`
+	Failures        int          `json:"failures"`
+	OpenedAt        *time.Time   `json:"opened_at,omitempty"`
+	RetryAt         *time.Time   `json:"retry_at,omitempty"`
+	LastFailure     string       `json:"last_failure,omitempty"`
+	LastFailureTime *time.Time   `json:"last_failure_time,omitempty"`
+	LastStateChange time.Time    `json:"last_state_change"`
+}
+
+var circuitBreakers = struct {
+	sync.Mutex
+	breakers map[string]*CircuitBreaker
+}{
+	breakers: make(map[string]*CircuitBreaker),
+}
+
+func getCircuitBreaker(name string) *CircuitBreaker {
+	circuitBreakers.Lock()
+	defer circuitBreakers.Unlock()
+
+	breaker, ok := circuitBreakers.breakers[name]
+	if !ok {
+		breaker = &CircuitBreaker{name: name, state: BreakerClosed, lastStateChange: time.Now()}
+		circuitBreakers.breakers[name] = breaker
+	}
+	return breaker
+}
+
+// Allow reports whether a request may be sent. Once the open period has
+// elapsed a single probe is let through in the half-open state.
+func (b *CircuitBreaker) Allow() error {
+	cfg := configuration.Get()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	switch b.state {
+	case BreakerOpen:
+		if now.Sub(b.openedAt) < cfg.CircuitBreaker.OpenDuration {
+			return fmt.Errorf("%w: %s", ErrCircuitOpen, b.name)
+		}
+		b.setState(BreakerHalfOpen)
+		b.probeStartedAt = now
+		return nil
+	case BreakerHalfOpen:
+		if !b.probeStartedAt.IsZero() && now.Sub(b.probeStartedAt) < cfg.CircuitBreaker.OpenDuration {
+			return fmt.Errorf("%w: %s (probe in progress)", ErrCircuitOpen, b.name)
+		}
+		b.probeStartedAt = now
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Blocked reports whether the breaker would currently reject requests,
+// without claiming the half-open probe.
+func (b *CircuitBreaker) Blocked() bool {
+	cfg := configuration.Get()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state == BreakerOpen && time.Since(b.openedAt) < cfg.CircuitBreaker.OpenDuration
+}
+
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probeStartedAt = time.Time{}
+	if b.state != BreakerClosed {
+		b.setState(BreakerClosed)
+	}
+}
+
+func (b *CircuitBreaker) RecordFailure(err error) {
+	cfg := configuration.Get()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.failures++
+	b.lastFailureTime = now
+	if err != nil {
+		b.lastFailure = err.Error()
+	}
+
+	if b.state == BreakerHalfOpen || b.failures >= cfg.CircuitBreaker.FailureThreshold {
+		b.openedAt = now
+		b.probeStartedAt = time.Time{}
+		if b.state != BreakerOpen {
+			b.setState(BreakerOpen)
+		}
+	}
+}
+
+func (b *CircuitBreaker) setState(state BreakerState) {
+	logger.Log.WithFields(logrus.Fields{
+		"breaker":  b.name,
+		"from":     b.state,
+		"to":       state,
+		"failures": b.failures,
+	}).Warn("Circuit breaker state changed")
+	b.state = state
+	b.lastStateChange = time.Now()
+}
+
+func (b *CircuitBreaker) Snapshot() BreakerSnapshot {
+	cfg := configuration.Get()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snapshot := BreakerSnapshot{
+		Name:            b.name,
+		State:           b.state,
+		Failures:        b.failures,
+		LastFailure:     b.lastFailure,
+		LastStateChange: b.lastStateChange,
+	}
+	if b.state == BreakerOpen {
+		openedAt := b.openedAt
+		retryAt := b.openedAt.Add(cfg.CircuitBreaker.OpenDuration)
+		snapshot.OpenedAt = &openedAt
+		snapshot.RetryAt = &retryAt
+		if time.Now().After(retryAt) {
+			snapshot.State = BreakerHalfOpen
+		}
+	}
+	if !b.lastFailureTime.IsZero() {
+		lastFailureTime := b.lastFailureTime
+		snapshot.LastFailureTime = &lastFailureTime
+	}
+	return snapshot
+}
+
+// GetCircuitBreakerStates returns a snapshot of every breaker that has seen
+// traffic, sorted by name.
+func GetCircuitBreakerStates() []BreakerSnapshot {
+	circuitBreakers.Lock()
+	breakers := make([]*CircuitBreaker, 0, len(circuitBreakers.breakers))
+	for _, breaker := range circuitBreakers.breakers {
+		breakers = append(breakers, breaker)
+	}
+	circuitBreakers.Unlock()
+
+	snapshots := make([]BreakerSnapshot, 0, len(breakers))
+	for _, breaker := range breakers {
+		snapshots = append(snapshots, breaker.Snapshot())
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Name < snapshots[j].Name
+	})
+	return snapshots
+}
+
+// OpenCircuitBreakers lists the breakers currently rejecting requests.
+func OpenCircuitBreakers(names ...string) []string {
+	if len(names) == 0 {
+		circuitBreakers.Lock()
+		for name := range circuitBreakers.breakers {
+			names = append(names, name)
+		}
+		circuitBreakers.Unlock()
+	}
+
+	var open []string
+	for _, name := range names {
+		if getCircuitBreaker(name).Blocked() {
+			open = append(open, name)
+		}
+	}
+	sort.Strings(open)
+	return open
+}
+
+// openActivisionBreakers lists the open breakers of the Activision
+// endpoints, which stop checks for every user. Captcha breakers only stop
+// the users of that provider.
+func openActivisionBreakers() []string {
+	return OpenCircuitBreakers(BreakerActivisionProfile, BreakerActivisionCheck)
+}
+
+func isUpstreamFailureStatus(statusCode int) bool {
+	return statusCode >= 500 || statusCode == http.StatusTooManyRequests
+}
+
+// doUpstreamRequest sends req through the named breaker, counting transport
+// errors, 5xx and 429 responses as failures.
+func doUpstreamRequest(breakerName string, client *http.Client, req *http.Request) (resp *http.Response, err error) {
+	// The query string is left out of the span because it can carry tokens.
+	ctx, span := tracing.Start(req.Context(), "http."+breakerName,
+		attribute.String("http.method", req.Method),
+		attribute.String("http.host", req.URL.Host),
+		attribute.String("http.path", req.URL.Path),
+	)
+	defer func() { tracing.End(span, err) }()
+	req = req.WithContext(ctx)
+
+	breaker := getCircuitBreaker(breakerName)
+	if err := breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err = client.Do(req)
+	if err != nil {
+		observeUpstreamResponse(breakerName, 0, time.Since(start))
+		breaker.RecordFailure(err)
+		return nil, err
+	}
+	observeUpstreamResponse(breakerName, resp.StatusCode, time.Since(start))
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+
+	if isUpstreamFailureStatus(resp.StatusCode) {
+		breaker.RecordFailure(fmt.Errorf("upstream returned status %d", resp.StatusCode))
+	} else {
+		breaker.RecordSuccess()
+	}
+	return resp, nil
+}
+
+func captchaBreakerForProvider(provider string) string {
+	switch provider {
+	case "ezcaptcha":
+		return BreakerEZCaptcha
+	case "2captcha":
+		return BreakerTwoCaptcha
+	default:
+		return BreakerCapsolver
+	}
+}
+
+func captchaBreakerForURL(url string) string {
+	switch {
+	case strings.Contains(url, "ez-captcha"):
+		return BreakerEZCaptcha
+	case strings.Contains(url, "2captcha"):
+		return BreakerTwoCaptcha
+	default:
+		return BreakerCapsolver
+	}
+}
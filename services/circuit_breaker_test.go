@@ -0,0 +1,200 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/configuration"
+)
+
+const testBreakerCooldown = time.Minute
+
+func setBreakerConfig(t *testing.T, threshold int) {
+	t.Helper()
+	previous := configuration.Get().CircuitBreaker
+	configuration.Update(func(cfg *configuration.Config) {
+		cfg.CircuitBreaker.FailureThreshold = threshold
+		cfg.CircuitBreaker.OpenDuration = testBreakerCooldown
+	})
+	t.Cleanup(func() {
+		configuration.Update(func(cfg *configuration.Config) { cfg.CircuitBreaker = previous })
+	})
+}
+
+// expireCooldown moves the breaker's timestamps back as if the cooldown had
+// passed.
+func expireCooldown(b *CircuitBreaker) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.openedAt.IsZero() {
+		b.openedAt = b.openedAt.Add(-testBreakerCooldown)
+	}
+	if !b.probeStartedAt.IsZero() {
+		b.probeStartedAt = b.probeStartedAt.Add(-testBreakerCooldown)
+	}
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	setBreakerConfig(t, 3)
+	failure := errors.New("upstream returned status 503")
+
+	tests := []struct {
+		name      string
+		run       func(b *CircuitBreaker)
+		wantState BreakerState
+		wantAllow bool
+	}{
+		{
+			name:      "closed below the threshold",
+			run:       func(b *CircuitBreaker) { b.RecordFailure(failure); b.RecordFailure(failure) },
+			wantState: BreakerClosed,
+			wantAllow: true,
+		},
+		{
+			name: "success resets the failure count",
+			run: func(b *CircuitBreaker) {
+				b.RecordFailure(failure)
+				b.RecordFailure(failure)
+				b.RecordSuccess()
+				b.RecordFailure(failure)
+			},
+			wantState: BreakerClosed,
+			wantAllow: true,
+		},
+		{
+			name: "opens at the threshold",
+			run: func(b *CircuitBreaker) {
+				for range 3 {
+					b.RecordFailure(failure)
+				}
+			},
+			wantState: BreakerOpen,
+			wantAllow: false,
+		},
+		{
+			name: "half-open after the cooldown",
+			run: func(b *CircuitBreaker) {
+				for range 3 {
+					b.RecordFailure(failure)
+				}
+				expireCooldown(b)
+			},
+			wantState: BreakerHalfOpen,
+			wantAllow: true,
+		},
+		{
+			name: "closes when the probe succeeds",
+			run: func(b *CircuitBreaker) {
+				for range 3 {
+					b.RecordFailure(failure)
+				}
+				expireCooldown(b)
+				_ = b.Allow()
+				b.RecordSuccess()
+			},
+			wantState: BreakerClosed,
+			wantAllow: true,
+		},
+		{
+			name: "reopens when the probe fails",
+			run: func(b *CircuitBreaker) {
+				for range 3 {
+					b.RecordFailure(failure)
+				}
+				expireCooldown(b)
+				_ = b.Allow()
+				b.RecordFailure(failure)
+			},
+			wantState: BreakerOpen,
+			wantAllow: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &CircuitBreaker{name: "test", state: BreakerClosed}
+			tt.run(b)
+
+			err := b.Allow()
+			if got := err == nil; got != tt.wantAllow {
+				t.Errorf("Allow() = %v, want allowed %v", err, tt.wantAllow)
+			}
+			if err != nil && !errors.Is(err, ErrCircuitOpen) {
+				t.Errorf("Allow() = %v, want ErrCircuitOpen", err)
+			}
+			if b.state != tt.wantState {
+				t.Errorf("state = %s, want %s", b.state, tt.wantState)
+			}
+			if got := b.Blocked(); got != (tt.wantState == BreakerOpen) {
+				t.Errorf("Blocked() = %v in state %s", got, b.state)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerSingleProbe(t *testing.T) {
+	setBreakerConfig(t, 1)
+
+	b := &CircuitBreaker{name: "test", state: BreakerClosed}
+	b.RecordFailure(errors.New("timeout"))
+	expireCooldown(b)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("first call after the cooldown: %v, want the probe allowed", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second call during the probe: %v, want ErrCircuitOpen", err)
+	}
+	if b.Blocked() {
+		t.Error("Blocked() = true while half-open, want false so callers do not skip work")
+	}
+
+	// A probe that never reports back is retried after another cooldown.
+	expireCooldown(b)
+	if err := b.Allow(); err != nil {
+		t.Errorf("call after a stalled probe: %v, want a new probe allowed", err)
+	}
+}
+
+func TestCircuitBreakerCooldown(t *testing.T) {
+	setBreakerConfig(t, 1)
+
+	b := &CircuitBreaker{name: "test", state: BreakerClosed}
+	b.RecordFailure(errors.New("timeout"))
+
+	snapshot := b.Snapshot()
+	if snapshot.State != BreakerOpen || snapshot.RetryAt == nil {
+		t.Fatalf("snapshot = %+v, want open with a retry time", snapshot)
+	}
+	if got := snapshot.RetryAt.Sub(*snapshot.OpenedAt); got != testBreakerCooldown {
+		t.Errorf("retry after %v, want the %v cooldown", got, testBreakerCooldown)
+	}
+
+	expireCooldown(b)
+	if b.Blocked() {
+		t.Error("Blocked() = true after the cooldown")
+	}
+	if got := b.Snapshot().State; got != BreakerHalfOpen {
+		t.Errorf("snapshot state after the cooldown = %s, want %s", got, BreakerHalfOpen)
+	}
+}
+
+func TestOpenActivisionBreakersIgnoresCaptcha(t *testing.T) {
+	setBreakerConfig(t, 1)
+	t.Cleanup(func() {
+		for _, name := range []string{BreakerTwoCaptcha, BreakerActivisionCheck} {
+			getCircuitBreaker(name).RecordSuccess()
+		}
+	})
+
+	getCircuitBreaker(BreakerTwoCaptcha).RecordFailure(errors.New("timeout"))
+	if open := openActivisionBreakers(); len(open) != 0 {
+		t.Errorf("openActivisionBreakers() = %v with only a captcha breaker open", open)
+	}
+
+	getCircuitBreaker(BreakerActivisionCheck).RecordFailure(errors.New("timeout"))
+	if open := openActivisionBreakers(); len(open) != 1 || open[0] != BreakerActivisionCheck {
+		t.Errorf("openActivisionBreakers() = %v, want [%s]", open, BreakerActivisionCheck)
+	}
+}
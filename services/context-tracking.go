@@ -38,6 +38,7 @@ func TrackUserInteraction(s *discordgo.Session, i *discordgo.InteractionCreate)
 	}
 
 	userSettings.UpdateInteractionContext(guildID)
+	rememberDiscordLocale(&userSettings, i)
 
 	if userSettings.InstallationType == "server" {
 		logger.Log.Infof("User %s interacting in %s context (installed in server: %s)",
@@ -68,6 +69,7 @@ func NotifyNewInstallation(s *discordgo.Session, context string) {
 		return
 	}
 
+	s = SessionForDM(s)
 	channel, err := s.UserChannelCreate(developerID)
 	if err != nil {
 		logger.Log.WithError(err).Error("Failed to create DM channel with developer")
@@ -125,7 +127,7 @@ func GetInstallationStats() (serverCount int64, directCount int64, err error) {
 }
 
 func LogInstallationStats(s *discordgo.Session) {
-	guildCount := len(s.State.Guilds)
+	guilds := guildCount(s)
 
 	serverUsers, directUsers, err := GetInstallationStats()
 	if err != nil {
@@ -149,7 +151,7 @@ func LogInstallationStats(s *discordgo.Session) {
 	}
 
 	logger.Log.Infof("Installation Stats: Bot is in %d servers | %d total users (%d active in last 7 days)",
-		guildCount, totalUsers, activeUsers)
+		guilds, totalUsers, activeUsers)
 	logger.Log.Infof("Usage Context: %d users installed in servers | %d users use direct installation",
 		serverUsers, directUsers)
 }
@@ -1,12 +1,18 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/bradselph/CODStatusBot/database"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bradselph/CODStatusBot/tracing"
 	"github.com/bwmarrin/discordgo"
+	"github.com/patrickmn/go-cache"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type InstallContext string
@@ -23,6 +29,34 @@ func GetInstallContext(i *discordgo.InteractionCreate) InstallContext {
 	return DirectContext
 }
 
+// interactionContexts holds the traced context of interactions being handled.
+// Entries outlive the handler so goroutines it starts, such as the delayed
+// check after /addaccount, still join the same trace.
+var interactionContexts = cache.New(10*time.Minute, 5*time.Minute)
+
+// InteractionContext returns a context whose correlation ID is derived from
+// the interaction, so every log line written while handling it can be joined
+// up without passing the context through each handler. Once
+// StartInteractionSpan has run it also carries the interaction's span.
+func InteractionContext(i *discordgo.InteractionCreate) context.Context {
+	if ctx, ok := interactionContexts.Get(i.ID); ok {
+		return ctx.(context.Context)
+	}
+	return logger.WithCorrelationID(context.Background(), "ix-"+i.ID)
+}
+
+// StartInteractionSpan starts a span for handling i and makes it the parent
+// of anything later started from InteractionContext(i).
+func StartInteractionSpan(i *discordgo.InteractionCreate, name string) (context.Context, trace.Span) {
+	ctx, span := tracing.Start(InteractionContext(i), name,
+		attribute.String("discord.interaction_id", i.ID),
+		attribute.String("discord.interaction_type", i.Type.String()),
+		attribute.String("discord.guild_id", i.GuildID),
+	)
+	interactionContexts.SetDefault(i.ID, ctx)
+	return ctx, span
+}
+
 func GetUserID(i *discordgo.InteractionCreate) (string, error) {
 	if i.Member != nil && i.Member.User != nil {
 		return i.Member.User.ID, nil
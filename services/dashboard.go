@@ -0,0 +1,37 @@
+package services
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"path"
+
+	"github.com/bradselph/CODStatusBot/configuration"
+	"github.com/bradselph/CODStatusBot/logger"
+)
+
+//go:embed dashboard/*
+var dashboardFiles embed.FS
+
+// registerDashboard serves the embedded admin dashboard. The static assets
+// are public; every data request the page makes goes through authMiddleware
+// with the key the operator enters.
+func registerDashboard() {
+	cfg := configuration.Get()
+
+	assets, err := fs.Sub(dashboardFiles, "dashboard")
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to load embedded dashboard")
+		return
+	}
+
+	prefix := path.Join("/", cfg.Admin.BasePath, "dashboard") + "/"
+	fileServer := http.StripPrefix(prefix, http.FileServer(http.FS(assets)))
+	http.Handle(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; img-src 'self' data:; frame-ancestors 'none'")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "no-cache")
+		fileServer.ServeHTTP(w, r)
+	}))
+	logger.Log.Infof("Admin dashboard available at %s", prefix)
+}
@@ -0,0 +1,345 @@
+"use strict";
+
+// The dashboard only talks to the existing admin API endpoints and keeps the
+// operator's key in sessionStorage so it disappears with the tab.
+const API = "/api";
+const KEY_STORAGE = "codstatusbot.adminKey";
+
+const $ = (id) => document.getElementById(id);
+
+let users = [];
+let accounts = [];
+
+function apiKey() {
+  return sessionStorage.getItem(KEY_STORAGE) || "";
+}
+
+class ApiError extends Error {
+  constructor(status, message) {
+    super(message);
+    this.status = status;
+  }
+}
+
+async function api(path, params) {
+  const url = new URL(API + path, window.location.origin);
+  Object.entries(params || {}).forEach(([k, v]) => {
+    if (v !== undefined && v !== "") url.searchParams.set(k, v);
+  });
+  const res = await fetch(url, { headers: { "X-API-Key": apiKey() } });
+  if (!res.ok) {
+    const text = (await res.text()).trim();
+    throw new ApiError(res.status, text || res.statusText);
+  }
+  return res.json();
+}
+
+function isoDate(d) {
+  return d.toISOString().slice(0, 10);
+}
+
+function rangeParams() {
+  return { start_date: $("start").value, end_date: $("end").value };
+}
+
+function el(tag, attrs, children) {
+  const node = document.createElement(tag);
+  Object.entries(attrs || {}).forEach(([k, v]) => {
+    if (k === "text") node.textContent = v;
+    else node.setAttribute(k, v);
+  });
+  (children || []).forEach((c) => node.appendChild(c));
+  return node;
+}
+
+function svgEl(tag, attrs) {
+  const node = document.createElementNS("http://www.w3.org/2000/svg", tag);
+  Object.entries(attrs || {}).forEach(([k, v]) => node.setAttribute(k, v));
+  return node;
+}
+
+function fmtNumber(n) {
+  return Number(n || 0).toLocaleString();
+}
+
+function fmtPercent(n) {
+  return Number(n || 0).toFixed(1) + "%";
+}
+
+function fmtUnix(seconds) {
+  if (!seconds) return "-";
+  return new Date(seconds * 1000).toLocaleString();
+}
+
+function statusClass(status) {
+  switch (status) {
+    case "Good":
+      return "status-good";
+    case "Permaban":
+    case "Shadowban":
+    case "Temporary":
+      return "status-bad";
+    default:
+      return "status-warn";
+  }
+}
+
+// lineChart draws one polyline per series; series is [{name, color, values}].
+function lineChart(svg, labels, series) {
+  svg.replaceChildren();
+  const width = svg.clientWidth || 600;
+  const height = svg.clientHeight || 220;
+  const pad = { top: 10, right: 10, bottom: 40, left: 44 };
+  svg.setAttribute("viewBox", `0 0 ${width} ${height}`);
+
+  const max = Math.max(1, ...series.flatMap((s) => s.values));
+  const plotW = width - pad.left - pad.right;
+  const plotH = height - pad.top - pad.bottom;
+  const x = (i) => pad.left + (labels.length > 1 ? (i / (labels.length - 1)) * plotW : plotW / 2);
+  const y = (v) => pad.top + plotH - (v / max) * plotH;
+
+  svg.appendChild(svgEl("line", { class: "axis", x1: pad.left, y1: pad.top + plotH, x2: width - pad.right, y2: pad.top + plotH }));
+  [0, 0.5, 1].forEach((f) => {
+    const t = svgEl("text", { x: pad.left - 6, y: y(max * f) + 4, "text-anchor": "end" });
+    t.textContent = fmtNumber(Math.round(max * f));
+    svg.appendChild(t);
+  });
+
+  const step = Math.max(1, Math.ceil(labels.length / 8));
+  labels.forEach((label, i) => {
+    if (i % step !== 0 && i !== labels.length - 1) return;
+    const t = svgEl("text", { x: x(i), y: height - pad.bottom + 16, "text-anchor": "middle" });
+    t.textContent = label;
+    svg.appendChild(t);
+  });
+
+  series.forEach((s) => {
+    const points = s.values.map((v, i) => `${x(i)},${y(v)}`).join(" ");
+    svg.appendChild(svgEl("polyline", { points, fill: "none", stroke: s.color, "stroke-width": 2 }));
+  });
+
+  const legend = el("div", { class: "legend" }, series.map((s) => {
+    const span = el("span", { text: s.name });
+    span.style.setProperty("--swatch", s.color);
+    return span;
+  }));
+  const old = svg.nextElementSibling;
+  if (old && old.classList.contains("legend")) old.remove();
+  svg.after(legend);
+}
+
+// barList renders horizontal bars; rows is [{label, value, note}].
+function barList(container, rows) {
+  container.replaceChildren();
+  if (rows.length === 0) {
+    container.appendChild(el("p", { text: "No data for this range." }));
+    return;
+  }
+  const max = Math.max(1, ...rows.map((r) => r.value));
+  rows.forEach((r) => {
+    const fill = el("div", { class: "bar-fill" });
+    fill.style.width = (r.value / max) * 100 + "%";
+    container.appendChild(el("div", { class: "bar-row" }, [
+      el("span", { text: r.label, class: r.className || "" }),
+      el("div", { class: "bar-track" }, [fill]),
+      el("span", { class: "bar-value", text: r.note || fmtNumber(r.value) }),
+    ]));
+  });
+}
+
+function card(label, value) {
+  return el("div", { class: "card" }, [
+    el("div", { class: "value", text: value }),
+    el("div", { class: "label", text: label }),
+  ]);
+}
+
+async function loadToday() {
+  const [daily, acct] = await Promise.all([
+    api("/stats/daily", { date: isoDate(new Date()) }),
+    api("/stats/accounts"),
+  ]);
+  $("today").replaceChildren(
+    card("Commands today", fmtNumber(daily.command_count)),
+    card("Checks today", fmtNumber(daily.account_check_count)),
+    card("Status changes today", fmtNumber(daily.status_change_count)),
+    card("Active users today", fmtNumber(daily.unique_users)),
+    card("Accounts", fmtNumber(acct.total)),
+    card("Checks disabled", fmtNumber(acct.disabled)),
+    card("Expired cookies", fmtNumber(acct.expired_cookies)),
+  );
+  barList($("status-breakdown"), (acct.status_breakdown || []).map((s) => ({
+    label: s.status,
+    value: s.count,
+    note: `${fmtNumber(s.count)} (${fmtPercent(s.percentage)})`,
+    className: statusClass(s.status),
+  })));
+}
+
+async function loadTrends() {
+  const data = await api("/stats/trends", { ...rangeParams(), interval: $("interval").value });
+  const trends = data.trends || [];
+  lineChart($("trend-chart"), trends.map((t) => t.day), [
+    { name: "Commands", color: "#5865f2", values: trends.map((t) => t.command_count) },
+    { name: "Account checks", color: "#3ba55d", values: trends.map((t) => t.account_checks) },
+    { name: "Status changes", color: "#ed4245", values: trends.map((t) => t.status_changes) },
+    { name: "Unique users", color: "#faa61a", values: trends.map((t) => t.unique_users) },
+  ]);
+}
+
+// loadCaptcha walks the daily stats endpoint for each day in the range,
+// capped at 31 requests, since it is the only endpoint reporting solves.
+async function loadCaptcha() {
+  const end = $("end").value ? new Date($("end").value) : new Date();
+  const start = $("start").value ? new Date($("start").value) : new Date(end.getTime() - 7 * 86400000);
+  const days = [];
+  for (let d = new Date(start); d <= end && days.length < 31; d.setDate(d.getDate() + 1)) {
+    days.push(isoDate(d));
+  }
+  const stats = await Promise.all(days.map((day) => api("/stats/daily", { date: day })));
+  lineChart($("captcha-chart"), days, [
+    { name: "Solves", color: "#3ba55d", values: stats.map((s) => s.captcha_used || 0) },
+    { name: "Errors", color: "#ed4245", values: stats.map((s) => s.captcha_errors || 0) },
+  ]);
+}
+
+async function loadCommands() {
+  const data = await api("/stats/commands", rangeParams());
+  barList($("command-usage"), (data.commands || []).map((c) => ({
+    label: c.command_name,
+    value: c.count,
+    note: `${fmtNumber(c.count)} · ${fmtPercent(c.success_rate)}`,
+  })));
+}
+
+async function loadStatusChanges() {
+  const data = await api("/stats/status", rangeParams());
+  barList($("status-changes"), (data.status_changes || []).map((s) => ({
+    label: `${s.previous_status || "?"} → ${s.status}`,
+    value: s.count,
+    className: statusClass(s.status),
+  })));
+}
+
+async function loadUsers() {
+  const data = await api("/stats/users", rangeParams());
+  users = data.users || [];
+  renderUsers();
+}
+
+function renderUsers() {
+  const filter = $("user-filter").value.trim().toLowerCase();
+  const body = $("users").tBodies[0];
+  body.replaceChildren();
+  users
+    .filter((u) => !filter || u.user_id.includes(filter) || (u.install_type || "").toLowerCase().includes(filter))
+    .forEach((u) => {
+      const link = el("a", { href: "#", text: u.user_id });
+      link.addEventListener("click", (e) => {
+        e.preventDefault();
+        $("account-user").value = u.user_id;
+        loadAccounts();
+      });
+      body.appendChild(el("tr", {}, [
+        el("td", {}, [link]),
+        el("td", { text: fmtNumber(u.command_count) }),
+        el("td", { text: fmtNumber(u.account_count) }),
+        el("td", { text: u.is_custom_key ? "yes" : "no" }),
+        el("td", { text: u.install_type || "-" }),
+        el("td", { text: u.last_active || "-" }),
+      ]));
+    });
+}
+
+async function loadAccounts() {
+  const userID = $("account-user").value.trim();
+  $("account-error").textContent = "";
+  accounts = [];
+  if (userID) {
+    try {
+      const data = await api("/admin/user", { user_id: userID });
+      accounts = data.accounts || [];
+    } catch (err) {
+      $("account-error").textContent = err.status === 403
+        ? "This key needs the accounts:write scope to view accounts."
+        : `Lookup failed: ${err.message}`;
+    }
+  }
+  renderAccounts();
+}
+
+function renderAccounts() {
+  const filter = $("account-filter").value.trim().toLowerCase();
+  const body = $("accounts").tBodies[0];
+  body.replaceChildren();
+  accounts
+    .filter((a) => !filter ||
+      String(a.ID).includes(filter) ||
+      (a.Title || "").toLowerCase().includes(filter) ||
+      (a.LastStatus || "").toLowerCase().includes(filter))
+    .forEach((a) => {
+      body.appendChild(el("tr", {}, [
+        el("td", { text: String(a.ID) }),
+        el("td", { text: a.Title }),
+        el("td", { text: a.LastStatus, class: statusClass(a.LastStatus) }),
+        el("td", { text: fmtUnix(a.LastCheck) }),
+        el("td", { text: a.IsCheckDisabled ? `disabled (${a.DisabledReason || "no reason"})` : "enabled" }),
+        el("td", { text: a.IsExpiredCookie ? "expired" : "ok" }),
+        el("td", { text: a.LastErrorCategory || "-" }),
+      ]));
+    });
+}
+
+async function refresh() {
+  const results = await Promise.allSettled([
+    loadToday(), loadTrends(), loadCaptcha(), loadCommands(), loadStatusChanges(), loadUsers(),
+  ]);
+  const unauthorized = results.find((r) => r.status === "rejected" && r.reason.status === 401);
+  if (unauthorized) {
+    signOut(unauthorized.reason.message);
+    return;
+  }
+  results
+    .filter((r) => r.status === "rejected")
+    .forEach((r) => console.error("dashboard request failed:", r.reason));
+}
+
+function showDashboard() {
+  $("login").hidden = true;
+  $("dashboard").hidden = false;
+  refresh();
+}
+
+function signOut(message) {
+  sessionStorage.removeItem(KEY_STORAGE);
+  $("dashboard").hidden = true;
+  $("login").hidden = false;
+  $("login-error").textContent = message || "";
+}
+
+document.addEventListener("DOMContentLoaded", () => {
+  const today = new Date();
+  $("end").value = isoDate(today);
+  $("start").value = isoDate(new Date(today.getTime() - 7 * 86400000));
+
+  $("login-form").addEventListener("submit", (e) => {
+    e.preventDefault();
+    sessionStorage.setItem(KEY_STORAGE, $("api-key").value.trim());
+    $("api-key").value = "";
+    showDashboard();
+  });
+  $("logout").addEventListener("click", () => signOut());
+  $("range").addEventListener("submit", (e) => {
+    e.preventDefault();
+    refresh();
+  });
+  $("user-filter").addEventListener("input", renderUsers);
+  $("account-filter").addEventListener("input", renderAccounts);
+  $("account-search").addEventListener("submit", (e) => {
+    e.preventDefault();
+    loadAccounts();
+  });
+
+  if (apiKey()) showDashboard();
+  else signOut();
+});
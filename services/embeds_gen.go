@@ -1,61 +1,40 @@
 package services
 
 import (
-	"fmt"
 	"strings"
 	"time"
 
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/models"
 	"github.com/bwmarrin/discordgo"
 )
 
-func CreateAnnouncementEmbed() *discordgo.MessageEmbed {
+// CapsolverSignupURL is the Capsolver registration link with the bot's
+// referral code.
+const CapsolverSignupURL = "https://dashboard.capsolver.com/passport/register?inviteCode=6YjROhACQnvP"
+
+func CreateAnnouncementEmbed(locale string) *discordgo.MessageEmbed {
+	field := func(name, value string, args ...interface{}) *discordgo.MessageEmbedField {
+		return &discordgo.MessageEmbedField{
+			Name:  i18n.T(locale, name),
+			Value: i18n.T(locale, value, args...),
+		}
+	}
+
 	return &discordgo.MessageEmbed{
-		Title:       "Important Update: Changes to COD Status Bot's Captcha Service",
-		Description: "I am excited to announce that I have upgraded the captcha service to provide better reliability and performance:",
+		Title:       i18n.T(locale, "announcement.title"),
+		Description: i18n.T(locale, "announcement.description"),
 		Color:       0xFFD700,
 		Fields: []*discordgo.MessageEmbedField{
-			{
-				Name: "What's Changing",
-				Value: "• I have switched to Capsolver as our primary captcha service provider\n" +
-					"• The bot now uses Capsolver's API for improved reliability\n" +
-					"• Users can still provide their own API keys for unlimited checks",
-			},
-			{
-				Name: "How to Get Your Own API Key",
-				Value: "1. Sign up at [Capsolver](https://dashboard.capsolver.com/passport/register?inviteCode=6YjROhACQnvP)\n" +
-					"2. Purchase credits (starting at just $0.001 per solve)\n" +
-					"3. Use the `/setcaptchaservice` command to set your API key in the bot",
-			},
-			{
-				Name: "Existing EZCaptcha Users",
-				Value: "• If you have an existing EZCaptcha key, it will continue to work\n" +
-					"• However, we highly recommend switching to Capsolver for:\n" +
-					"  - Better reliability and faster solves\n" +
-					"  - Lower cost per solve\n" +
-					"  - Improved enterprise support",
-			},
-			{
-				Name: "Benefits of Using Your Own API Key",
-				Value: "• Uninterrupted access to the check now feature\n" +
-					"• Ability to customize check intervals\n" +
-					"• Support the bot's development through our referral program",
-			},
-			{
-				Name: "Next Steps",
-				Value: "1. Visit Capsolver to obtain your API key\n" +
-					"2. Set up your key using the `/setcaptchaservice` command\n" +
-					"3. Adjust your check interval preferences if desired",
-			},
-			{
-				Name: "Our Commitment",
-				Value: "I am committed to providing the most reliable and cost-effective service.\n" +
-					"I have tried to maintain compatibility with EZCaptcha for existing users, but\n" +
-					"I strongly recommend Capsolver for its superior performance and cost benefits.",
-			},
+			field("announcement.changing.name", "announcement.changing.value"),
+			field("announcement.get_key.name", "announcement.get_key.value", CapsolverSignupURL),
+			field("announcement.ezcaptcha.name", "announcement.ezcaptcha.value"),
+			field("announcement.benefits.name", "announcement.benefits.value"),
+			field("announcement.next.name", "announcement.next.value"),
+			field("announcement.commitment.name", "announcement.commitment.value"),
 		},
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Thank you for using CODStatusbot!",
+			Text: i18n.T(locale, "announcement.footer"),
 		},
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
@@ -82,31 +61,36 @@ func GetColorForStatus(status models.Status, isExpiredCookie bool, isCheckDisabl
 	}
 }
 
-func EmbedTitleFromStatus(status models.Status) string {
+// StatusName is the localized name of an account status.
+func StatusName(locale string, status models.Status) string {
+	return i18n.T(locale, "status.name."+strings.ToLower(string(status)))
+}
+
+func EmbedTitleFromStatus(locale string, status models.Status) string {
 	switch status {
 	case models.StatusTempban:
-		return "TEMPORARY BAN DETECTED"
+		return i18n.T(locale, "status.title.tempban")
 	case models.StatusPermaban:
-		return "PERMANENT BAN DETECTED"
+		return i18n.T(locale, "status.title.permaban")
 	case models.StatusShadowban:
-		return "ACCOUNT UNDER REVIEW (SHADOWBAN)"
+		return i18n.T(locale, "status.title.shadowban")
 	default:
-		return "ACCOUNT NOT BANNED"
+		return i18n.T(locale, "status.title.good")
 	}
 }
 
-func GetStatusDescription(status models.Status, accountTitle string, ban models.Ban) string {
+func GetStatusDescription(locale string, status models.Status, accountTitle string, ban models.Ban) string {
 	affectedGames := strings.Split(ban.AffectedGames, ",")
 	gamesList := strings.Join(affectedGames, ", ")
 
 	switch status {
 	case models.StatusPermaban:
-		return fmt.Sprintf("The account %s has been permanently banned.\nAffected games: %s", accountTitle, gamesList)
+		return i18n.T(locale, "status.description.permaban", accountTitle, gamesList)
 	case models.StatusShadowban:
-		return fmt.Sprintf("The account %s has been placed under review (shadowban).\nAffected games: %s", accountTitle, gamesList)
+		return i18n.T(locale, "status.description.shadowban", accountTitle, gamesList)
 	case models.StatusTempban:
-		return fmt.Sprintf("The account %s is temporarily banned for %s.\nAffected games: %s", accountTitle, ban.TempBanDuration, gamesList)
+		return i18n.T(locale, "status.description.tempban", accountTitle, ban.TempBanDuration, gamesList)
 	default:
-		return fmt.Sprintf("The account %s is currently not banned.", accountTitle)
+		return i18n.T(locale, "status.description.good", accountTitle)
 	}
 }
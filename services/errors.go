@@ -0,0 +1,105 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/i18n"
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	ErrInsufficientBalance = errors.New("insufficient captcha balance")
+	ErrCaptchaKeyInvalid   = errors.New("invalid captcha API key")
+	ErrCaptchaRejected     = errors.New("captcha rejected by Activision")
+	ErrCookieInvalid       = errors.New("SSO cookie invalid")
+	ErrRateLimited         = errors.New("rate limited")
+	ErrUpstreamUnavailable = errors.New("upstream service unavailable")
+	// ErrCaptchaTransport marks failures talking to a captcha provider, as
+	// opposed to the provider rejecting or failing the task.
+	ErrCaptchaTransport = errors.New("captcha provider unreachable")
+)
+
+// Is lets errors.Is match the sentinel that corresponds to the check error's
+// category, so callers do not need to know about CheckError.
+func (e *CheckError) Is(target error) bool {
+	switch e.Category {
+	case CheckErrorUpstream:
+		return target == ErrUpstreamUnavailable
+	case CheckErrorRateLimited:
+		return target == ErrRateLimited
+	case CheckErrorCookieInvalid:
+		return target == ErrCookieInvalid
+	default:
+		return false
+	}
+}
+
+type UserError struct {
+	Title       string
+	Description string
+	Remediation []string
+	Color       int
+}
+
+// errorCatalog maps sentinels to the message IDs of their user error. Each
+// key has a .title, .description and .fix message; .fix holds one
+// remediation step per line.
+var errorCatalog = []struct {
+	target error
+	key    string
+	color  int
+}{
+	{ErrInsufficientBalance, "usererror.balance", 0xFFA500},
+	{ErrCaptchaKeyInvalid, "usererror.key_invalid", 0xFFA500},
+	{ErrCaptchaRejected, "usererror.captcha_rejected", 0xFFA500},
+	{ErrCookieInvalid, "usererror.cookie_invalid", 0xFF0000},
+	{ErrRateLimited, "usererror.rate_limited", 0xFFA500},
+	{ErrUpstreamUnavailable, "usererror.upstream", 0x808080},
+	{ErrCaptchaTransport, "usererror.upstream", 0x808080},
+}
+
+const genericUserError = "usererror.generic"
+
+// LookupUserError maps err to the friendly message shown to users.
+func LookupUserError(locale string, err error) UserError {
+	key, color := genericUserError, 0xFF0000
+	for _, entry := range errorCatalog {
+		if errors.Is(err, entry.target) {
+			key, color = entry.key, entry.color
+			break
+		}
+	}
+	return UserError{
+		Title:       i18n.T(locale, key+".title"),
+		Description: i18n.T(locale, key+".description"),
+		Remediation: strings.Split(i18n.T(locale, key+".fix"), "\n"),
+		Color:       color,
+	}
+}
+
+// UserErrorEmbed renders err as an embed with remediation steps. Raw error
+// text is never included.
+func UserErrorEmbed(locale string, err error) *discordgo.MessageEmbed {
+	userError := LookupUserError(locale, err)
+
+	remediation := make([]string, 0, len(userError.Remediation))
+	for _, step := range userError.Remediation {
+		remediation = append(remediation, "• "+step)
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       userError.Title,
+		Description: userError.Description,
+		Color:       userError.Color,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   i18n.T(locale, "usererror.how_to_fix"),
+				Value:  strings.Join(remediation, "\n"),
+				Inline: false,
+			},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,447 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/configuration"
+	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bwmarrin/discordgo"
+)
+
+type HealthState string
+
+const (
+	HealthOK       HealthState = "ok"
+	HealthDegraded HealthState = "degraded"
+	HealthDown     HealthState = "down"
+)
+
+const (
+	healthCheckTimeout          = 5 * time.Second
+	captchaHealthCacheDuration  = 5 * time.Minute
+	notificationBacklogDegraded = 100
+)
+
+type ComponentHealth struct {
+	Name      string      `json:"name"`
+	Status    HealthState `json:"status"`
+	Critical  bool        `json:"critical"`
+	LatencyMs int64       `json:"latency_ms"`
+	Detail    string      `json:"detail,omitempty"`
+}
+
+type HealthReport struct {
+	Status     HealthState       `json:"status"`
+	Time       time.Time         `json:"time"`
+	Leader     *LeaderInfo       `json:"leader,omitempty"`
+	Components []ComponentHealth `json:"components"`
+}
+
+// Ready reports whether every critical component is up.
+func (r HealthReport) Ready() bool {
+	return r.Status != HealthDown
+}
+
+var healthState = struct {
+	sync.RWMutex
+	session              *discordgo.Session
+	startedAt            time.Time
+	lastScheduledRun     time.Time
+	lastScheduledSuccess time.Time
+	captcha              []ComponentHealth
+	captchaCheckedAt     time.Time
+}{
+	startedAt: time.Now(),
+}
+
+// SetDiscordSession gives the admin API and readiness checks access to the
+// gateway session.
+func SetDiscordSession(s *discordgo.Session) {
+	healthState.Lock()
+	healthState.session = s
+	healthState.Unlock()
+}
+
+func currentDiscordSession() *discordgo.Session {
+	healthState.RLock()
+	defer healthState.RUnlock()
+	return healthState.session
+}
+
+func markScheduledRun() {
+	healthState.Lock()
+	healthState.lastScheduledRun = time.Now()
+	healthState.Unlock()
+}
+
+func markScheduledCheckSuccess() {
+	healthState.Lock()
+	healthState.lastScheduledSuccess = time.Now()
+	healthState.Unlock()
+}
+
+// RunHealthChecks probes every component and returns the combined report.
+// s may be nil, in which case the session registered with SetDiscordSession
+// is used.
+func RunHealthChecks(ctx context.Context, s *discordgo.Session) HealthReport {
+	if s == nil {
+		s = currentDiscordSession()
+	}
+
+	components := []ComponentHealth{checkDatabaseHealth(ctx)}
+	if CurrentRole() == RoleAll {
+		components = append(components, checkDiscordHealth(s), checkSchedulerHealth())
+	} else {
+		// Split deployments report the shared queue instead, since the
+		// gateway and the scheduler run in another process.
+		components = append(components, checkJobQueueHealth(ctx))
+	}
+	components = append(components, checkCaptchaHealth()...)
+	components = append(components, checkNotificationHealth(), checkUpstreamHealth())
+
+	leader, leaderHealth := checkLeaderHealth()
+	components = append(components, leaderHealth)
+
+	report := HealthReport{
+		Status:     HealthOK,
+		Time:       time.Now(),
+		Leader:     leader,
+		Components: components,
+	}
+	for _, component := range components {
+		switch {
+		case component.Status == HealthDown && component.Critical:
+			report.Status = HealthDown
+		case component.Status != HealthOK && report.Status == HealthOK:
+			report.Status = HealthDegraded
+		}
+	}
+	return report
+}
+
+func checkDatabaseHealth(ctx context.Context) ComponentHealth {
+	component := ComponentHealth{Name: "database", Critical: true}
+	start := time.Now()
+
+	if database.DB == nil {
+		component.Status = HealthDown
+		component.Detail = "not connected"
+		return component
+	}
+
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		component.Status = HealthDown
+		component.Detail = err.Error()
+		return component
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	err = sqlDB.PingContext(pingCtx)
+	component.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		component.Status = HealthDown
+		component.Detail = err.Error()
+		return component
+	}
+
+	stats := sqlDB.Stats()
+	component.Status = HealthOK
+	component.Detail = fmt.Sprintf("%d open, %d in use, %d idle", stats.OpenConnections, stats.InUse, stats.Idle)
+	return component
+}
+
+func checkDiscordHealth(s *discordgo.Session) ComponentHealth {
+	component := ComponentHealth{Name: "discord", Critical: true}
+
+	if s == nil {
+		component.Status = HealthDown
+		component.Detail = "session not started"
+		return component
+	}
+
+	sessions := AllSessions(s)
+	var down []string
+	for _, session := range sessions {
+		if latency := session.HeartbeatLatency().Milliseconds(); latency > component.LatencyMs {
+			component.LatencyMs = latency
+		}
+		if !session.DataReady {
+			down = append(down, strconv.Itoa(session.ShardID))
+		}
+	}
+
+	switch {
+	case len(down) > 0 && len(sessions) > 1:
+		component.Status = HealthDown
+		component.Detail = fmt.Sprintf("%d of %d shards disconnected (%s)", len(down), len(sessions), strings.Join(down, ", "))
+	case len(down) > 0:
+		component.Status = HealthDown
+		component.Detail = "gateway disconnected"
+	case len(sessions) > 1:
+		component.Status = HealthOK
+		component.Detail = fmt.Sprintf("gateway connected on %d shards", len(sessions))
+	default:
+		component.Status = HealthOK
+		component.Detail = "gateway connected"
+	}
+	return component
+}
+
+func checkSchedulerHealth() ComponentHealth {
+	cfg := configuration.Get()
+	component := ComponentHealth{Name: "scheduled_checks"}
+
+	healthState.RLock()
+	startedAt := healthState.startedAt
+	lastRun := healthState.lastScheduledRun
+	lastSuccess := healthState.lastScheduledSuccess
+	healthState.RUnlock()
+
+	interval := cfg.Intervals.Check
+	if cfg.Intervals.Sleep > interval {
+		interval = cfg.Intervals.Sleep
+	}
+	staleAfter := 3 * time.Duration(interval) * time.Minute
+
+	switch {
+	case IsCheckingPausedForDrift():
+		component.Status = HealthDegraded
+		component.Detail = "paused for schema drift"
+	case IsSchedulerPaused():
+		component.Status = HealthDegraded
+		component.Detail = "paused by an admin"
+	case lastRun.IsZero() && time.Since(startedAt) > staleAfter:
+		component.Status = HealthDegraded
+		component.Detail = "no scheduled run since startup"
+	case !lastRun.IsZero() && time.Since(lastRun) > staleAfter:
+		component.Status = HealthDegraded
+		component.Detail = fmt.Sprintf("last run %s ago", time.Since(lastRun).Round(time.Second))
+	default:
+		component.Status = HealthOK
+		if lastSuccess.IsZero() {
+			component.Detail = "no successful check since startup"
+		} else {
+			component.Detail = fmt.Sprintf("last successful check %s ago", time.Since(lastSuccess).Round(time.Second))
+		}
+	}
+	return component
+}
+
+func checkJobQueueHealth(ctx context.Context) ComponentHealth {
+	component := ComponentHealth{Name: "job_queue"}
+	if database.DB == nil {
+		component.Status = HealthDown
+		component.Detail = "database not connected"
+		return component
+	}
+
+	db := database.DB.WithContext(ctx)
+	var pendingJobs, pendingMessages int64
+	var oldest models.CheckJob
+	if err := db.Model(&models.CheckJob{}).Where("status = ?", jobPending).Count(&pendingJobs).Error; err != nil {
+		component.Status = HealthDegraded
+		component.Detail = err.Error()
+		return component
+	}
+	if err := db.Model(&models.OutboundMessage{}).Where("status = ?", outboxPending).Count(&pendingMessages).Error; err != nil {
+		component.Status = HealthDegraded
+		component.Detail = err.Error()
+		return component
+	}
+
+	component.Status = HealthOK
+	component.Detail = fmt.Sprintf("%d pending check jobs, %d undelivered messages", pendingJobs, pendingMessages)
+	if pendingJobs == 0 {
+		return component
+	}
+
+	cfg := configuration.Get()
+	staleAfter := 3 * time.Duration(cfg.Intervals.Check) * time.Minute
+	if err := db.Where("status = ?", jobPending).Order("id").First(&oldest).Error; err == nil && time.Since(oldest.CreatedAt) > staleAfter {
+		component.Status = HealthDegraded
+		component.Detail = fmt.Sprintf("%s; oldest job waiting %s, workers may be down", component.Detail, time.Since(oldest.CreatedAt).Round(time.Second))
+	}
+	return component
+}
+
+func checkLeaderHealth() (*LeaderInfo, ComponentHealth) {
+	component := ComponentHealth{Name: "scheduler_leader"}
+	if database.DB == nil {
+		component.Status = HealthDown
+		component.Detail = "database not connected"
+		return nil, component
+	}
+
+	info, held, err := CurrentLeader(SchedulerElection)
+	switch {
+	case err != nil:
+		component.Status = HealthDegraded
+		component.Detail = err.Error()
+		return nil, component
+	case !held:
+		component.Status = HealthDegraded
+		component.Detail = "no leader, periodic jobs are not running"
+		return nil, component
+	}
+
+	component.Status = HealthOK
+	component.Detail = fmt.Sprintf("%s (term %d)", info.Holder, info.Token)
+	if info.Self {
+		component.Detail += ", this instance"
+	}
+	return &info, component
+}
+
+// checkCaptchaHealth queries the balance of every enabled default provider.
+// Results are cached so readiness probes do not spend provider quota.
+func checkCaptchaHealth() []ComponentHealth {
+	healthState.RLock()
+	if time.Since(healthState.captchaCheckedAt) < captchaHealthCacheDuration && healthState.captcha != nil {
+		cached := append([]ComponentHealth(nil), healthState.captcha...)
+		healthState.RUnlock()
+		return cached
+	}
+	healthState.RUnlock()
+
+	cfg := configuration.Get()
+	providers := []struct {
+		name string
+		key  string
+	}{
+		{"capsolver", cfg.CaptchaService.Capsolver.ClientKey},
+		{"ezcaptcha", cfg.CaptchaService.EZCaptcha.ClientKey},
+		{"2captcha", cfg.CaptchaService.TwoCaptcha.ClientKey},
+	}
+
+	var components []ComponentHealth
+	reachable := 0
+	for _, provider := range providers {
+		if !IsServiceEnabled(provider.name) || provider.key == "" {
+			continue
+		}
+
+		component := ComponentHealth{Name: "captcha_" + provider.name}
+		start := time.Now()
+		isValid, balance, err := ValidateCaptchaKey(provider.key, provider.name)
+		component.LatencyMs = time.Since(start).Milliseconds()
+
+		switch {
+		case err != nil:
+			component.Status = HealthDown
+			component.Detail = err.Error()
+		case !isValid:
+			component.Status = HealthDown
+			component.Detail = "API key rejected"
+		case balance < getBalanceThreshold(provider.name):
+			reachable++
+			component.Status = HealthDegraded
+			component.Detail = fmt.Sprintf("balance %.2f below threshold %.2f", balance, getBalanceThreshold(provider.name))
+		default:
+			reachable++
+			component.Status = HealthOK
+			component.Detail = fmt.Sprintf("balance %.2f", balance)
+		}
+		components = append(components, component)
+	}
+
+	summary := ComponentHealth{Name: "captcha", Critical: true, Status: HealthOK}
+	switch {
+	case len(components) == 0:
+		summary.Status = HealthDown
+		summary.Detail = "no captcha providers enabled"
+	case reachable == 0:
+		summary.Status = HealthDown
+		summary.Detail = "no captcha provider reachable"
+	default:
+		summary.Detail = fmt.Sprintf("%d of %d providers reachable", reachable, len(components))
+	}
+	components = append([]ComponentHealth{summary}, components...)
+
+	healthState.Lock()
+	healthState.captcha = components
+	healthState.captchaCheckedAt = time.Now()
+	healthState.Unlock()
+
+	return append([]ComponentHealth(nil), components...)
+}
+
+func checkNotificationHealth() ComponentHealth {
+	component := ComponentHealth{Name: "notification_queue", Status: HealthOK}
+
+	notificationQueue.mutex.RLock()
+	depth := len(notificationQueue.items)
+	notificationQueue.mutex.RUnlock()
+
+	component.Detail = fmt.Sprintf("%d pending", depth)
+	if depth > notificationBacklogDegraded {
+		component.Status = HealthDegraded
+	}
+	return component
+}
+
+func checkUpstreamHealth() ComponentHealth {
+	component := ComponentHealth{Name: "circuit_breakers", Status: HealthOK, Detail: "all closed"}
+	if open := OpenCircuitBreakers(); len(open) > 0 {
+		component.Status = HealthDegraded
+		component.Detail = "open: " + strings.Join(open, ", ")
+	}
+	return component
+}
+
+// HealthReportEmbed renders report for the /health command.
+func HealthReportEmbed(report HealthReport) *discordgo.MessageEmbed {
+	fields := make([]*discordgo.MessageEmbedField, 0, len(report.Components))
+	for _, component := range report.Components {
+		name := fmt.Sprintf("%s %s", healthStateEmoji(component.Status), component.Name)
+		if component.Critical {
+			name += " (critical)"
+		}
+		value := string(component.Status)
+		if component.LatencyMs > 0 {
+			value += fmt.Sprintf(" • %dms", component.LatencyMs)
+		}
+		if component.Detail != "" {
+			value += "\n" + component.Detail
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   name,
+			Value:  value,
+			Inline: true,
+		})
+	}
+
+	color := 0x00ff00
+	switch report.Status {
+	case HealthDegraded:
+		color = 0xFFA500
+	case HealthDown:
+		color = 0xFF0000
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "Bot Health",
+		Description: fmt.Sprintf("Overall status: **%s**", report.Status),
+		Color:       color,
+		Fields:      fields,
+		Timestamp:   report.Time.Format(time.RFC3339),
+	}
+}
+
+func healthStateEmoji(state HealthState) string {
+	switch state {
+	case HealthOK:
+		return "🟢"
+	case HealthDegraded:
+		return "🟡"
+	default:
+		return "🔴"
+	}
+}
@@ -1,7 +1,7 @@
 package services
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -11,9 +11,12 @@ import (
 
 	"github.com/bradselph/CODStatusBot/configuration"
 	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bradselph/CODStatusBot/tracing"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type AccountValidationResult struct {
@@ -30,14 +33,34 @@ func init() {
 	logger.Log.Infof("Initialized endpoints: Profile URL: %s", cfg.API.ProfileEndpoint)
 }
 
+// VerifySSOCookie reports whether ssoCookie is accepted by the profile
+// endpoint. It is false whenever the cookie could not be verified, including
+// during outages.
 func VerifySSOCookie(ssoCookie string) bool {
+	return verifySSOCookie(context.Background(), ssoCookie) == nil
+}
+
+// verifyRetryDelay is how much longer verifySSOCookie waits before each
+// retry.
+var verifyRetryDelay = time.Second
+
+// verifySSOCookie asks the profile endpoint about ssoCookie. The error is a
+// *CheckError: cookie_invalid only when Activision rejects the cookie with a
+// 401 or 403, upstream_outage for 5xx, 429 and transport errors.
+func verifySSOCookie(ctx context.Context, ssoCookie string) (err error) {
+	ctx, span := tracing.Start(ctx, "activision.verify_cookie")
+	defer func() {
+		span.SetAttributes(attribute.Bool("cookie.valid", err == nil))
+		span.End()
+	}()
+
 	cfg := configuration.Get()
-	logger.Log.Infof("Starting SSO cookie verification for cookie: %s", ssoCookie)
+	logger.Log.Infof("Starting SSO cookie verification (cookie length %d)", len(ssoCookie))
 
 	profileURL := cfg.API.ProfileEndpoint
 	if profileURL == "" {
 		logger.Log.Error("PROFILE_ENDPOINT not configured")
-		return false
+		return newCheckError(CheckErrorUnknown, errors.New("PROFILE_ENDPOINT not configured"))
 	}
 
 	client := GetLongTimeoutHTTPClient()
@@ -45,9 +68,9 @@ func VerifySSOCookie(ssoCookie string) bool {
 	var lastError error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		req, err := http.NewRequest("GET", profileURL, nil)
+		req, err := http.NewRequestWithContext(ctx, "GET", profileURL, nil)
 		if err != nil {
-			lastError = fmt.Errorf("error creating verification request (attempt %d/%d): %w", attempt, maxRetries, err)
+			lastError = newCheckError(CheckErrorUnknown, fmt.Errorf("error creating verification request (attempt %d/%d): %w", attempt, maxRetries, err))
 			logger.Log.WithError(err).Error("Error creating verification request")
 			continue
 		}
@@ -58,11 +81,14 @@ func VerifySSOCookie(ssoCookie string) bool {
 		}
 
 		logger.Log.Infof("Sending verification request to: %s (attempt %d/%d)", profileURL, attempt, maxRetries)
-		resp, err := client.Do(req)
+		resp, err := doUpstreamRequest(BreakerActivisionProfile, client, req)
 		if err != nil {
-			lastError = fmt.Errorf("error sending verification request (attempt %d/%d): %w", attempt, maxRetries, err)
+			lastError = newCheckError(CheckErrorUpstream, fmt.Errorf("error sending verification request (attempt %d/%d): %w", attempt, maxRetries, err))
+			if errors.Is(err, ErrCircuitOpen) {
+				break
+			}
 			logger.Log.WithError(err).Error("Error sending verification request")
-			time.Sleep(time.Duration(attempt) * time.Second)
+			time.Sleep(time.Duration(attempt) * verifyRetryDelay)
 			continue
 		}
 
@@ -75,43 +101,77 @@ func VerifySSOCookie(ssoCookie string) bool {
 			}(resp.Body)
 		}
 
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			logger.Log.WithField("statusCode", resp.StatusCode).Info("SSO cookie rejected by profile endpoint")
+			return newCheckError(CheckErrorCookieInvalid, fmt.Errorf("profile endpoint rejected the cookie with status %d", resp.StatusCode))
+		}
+
 		if resp.StatusCode != http.StatusOK {
-			lastError = fmt.Errorf("invalid status code (attempt %d/%d): %d", attempt, maxRetries, resp.StatusCode)
+			category := CheckErrorUnknown
+			if isUpstreamFailureStatus(resp.StatusCode) {
+				category = CheckErrorUpstream
+			}
+			lastError = newCheckError(category, fmt.Errorf("invalid status code (attempt %d/%d): %d", attempt, maxRetries, resp.StatusCode))
 			logger.Log.WithFields(logrus.Fields{
 				"statusCode": resp.StatusCode,
 				"attempt":    attempt,
 			}).Error("Invalid status code in SSO verification")
-			time.Sleep(time.Duration(attempt) * time.Second)
+			time.Sleep(time.Duration(attempt) * verifyRetryDelay)
 			continue
 		}
 
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
-			lastError = fmt.Errorf("error reading response body (attempt %d/%d): %w", attempt, maxRetries, err)
+			lastError = newCheckError(CheckErrorUpstream, fmt.Errorf("error reading response body (attempt %d/%d): %w", attempt, maxRetries, err))
 			logger.Log.WithError(err).Error("Error reading response body")
-			time.Sleep(time.Duration(attempt) * time.Second)
+			time.Sleep(time.Duration(attempt) * verifyRetryDelay)
 			continue
 		}
 
 		if len(body) == 0 {
-			lastError = fmt.Errorf("empty response body (attempt %d/%d)", attempt, maxRetries)
+			lastError = newCheckError(CheckErrorParse, fmt.Errorf("empty response body (attempt %d/%d)", attempt, maxRetries))
 			logger.Log.Error("Empty response body in SSO verification")
-			time.Sleep(time.Duration(attempt) * time.Second)
+			time.Sleep(time.Duration(attempt) * verifyRetryDelay)
 			continue
 		}
 
 		logger.Log.Info("SSO cookie verified successfully")
-		return true
+		return nil
 	}
 
 	logger.Log.WithError(lastError).Error("SSO cookie verification failed after all retries")
-	return false
+	return lastError
+}
+
+// CheckBlocked reports why a check for a user with settings cannot run
+// right now: checks paused for schema drift, or an open breaker on the
+// Activision endpoints or the user's captcha provider. Callers that charge
+// a quota for checks should ask first so a check that never runs is free.
+func CheckBlocked(settings models.UserSettings) error {
+	if IsCheckingPausedForDrift() {
+		return newCheckError(CheckErrorParse, fmt.Errorf("account checks paused: upstream schema drift awaiting admin acknowledgement"))
+	}
+	open := openActivisionBreakers()
+	if captchaBreaker := captchaBreakerForProvider(settings.PreferredCaptchaProvider); getCircuitBreaker(captchaBreaker).Blocked() {
+		open = append(open, captchaBreaker)
+	}
+	if len(open) > 0 {
+		return newCheckError(CheckErrorUpstream, fmt.Errorf("%w: %s", ErrCircuitOpen, strings.Join(open, ", ")))
+	}
+	return nil
 }
 
-func CheckAccount(ssoCookie string, userID string, captchaAPIKey string) (models.Status, error) {
+func CheckAccount(ctx context.Context, ssoCookie string, userID string, captchaAPIKey string) (status models.Status, err error) {
 	startTime := time.Now()
 	cfg := configuration.Get()
-	logger.Log.Info("Starting CheckAccount function")
+	ctx, span := tracing.Start(ctx, "account.check", attribute.String("user_id", userID))
+	log := logger.FromContext(ctx).WithField("user_id", userID)
+	log.Info("Starting CheckAccount function")
+	defer func() {
+		observeAccountCheck(status, err, time.Since(startTime))
+		span.SetAttributes(attribute.String("account.status", string(status)))
+		tracing.End(span, err)
+	}()
 
 	var accountID uint = 0
 	var captchaProvider string = ""
@@ -119,7 +179,7 @@ func CheckAccount(ssoCookie string, userID string, captchaAPIKey string) (models
 
 	// Find accountID based on SSO cookie
 	var account models.Account
-	if result := database.DB.Where("sso_cookie = ?", ssoCookie).First(&account); result.Error == nil {
+	if result := database.DB.WithContext(ctx).Where("sso_cookie = ?", ssoCookie).First(&account); result.Error == nil {
 		accountID = account.ID
 	}
 
@@ -128,14 +188,21 @@ func CheckAccount(ssoCookie string, userID string, captchaAPIKey string) (models
 		return models.StatusUnknown, fmt.Errorf("failed to get user settings: %w", err)
 	}
 
-	if !VerifySSOCookie(ssoCookie) {
-		return models.StatusInvalidCookie, nil
+	if err := CheckBlocked(userSettings); err != nil {
+		return models.StatusUnknown, err
+	}
+
+	if err := verifySSOCookie(ctx, ssoCookie); err != nil {
+		if ClassifyCheckError(err) == CheckErrorCookieInvalid {
+			return models.StatusInvalidCookie, nil
+		}
+		return models.StatusUnknown, err
 	}
 
 	if !IsServiceEnabled("ezcaptcha") &&
 		!IsServiceEnabled("capsolver") &&
 		!IsServiceEnabled("2captcha") {
-		return models.StatusUnknown, fmt.Errorf("no captcha services are currently enabled")
+		return models.StatusUnknown, newCheckError(CheckErrorCaptcha, fmt.Errorf("no captcha services are currently enabled"))
 	}
 
 	if !IsServiceEnabled(userSettings.PreferredCaptchaProvider) {
@@ -149,54 +216,54 @@ func CheckAccount(ssoCookie string, userID string, captchaAPIKey string) (models
 			userSettings.PreferredCaptchaProvider = "2captcha"
 			database.DB.Save(&userSettings)
 		} else {
-			return models.StatusUnknown, fmt.Errorf("no captcha services are currently enabled")
+			return models.StatusUnknown, newCheckError(CheckErrorCaptcha, fmt.Errorf("no captcha services are currently enabled"))
 		}
 	}
 
-	isUsingDefaultKey := userSettings.CapSolverAPIKey == "" &&
-		userSettings.EZCaptchaAPIKey == "" &&
-		userSettings.TwoCaptchaAPIKey == ""
-
-	if isUsingDefaultKey {
-		if !validateRateLimit(userID, "check_account", cfg.RateLimits.CheckNow) {
-			return models.StatusUnknown, fmt.Errorf("rate limit exceeded for default key users")
-		}
+	captchaBreaker := captchaBreakerForProvider(userSettings.PreferredCaptchaProvider)
+	if getCircuitBreaker(captchaBreaker).Blocked() {
+		return models.StatusUnknown, newCheckError(CheckErrorUpstream, fmt.Errorf("%w: %s", ErrCircuitOpen, captchaBreaker))
 	}
 
 	solver, err := GetCaptchaSolver(userID)
 	if err != nil {
-		if strings.Contains(err.Error(), "insufficient balance") {
-			if err := DisableUserCaptcha(nil, userID, "Insufficient balance"); err != nil {
-				logger.Log.WithError(err).Error("Failed to disable user captcha service")
+		if errors.Is(err, ErrInsufficientBalance) {
+			if err := DisableUserCaptcha(nil, userID, i18n.T(UserLocale(userID), "captcha.reason.insufficient_balance")); err != nil {
+				log.WithError(err).Error("Failed to disable user captcha service")
 			}
-			return models.StatusUnknown, fmt.Errorf("critical error: %w", err)
+			return models.StatusUnknown, newCheckError(CheckErrorCaptcha, fmt.Errorf("critical error: %w", err))
 		}
-		return models.StatusUnknown, fmt.Errorf("failed to create captcha solver: %w", err)
+		return models.StatusUnknown, newCheckError(CheckErrorCaptcha, fmt.Errorf("failed to create captcha solver: %w", err))
 	}
 
-	gRecaptchaResponse, err := solver.SolveReCaptchaV2(cfg.CaptchaService.RecaptchaSiteKey, cfg.CaptchaService.RecaptchaURL)
+	solveStart := time.Now()
+	gRecaptchaResponse, err := solver.SolveReCaptchaV2(ctx, cfg.CaptchaService.RecaptchaSiteKey, cfg.CaptchaService.RecaptchaURL)
 	if err != nil {
-		if strings.Contains(err.Error(), "insufficient balance") {
-			if err := DisableUserCaptcha(nil, userID, "Insufficient balance"); err != nil {
-				logger.Log.WithError(err).Error("Failed to disable user captcha service")
+		if errors.Is(err, ErrInsufficientBalance) {
+			observeCaptchaSolve(userSettings.PreferredCaptchaProvider, "insufficient_balance", time.Since(solveStart))
+			if err := DisableUserCaptcha(nil, userID, i18n.T(UserLocale(userID), "captcha.reason.insufficient_balance")); err != nil {
+				log.WithError(err).Error("Failed to disable user captcha service")
 			}
-			return models.StatusUnknown, fmt.Errorf("insufficient captcha balance")
+			return models.StatusUnknown, newCheckError(CheckErrorCaptcha, err)
 		}
-		return models.StatusUnknown, fmt.Errorf("failed to solve reCAPTCHA: %w", err)
+		observeCaptchaSolve(userSettings.PreferredCaptchaProvider, "error", time.Since(solveStart))
+		return models.StatusUnknown, newCheckError(CheckErrorCaptcha, fmt.Errorf("failed to solve reCAPTCHA: %w", err))
 	}
 
 	if strings.Contains(gRecaptchaResponse, "Invalid") || len(gRecaptchaResponse) < 50 {
-		return models.StatusUnknown, fmt.Errorf("invalid captcha response received")
+		observeCaptchaSolve(userSettings.PreferredCaptchaProvider, "invalid_token", time.Since(solveStart))
+		return models.StatusUnknown, newCheckError(CheckErrorCaptcha, fmt.Errorf("invalid captcha response received"))
 	}
+	observeCaptchaSolve(userSettings.PreferredCaptchaProvider, "success", time.Since(solveStart))
 
-	logger.Log.Info("Successfully received reCAPTCHA response")
+	log.Info("Successfully received reCAPTCHA response")
 
 	checkRequest := fmt.Sprintf("%s?locale=en&g-cc=%s", cfg.API.CheckEndpoint, gRecaptchaResponse)
-	logger.Log.WithField("url", checkRequest).Info("Constructed account check request")
+	log.WithField("endpoint", cfg.API.CheckEndpoint).Info("Constructed account check request")
 
 	client := GetLongTimeoutHTTPClient()
 
-	req, err := http.NewRequest("GET", checkRequest, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", checkRequest, nil)
 	if err != nil {
 		return models.StatusUnknown, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -206,9 +273,9 @@ func CheckAccount(ssoCookie string, userID string, captchaAPIKey string) (models
 		req.Header.Set(k, v)
 	}
 
-	logger.Log.WithFields(logrus.Fields{
-		"url":          checkRequest,
-		"headers":      headers,
+	log.WithFields(logrus.Fields{
+		"endpoint":     cfg.API.CheckEndpoint,
+		"headerCount":  len(headers),
 		"cookieLength": len(ssoCookie),
 	}).Debug("Set request headers")
 	var resp *http.Response
@@ -217,11 +284,14 @@ func CheckAccount(ssoCookie string, userID string, captchaAPIKey string) (models
 
 	backoffDuration := time.Second
 	for i := 0; i < maxRetries; i++ {
-		logger.Log.Infof("Sending request to check account (attempt %d/%d)", i+1, maxRetries)
-		resp, err = client.Do(req)
+		log.Infof("Sending request to check account (attempt %d/%d)", i+1, maxRetries)
+		resp, err = doUpstreamRequest(BreakerActivisionCheck, client, req)
 		if err != nil {
+			if errors.Is(err, ErrCircuitOpen) {
+				return models.StatusUnknown, newCheckError(CheckErrorUpstream, err)
+			}
 			if i == maxRetries-1 {
-				return models.StatusUnknown, fmt.Errorf("failed to send request after %d attempts: %w", maxRetries, err)
+				return models.StatusUnknown, newCheckError(CheckErrorUpstream, fmt.Errorf("failed to send request after %d attempts: %w", maxRetries, err))
 			}
 			backoffDuration *= 2
 			time.Sleep(backoffDuration)
@@ -232,99 +302,78 @@ func CheckAccount(ssoCookie string, userID string, captchaAPIKey string) (models
 		resp.Body.Close()
 		if err != nil {
 			if i == maxRetries-1 {
-				return models.StatusUnknown, fmt.Errorf("failed to read response body after %d attempts: %w", maxRetries, err)
+				return models.StatusUnknown, newCheckError(CheckErrorUpstream, fmt.Errorf("failed to read response body after %d attempts: %w", maxRetries, err))
 			}
 			time.Sleep(time.Duration(i+1) * time.Second)
 			continue
 		}
 
-		logger.Log.WithFields(logrus.Fields{
-			"statusCode":  resp.StatusCode,
-			"bodyLength":  len(body),
-			"bodyContent": string(body),
+		log.WithFields(logrus.Fields{
+			"statusCode": resp.StatusCode,
+			"bodyLength": len(body),
 		}).Info("Received API response")
 
 		break
 	}
 
-	logger.Log.WithField("body", string(body)).Info("Read response body")
+	log.WithField("body", redactBody(body, []string{ssoCookie, gRecaptchaResponse})).Debug("Read response body")
 
 	if resp.ContentLength == 0 {
-		logger.Log.Warn("Received empty response (Content-Length: 0)")
+		log.Warn("Received empty response (Content-Length: 0)")
 	}
 
 	if len(body) == 0 {
-		logger.Log.Warn("Empty response body after reading")
+		log.Warn("Empty response body after reading")
 	}
 
-	var errorResponse struct {
-		Timestamp string `json:"timestamp"`
-		Path      string `json:"path"`
-		Status    int    `json:"status"`
-		Error     string `json:"error"`
-		RequestId string `json:"requestId"`
-		Exception string `json:"exception"`
-	}
+	recordActivisionExchange(EndpointBanCheck, req, resp.StatusCode, body, ssoCookie, gRecaptchaResponse)
 
-	if err := json.Unmarshal(body, &errorResponse); err == nil {
-		logger.Log.WithField("errorResponse", errorResponse).Info("Parsed error response")
-		if errorResponse.Status == 400 && errorResponse.Path == "/api/bans/v2/appeal" {
-			return models.StatusUnknown, fmt.Errorf("invalid request to endpoint: %s", errorResponse.Error)
-		}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return models.StatusUnknown, newCheckError(CheckErrorRateLimited, fmt.Errorf("%w: activision check endpoint", ErrRateLimited))
 	}
-
-	var data struct {
-		Error     string `json:"error"`
-		Success   string `json:"success"`
-		CanAppeal bool   `json:"canAppeal"`
-		Bans      []struct {
-			Enforcement string   `json:"enforcement"`
-			Title       string   `json:"title"`
-			CanAppeal   bool     `json:"canAppeal"`
-			Bar         struct{} `json:"bar,omitempty"`
-		} `json:"bans"`
+	if isUpstreamFailureStatus(resp.StatusCode) {
+		return models.StatusUnknown, newCheckError(CheckErrorUpstream, fmt.Errorf("activision check endpoint returned status %d", resp.StatusCode))
 	}
 
-	if err := json.Unmarshal(body, &data); err != nil {
-		return models.StatusUnknown, fmt.Errorf("failed to parse response: %w", err)
+	result, parseErr := parseBanCheckResponse(resp.StatusCode, body)
+	driftErr := parseErr
+	if result.CaptchaRejected {
+		driftErr = nil
 	}
-	logger.Log.WithField("data", data).Info("Parsed ban data")
+	trackSchemaDrift(EndpointBanCheck, resp.StatusCode, body, driftErr, result.UnknownEnforcements, ssoCookie, gRecaptchaResponse)
 
-	if strings.Contains(string(body), "InvalidCaptchaException") || resp.StatusCode == 400 {
+	if result.CaptchaRejected {
 		ReportCapsolverTaskResult(gRecaptchaResponse, false, "Invalid captcha token rejected by Activision API")
-		return models.StatusUnknown, fmt.Errorf("invalid captcha response")
-	} else if resp.StatusCode == 200 {
+		observeCaptchaSolve(userSettings.PreferredCaptchaProvider, "rejected", 0)
+		return models.StatusUnknown, parseErr
+	}
+	if parseErr != nil {
+		return models.StatusUnknown, parseErr
+	}
+	log.WithField("status", result.Status).Info("Parsed ban data")
+
+	if resp.StatusCode == 200 {
 		ReportCapsolverTaskResult(gRecaptchaResponse, true, "")
 	}
 
-	if data.Success == "true" && len(data.Bans) == 0 {
-		logger.Log.Info("No bans found, account status is good")
+	if result.Status == models.StatusGood {
+		log.Info("No bans found, account status is good")
 		return models.StatusGood, nil
 	}
 
 	if err := UpdateCaptchaUsage(userID); err != nil {
-		logger.Log.WithError(err).Error("Failed to update captcha usage")
-	}
-
-	for _, ban := range data.Bans {
-		logger.Log.WithField("ban", ban).Info("Processing ban")
-		switch ban.Enforcement {
-		case "PERMANENT":
-			logger.Log.Info("Permanent ban detected")
-			return models.StatusPermaban, nil
-		case "UNDER_REVIEW":
-			logger.Log.Info("Shadowban detected")
-			return models.StatusShadowban, nil
-		case "TEMPORARY":
-			logger.Log.Info("Temporary ban detected")
-			return models.StatusTempban, nil
-		}
+		log.WithError(err).Error("Failed to update captcha usage")
+	}
+
+	if result.Status != models.StatusUnknown {
+		log.WithField("status", result.Status).Info("Ban detected")
+		return result.Status, nil
 	}
 
 	LogAccountCheck(accountID, userID, "", err == nil,
 		captchaProvider, captchaCost, time.Since(startTime).Milliseconds())
 
-	logger.Log.Info("Unknown account status")
+	log.Info("Unknown account status")
 	return models.StatusUnknown, nil
 }
 
@@ -385,9 +434,9 @@ func CheckAccountAge(ssoCookie string) (int, int, int, int64, error) {
 		req.Header.Set(k, v)
 	}
 
-	resp, err := client.Do(req)
+	resp, err := doUpstreamRequest(BreakerActivisionProfile, client, req)
 	if err != nil {
-		return 0, 0, 0, 0, errors.New("failed to send HTTP request to check account age")
+		return 0, 0, 0, 0, fmt.Errorf("failed to send HTTP request to check account age: %w", ErrUpstreamUnavailable)
 	}
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
@@ -396,22 +445,24 @@ func CheckAccountAge(ssoCookie string) (int, int, int, int64, error) {
 		}
 	}(resp.Body)
 
-	var data struct {
-		Created string `json:"created"`
-	}
-	err = json.NewDecoder(resp.Body).Decode(&data)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return 0, 0, 0, 0, errors.New("failed to decode JSON response from check account age request")
+		return 0, 0, 0, 0, errors.New("failed to read response from check account age request")
 	}
+	recordActivisionExchange(EndpointAccountAge, req, resp.StatusCode, body, ssoCookie)
 
-	logger.Log.Infof("Account created date: %s", data.Created)
+	if isUpstreamFailureStatus(resp.StatusCode) {
+		return 0, 0, 0, 0, fmt.Errorf("%w: profile endpoint returned status %d", ErrUpstreamUnavailable, resp.StatusCode)
+	}
 
-	created, err := time.Parse(time.RFC3339, data.Created)
+	createdUTC, err := parseAccountAgeResponse(body)
+	trackSchemaDrift(EndpointAccountAge, resp.StatusCode, body, err, nil, ssoCookie)
 	if err != nil {
-		return 0, 0, 0, 0, errors.New("failed to parse created date in check account age request")
+		return 0, 0, 0, 0, err
 	}
 
-	createdUTC := created.UTC()
+	logger.Log.Infof("Account created date: %s", createdUTC.Format(time.RFC3339))
+
 	createdEpoch := createdUTC.Unix()
 
 	now := time.Now().UTC()
@@ -439,7 +490,7 @@ func CheckVIPStatus(ssoCookie string) (bool, error) {
 		req.Header.Set(k, v)
 	}
 
-	resp, err := client.Do(req)
+	resp, err := doUpstreamRequest(BreakerActivisionVIP, client, req)
 	if err != nil {
 		return false, fmt.Errorf("failed to send HTTP request to check VIP status: %w", err)
 	}
@@ -451,26 +502,26 @@ func CheckVIPStatus(ssoCookie string) (bool, error) {
 		}
 	}(resp.Body)
 
-	if resp.StatusCode != http.StatusOK {
-		return false, fmt.Errorf("invalid response status code: %d", resp.StatusCode)
-	}
-
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return false, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	var data struct {
-		VIP bool `json:"vip"`
+	if resp.StatusCode != http.StatusOK {
+		recordActivisionExchange(EndpointVIPStatus, req, resp.StatusCode, body, ssoCookie)
+		return false, fmt.Errorf("invalid response status code: %d", resp.StatusCode)
 	}
 
-	err = json.Unmarshal(body, &data)
+	recordActivisionExchange(EndpointVIPStatus, req, resp.StatusCode, body, ssoCookie)
+
+	isVIP, err := parseVIPResponse(body)
+	trackSchemaDrift(EndpointVIPStatus, resp.StatusCode, body, err, nil, ssoCookie)
 	if err != nil {
-		return false, fmt.Errorf("failed to decode JSON response: %w", err)
+		return false, err
 	}
 
-	logger.Log.Infof("VIP status check complete. Result: %v", data.VIP)
-	return data.VIP, nil
+	logger.Log.Infof("VIP status check complete. Result: %v", isVIP)
+	return isVIP, nil
 }
 
 func ValidateAndGetAccountInfo(ssoCookie string) (*AccountValidationResult, error) {
@@ -488,7 +539,7 @@ func ValidateAndGetAccountInfo(ssoCookie string) (*AccountValidationResult, erro
 		req.Header.Set(k, v)
 	}
 
-	resp, err := client.Do(req)
+	resp, err := doUpstreamRequest(BreakerActivisionProfile, client, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send profile request: %w", err)
 	}
@@ -499,23 +550,20 @@ func ValidateAndGetAccountInfo(ssoCookie string) (*AccountValidationResult, erro
 		}
 	}(resp.Body)
 
-	if resp.StatusCode != http.StatusOK {
-		return &AccountValidationResult{IsValid: false}, nil
-	}
-
-	var profileData map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&profileData); err != nil {
-		return nil, fmt.Errorf("failed to decode profile response: %w", err)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile response: %w", err)
 	}
+	recordActivisionExchange(EndpointProfile, req, resp.StatusCode, body, ssoCookie)
 
-	createdStr, ok := profileData["created"].(string)
-	if !ok {
-		return nil, fmt.Errorf("invalid creation date format")
+	if resp.StatusCode != http.StatusOK {
+		return &AccountValidationResult{IsValid: false}, nil
 	}
 
-	created, err := time.Parse(time.RFC3339, createdStr)
+	profileData, created, err := parseProfileResponse(body)
+	trackSchemaDrift(EndpointProfile, resp.StatusCode, body, err, nil, ssoCookie)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse creation date: %w", err)
+		return nil, err
 	}
 
 	isVIP, err := CheckVIPStatus(ssoCookie)
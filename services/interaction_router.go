@@ -0,0 +1,108 @@
+package services
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type InteractionHandler func(*discordgo.Session, *discordgo.InteractionCreate)
+
+// InteractionRouter maps command names and modal/component custom IDs to
+// handlers. It is shared by every shard. Exact routes win over prefix
+// routes, and the longest matching prefix wins among prefixes.
+type InteractionRouter struct {
+	sync.RWMutex
+	tables map[discordgo.InteractionType]*routeTable
+}
+
+type routeTable struct {
+	exact    map[string]InteractionHandler
+	prefixes []prefixRoute
+}
+
+type prefixRoute struct {
+	prefix  string
+	handler InteractionHandler
+}
+
+func NewInteractionRouter() *InteractionRouter {
+	return &InteractionRouter{tables: make(map[discordgo.InteractionType]*routeTable)}
+}
+
+func (r *InteractionRouter) table(kind discordgo.InteractionType) *routeTable {
+	t, ok := r.tables[kind]
+	if !ok {
+		t = &routeTable{exact: make(map[string]InteractionHandler)}
+		r.tables[kind] = t
+	}
+	return t
+}
+
+// Handle routes interactions of kind whose key equals id.
+func (r *InteractionRouter) Handle(kind discordgo.InteractionType, id string, handler InteractionHandler) {
+	r.Lock()
+	defer r.Unlock()
+	r.table(kind).exact[id] = handler
+}
+
+// HandlePrefix routes interactions of kind whose key starts with prefix. An
+// empty prefix catches everything not matched otherwise.
+func (r *InteractionRouter) HandlePrefix(kind discordgo.InteractionType, prefix string, handler InteractionHandler) {
+	r.Lock()
+	defer r.Unlock()
+	t := r.table(kind)
+	t.prefixes = append(t.prefixes, prefixRoute{prefix: prefix, handler: handler})
+}
+
+// Lookup returns the handler for an interaction of kind with the given key.
+func (r *InteractionRouter) Lookup(kind discordgo.InteractionType, key string) (InteractionHandler, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	t, ok := r.tables[kind]
+	if !ok {
+		return nil, false
+	}
+	if handler, ok := t.exact[key]; ok {
+		return handler, true
+	}
+
+	var best *prefixRoute
+	for i := range t.prefixes {
+		route := &t.prefixes[i]
+		if strings.HasPrefix(key, route.prefix) && (best == nil || len(route.prefix) > len(best.prefix)) {
+			best = route
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	return best.handler, true
+}
+
+// Route dispatches i and reports whether a handler was found.
+func (r *InteractionRouter) Route(s *discordgo.Session, i *discordgo.InteractionCreate) bool {
+	handler, ok := r.Lookup(i.Type, InteractionKey(i))
+	if !ok {
+		return false
+	}
+	handler(s, i)
+	return true
+}
+
+// InteractionKey is the routing key of i: the command name for commands and
+// the custom ID for modals and components.
+func InteractionKey(i *discordgo.InteractionCreate) string {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand, discordgo.InteractionApplicationCommandAutocomplete:
+		return i.ApplicationCommandData().Name
+	case discordgo.InteractionModalSubmit:
+		return i.ModalSubmitData().CustomID
+	case discordgo.InteractionMessageComponent:
+		return i.MessageComponentData().CustomID
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	jobPending = "pending"
+	jobLeased  = "leased"
+	jobDone    = "done"
+	jobFailed  = "failed"
+
+	maxCheckJobAttempts = 3
+	workerPollInterval  = 5 * time.Second
+	jobClaimBatch       = 10
+)
+
+// EnqueueCheckJobs is the gateway's half of CheckAccounts: it queues one job
+// per user with enabled accounts for the workers to run. Users that already
+// have a pending or leased job are skipped.
+func EnqueueCheckJobs(s *discordgo.Session) {
+	accountsByUser, ok := accountsDueForCheck(s)
+	if !ok {
+		return
+	}
+
+	queued := 0
+	for userID := range accountsByUser {
+		if !StillLeader() {
+			break
+		}
+		created, err := enqueueCheckJob(userID)
+		if err != nil {
+			logger.Log.WithError(err).Errorf("Failed to queue check job for user %s", userID)
+			continue
+		}
+		if created {
+			queued++
+		}
+	}
+	logger.Log.Infof("Queued %d check jobs for %d users", queued, len(accountsByUser))
+
+	reportSchemaDrift(s)
+}
+
+func enqueueCheckJob(userID string) (bool, error) {
+	var open int64
+	if err := database.DB.Model(&models.CheckJob{}).
+		Where("user_id = ? AND status IN ?", userID, []string{jobPending, jobLeased}).
+		Count(&open).Error; err != nil {
+		return false, err
+	}
+	if open > 0 {
+		return false, nil
+	}
+	return true, database.DB.Create(&models.CheckJob{UserID: userID, Status: jobPending}).Error
+}
+
+// claimCheckJob leases the oldest available job to owner. A leased job whose
+// lease has expired is available again, so jobs held by a crashed worker are
+// picked up by another one. The conditional update makes the claim atomic
+// when several workers race for the same job.
+func claimCheckJob(owner string, lease time.Duration) (*models.CheckJob, error) {
+	now := time.Now()
+	var candidates []models.CheckJob
+	if err := database.DB.Where("status = ? OR (status = ? AND lease_expires_at < ?)", jobPending, jobLeased, now).
+		Order("id").
+		Limit(jobClaimBatch).
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	for _, job := range candidates {
+		available := database.DB.Model(&models.CheckJob{}).
+			Where("id = ? AND (status = ? OR (status = ? AND lease_expires_at < ?))", job.ID, jobPending, jobLeased, now)
+
+		if job.Attempts >= maxCheckJobAttempts {
+			if err := available.Updates(map[string]interface{}{
+				"status":     jobFailed,
+				"last_error": fmt.Sprintf("gave up after %d attempts", job.Attempts),
+			}).Error; err != nil {
+				logger.Log.WithError(err).Errorf("Failed to mark check job %d as failed", job.ID)
+			}
+			continue
+		}
+
+		result := available.Updates(map[string]interface{}{
+			"status":           jobLeased,
+			"lease_owner":      owner,
+			"lease_expires_at": now.Add(lease),
+			"heartbeat_at":     now,
+			"attempts":         gorm.Expr("attempts + 1"),
+		})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			job.Status = jobLeased
+			job.LeaseOwner = owner
+			job.LeaseExpiresAt = now.Add(lease)
+			job.HeartbeatAt = now
+			job.Attempts++
+			return &job, nil
+		}
+	}
+	return nil, nil
+}
+
+func ownedJob(job *models.CheckJob) *gorm.DB {
+	return database.DB.Model(&models.CheckJob{}).
+		Where("id = ? AND status = ? AND lease_owner = ?", job.ID, jobLeased, job.LeaseOwner)
+}
+
+// heartbeatCheckJob extends the lease. It reports false once the lease has
+// been lost to another worker.
+func heartbeatCheckJob(job *models.CheckJob, lease time.Duration) (bool, error) {
+	now := time.Now()
+	result := ownedJob(job).Updates(map[string]interface{}{
+		"lease_expires_at": now.Add(lease),
+		"heartbeat_at":     now,
+	})
+	return result.RowsAffected == 1, result.Error
+}
+
+func finishCheckJob(job *models.CheckJob, runErr error) {
+	updates := map[string]interface{}{"status": jobDone, "last_error": ""}
+	if runErr != nil {
+		updates["last_error"] = runErr.Error()
+		updates["status"] = jobPending
+		if job.Attempts >= maxCheckJobAttempts {
+			updates["status"] = jobFailed
+		}
+	}
+
+	result := ownedJob(job).Updates(updates)
+	if result.Error != nil {
+		logger.Log.WithError(result.Error).Errorf("Failed to finish check job %d", job.ID)
+	} else if result.RowsAffected == 0 {
+		logger.Log.Warnf("Lease on check job %d was lost before it finished", job.ID)
+	}
+}
+
+// releaseCheckJob hands a job back without counting the attempt, for when
+// the worker cannot run it right now.
+func releaseCheckJob(job *models.CheckJob) {
+	if err := ownedJob(job).Updates(map[string]interface{}{
+		"status":   jobPending,
+		"attempts": gorm.Expr("attempts - 1"),
+	}).Error; err != nil {
+		logger.Log.WithError(err).Errorf("Failed to release check job %d", job.ID)
+	}
+}
+
+// RunCheckWorker claims and runs check jobs until ctx is cancelled. Each of
+// the concurrency slots holds at most one lease at a time. It returns once
+// every in-flight job has finished.
+func RunCheckWorker(ctx context.Context, workerID string, concurrency int, lease time.Duration) {
+	logger.Log.Infof("Check worker %s started with %d slots and a %s lease", workerID, concurrency, lease)
+
+	var wg sync.WaitGroup
+	for slot := 0; slot < concurrency; slot++ {
+		wg.Add(1)
+		go func(owner string) {
+			defer wg.Done()
+			runWorkerSlot(ctx, owner, lease)
+		}(fmt.Sprintf("%s/%d", workerID, slot))
+	}
+	wg.Wait()
+
+	logger.Log.Infof("Check worker %s stopped", workerID)
+}
+
+func runWorkerSlot(ctx context.Context, owner string, lease time.Duration) {
+	for ctx.Err() == nil {
+		job, err := claimCheckJob(owner, lease)
+		if err != nil {
+			logger.Log.WithError(err).Error("Failed to claim check job")
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(workerPollInterval):
+			}
+			continue
+		}
+		runCheckJob(ctx, job, lease)
+	}
+}
+
+func runCheckJob(ctx context.Context, job *models.CheckJob, lease time.Duration) {
+	log := logger.Log.WithFields(logrus.Fields{
+		"job_id":  job.ID,
+		"user_id": job.UserID,
+		"attempt": job.Attempts,
+	})
+
+	if IsCheckingPausedForDrift() || IsSchedulerPaused() || len(openActivisionBreakers()) > 0 {
+		log.Warn("Checks paused or Activision circuit breakers open, releasing check job")
+		releaseCheckJob(job)
+		select {
+		case <-ctx.Done():
+		case <-time.After(workerPollInterval):
+		}
+		return
+	}
+
+	stop := make(chan struct{})
+	heartbeats := make(chan struct{})
+	go func() {
+		defer close(heartbeats)
+		ticker := time.NewTicker(lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				held, err := heartbeatCheckJob(job, lease)
+				if err != nil {
+					log.WithError(err).Warn("Failed to extend check job lease")
+				} else if !held {
+					log.Warn("Check job lease was taken over by another worker")
+					return
+				}
+			}
+		}
+	}()
+
+	log.Info("Running check job")
+	err := runUserChecks(job.UserID)
+	close(stop)
+	<-heartbeats
+
+	finishCheckJob(job, err)
+	if err != nil {
+		log.WithError(err).Error("Check job failed")
+	}
+}
+
+func runUserChecks(userID string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while checking accounts: %v", r)
+		}
+	}()
+
+	var accounts []models.Account
+	if err := database.DB.Where("user_id = ? AND is_check_disabled = ? AND is_expired_cookie = ?", userID, false, false).
+		Find(&accounts).Error; err != nil {
+		return fmt.Errorf("failed to fetch accounts: %w", err)
+	}
+
+	processUserAccounts(nil, userID, accounts)
+	return nil
+}
+
+// CleanupJobQueue removes finished check jobs and delivered messages older
+// than a week.
+func CleanupJobQueue() {
+	cutoff := time.Now().Add(-outboxRetention)
+
+	jobs := database.DB.Unscoped().Where("status IN ? AND updated_at < ?", []string{jobDone, jobFailed}, cutoff).Delete(&models.CheckJob{})
+	if jobs.Error != nil {
+		logger.Log.WithError(jobs.Error).Error("Failed to clean up check jobs")
+	}
+	messages := database.DB.Unscoped().Where("status IN ? AND updated_at < ?", []string{outboxSent, outboxFailed}, cutoff).Delete(&models.OutboundMessage{})
+	if messages.Error != nil {
+		logger.Log.WithError(messages.Error).Error("Failed to clean up outbound messages")
+	}
+	if jobs.RowsAffected > 0 || messages.RowsAffected > 0 {
+		logger.Log.Infof("Removed %d finished check jobs and %d outbound messages", jobs.RowsAffected, messages.RowsAffected)
+	}
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/models"
+	"gorm.io/gorm"
+)
+
+// SchedulerElection is the election whose leader runs periodic jobs.
+const SchedulerElection = "scheduler"
+
+const fenceCheckInterval = 5 * time.Second
+
+// LeaderElector holds a lease row in the database. Whoever holds an
+// unexpired lease is the leader; every change of leader increments the
+// row's fencing token, so a replica that stalls past its lease finds its
+// token stale when it resumes and stops.
+type LeaderElector struct {
+	sync.Mutex
+	name        string
+	holder      string
+	ttl         time.Duration
+	token       uint64
+	until       time.Time
+	lastFenceOK time.Time
+}
+
+// LeaderInfo describes the current lease holder.
+type LeaderInfo struct {
+	Election  string    `json:"election"`
+	Holder    string    `json:"holder"`
+	Token     uint64    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Self      bool      `json:"self"`
+}
+
+func NewLeaderElector(name, holder string, ttl time.Duration) *LeaderElector {
+	return &LeaderElector{name: name, holder: holder, ttl: ttl}
+}
+
+// Start makes a first attempt at the lease before returning, so a lone
+// instance leads as soon as it starts, then keeps renewing or retrying until
+// ctx is cancelled.
+func (e *LeaderElector) Start(ctx context.Context) {
+	e.tick()
+	go func() {
+		ticker := time.NewTicker(e.ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.tick()
+			}
+		}
+	}()
+}
+
+func (e *LeaderElector) tick() {
+	e.Lock()
+	defer e.Unlock()
+
+	leading := e.token != 0
+	var err error
+	if leading {
+		err = e.renew()
+	} else if err = e.ensureRow(); err == nil {
+		err = e.acquire()
+	}
+	if err != nil {
+		logger.Log.WithError(err).Errorf("Leader election for %s failed", e.name)
+	}
+
+	switch {
+	case !leading && e.token != 0:
+		logger.Log.Infof("Became %s leader as %s (fencing token %d)", e.name, e.holder, e.token)
+	case leading && e.token == 0:
+		logger.Log.Warnf("Lost %s leadership", e.name)
+	}
+}
+
+func (e *LeaderElector) ensureRow() error {
+	var lease models.LeaderLease
+	err := database.DB.Where("name = ?", e.name).First(&lease).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Another replica may create the row first; the unique index makes
+		// that harmless.
+		database.DB.Create(&models.LeaderLease{Name: e.name})
+		return nil
+	}
+	return err
+}
+
+func (e *LeaderElector) acquire() error {
+	now := time.Now()
+	result := database.DB.Model(&models.LeaderLease{}).
+		Where("name = ? AND (holder = '' OR expires_at < ?)", e.name, now).
+		Updates(map[string]interface{}{
+			"holder":     e.holder,
+			"token":      gorm.Expr("token + 1"),
+			"expires_at": now.Add(e.ttl),
+			"renewed_at": now,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	var lease models.LeaderLease
+	if err := database.DB.Where("name = ? AND holder = ?", e.name, e.holder).First(&lease).Error; err != nil {
+		return err
+	}
+	e.token = lease.Token
+	e.until = now.Add(e.ttl)
+	e.lastFenceOK = now
+	return nil
+}
+
+func (e *LeaderElector) renew() error {
+	now := time.Now()
+	result := database.DB.Model(&models.LeaderLease{}).
+		Where("name = ? AND holder = ? AND token = ?", e.name, e.holder, e.token).
+		Updates(map[string]interface{}{
+			"expires_at": now.Add(e.ttl),
+			"renewed_at": now,
+		})
+	if result.Error != nil {
+		// Keep leading until the lease runs out; the next tick retries.
+		if now.After(e.until) {
+			e.token = 0
+		}
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		e.token = 0
+		return nil
+	}
+	e.until = now.Add(e.ttl)
+	e.lastFenceOK = now
+	return nil
+}
+
+// Release gives up the lease on shutdown so a standby takes over without
+// waiting for it to expire.
+func (e *LeaderElector) Release() {
+	e.Lock()
+	defer e.Unlock()
+	if e.token == 0 {
+		return
+	}
+	if err := database.DB.Model(&models.LeaderLease{}).
+		Where("name = ? AND holder = ? AND token = ?", e.name, e.holder, e.token).
+		Updates(map[string]interface{}{"holder": "", "expires_at": time.Now()}).Error; err != nil {
+		logger.Log.WithError(err).Errorf("Failed to release %s leader lease", e.name)
+	}
+	e.token = 0
+	logger.Log.Infof("Released %s leadership", e.name)
+}
+
+// IsLeader reports whether this instance holds an unexpired lease, as far as
+// it knows without asking the database.
+func (e *LeaderElector) IsLeader() bool {
+	e.Lock()
+	defer e.Unlock()
+	return e.token != 0 && time.Now().Before(e.until)
+}
+
+// Token returns the fencing token of the current term, or 0 when not leading.
+func (e *LeaderElector) Token() uint64 {
+	e.Lock()
+	defer e.Unlock()
+	return e.token
+}
+
+// verifyFence checks the fencing token against the database, at most once
+// per fenceCheckInterval.
+func (e *LeaderElector) verifyFence() bool {
+	e.Lock()
+	defer e.Unlock()
+
+	now := time.Now()
+	if e.token == 0 || !now.Before(e.until) {
+		return false
+	}
+	if now.Sub(e.lastFenceOK) < fenceCheckInterval {
+		return true
+	}
+
+	var current int64
+	if err := database.DB.Model(&models.LeaderLease{}).
+		Where("name = ? AND holder = ? AND token = ? AND expires_at > ?", e.name, e.holder, e.token, now).
+		Count(&current).Error; err != nil {
+		logger.Log.WithError(err).Warnf("Failed to verify %s fencing token", e.name)
+		return false
+	}
+	if current == 0 {
+		logger.Log.Warnf("Fencing token %d for %s is stale, stopping leader-only work", e.token, e.name)
+		e.token = 0
+		return false
+	}
+	e.lastFenceOK = now
+	return true
+}
+
+var activeElector struct {
+	sync.RWMutex
+	elector *LeaderElector
+}
+
+// SetLeaderElector installs the scheduler election. Without one, the process
+// behaves as the only instance and always leads.
+func SetLeaderElector(e *LeaderElector) {
+	activeElector.Lock()
+	activeElector.elector = e
+	activeElector.Unlock()
+}
+
+func currentElector() *LeaderElector {
+	activeElector.RLock()
+	defer activeElector.RUnlock()
+	return activeElector.elector
+}
+
+// IsLeader reports whether this instance should start leader-only work.
+func IsLeader() bool {
+	if e := currentElector(); e != nil {
+		return e.IsLeader()
+	}
+	return true
+}
+
+// StillLeader is checked between items of long leader-only loops. It
+// verifies the fencing token in the database, so a replica that lost the
+// lease while stalled stops before doing more work.
+func StillLeader() bool {
+	if e := currentElector(); e != nil {
+		return e.verifyFence()
+	}
+	return true
+}
+
+// CurrentLeader reads the holder of an election's lease from the database.
+// It reports false when nobody holds an unexpired lease.
+func CurrentLeader(election string) (LeaderInfo, bool, error) {
+	var lease models.LeaderLease
+	err := database.DB.Where("name = ?", election).First(&lease).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return LeaderInfo{Election: election}, false, nil
+	}
+	if err != nil {
+		return LeaderInfo{Election: election}, false, err
+	}
+
+	info := LeaderInfo{
+		Election:  election,
+		Holder:    lease.Holder,
+		Token:     lease.Token,
+		ExpiresAt: lease.ExpiresAt,
+	}
+	if e := currentElector(); e != nil && e.name == election {
+		info.Self = lease.Holder == e.holder && lease.Token == e.Token()
+	}
+	return info, lease.Holder != "" && time.Now().Before(lease.ExpiresAt), nil
+}
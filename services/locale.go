@@ -0,0 +1,87 @@
+package services
+
+import (
+	"time"
+
+	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickmn/go-cache"
+)
+
+// userLocales caches the locale preference of recent users, so rendering a
+// reply does not cost a query per message.
+var userLocales = cache.New(10*time.Minute, 5*time.Minute)
+
+type localePreference struct {
+	chosen  string
+	discord string
+}
+
+func loadLocalePreference(userID string) localePreference {
+	if cached, ok := userLocales.Get(userID); ok {
+		return cached.(localePreference)
+	}
+
+	var settings models.UserSettings
+	err := database.DB.Select("locale", "discord_locale").Where("user_id = ?", userID).Limit(1).Find(&settings).Error
+	if err != nil {
+		logger.Log.WithError(err).Warnf("Failed to load locale for user %s", userID)
+		return localePreference{}
+	}
+	pref := localePreference{chosen: settings.Locale, discord: settings.DiscordLocale}
+	userLocales.Set(userID, pref, cache.DefaultExpiration)
+	return pref
+}
+
+// InteractionLocale is the locale to reply to i in: the user's chosen
+// language, otherwise the locale of their Discord client.
+func InteractionLocale(i *discordgo.InteractionCreate) string {
+	if userID, err := GetUserID(i); err == nil {
+		if pref := loadLocalePreference(userID); pref.chosen != "" {
+			return pref.chosen
+		}
+	}
+	if i.Locale != "" {
+		return string(i.Locale)
+	}
+	return i18n.DefaultLocale
+}
+
+// UserLocale is the locale for messages sent outside an interaction, such as
+// notifications: the chosen language, otherwise the Discord client locale
+// seen on the user's last command.
+func UserLocale(userID string) string {
+	pref := loadLocalePreference(userID)
+	switch {
+	case pref.chosen != "":
+		return pref.chosen
+	case pref.discord != "":
+		return pref.discord
+	default:
+		return i18n.DefaultLocale
+	}
+}
+
+// SetUserLocale stores the user's chosen language. An empty locale goes back
+// to following the Discord client.
+func SetUserLocale(userID, locale string) error {
+	if _, err := GetUserSettings(userID); err != nil {
+		return err
+	}
+	err := database.DB.Model(&models.UserSettings{}).Where("user_id = ?", userID).Update("locale", locale).Error
+	userLocales.Delete(userID)
+	return err
+}
+
+// rememberDiscordLocale records the client locale of an interaction for
+// later background messages.
+func rememberDiscordLocale(settings *models.UserSettings, i *discordgo.InteractionCreate) {
+	if i.Locale == "" || settings.DiscordLocale == string(i.Locale) {
+		return
+	}
+	settings.DiscordLocale = string(i.Locale)
+	userLocales.Delete(settings.UserID)
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/bradselph/CODStatusBot/configuration"
 	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
 	"github.com/bwmarrin/discordgo"
@@ -30,7 +32,8 @@ func init() {}
 
 func InitializeServices() {
 	cfg := configuration.Get()
-	initDefaultSettings()
+	applyRuntimeConfig()
+	configuration.OnReload(applyRuntimeConfig)
 	logger.Log.Infof("Loaded rate limits and intervals: CHECK_INTERVAL=%d, NOTIFICATION_INTERVAL=%.2f, "+
 		"COOLDOWN_DURATION=%.2f, SLEEP_DURATION=%d, COOKIE_CHECK_INTERVAL_PERMABAN=%.2f, "+
 		"STATUS_CHANGE_COOLDOWN=%.2f, GLOBAL_NOTIFICATION_COOLDOWN=%.2f, COOKIE_EXPIRATION_WARNING=%.2f, "+
@@ -41,22 +44,56 @@ func InitializeServices() {
 }
 
 func CheckAccounts(s *discordgo.Session) {
+	accountsByUser, ok := accountsDueForCheck(s)
+	if !ok {
+		return
+	}
+
+	for userID, userAccounts := range accountsByUser {
+		if IsCheckingPausedForDrift() || IsSchedulerPaused() || len(openActivisionBreakers()) > 0 || !StillLeader() {
+			break
+		}
+		processUserAccounts(s, userID, userAccounts)
+	}
+
+	reportSchemaDrift(s)
+}
+
+// accountsDueForCheck starts a scheduled run and returns the enabled accounts
+// grouped by user. It reports false when checks are paused.
+func accountsDueForCheck(s *discordgo.Session) (map[string][]models.Account, bool) {
 	logger.Log.Info("Starting periodic account check")
+	markScheduledRun()
+
+	if IsCheckingPausedForDrift() {
+		logger.Log.Warn("Skipping periodic account check: schema drift awaiting admin acknowledgement")
+		reportSchemaDrift(s)
+		return nil, false
+	}
+
+	if IsSchedulerPaused() {
+		logger.Log.Warn("Skipping periodic account check: scheduler paused by an admin")
+		return nil, false
+	}
+
+	if open := openActivisionBreakers(); len(open) > 0 {
+		logger.Log.WithField("breakers", open).Warn("Skipping periodic account check: Activision circuit breakers open")
+		return nil, false
+	}
+
+	ReenableOutageDisabledAccounts(s)
 
 	var accounts []models.Account
 	if err := database.DB.Where("is_check_disabled = ? AND is_expired_cookie = ?", false, false).Find(&accounts).Error; err != nil {
 		logger.Log.WithError(err).Error("Failed to fetch accounts from database")
-		return
+		return nil, false
 	}
 
 	accountsByUser := make(map[string][]models.Account)
 	for _, account := range accounts {
 		accountsByUser[account.UserID] = append(accountsByUser[account.UserID], account)
 	}
-
-	for userID, userAccounts := range accountsByUser {
-		processUserAccounts(s, userID, userAccounts)
-	}
+	return accountsByUser, true
 }
 
 func HandleStatusChange(s *discordgo.Session, account models.Account, newStatus models.Status, userSettings models.UserSettings) {
@@ -131,18 +168,19 @@ func HandleStatusChange(s *discordgo.Session, account models.Account, newStatus
 
 		LogStatusChange(account.ID, account.UserID, newStatus, previousStatus)
 
+		locale := UserLocale(account.UserID)
 		embed := &discordgo.MessageEmbed{
-			Title:       fmt.Sprintf("%s - %s", account.Title, EmbedTitleFromStatus(newStatus)),
-			Description: GetStatusDescription(newStatus, account.Title, ban),
+			Title:       fmt.Sprintf("%s - %s", account.Title, EmbedTitleFromStatus(locale, newStatus)),
+			Description: GetStatusDescription(locale, newStatus, account.Title, ban),
 			Color:       GetColorForStatus(newStatus, account.IsExpiredCookie, account.IsCheckDisabled),
-			Fields:      getStatusFields(account, newStatus, ban),
+			Fields:      getStatusFields(locale, account, newStatus, ban),
 			Timestamp:   now.Format(time.RFC3339),
 		}
 
 		if previousStatus != models.StatusUnknown {
 			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-				Name:   "Previous Status",
-				Value:  string(previousStatus),
+				Name:   i18n.T(locale, "field.previous_status"),
+				Value:  StatusName(locale, previousStatus),
 				Inline: true,
 			})
 		}
@@ -164,25 +202,24 @@ func HandleStatusChange(s *discordgo.Session, account models.Account, newStatus
 
 		case models.StatusPermaban:
 			permaBanEmbed := &discordgo.MessageEmbed{
-				Title: fmt.Sprintf("%s - Permanent Ban Detected", account.Title),
-				Description: "This account has been permanently banned. It's recommended to remove it from monitoring " +
-					"using the /removeaccount command to free up your account slot.",
-				Color:     GetColorForStatus(newStatus, false, false),
-				Timestamp: now.Format(time.RFC3339),
+				Title:       i18n.T(locale, "permaban.title", account.Title),
+				Description: i18n.T(locale, "permaban.description"),
+				Color:       GetColorForStatus(newStatus, false, false),
+				Timestamp:   now.Format(time.RFC3339),
 				Fields: []*discordgo.MessageEmbedField{
 					{
-						Name:   "Account Status",
-						Value:  "Permanently Banned",
+						Name:   i18n.T(locale, "field.account_status"),
+						Value:  i18n.T(locale, "permaban.status"),
 						Inline: true,
 					},
 					{
-						Name:   "Action Required",
-						Value:  "Remove account using /removeaccount",
+						Name:   i18n.T(locale, "field.action_required"),
+						Value:  i18n.T(locale, "permaban.action"),
 						Inline: true,
 					},
 					{
-						Name:   "Note",
-						Value:  "Removing this account will free up a slot for monitoring another account.",
+						Name:   i18n.T(locale, "field.note"),
+						Value:  i18n.T(locale, "permaban.note"),
 						Inline: false,
 					},
 				},
@@ -190,7 +227,7 @@ func HandleStatusChange(s *discordgo.Session, account models.Account, newStatus
 
 			if ban.AffectedGames != "" {
 				permaBanEmbed.Fields = append(permaBanEmbed.Fields, &discordgo.MessageEmbedField{
-					Name:   "Affected Games",
+					Name:   i18n.T(locale, "field.affected_games"),
 					Value:  ban.AffectedGames,
 					Inline: false,
 				})
@@ -269,15 +306,15 @@ func getAffectedGames(ssoCookie string) string {
 	return strings.Join(games, ", ")
 }
 
-func getStatusFields(account models.Account, status models.Status, ban models.Ban) []*discordgo.MessageEmbedField {
+func getStatusFields(locale string, account models.Account, status models.Status, ban models.Ban) []*discordgo.MessageEmbedField {
 	fields := []*discordgo.MessageEmbedField{
 		{
-			Name:   "Account Status",
-			Value:  string(status),
+			Name:   i18n.T(locale, "field.account_status"),
+			Value:  StatusName(locale, status),
 			Inline: true,
 		},
 		{
-			Name:   "Last Checked",
+			Name:   i18n.T(locale, "field.last_checked"),
 			Value:  time.Unix(account.LastCheck, 0).Format(time.RFC1123),
 			Inline: true,
 		},
@@ -285,7 +322,7 @@ func getStatusFields(account models.Account, status models.Status, ban models.Ba
 
 	if ban.AffectedGames != "" {
 		fields = append(fields, &discordgo.MessageEmbedField{
-			Name:   "Affected Games",
+			Name:   i18n.T(locale, "field.affected_games"),
 			Value:  ban.AffectedGames,
 			Inline: false,
 		})
@@ -293,8 +330,8 @@ func getStatusFields(account models.Account, status models.Status, ban models.Ba
 
 	if isVIP, err := CheckVIPStatus(account.SSOCookie); err == nil {
 		fields = append(fields, &discordgo.MessageEmbedField{
-			Name:   "VIP Status",
-			Value:  formatVIPStatus(isVIP),
+			Name:   i18n.T(locale, "field.vip_status"),
+			Value:  formatVIPStatus(locale, isVIP),
 			Inline: true,
 		})
 	}
@@ -303,7 +340,7 @@ func getStatusFields(account models.Account, status models.Status, ban models.Ba
 		timeUntilExpiration, err := CheckSSOCookieExpiration(account.SSOCookieExpiration)
 		if err == nil {
 			fields = append(fields, &discordgo.MessageEmbedField{
-				Name:   "Cookie Expires",
+				Name:   i18n.T(locale, "field.cookie_expires"),
 				Value:  FormatDuration(timeUntilExpiration),
 				Inline: true,
 			})
@@ -316,8 +353,8 @@ func getStatusFields(account models.Account, status models.Status, ban models.Ba
 		years := int(accountAge.Hours() / 24 / 365)
 		months := int(accountAge.Hours()/24/30.44) % 12
 		fields = append(fields, &discordgo.MessageEmbedField{
-			Name:   "Account Age",
-			Value:  fmt.Sprintf("%d years, %d months", years, months),
+			Name:   i18n.T(locale, "field.account_age"),
+			Value:  i18n.N(locale, "age.years", years) + ", " + i18n.N(locale, "age.months", months),
 			Inline: true,
 		})
 	}
@@ -325,8 +362,8 @@ func getStatusFields(account models.Account, status models.Status, ban models.Ba
 	switch status {
 	case models.StatusPermaban:
 		fields = append(fields, &discordgo.MessageEmbedField{
-			Name:   "Ban Type",
-			Value:  "Permanent",
+			Name:   i18n.T(locale, "field.ban_type"),
+			Value:  i18n.T(locale, "bantype.permanent"),
 			Inline: true,
 		})
 
@@ -336,7 +373,7 @@ func getStatusFields(account models.Account, status models.Status, ban models.Ba
 			Order("created_at DESC").
 			First(&latestBan).Error; err == nil {
 			fields = append(fields, &discordgo.MessageEmbedField{
-				Name:   "Ban Duration",
+				Name:   i18n.T(locale, "field.ban_duration"),
 				Value:  latestBan.TempBanDuration,
 				Inline: true,
 			})
@@ -344,16 +381,16 @@ func getStatusFields(account models.Account, status models.Status, ban models.Ba
 
 	case models.StatusShadowban:
 		fields = append(fields, &discordgo.MessageEmbedField{
-			Name:   "Review Status",
-			Value:  "Account Under Review",
+			Name:   i18n.T(locale, "field.review_status"),
+			Value:  i18n.T(locale, "review.under_review"),
 			Inline: true,
 		})
 	}
 
 	if account.ConsecutiveErrors > 0 {
 		fields = append(fields, &discordgo.MessageEmbedField{
-			Name:   "Check Errors",
-			Value:  fmt.Sprintf("%d consecutive errors", account.ConsecutiveErrors),
+			Name:   i18n.T(locale, "field.check_errors"),
+			Value:  i18n.N(locale, "check_errors.consecutive", account.ConsecutiveErrors),
 			Inline: true,
 		})
 	}
@@ -372,25 +409,25 @@ func disableAccount(s *discordgo.Session, account models.Account, reason string)
 	}
 
 	logger.Log.Infof("Account %s has been disabled. Reason: %s", account.Title, reason)
-	NotifyUserAboutDisabledAccount(s, account, reason)
+	NotifyUserAboutDisabledAccount(s, account, AccountDisabledReason(UserLocale(account.UserID), account))
 }
 
 func handlePermaBanNotification(s *discordgo.Session, account models.Account, ban models.Ban) {
+	locale := UserLocale(account.UserID)
 	permaBanEmbed := &discordgo.MessageEmbed{
-		Title: fmt.Sprintf("%s - Permanent Ban Detected", account.Title),
-		Description: "This account has been permanently banned. The account will no longer be checked automatically.\n" +
-			"using the /removeaccount command to free up your account slot.",
-		Color:     GetColorForStatus(models.StatusPermaban, false, false),
-		Timestamp: time.Now().Format(time.RFC3339),
+		Title:       i18n.T(locale, "permaban.title", account.Title),
+		Description: i18n.T(locale, "permaban.description_unchecked"),
+		Color:       GetColorForStatus(models.StatusPermaban, false, false),
+		Timestamp:   time.Now().Format(time.RFC3339),
 		Fields: []*discordgo.MessageEmbedField{
 			{
-				Name:   "Account Status",
-				Value:  "Permanently Banned",
+				Name:   i18n.T(locale, "field.account_status"),
+				Value:  i18n.T(locale, "permaban.status"),
 				Inline: true,
 			},
 			{
-				Name:   "Suggested Action",
-				Value:  "Remove account using /removeaccount",
+				Name:   i18n.T(locale, "field.suggested_action"),
+				Value:  i18n.T(locale, "permaban.action"),
 				Inline: true,
 			},
 		},
@@ -398,7 +435,7 @@ func handlePermaBanNotification(s *discordgo.Session, account models.Account, ba
 
 	if ban.AffectedGames != "" {
 		permaBanEmbed.Fields = append(permaBanEmbed.Fields, &discordgo.MessageEmbedField{
-			Name:   "Affected Games",
+			Name:   i18n.T(locale, "field.affected_games"),
 			Value:  ban.AffectedGames,
 			Inline: false,
 		})
@@ -410,13 +447,13 @@ func handlePermaBanNotification(s *discordgo.Session, account models.Account, ba
 }
 
 func handleShadowBanNotification(s *discordgo.Session, account models.Account, ban models.Ban) {
+	locale := UserLocale(account.UserID)
 	shadowBanEmbed := &discordgo.MessageEmbed{
-		Title: fmt.Sprintf("%s - Account Under Review", account.Title),
-		Description: "Your account has been placed under review (shadowban). " +
-			"This typically means your account is being investigated.",
-		Color:     GetColorForStatus(models.StatusShadowban, false, false),
-		Timestamp: time.Now().Format(time.RFC3339),
-		Fields:    getStatusFields(account, models.StatusShadowban, ban),
+		Title:       i18n.T(locale, "shadowban.title", account.Title),
+		Description: i18n.T(locale, "shadowban.description"),
+		Color:       GetColorForStatus(models.StatusShadowban, false, false),
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Fields:      getStatusFields(locale, account, models.StatusShadowban, ban),
 	}
 
 	if err := SendNotification(s, account, shadowBanEmbed, "", "shadowban_notice"); err != nil {
@@ -434,6 +471,7 @@ func ScheduleTempBanNotification(s *discordgo.Session, account models.Account, d
 	hours, _ := strconv.Atoi(strings.TrimSpace(strings.Split(parts[1], " ")[0]))
 
 	sleepDuration := time.Duration(days)*24*time.Hour + time.Duration(hours)*time.Hour
+	locale := UserLocale(account.UserID)
 
 	for remainingTime := sleepDuration; remainingTime > 0; remainingTime -= 24 * time.Hour {
 		if remainingTime > 24*time.Hour {
@@ -443,8 +481,8 @@ func ScheduleTempBanNotification(s *discordgo.Session, account models.Account, d
 		}
 
 		embed := &discordgo.MessageEmbed{
-			Title:       fmt.Sprintf("%s - Temporary Ban Update", account.Title),
-			Description: fmt.Sprintf("Your account is still temporarily banned. Remaining time: %v", remainingTime),
+			Title:       i18n.T(locale, "tempban.update.title", account.Title),
+			Description: i18n.T(locale, "tempban.update.remaining", FormatDuration(remainingTime)),
 			Color:       GetColorForStatus(models.StatusTempban, false, account.IsCheckDisabled),
 			Timestamp:   time.Now().Format(time.RFC3339),
 		}
@@ -454,31 +492,32 @@ func ScheduleTempBanNotification(s *discordgo.Session, account models.Account, d
 		}
 	}
 
-	result, err := CheckAccount(account.SSOCookie, account.UserID, "")
+	ctx := logger.WithCorrelationID(context.Background(), logger.NewCorrelationID())
+	result, err := CheckAccount(ctx, account.SSOCookie, account.UserID, "")
 	if err != nil {
-		logger.Log.WithError(err).Errorf("Failed to check account %s after temporary ban duration", account.Title)
+		logger.FromContext(ctx).WithError(err).Errorf("Failed to check account %s after temporary ban duration", account.Title)
 		return
 	}
 
 	var embed *discordgo.MessageEmbed
 	if result == models.StatusGood {
 		embed = &discordgo.MessageEmbed{
-			Title:       fmt.Sprintf("%s - Temporary Ban Lifted", account.Title),
-			Description: fmt.Sprintf("The temporary ban for account %s has been lifted. The account is now in good standing.", account.Title),
+			Title:       i18n.T(locale, "tempban.lifted.title", account.Title),
+			Description: i18n.T(locale, "tempban.lifted.description", account.Title),
 			Color:       GetColorForStatus(result, false, account.IsCheckDisabled),
 			Timestamp:   time.Now().Format(time.RFC3339),
 		}
 	} else if result == models.StatusPermaban {
 		embed = &discordgo.MessageEmbed{
-			Title:       fmt.Sprintf("%s - Temporary Ban Escalated", account.Title),
-			Description: fmt.Sprintf("The temporary ban for account %s has been escalated to a permanent ban.", account.Title),
+			Title:       i18n.T(locale, "tempban.escalated.title", account.Title),
+			Description: i18n.T(locale, "tempban.escalated.description", account.Title),
 			Color:       GetColorForStatus(result, false, account.IsCheckDisabled),
 			Timestamp:   time.Now().Format(time.RFC3339),
 		}
 	} else {
 		embed = &discordgo.MessageEmbed{
-			Title:       fmt.Sprintf("%s - Temporary Ban Update", account.Title),
-			Description: fmt.Sprintf("The temporary ban for account %s is still in effect. Current status: %s", account.Title, result),
+			Title:       i18n.T(locale, "tempban.update.title", account.Title),
+			Description: i18n.T(locale, "tempban.update.still", account.Title, StatusName(locale, result)),
 			Color:       GetColorForStatus(result, false, account.IsCheckDisabled),
 			Timestamp:   time.Now().Format(time.RFC3339),
 		}
@@ -490,24 +529,20 @@ func ScheduleTempBanNotification(s *discordgo.Session, account models.Account, d
 	}
 }
 
-func getChannelForAnnouncement(s *discordgo.Session, userID string, userSettings models.UserSettings) (string, error) {
-	if userSettings.NotificationType == "dm" {
-		channel, err := s.UserChannelCreate(userID)
-		if err != nil {
-			return "", fmt.Errorf("failed to create DM channel: %w", err)
-		}
-		return channel.ID, nil
+// GetAnnouncementChannel picks where to send an announcement to userID and
+// the shard session that owns that channel.
+func GetAnnouncementChannel(s *discordgo.Session, userID string, userSettings models.UserSettings) (*discordgo.Session, string, error) {
+	target := announcementTarget(userID, userSettings)
+	session := target.session(s)
+	if !target.DM {
+		return session, target.ChannelID, nil
 	}
 
-	var account models.Account
-	if err := database.DB.Where("user_id = ?", userID).Order("updated_at DESC").First(&account).Error; err != nil {
-		channel, err := s.UserChannelCreate(userID)
-		if err != nil {
-			return "", fmt.Errorf("both channel lookup and DM creation failed: %w", err)
-		}
-		return channel.ID, nil
+	channel, err := session.UserChannelCreate(userID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create DM channel: %w", err)
 	}
-	return account.ChannelID, nil
+	return session, channel.ID, nil
 }
 
 func calculateBanDuration(endTime time.Time) string {
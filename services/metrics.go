@@ -0,0 +1,234 @@
+package services
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bwmarrin/discordgo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "codstatusbot"
+
+var metricsRegistry = prometheus.NewRegistry()
+
+var metricsFactory = promauto.With(metricsRegistry)
+
+var (
+	accountChecksTotal = metricsFactory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "account_checks_total",
+		Help:      "Account checks by result status, or \"error\" when the check failed.",
+	}, []string{"result"})
+
+	accountCheckErrorsTotal = metricsFactory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "account_check_errors_total",
+		Help:      "Failed account checks by error category.",
+	}, []string{"category"})
+
+	accountCheckDuration = metricsFactory.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "account_check_duration_seconds",
+		Help:      "End-to-end duration of an account check, including the captcha solve.",
+		Buckets:   []float64{1, 2.5, 5, 10, 20, 30, 45, 60, 90, 120},
+	})
+
+	captchaSolvesTotal = metricsFactory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "captcha_solves_total",
+		Help:      "Captcha solve attempts by provider and outcome.",
+	}, []string{"provider", "outcome"})
+
+	captchaSolveDuration = metricsFactory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "captcha_solve_duration_seconds",
+		Help:      "Time taken to solve a captcha by provider.",
+		Buckets:   []float64{2.5, 5, 10, 15, 20, 30, 45, 60, 90},
+	}, []string{"provider"})
+
+	upstreamRequestDuration = metricsFactory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Latency of requests to Activision and captcha providers by upstream.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"upstream"})
+
+	upstreamResponsesTotal = metricsFactory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "upstream_responses_total",
+		Help:      "Responses from Activision and captcha providers by upstream and status code.",
+	}, []string{"upstream", "code"})
+
+	notificationsSuppressedTotal = metricsFactory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "notifications_suppressed_total",
+		Help:      "Notifications that were not delivered, by type and reason.",
+	}, []string{"type", "reason"})
+
+	commandDuration = metricsFactory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "command_duration_seconds",
+		Help:      "Slash command handling latency by command and success.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"command", "success"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	metricsFactory.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "notification_queue_depth",
+		Help:      "Notifications waiting in the delivery queue.",
+	}, func() float64 {
+		notificationQueue.mutex.RLock()
+		defer notificationQueue.mutex.RUnlock()
+		return float64(len(notificationQueue.items))
+	})
+
+	metricsFactory.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "schema_drift_tripped",
+		Help:      "1 when Activision schema drift has tripped and is awaiting acknowledgement.",
+	}, func() float64 {
+		if GetSchemaDriftStatus().Tripped {
+			return 1
+		}
+		return 0
+	})
+
+	metricsFactory.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "circuit_breakers_open",
+		Help:      "Circuit breakers currently rejecting requests.",
+	}, func() float64 {
+		return float64(len(OpenCircuitBreakers()))
+	})
+}
+
+// registerDatabaseMetrics exposes connection pool stats once the database is
+// connected.
+func registerDatabaseMetrics() {
+	if database.DB == nil {
+		return
+	}
+
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		logger.Log.WithError(err).Warn("Database pool metrics unavailable")
+		return
+	}
+
+	if err := metricsRegistry.Register(collectors.NewDBStatsCollector(sqlDB, metricsNamespace)); err != nil {
+		logger.Log.WithError(err).Warn("Failed to register database pool metrics")
+	}
+}
+
+// RegisterDiscordMetrics exposes gateway state across all shards, or for s
+// alone when the bot is not sharded.
+func RegisterDiscordMetrics(s *discordgo.Session) {
+	up := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "discord_gateway_up",
+		Help:      "1 when the Discord gateway connection is ready.",
+	}, func() float64 {
+		for _, session := range AllSessions(s) {
+			if !session.DataReady {
+				return 0
+			}
+		}
+		return 1
+	})
+
+	latency := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "discord_heartbeat_latency_seconds",
+		Help:      "Latency of the last Discord gateway heartbeat.",
+	}, func() float64 {
+		var worst time.Duration
+		for _, session := range AllSessions(s) {
+			if latency := session.HeartbeatLatency(); latency > worst {
+				worst = latency
+			}
+		}
+		return worst.Seconds()
+	})
+
+	guilds := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "discord_guilds",
+		Help:      "Guilds in the Discord session state.",
+	}, func() float64 {
+		return float64(guildCount(s))
+	})
+
+	for _, collector := range []prometheus.Collector{up, latency, guilds} {
+		if err := metricsRegistry.Register(collector); err != nil {
+			logger.Log.WithError(err).Warn("Failed to register Discord metrics")
+		}
+	}
+}
+
+func metricsHandler() http.Handler {
+	return promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{})
+}
+
+func observeAccountCheck(status models.Status, err error, duration time.Duration) {
+	accountCheckDuration.Observe(duration.Seconds())
+	if err != nil {
+		accountChecksTotal.WithLabelValues("error").Inc()
+		accountCheckErrorsTotal.WithLabelValues(string(ClassifyCheckError(err))).Inc()
+		return
+	}
+	accountChecksTotal.WithLabelValues(string(status)).Inc()
+}
+
+func observeCaptchaSolve(provider, outcome string, duration time.Duration) {
+	captchaSolvesTotal.WithLabelValues(provider, outcome).Inc()
+	if outcome == "success" {
+		captchaSolveDuration.WithLabelValues(provider).Observe(duration.Seconds())
+	}
+}
+
+func observeUpstreamResponse(upstream string, statusCode int, duration time.Duration) {
+	code := "error"
+	if statusCode > 0 {
+		code = strconv.Itoa(statusCode)
+	}
+	upstreamRequestDuration.WithLabelValues(upstream).Observe(duration.Seconds())
+	upstreamResponsesTotal.WithLabelValues(upstream, code).Inc()
+}
+
+func observeNotificationSuppressed(notificationType, reason string) {
+	notificationsSuppressedTotal.WithLabelValues(notificationType, reason).Inc()
+}
+
+// ObserveCommandLatency records how long a slash command took to handle.
+func ObserveCommandLatency(commandName string, success bool, duration time.Duration) {
+	commandDuration.WithLabelValues(commandName, strconv.FormatBool(success)).Observe(duration.Seconds())
+}
+
+// guildCount sums the guilds cached by every shard.
+func guildCount(s *discordgo.Session) int {
+	total := 0
+	for _, session := range AllSessions(s) {
+		if session.State == nil {
+			continue
+		}
+		session.State.RLock()
+		total += len(session.State.Guilds)
+		session.State.RUnlock()
+	}
+	return total
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/bradselph/CODStatusBot/configuration"
 	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
 	"github.com/bwmarrin/discordgo"
@@ -51,6 +52,7 @@ var (
 		"permaban":             {Type: "permaban", Cooldown: 12 * time.Hour, AllowConsolidated: true, MaxPerHour: 4},
 		"shadowban":            {Type: "shadowban", Cooldown: 6 * time.Hour, AllowConsolidated: true, MaxPerHour: 5},
 		"temp_ban_update":      {Type: "temp_ban_update", Cooldown: 30 * time.Minute, AllowConsolidated: true, MaxPerHour: 8},
+		"check_reenabled":      {Type: "check_reenabled", Cooldown: 1 * time.Hour, AllowConsolidated: true, MaxPerHour: 4},
 	}
 )
 
@@ -89,12 +91,6 @@ func NotifyAdmin(s *discordgo.Session, message string) {
 		return
 	}
 
-	channel, err := s.UserChannelCreate(adminID)
-	if err != nil {
-		logger.Log.WithError(err).Error("Failed to create DM channel with admin")
-		return
-	}
-
 	embed := &discordgo.MessageEmbed{
 		Title:       "Admin Notification",
 		Description: message,
@@ -102,8 +98,8 @@ func NotifyAdmin(s *discordgo.Session, message string) {
 		Timestamp:   time.Now().Format(time.RFC3339),
 	}
 
-	_, err = s.ChannelMessageSendEmbed(channel.ID, embed)
-	if err != nil {
+	target := messageTarget{UserID: adminID, DM: true}
+	if err := deliverMessage(s, target, &discordgo.MessageSend{Embed: embed}); err != nil {
 		logger.Log.WithError(err).Error("Failed to send admin notification")
 	}
 }
@@ -125,22 +121,6 @@ func GetCooldownDuration(userSettings models.UserSettings, notificationType stri
 	}
 }
 
-func GetNotificationChannel(s *discordgo.Session, account models.Account, userSettings models.UserSettings) (string, error) {
-	if userSettings.NotificationType == "dm" {
-		channel, err := s.UserChannelCreate(account.UserID)
-		if err != nil {
-			return "", fmt.Errorf("failed to create DM channel: %w", err)
-		}
-		return channel.ID, nil
-	}
-
-	if account.ChannelID == "" {
-		return "", fmt.Errorf("no channel ID set for account")
-	}
-
-	return account.ChannelID, nil
-}
-
 func FormatDuration(d time.Duration) string {
 	days := int(d.Hours() / 24)
 	hours := int(d.Hours()) % 24
@@ -180,15 +160,15 @@ func CheckAndNotifyBalance(s *discordgo.Session, userID string, balance float64)
 
 		if apiKey == cfg.CaptchaService.EZCaptcha.ClientKey {
 			if balance < cfg.CaptchaService.EZCaptcha.BalanceMin {
+				locale := UserLocale(userID)
 				embed := &discordgo.MessageEmbed{
-					Title: "Default API Key Balance Low",
-					Description: fmt.Sprintf("The bot's default API key balance is currently low (%.2f points). "+
-						"To ensure uninterrupted service, consider the following options:", balance),
-					Color:     0xFFA500,
-					Fields:    BalanceWarningFields(),
-					Timestamp: time.Now().Format(time.RFC3339),
+					Title:       i18n.T(locale, "notify.default_key_low.title"),
+					Description: i18n.T(locale, "notify.default_key_low.description", balance),
+					Color:       0xFFA500,
+					Fields:      BalanceWarningFields(locale),
+					Timestamp:   time.Now().Format(time.RFC3339),
 					Footer: &discordgo.MessageEmbedFooter{
-						Text: "Thank you for using COD Status Bot!",
+						Text: i18n.T(locale, "footer.thanks"),
 					},
 				}
 
@@ -211,25 +191,25 @@ func CheckAndNotifyBalance(s *discordgo.Session, userID string, balance float64)
 	threshold := getBalanceThreshold(userSettings.PreferredCaptchaProvider)
 
 	if balance < threshold {
+		locale := UserLocale(userID)
+		provider := userSettings.PreferredCaptchaProvider
 		embed := &discordgo.MessageEmbed{
-			Title: fmt.Sprintf("Low %s Balance Alert", userSettings.PreferredCaptchaProvider),
-			Description: fmt.Sprintf("Your %s balance is currently %.2f points, which is below the recommended threshold of %.2f points.",
-				userSettings.PreferredCaptchaProvider, balance, threshold),
-			Color: 0xFFA500,
+			Title:       i18n.T(locale, "notify.balance_low.title", provider),
+			Description: i18n.T(locale, "notify.balance_low.description", provider, balance, threshold),
+			Color:       0xFFA500,
 			Fields: []*discordgo.MessageEmbedField{
 				{
-					Name: "Action Required",
-					Value: fmt.Sprintf("Please recharge your %s balance to ensure uninterrupted service for your account checks.",
-						userSettings.PreferredCaptchaProvider),
+					Name:   i18n.T(locale, "field.action_required"),
+					Value:  i18n.T(locale, "notify.balance_low.action", provider),
 					Inline: false,
 				},
 				{
-					Name:   "Current Provider",
-					Value:  userSettings.PreferredCaptchaProvider,
+					Name:   i18n.T(locale, "field.current_provider"),
+					Value:  provider,
 					Inline: true,
 				},
 				{
-					Name:   "Current Balance",
+					Name:   i18n.T(locale, "field.current_balance"),
 					Value:  fmt.Sprintf("%.2f", balance),
 					Inline: true,
 				},
@@ -259,18 +239,16 @@ func CheckAndNotifyBalance(s *discordgo.Session, userID string, balance float64)
 	}
 }
 
-func BalanceWarningFields() []*discordgo.MessageEmbedField {
+func BalanceWarningFields(locale string) []*discordgo.MessageEmbedField {
 	fields := []*discordgo.MessageEmbedField{
 		{
-			Name: "Option 1: Add Your Own API Key",
-			Value: "Use `/setcaptchaservice` to configure your own Captcha API key. " +
-				"This gives you unlimited checks and customizable intervals.",
+			Name:   i18n.T(locale, "balance.option_own_key.name"),
+			Value:  i18n.T(locale, "balance.option_own_key.value"),
 			Inline: false,
 		},
 		{
-			Name: "Option 2: Wait for Balance Refresh",
-			Value: "Continue using the bot's default key with standard rate limits. " +
-				"Checks will still work but may be delayed.",
+			Name:   i18n.T(locale, "balance.option_wait.name"),
+			Value:  i18n.T(locale, "balance.option_wait.value"),
 			Inline: false,
 		},
 	}
@@ -283,6 +261,10 @@ func ScheduleBalanceChecks(s *discordgo.Session) {
 	defer ticker.Stop()
 
 	for range ticker.C {
+		if !IsLeader() {
+			continue
+		}
+
 		var users []models.UserSettings
 		if err := database.DB.Find(&users).Error; err != nil {
 			logger.Log.WithError(err).Error("Failed to fetch users for balance check")
@@ -290,11 +272,14 @@ func ScheduleBalanceChecks(s *discordgo.Session) {
 		}
 
 		for _, user := range users {
+			if !StillLeader() {
+				break
+			}
 			if !IsServiceEnabled(user.PreferredCaptchaProvider) {
 				continue
 			}
 
-			if user.EZCaptchaAPIKey == "" && user.TwoCaptchaAPIKey == "" && user.CapSolverAPIKey == "" {
+			if !HasOwnCaptchaKey(user) {
 				continue
 			}
 
@@ -321,7 +306,7 @@ func ScheduleBalanceChecks(s *discordgo.Session) {
 			}
 
 			if !isValid {
-				if err := DisableUserCaptcha(s, user.UserID, fmt.Sprintf("Invalid %s API key", provider)); err != nil {
+				if err := DisableUserCaptcha(s, user.UserID, i18n.T(UserLocale(user.UserID), "captcha.reason.invalid_key", provider)); err != nil {
 					logger.Log.WithError(err).Errorf("Failed to disable captcha for user %s", user.UserID)
 				}
 				continue
@@ -366,15 +351,12 @@ func DisableUserCaptcha(s *discordgo.Session, userID string, reason string) erro
 		return err
 	}
 
+	locale := UserLocale(userID)
 	embed := &discordgo.MessageEmbed{
-		Title: "Captcha Service Configuration Update",
-		Description: fmt.Sprintf("Your captcha service configuration has been updated. Reason: %s\n\n"+
-			"Current available services: %s\n"+
-			"The bot will use default settings for the available service.",
-			reason,
-			getEnabledServicesString()),
-		Color:     0xFF0000,
-		Timestamp: time.Now().Format(time.RFC3339),
+		Title:       i18n.T(locale, "notify.captcha_disabled.title"),
+		Description: i18n.T(locale, "notify.captcha_disabled.description", reason, getEnabledServicesString(locale)),
+		Color:       0xFF0000,
+		Timestamp:   time.Now().Format(time.RFC3339),
 	}
 
 	var account models.Account
@@ -385,7 +367,7 @@ func DisableUserCaptcha(s *discordgo.Session, userID string, reason string) erro
 	return SendNotification(s, account, embed, "", "captcha_disabled")
 }
 
-func getEnabledServicesString() string {
+func getEnabledServicesString(locale string) string {
 	var enabledServices []string
 	if IsServiceEnabled("capsolver") {
 		enabledServices = append(enabledServices, "Capsolver")
@@ -399,7 +381,7 @@ func getEnabledServicesString() string {
 		enabledServices = append(enabledServices, "2Captcha")
 	}
 	if len(enabledServices) == 0 {
-		return "No services currently enabled"
+		return i18n.T(locale, "captcha.none_enabled")
 	}
 	return strings.Join(enabledServices, ", ")
 }
@@ -415,7 +397,8 @@ func (nl *NotificationLimiter) CanSendNotification(userID string, notificationTy
 		return false
 	}
 
-	if userSettings.CapSolverAPIKey != "" || userSettings.EZCaptchaAPIKey != "" || userSettings.TwoCaptchaAPIKey != "" {
+	perHour := UserQuotas(userSettings).NotificationsPerHour
+	if perHour == 0 {
 		return true
 	}
 
@@ -423,6 +406,9 @@ func (nl *NotificationLimiter) CanSendNotification(userID string, notificationTy
 	if !exists {
 		return true
 	}
+	if config.MaxPerHour < perHour {
+		perHour = config.MaxPerHour
+	}
 
 	nl.Lock()
 	defer nl.Unlock()
@@ -443,7 +429,7 @@ func (nl *NotificationLimiter) CanSendNotification(userID string, notificationTy
 		state.lastReset = now
 	}
 
-	if state.hourlyCount >= config.MaxPerHour {
+	if state.hourlyCount >= perHour {
 		storeSuppressedNotification(userID, notificationType, nil, "")
 		logger.Log.WithFields(logrus.Fields{
 			"userID":       userID,
@@ -467,6 +453,7 @@ func (nl *NotificationLimiter) CanSendNotification(userID string, notificationTy
 func SendNotification(s *discordgo.Session, account models.Account, embed *discordgo.MessageEmbed, content, notificationType string) error {
 	if !globalLimiter.CanSendNotification(account.UserID, notificationType) {
 		storeSuppressedNotification(account.UserID, notificationType, embed, content)
+		observeNotificationSuppressed(notificationType, "rate_limited")
 		logger.Log.WithFields(logrus.Fields{
 			"userID":           account.UserID,
 			"accountTitle":     account.Title,
@@ -484,6 +471,7 @@ func SendNotification(s *discordgo.Session, account models.Account, embed *disco
 		cfg := configuration.Get()
 		if time.Since(userSettings.UnreachableSince) < cfg.Users.UnreachableResetPeriod {
 			logger.Log.Debugf("Skipping notification to unreachable user %s", account.UserID)
+			observeNotificationSuppressed(notificationType, "unreachable")
 			return nil
 		}
 		userSettings.IsUnreachable = false
@@ -498,23 +486,18 @@ func SendNotification(s *discordgo.Session, account models.Account, embed *disco
 	cooldownDuration := GetCooldownDuration(userSettings, notificationType, getDefaultCooldown())
 	if !lastNotification.IsZero() && now.Sub(lastNotification) < cooldownDuration {
 		logger.Log.Infof("Skipping %s notification for user %s (cooldown)", notificationType, account.UserID)
+		observeNotificationSuppressed(notificationType, "cooldown")
 		return nil
 	}
 
-	channelID, err := GetNotificationChannel(s, account, userSettings)
-	if err != nil {
-		if userSettings.NotificationType == "dm" {
-			channel, dmErr := s.UserChannelCreate(account.UserID)
-			if dmErr != nil {
-				return fmt.Errorf("failed to create DM channel: %w", dmErr)
-			}
-			channelID = channel.ID
-		} else {
-			return fmt.Errorf("failed to get notification channel: %w", err)
-		}
+	target := messageTarget{UserID: account.UserID, ChannelID: account.ChannelID, GuildID: account.GuildID}
+	if userSettings.NotificationType == "dm" {
+		target = messageTarget{UserID: account.UserID, DM: true}
+	} else if account.ChannelID == "" {
+		return fmt.Errorf("failed to get notification channel: no channel ID set for account")
 	}
 
-	_, err = s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+	err = deliverMessage(s, target, &discordgo.MessageSend{
 		Embed:   embed,
 		Content: content,
 	})
@@ -524,7 +507,7 @@ func SendNotification(s *discordgo.Session, account models.Account, embed *disco
 
 	if err != nil {
 		TrackMessageFailure(account.UserID, err.Error())
-		return fmt.Errorf("failed to send message: %w", err)
+		return err
 	}
 
 	userSettings.LastCommandTimes[notificationType] = now
@@ -586,63 +569,13 @@ func NotifyAdminWithCooldown(s *discordgo.Session, message string, cooldownDurat
 	}
 }
 
-func SendGlobalAnnouncement(s *discordgo.Session, userID string) error {
-	var userSettings models.UserSettings
-	result := database.DB.Where(models.UserSettings{UserID: userID}).FirstOrCreate(&userSettings)
-	if result.Error != nil {
-		logger.Log.WithError(result.Error).Error("Error getting user settings for global announcement")
-		return result.Error
-	}
-
-	if !userSettings.HasSeenAnnouncement {
-		channelID, err := getChannelForAnnouncement(s, userID, userSettings)
-		if err != nil {
-			logger.Log.WithError(err).Error("Error finding recent channel for user")
-			return err
-		}
-
-		announcementEmbed := CreateAnnouncementEmbed()
-
-		_, err = s.ChannelMessageSendEmbed(channelID, announcementEmbed)
-		if err != nil {
-			TrackMessageFailure(userID, err.Error())
-			logger.Log.WithError(err).Error("Error sending global announcement")
-			return err
-		}
-
-		userSettings.HasSeenAnnouncement = true
-		if err := database.DB.Save(&userSettings).Error; err != nil {
-			logger.Log.WithError(err).Error("Error updating user settings after sending global announcement")
-			return err
-		}
-	}
-
-	return nil
-}
-
-func SendAnnouncementToAllUsers(s *discordgo.Session) error {
-	var users []models.UserSettings
-	if err := database.DB.Find(&users).Error; err != nil {
-		logger.Log.WithError(err).Error("Error fetching all users")
-		return err
-	}
-
-	for _, user := range users {
-		if err := SendGlobalAnnouncement(s, user.UserID); err != nil {
-			logger.Log.WithError(err).Errorf("Failed to send announcement to user %s", user.UserID)
-		}
-	}
-
-	return nil
-}
-
 func NotifyUserAboutDisabledAccount(s *discordgo.Session, account models.Account, reason string) {
+	locale := UserLocale(account.UserID)
 	embed := &discordgo.MessageEmbed{
-		Title: "Account Disabled",
-		Description: fmt.Sprintf("Your account '%s' has been disabled. Reason: %s\n\n"+
-			"To re-enable monitoring, please address the issue and use the /togglecheck command to re-enable your account.", account.Title, reason),
-		Color:     0xFF0000,
-		Timestamp: time.Now().Format(time.RFC3339),
+		Title:       i18n.T(locale, "notify.account_disabled.title"),
+		Description: i18n.T(locale, "notify.account_disabled.description", account.Title, reason),
+		Color:       0xFF0000,
+		Timestamp:   time.Now().Format(time.RFC3339),
 	}
 
 	err := SendNotification(s, account, embed, "", "account_disabled")
@@ -657,6 +590,7 @@ func NotifyCookieExpiringSoon(s *discordgo.Session, accounts []models.Account) e
 		return nil
 	}
 
+	locale := UserLocale(accounts[0].UserID)
 	var embedFields []*discordgo.MessageEmbedField
 	for _, account := range accounts {
 		timeUntilExpiration, err := CheckSSOCookieExpiration(account.SSOCookieExpiration)
@@ -667,7 +601,7 @@ func NotifyCookieExpiringSoon(s *discordgo.Session, accounts []models.Account) e
 
 		embedFields = append(embedFields, &discordgo.MessageEmbedField{
 			Name:   account.Title,
-			Value:  fmt.Sprintf("Cookie expires in %s", FormatDuration(timeUntilExpiration)),
+			Value:  i18n.T(locale, "notify.cookie_expiring.field", FormatDuration(timeUntilExpiration)),
 			Inline: false,
 		})
 	}
@@ -677,8 +611,8 @@ func NotifyCookieExpiringSoon(s *discordgo.Session, accounts []models.Account) e
 	}
 
 	embed := &discordgo.MessageEmbed{
-		Title:       "SSO Cookie Expiration Warning",
-		Description: "The following accounts have SSO cookies that will expire soon:",
+		Title:       i18n.T(locale, "notify.cookie_expiring.title"),
+		Description: i18n.T(locale, "notify.cookie_expiring.description"),
 		Color:       0xFFA500,
 		Fields:      embedFields,
 		Timestamp:   time.Now().Format(time.RFC3339),
@@ -711,10 +645,11 @@ func SendConsolidatedDailyUpdate(s *discordgo.Session, userID string, userSettin
 		return
 	}
 
+	locale := UserLocale(userID)
 	var embedFields []*discordgo.MessageEmbedField
 	embedFields = append(embedFields, &discordgo.MessageEmbedField{
-		Name: "Summary",
-		Value: fmt.Sprintf("Total Accounts: %d\nGood Standing: %d\nBanned: %d\nUnder Review: %d",
+		Name: i18n.T(locale, "field.summary"),
+		Value: i18n.T(locale, "daily.summary",
 			len(accounts),
 			len(accountsByStatus[models.StatusGood]),
 			len(accountsByStatus[models.StatusPermaban])+len(accountsByStatus[models.StatusTempban]),
@@ -733,12 +668,12 @@ func SendConsolidatedDailyUpdate(s *discordgo.Session, userID string, userSettin
 
 			statusSymbol := GetStatusIcon(status)
 			description.WriteString(fmt.Sprintf("%s %s: %s\n", statusSymbol, account.Title,
-				formatAccountStatus(account, status, timeUntilExpiration)))
+				formatAccountStatus(locale, account, status, timeUntilExpiration)))
 		}
 
 		if description.Len() > 0 {
 			embedFields = append(embedFields, &discordgo.MessageEmbedField{
-				Name:   fmt.Sprintf("%s Accounts", strings.Title(string(status))),
+				Name:   i18n.T(locale, "daily.group", StatusName(locale, status)),
 				Value:  description.String(),
 				Inline: false,
 			})
@@ -746,13 +681,13 @@ func SendConsolidatedDailyUpdate(s *discordgo.Session, userID string, userSettin
 	}
 
 	embed := &discordgo.MessageEmbed{
-		Title:       fmt.Sprintf("%.2f Hour Update - Account Status Report", userSettings.NotificationInterval),
-		Description: "Here's a consolidated update on your monitored accounts:",
+		Title:       i18n.T(locale, "daily.title", userSettings.NotificationInterval),
+		Description: i18n.T(locale, "daily.description"),
 		Color:       0x00ff00,
 		Fields:      embedFields,
 		Timestamp:   time.Now().Format(time.RFC3339),
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Use /checknow to check any account immediately",
+			Text: i18n.T(locale, "daily.footer"),
 		},
 	}
 
@@ -786,50 +721,50 @@ func GetStatusIcon(status models.Status) string {
 	}
 }
 
-func formatAccountStatus(account models.Account, status models.Status, timeUntilExpiration time.Duration) string {
+func formatAccountStatus(locale string, account models.Account, status models.Status, timeUntilExpiration time.Duration) string {
 	var statusDesc strings.Builder
 
 	switch status {
 	case models.StatusGood:
-		statusDesc.WriteString(fmt.Sprintf("Good standing | Expires in %s", FormatDuration(timeUntilExpiration)))
+		statusDesc.WriteString(i18n.T(locale, "accountstatus.good", FormatDuration(timeUntilExpiration)))
 	case models.StatusPermaban:
-		statusDesc.WriteString("Permanently banned")
+		statusDesc.WriteString(i18n.T(locale, "accountstatus.permaban"))
 	case models.StatusShadowban:
-		statusDesc.WriteString("Under review")
+		statusDesc.WriteString(i18n.T(locale, "accountstatus.shadowban"))
 	case models.StatusTempban:
 		var latestBan models.Ban
 		if err := database.DB.Where("account_id = ?", account.ID).
 			Order("created_at DESC").
 			First(&latestBan).Error; err == nil {
-			statusDesc.WriteString(fmt.Sprintf("Temporarily banned (%s remaining)", latestBan.TempBanDuration))
+			statusDesc.WriteString(i18n.T(locale, "accountstatus.tempban", latestBan.TempBanDuration))
 		} else {
-			statusDesc.WriteString("Temporarily banned (duration unknown)")
+			statusDesc.WriteString(i18n.T(locale, "accountstatus.tempban_unknown"))
 		}
 	default:
-		statusDesc.WriteString("Unknown status")
+		statusDesc.WriteString(i18n.T(locale, "accountstatus.unknown"))
 	}
 
 	if isVIP, err := CheckVIPStatus(account.SSOCookie); err == nil {
-		statusDesc.WriteString(fmt.Sprintf(" | %s", formatVIPStatus(isVIP)))
+		statusDesc.WriteString(fmt.Sprintf(" | %s", formatVIPStatus(locale, isVIP)))
 	}
 
-	statusDesc.WriteString(fmt.Sprintf(" | Checks: %s", formatCheckStatus(account.IsCheckDisabled)))
+	statusDesc.WriteString(" | " + i18n.T(locale, "accountstatus.checks", formatCheckStatus(locale, account.IsCheckDisabled)))
 
 	return statusDesc.String()
 }
 
-func formatVIPStatus(isVIP bool) string {
+func formatVIPStatus(locale string, isVIP bool) string {
 	if isVIP {
-		return "VIP Account"
+		return i18n.T(locale, "vip.yes")
 	}
-	return "Regular Account"
+	return i18n.T(locale, "vip.no")
 }
 
-func formatCheckStatus(isDisabled bool) string {
+func formatCheckStatus(locale string, isDisabled bool) string {
 	if isDisabled {
-		return "DISABLED"
+		return i18n.T(locale, "checks.disabled")
 	}
-	return "ENABLED"
+	return i18n.T(locale, "checks.enabled")
 }
 
 func getNotificationType(status models.Status) string {
@@ -882,9 +817,10 @@ func notifyAccountErrors(s *discordgo.Session, errorAccounts []models.Account, u
 	}
 
 	cfg := configuration.Get()
+	locale := UserLocale(errorAccounts[0].UserID)
 	embed := &discordgo.MessageEmbed{
-		Title:       "Account Check Errors",
-		Description: "The following accounts have encountered errors during status checks:",
+		Title:       i18n.T(locale, "notify.check_errors.title"),
+		Description: i18n.T(locale, "notify.check_errors.description"),
 		Color:       0xFF0000,
 		Fields:      make([]*discordgo.MessageEmbedField, 0),
 		Timestamp:   time.Now().Format(time.RFC3339),
@@ -893,12 +829,12 @@ func notifyAccountErrors(s *discordgo.Session, errorAccounts []models.Account, u
 	for _, account := range errorAccounts {
 		var errorDescription string
 		if account.IsCheckDisabled {
-			errorDescription = fmt.Sprintf("Checks disabled - Reason: %s", account.DisabledReason)
+			errorDescription = i18n.T(locale, "notify.check_errors.disabled", account.DisabledReason)
 		} else if account.ConsecutiveErrors >= cfg.CaptchaService.MaxRetries {
-			errorDescription = fmt.Sprintf("Multiple check failures - Last error time: %s",
+			errorDescription = i18n.T(locale, "notify.check_errors.repeated",
 				account.LastErrorTime.Format("2006-01-02 15:04:05"))
 		} else {
-			errorDescription = "Unknown error"
+			errorDescription = i18n.T(locale, "checkerror.unknown")
 		}
 
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
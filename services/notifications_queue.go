@@ -46,9 +46,19 @@ var notificationQueue = &NotificationQueue{
 }
 
 var adaptiveRateLimits = &AdaptiveRateLimits{
-	UserBackoffs:  make(map[string]*UserBackoff),
-	BaseLimit:     configuration.Get().Notifications.MaxPerHour,
-	HistoryWindow: configuration.Get().Notifications.BackoffHistoryWindow,
+	UserBackoffs: make(map[string]*UserBackoff),
+}
+
+// applyRuntimeConfig refreshes values copied out of the configuration at
+// startup; it runs again after every configuration reload.
+func applyRuntimeConfig() {
+	cfg := configuration.Get()
+	initDefaultSettings()
+
+	adaptiveRateLimits.Lock()
+	adaptiveRateLimits.BaseLimit = cfg.Notifications.MaxPerHour
+	adaptiveRateLimits.HistoryWindow = cfg.Notifications.BackoffHistoryWindow
+	adaptiveRateLimits.Unlock()
 }
 
 func (a *AdaptiveRateLimits) GetBackoffDuration(userID string) time.Duration {
@@ -129,6 +139,7 @@ func (q *NotificationQueue) processNextNotification(discord *discordgo.Session)
 				q.AddNotification(item)
 			} else {
 				logger.Log.Warnf("Dropping notification for user %s after max retries", item.UserID)
+				observeNotificationSuppressed("queued", "max_retries")
 			}
 			return
 		}
@@ -139,7 +150,7 @@ func (q *NotificationQueue) processNextNotification(discord *discordgo.Session)
 		go func() {
 			defer close(ch)
 			defer close(errCh)
-			channel, err := discord.UserChannelCreate(item.UserID)
+			channel, err := SessionForDM(discord).UserChannelCreate(item.UserID)
 			if err != nil {
 				errCh <- err
 				return
@@ -149,7 +160,7 @@ func (q *NotificationQueue) processNextNotification(discord *discordgo.Session)
 
 		select {
 		case channel := <-ch:
-			if err := sendMessageWithRetry(discord, channel.ID, item.Content); err != nil {
+			if err := sendMessageWithRetry(SessionForDM(discord), channel.ID, item.Content); err != nil {
 				logger.Log.WithError(err).Errorf("Failed to send notification to user %s", item.UserID)
 				if item.RetryCount < 3 {
 					item.RetryCount++
@@ -255,7 +266,20 @@ func (q *NotificationQueue) Shutdown(ctx context.Context) error {
 		return ctx.Err()
 	}
 }
+
+// CleanupOldRateLimitData purges expired rate limit state from the store.
+// The database store is shared by every process, so only the leader runs it.
 func CleanupOldRateLimitData() {
+	if purged, err := Limiter().Purge(); err != nil {
+		logger.Log.WithError(err).Error("Failed to purge expired rate limit state")
+	} else {
+		logger.Log.Infof("Purged %d expired rate limit entries", purged)
+	}
+}
+
+// CleanupNotificationBackoffs drops this process's notification backoff
+// history once it falls outside the history window.
+func CleanupNotificationBackoffs() {
 	adaptiveRateLimits.Lock()
 	defer adaptiveRateLimits.Unlock()
 
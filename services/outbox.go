@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	outboxPending = "pending"
+	outboxSent    = "sent"
+	outboxFailed  = "failed"
+
+	outboxPollInterval = 2 * time.Second
+	outboxBatchSize    = 50
+	outboxMaxAttempts  = 5
+	outboxRetryBackoff = 30 * time.Second
+	outboxRetention    = 7 * 24 * time.Hour
+)
+
+// messageTarget is where a message goes: a DM to UserID, or ChannelID in
+// GuildID.
+type messageTarget struct {
+	UserID    string
+	ChannelID string
+	GuildID   string
+	DM        bool
+}
+
+func (t messageTarget) session(s *discordgo.Session) *discordgo.Session {
+	if t.DM {
+		return SessionForDM(s)
+	}
+	return SessionForGuild(s, t.GuildID)
+}
+
+// deliverMessage sends msg on the shard that owns the target. Processes
+// without a Discord connection, such as workers, store the message in the
+// outbox for the gateway to deliver instead.
+func deliverMessage(s *discordgo.Session, target messageTarget, msg *discordgo.MessageSend) error {
+	session := target.session(s)
+	if session == nil {
+		return enqueueOutboundMessage(target, msg)
+	}
+	return sendToTarget(session, target, msg)
+}
+
+func sendToTarget(session *discordgo.Session, target messageTarget, msg *discordgo.MessageSend) error {
+	channelID := target.ChannelID
+	if target.DM {
+		channel, err := session.UserChannelCreate(target.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to create DM channel: %w", err)
+		}
+		channelID = channel.ID
+	}
+
+	if _, err := session.ChannelMessageSendComplex(channelID, msg); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return nil
+}
+
+func enqueueOutboundMessage(target messageTarget, msg *discordgo.MessageSend) error {
+	// MessageSend.Embed is not serialized, discordgo only folds it into
+	// Embeds when sending.
+	if msg.Embed != nil {
+		folded := *msg
+		folded.Embeds = append([]*discordgo.MessageEmbed{msg.Embed}, msg.Embeds...)
+		folded.Embed = nil
+		msg = &folded
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbound message: %w", err)
+	}
+
+	outbound := models.OutboundMessage{
+		UserID:        target.UserID,
+		ChannelID:     target.ChannelID,
+		GuildID:       target.GuildID,
+		DirectMessage: target.DM,
+		Payload:       string(payload),
+		Status:        outboxPending,
+	}
+	if err := database.DB.Create(&outbound).Error; err != nil {
+		return fmt.Errorf("failed to queue outbound message: %w", err)
+	}
+	return nil
+}
+
+// StartOutboxDispatcher delivers messages queued by workers and API
+// processes until ctx is cancelled.
+func StartOutboxDispatcher(ctx context.Context, s *discordgo.Session) {
+	go func() {
+		ticker := time.NewTicker(outboxPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				dispatchOutbox(s)
+			}
+		}
+	}()
+	logger.Log.Info("Outbox dispatcher started")
+}
+
+func dispatchOutbox(s *discordgo.Session) {
+	// Replicated gateways would otherwise deliver every message twice.
+	if !IsLeader() {
+		return
+	}
+
+	var pending []models.OutboundMessage
+	if err := database.DB.Where("status = ?", outboxPending).
+		Order("id").
+		Limit(outboxBatchSize).
+		Find(&pending).Error; err != nil {
+		logger.Log.WithError(err).Error("Failed to fetch outbound messages")
+		return
+	}
+
+	for _, outbound := range pending {
+		if !StillLeader() {
+			return
+		}
+		if outbound.Attempts > 0 && time.Since(outbound.UpdatedAt) < time.Duration(outbound.Attempts)*outboxRetryBackoff {
+			continue
+		}
+		deliverOutboundMessage(s, outbound)
+	}
+}
+
+func deliverOutboundMessage(s *discordgo.Session, outbound models.OutboundMessage) {
+	target := messageTarget{
+		UserID:    outbound.UserID,
+		ChannelID: outbound.ChannelID,
+		GuildID:   outbound.GuildID,
+		DM:        outbound.DirectMessage,
+	}
+
+	var msg discordgo.MessageSend
+	err := json.Unmarshal([]byte(outbound.Payload), &msg)
+	if err != nil {
+		outbound.Attempts = outboxMaxAttempts
+		err = fmt.Errorf("failed to decode outbound message: %w", err)
+	} else {
+		outbound.Attempts++
+		err = sendToTarget(target.session(s), target, &msg)
+	}
+
+	if err == nil {
+		now := time.Now()
+		outbound.Status = outboxSent
+		outbound.SentAt = &now
+		outbound.LastError = ""
+	} else {
+		outbound.LastError = err.Error()
+		if outbound.Attempts >= outboxMaxAttempts {
+			outbound.Status = outboxFailed
+		}
+		TrackMessageFailure(outbound.UserID, err.Error())
+		logger.Log.WithError(err).Warnf("Failed to deliver outbound message %d to user %s (attempt %d)", outbound.ID, outbound.UserID, outbound.Attempts)
+	}
+
+	if err := database.DB.Save(&outbound).Error; err != nil {
+		logger.Log.WithError(err).Errorf("Failed to update outbound message %d", outbound.ID)
+	}
+}
@@ -0,0 +1,568 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/configuration"
+	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bradselph/CODStatusBot/utils"
+	"gorm.io/gorm"
+)
+
+//go:embed portal/*
+var portalFiles embed.FS
+
+const (
+	portalSessionCookie = "csb_portal_session"
+	portalStateCookie   = "csb_portal_state"
+
+	discordAuthorizeURL = "https://discord.com/oauth2/authorize"
+	discordTokenURL     = "https://discord.com/api/oauth2/token"
+	discordUserURL      = "https://discord.com/api/users/@me"
+
+	portalBanHistoryLimit = 200
+)
+
+var errPortalUnauthenticated = errors.New("not logged in")
+
+// portalAccountView is what the browser sees of an account. It deliberately
+// has no field for the SSO cookie.
+type portalAccountView struct {
+	ID                   uint          `json:"id"`
+	Title                string        `json:"title"`
+	Status               models.Status `json:"status"`
+	LastCheck            int64         `json:"last_check"`
+	LastStatusChange     int64         `json:"last_status_change"`
+	Created              int64         `json:"created"`
+	IsVIP                bool          `json:"is_vip"`
+	IsCheckDisabled      bool          `json:"is_check_disabled"`
+	DisabledReason       string        `json:"disabled_reason"`
+	IsExpiredCookie      bool          `json:"is_expired_cookie"`
+	CookieExpiresAt      int64         `json:"cookie_expires_at"`
+	CookieExpiresIn      int64         `json:"cookie_expires_in"`
+	CookieExpiresDisplay string        `json:"cookie_expires_display"`
+}
+
+type portalBanView struct {
+	Timestamp       time.Time     `json:"timestamp"`
+	LogType         string        `json:"log_type"`
+	Status          models.Status `json:"status"`
+	PreviousStatus  models.Status `json:"previous_status"`
+	Message         string        `json:"message"`
+	TempBanDuration string        `json:"temp_ban_duration"`
+	AffectedGames   string        `json:"affected_games"`
+	Initiator       string        `json:"initiator"`
+}
+
+type portalSettingsView struct {
+	NotificationType         string  `json:"notification_type"`
+	CheckInterval            int     `json:"check_interval"`
+	NotificationInterval     float64 `json:"notification_interval"`
+	PreferredCaptchaProvider string  `json:"preferred_captcha_provider"`
+	HasCustomCaptchaKey      bool    `json:"has_custom_captcha_key"`
+	CaptchaBalance           float64 `json:"captcha_balance"`
+}
+
+// registerPortalRoutes mounts the user portal when PORTAL_ENABLED is set.
+// Users log in with Discord OAuth2 and only ever see their own accounts.
+func registerPortalRoutes() {
+	cfg := configuration.Get()
+	if !cfg.Portal.Enabled {
+		return
+	}
+
+	assets, err := fs.Sub(portalFiles, "portal")
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to load embedded portal")
+		return
+	}
+	fileServer := http.StripPrefix("/portal/", http.FileServer(http.FS(assets)))
+
+	http.Handle("/portal/", portalHeaders(fileServer))
+	http.Handle("/portal/login", portalHeaders(http.HandlerFunc(portalLogin)))
+	http.Handle("/portal/callback", portalHeaders(http.HandlerFunc(portalCallback)))
+	http.Handle("/portal/logout", portalHeaders(http.HandlerFunc(portalLogout)))
+	http.Handle("/portal/api/me", portalHeaders(portalAuth(portalMe)))
+	http.Handle("/portal/api/accounts", portalHeaders(portalAuth(portalAccounts)))
+	http.Handle("/portal/api/accounts/history", portalHeaders(portalAuth(portalAccountHistory)))
+	http.Handle("/portal/api/accounts/update", portalHeaders(portalAuth(portalUpdateAccount)))
+	http.Handle("/portal/api/settings", portalHeaders(portalAuth(portalUpdateSettings)))
+
+	logger.Log.Info("User portal available at /portal/")
+}
+
+func portalHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "same-origin")
+		w.Header().Set("Cache-Control", "no-store")
+		next.ServeHTTP(w, r)
+	})
+}
+
+func portalRandomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func portalLogin(w http.ResponseWriter, r *http.Request) {
+	cfg := configuration.Get()
+
+	state, err := portalRandomToken()
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to generate portal OAuth state")
+		http.Error(w, "Login unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     portalStateCookie,
+		Value:    state,
+		Path:     "/portal/",
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   cfg.Portal.CookieSecure,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	params := url.Values{
+		"client_id":     {cfg.Discord.ClientID},
+		"redirect_uri":  {cfg.Portal.RedirectURL},
+		"response_type": {"code"},
+		"scope":         {"identify"},
+		"state":         {state},
+		"prompt":        {"none"},
+	}
+	http.Redirect(w, r, discordAuthorizeURL+"?"+params.Encode(), http.StatusFound)
+}
+
+func portalCallback(w http.ResponseWriter, r *http.Request) {
+	cfg := configuration.Get()
+
+	stateCookie, err := r.Cookie(portalStateCookie)
+	state := r.URL.Query().Get("state")
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(stateCookie.Value), []byte(state)) != 1 {
+		http.Error(w, "Login expired, please try again", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: portalStateCookie, Path: "/portal/", MaxAge: -1})
+
+	if oauthErr := r.URL.Query().Get("error"); oauthErr != "" {
+		http.Redirect(w, r, "/portal/", http.StatusFound)
+		return
+	}
+
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		http.Error(w, "Missing authorization code", http.StatusBadRequest)
+		return
+	}
+
+	accessToken, err := exchangeDiscordCode(code)
+	if err != nil {
+		logger.Log.WithError(err).Error("Portal OAuth2 code exchange failed")
+		http.Error(w, "Discord login failed", http.StatusBadGateway)
+		return
+	}
+
+	userID, username, err := fetchDiscordUser(accessToken)
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to fetch Discord user for portal login")
+		http.Error(w, "Discord login failed", http.StatusBadGateway)
+		return
+	}
+
+	token, err := portalRandomToken()
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to generate portal session token")
+		http.Error(w, "Login unavailable", http.StatusInternalServerError)
+		return
+	}
+	csrf, err := portalRandomToken()
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to generate portal CSRF token")
+		http.Error(w, "Login unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	session := models.PortalSession{
+		TokenHash: hashAdminKey(token),
+		UserID:    userID,
+		Username:  username,
+		CSRFToken: csrf,
+		ExpiresAt: time.Now().Add(cfg.Portal.SessionTTL),
+	}
+	if err := database.DB.Create(&session).Error; err != nil {
+		logger.Log.WithError(err).Error("Failed to store portal session")
+		http.Error(w, "Login unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     portalSessionCookie,
+		Value:    token,
+		Path:     "/portal/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   cfg.Portal.CookieSecure,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	logger.Log.Infof("Portal login for user %s", userID)
+	http.Redirect(w, r, "/portal/", http.StatusFound)
+}
+
+func exchangeDiscordCode(code string) (string, error) {
+	cfg := configuration.Get()
+
+	form := url.Values{
+		"client_id":     {cfg.Discord.ClientID},
+		"client_secret": {cfg.Portal.ClientSecret},
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {cfg.Portal.RedirectURL},
+	}
+	resp, err := GetDefaultHTTPClient().PostForm(discordTokenURL, form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("token response had no access token")
+	}
+	return token.AccessToken, nil
+}
+
+func fetchDiscordUser(accessToken string) (string, string, error) {
+	req, err := http.NewRequest(http.MethodGet, discordUserURL, nil)
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := GetDefaultHTTPClient().Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("user endpoint returned %d", resp.StatusCode)
+	}
+
+	var user struct {
+		ID         string `json:"id"`
+		Username   string `json:"username"`
+		GlobalName string `json:"global_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return "", "", fmt.Errorf("failed to decode user response: %w", err)
+	}
+	if user.ID == "" {
+		return "", "", errors.New("user response had no id")
+	}
+	if user.GlobalName != "" {
+		return user.ID, user.GlobalName, nil
+	}
+	return user.ID, user.Username, nil
+}
+
+func portalLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if session, err := lookupPortalSession(r); err == nil {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-CSRF-Token")), []byte(session.CSRFToken)) != 1 {
+			http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+			return
+		}
+		if err := database.DB.Unscoped().Delete(&session).Error; err != nil {
+			logger.Log.WithError(err).Error("Failed to delete portal session")
+		}
+	}
+	http.SetCookie(w, &http.Cookie{Name: portalSessionCookie, Path: "/portal/", MaxAge: -1})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func lookupPortalSession(r *http.Request) (models.PortalSession, error) {
+	cookie, err := r.Cookie(portalSessionCookie)
+	if err != nil || cookie.Value == "" {
+		return models.PortalSession{}, errPortalUnauthenticated
+	}
+
+	var session models.PortalSession
+	if err := database.DB.Where("token_hash = ? AND expires_at > ?", hashAdminKey(cookie.Value), time.Now()).
+		First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.PortalSession{}, errPortalUnauthenticated
+		}
+		return models.PortalSession{}, err
+	}
+	return session, nil
+}
+
+// portalAuth resolves the session cookie and requires the CSRF token on
+// anything other than GET.
+func portalAuth(next func(http.ResponseWriter, *http.Request, models.PortalSession)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, err := lookupPortalSession(r)
+		if err != nil {
+			if !errors.Is(err, errPortalUnauthenticated) {
+				logger.Log.WithError(err).Error("Portal session lookup failed")
+				http.Error(w, "Session lookup failed", http.StatusInternalServerError)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if r.Method != http.MethodGet {
+			if r.Method != http.MethodPost {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-CSRF-Token")), []byte(session.CSRFToken)) != 1 {
+				http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+				return
+			}
+		}
+
+		next(w, r, session)
+	})
+}
+
+func portalMe(w http.ResponseWriter, r *http.Request, session models.PortalSession) {
+	settings, err := GetUserSettings(session.UserID)
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to load portal user settings")
+		http.Error(w, "Failed to load settings", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSONResponse(w, map[string]interface{}{
+		"user_id":    session.UserID,
+		"username":   session.Username,
+		"csrf_token": session.CSRFToken,
+		"expires_at": session.ExpiresAt,
+		"settings": portalSettingsView{
+			NotificationType:         settings.NotificationType,
+			CheckInterval:            settings.CheckInterval,
+			NotificationInterval:     settings.NotificationInterval,
+			PreferredCaptchaProvider: settings.PreferredCaptchaProvider,
+			HasCustomCaptchaKey:      HasOwnCaptchaKey(settings),
+			CaptchaBalance:           settings.CaptchaBalance,
+		},
+	})
+}
+
+func newPortalAccountView(account models.Account) portalAccountView {
+	view := portalAccountView{
+		ID:               account.ID,
+		Title:            account.Title,
+		Status:           account.LastStatus,
+		LastCheck:        account.LastCheck,
+		LastStatusChange: account.LastStatusChange,
+		Created:          account.Created,
+		IsVIP:            account.IsVIP,
+		IsCheckDisabled:  account.IsCheckDisabled,
+		DisabledReason:   AccountDisabledReason(UserLocale(account.UserID), account),
+		IsExpiredCookie:  account.IsExpiredCookie,
+		CookieExpiresAt:  account.SSOCookieExpiration,
+	}
+	if account.SSOCookieExpiration > 0 {
+		if remaining, err := CheckSSOCookieExpiration(account.SSOCookieExpiration); err == nil {
+			view.CookieExpiresIn = int64(remaining.Seconds())
+		}
+		view.CookieExpiresDisplay = FormatExpirationTime(account.SSOCookieExpiration)
+	}
+	return view
+}
+
+func portalAccounts(w http.ResponseWriter, r *http.Request, session models.PortalSession) {
+	var accounts []models.Account
+	if err := database.DB.Where("user_id = ?", session.UserID).Order("title").Find(&accounts).Error; err != nil {
+		logger.Log.WithError(err).Error("Failed to load portal accounts")
+		http.Error(w, "Failed to load accounts", http.StatusInternalServerError)
+		return
+	}
+
+	views := make([]portalAccountView, 0, len(accounts))
+	for _, account := range accounts {
+		views = append(views, newPortalAccountView(account))
+	}
+	writeJSONResponse(w, map[string]interface{}{"accounts": views})
+}
+
+// lookupPortalAccount loads an account by the account_id parameter, scoped
+// to the session's user so one user can never reach another's accounts.
+func lookupPortalAccount(w http.ResponseWriter, r *http.Request, session models.PortalSession) (models.Account, bool) {
+	accountID, err := strconv.ParseUint(r.FormValue("account_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "account_id is required", http.StatusBadRequest)
+		return models.Account{}, false
+	}
+
+	var account models.Account
+	if err := database.DB.Where("id = ? AND user_id = ?", accountID, session.UserID).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Account not found", http.StatusNotFound)
+		} else {
+			logger.Log.WithError(err).Error("Failed to load portal account")
+			http.Error(w, "Failed to load account", http.StatusInternalServerError)
+		}
+		return models.Account{}, false
+	}
+	return account, true
+}
+
+func portalAccountHistory(w http.ResponseWriter, r *http.Request, session models.PortalSession) {
+	account, ok := lookupPortalAccount(w, r, session)
+	if !ok {
+		return
+	}
+
+	var bans []models.Ban
+	if err := database.DB.Where("account_id = ?", account.ID).
+		Order("timestamp DESC").
+		Limit(portalBanHistoryLimit).
+		Find(&bans).Error; err != nil {
+		logger.Log.WithError(err).Error("Failed to load portal ban history")
+		http.Error(w, "Failed to load history", http.StatusInternalServerError)
+		return
+	}
+
+	history := make([]portalBanView, 0, len(bans))
+	for _, ban := range bans {
+		history = append(history, portalBanView{
+			Timestamp:       ban.Timestamp,
+			LogType:         ban.LogType,
+			Status:          ban.Status,
+			PreviousStatus:  ban.PreviousStatus,
+			Message:         ban.Message,
+			TempBanDuration: ban.TempBanDuration,
+			AffectedGames:   ban.AffectedGames,
+			Initiator:       ban.Initiator,
+		})
+	}
+
+	writeJSONResponse(w, map[string]interface{}{
+		"account": newPortalAccountView(account),
+		"history": history,
+	})
+}
+
+func validPortalNotificationType(value string) bool {
+	return value == "channel" || value == "dm"
+}
+
+func portalUpdateAccount(w http.ResponseWriter, r *http.Request, session models.PortalSession) {
+	account, ok := lookupPortalAccount(w, r, session)
+	if !ok {
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if r.Form.Has("title") {
+		title := utils.SanitizeInput(strings.TrimSpace(r.FormValue("title")))
+		if len(title) < 3 || len(title) > 40 {
+			http.Error(w, "Title must be between 3 and 40 characters", http.StatusBadRequest)
+			return
+		}
+		updates["title"] = title
+		account.Title = title
+	}
+	if len(updates) == 0 {
+		http.Error(w, "Nothing to update", http.StatusBadRequest)
+		return
+	}
+
+	DBMutex.Lock()
+	err := database.DB.Model(&account).Updates(updates).Error
+	DBMutex.Unlock()
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to update account from portal")
+		http.Error(w, "Failed to update account", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Log.Infof("User %s updated account %d from the portal", session.UserID, account.ID)
+	writeJSONResponse(w, newPortalAccountView(account))
+}
+
+// portalUpdateSettings mirrors /setnotifications: the preference is stored on
+// the user and applied to every account they own.
+func portalUpdateSettings(w http.ResponseWriter, r *http.Request, session models.PortalSession) {
+	notificationType := strings.ToLower(strings.TrimSpace(r.FormValue("notification_type")))
+	if !validPortalNotificationType(notificationType) {
+		http.Error(w, "notification_type must be channel or dm", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := GetUserSettings(session.UserID)
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to load portal user settings")
+		http.Error(w, "Failed to load settings", http.StatusInternalServerError)
+		return
+	}
+
+	err = utils.WithTransaction(database.DB, func(tx *gorm.DB) error {
+		if err := tx.Model(&settings).Update("notification_type", notificationType).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Account{}).
+			Where("user_id = ?", session.UserID).
+			Update("notification_type", notificationType).Error
+	})
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to update settings from portal")
+		http.Error(w, "Failed to update settings", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Log.Infof("User %s set notification type to %s from the portal", session.UserID, notificationType)
+	writeJSONResponse(w, map[string]string{"notification_type": notificationType})
+}
+
+// CleanupExpiredPortalSessions removes sessions past their expiry.
+func CleanupExpiredPortalSessions() {
+	result := database.DB.Unscoped().Where("expires_at < ?", time.Now()).Delete(&models.PortalSession{})
+	if result.Error != nil {
+		logger.Log.WithError(result.Error).Error("Failed to clean up portal sessions")
+		return
+	}
+	if result.RowsAffected > 0 {
+		logger.Log.Infof("Removed %d expired portal sessions", result.RowsAffected)
+	}
+}
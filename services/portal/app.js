@@ -0,0 +1,203 @@
+"use strict";
+
+// The portal talks to /portal/api with the session cookie set at login.
+// State-changing requests echo the CSRF token handed out by /portal/api/me.
+const $ = (id) => document.getElementById(id);
+
+let csrfToken = "";
+let accounts = [];
+let selected = null;
+
+class ApiError extends Error {
+  constructor(status, message) {
+    super(message);
+    this.status = status;
+  }
+}
+
+async function api(path, options) {
+  const res = await fetch(path, { credentials: "same-origin", ...options });
+  if (!res.ok) {
+    const text = (await res.text()).trim();
+    throw new ApiError(res.status, text || res.statusText);
+  }
+  return res.status === 204 ? null : res.json();
+}
+
+function post(path, fields) {
+  return api(path, {
+    method: "POST",
+    headers: { "X-CSRF-Token": csrfToken },
+    body: new URLSearchParams(fields),
+  });
+}
+
+function el(tag, attrs, children) {
+  const node = document.createElement(tag);
+  Object.entries(attrs || {}).forEach(([k, v]) => {
+    if (k === "text") node.textContent = v;
+    else node.setAttribute(k, v);
+  });
+  (children || []).forEach((c) => node.appendChild(c));
+  return node;
+}
+
+function fmtUnix(seconds) {
+  if (!seconds) return "-";
+  return new Date(seconds * 1000).toLocaleString();
+}
+
+function statusClass(status) {
+  switch (status) {
+    case "Good":
+      return "status-good";
+    case "Permaban":
+    case "Shadowban":
+    case "Temporary":
+      return "status-bad";
+    default:
+      return "status-warn";
+  }
+}
+
+// countdown renders the time left on an account's SSO cookie, counting down
+// from the expiry the server reported.
+function countdown(account) {
+  if (account.is_expired_cookie) return { text: "Expired", className: "countdown-expired" };
+  if (!account.cookie_expires_at) return { text: "Unknown", className: "" };
+  const left = account.cookie_expires_at - Math.floor(Date.now() / 1000);
+  if (left <= 0) return { text: "Expired", className: "countdown-expired" };
+  const days = Math.floor(left / 86400);
+  const hours = Math.floor((left % 86400) / 3600);
+  const minutes = Math.floor((left % 3600) / 60);
+  const text = days > 0 ? `${days}d ${hours}h` : `${hours}h ${minutes}m`;
+  return { text, className: left < 3 * 86400 ? "countdown-soon" : "" };
+}
+
+function renderSettings(me) {
+  const s = me.settings;
+  $("username").textContent = me.username;
+  $("settings").replaceChildren(
+    el("dt", { text: "Notifications" }), el("dd", { text: s.notification_type === "dm" ? "Direct message" : "Channel" }),
+    el("dt", { text: "Check interval" }), el("dd", { text: `${s.check_interval} minutes` }),
+    el("dt", { text: "Daily update interval" }), el("dd", { text: `${s.notification_interval} hours` }),
+    el("dt", { text: "Captcha provider" }), el("dd", { text: s.preferred_captcha_provider }),
+    el("dt", { text: "Own captcha key" }), el("dd", { text: s.has_custom_captcha_key ? `yes (balance ${s.captcha_balance.toFixed(2)})` : "no" }),
+  );
+  $("settings-notification-type").value = s.notification_type;
+}
+
+function renderAccounts() {
+  const filter = $("account-filter").value.trim().toLowerCase();
+  const body = $("accounts").tBodies[0];
+  body.replaceChildren();
+  accounts
+    .filter((a) => !filter || a.title.toLowerCase().includes(filter) || a.status.toLowerCase().includes(filter))
+    .forEach((a) => {
+      const cd = countdown(a);
+      const open = el("button", { type: "button", text: "Details" });
+      open.addEventListener("click", () => showAccount(a.id));
+      body.appendChild(el("tr", {}, [
+        el("td", { text: a.title }),
+        el("td", { text: a.status, class: statusClass(a.status) }),
+        el("td", { text: fmtUnix(a.last_check) }),
+        el("td", { text: cd.text, class: cd.className }),
+        el("td", { text: a.is_check_disabled ? `disabled${a.disabled_reason ? ": " + a.disabled_reason : ""}` : "enabled" }),
+        el("td", {}, [open]),
+      ]));
+    });
+}
+
+async function loadAccounts() {
+  const data = await api("api/accounts");
+  accounts = data.accounts || [];
+  renderAccounts();
+}
+
+async function showAccount(id) {
+  const data = await api(`api/accounts/history?account_id=${encodeURIComponent(id)}`);
+  selected = data.account;
+  $("detail").hidden = false;
+  $("detail-title").textContent = selected.title;
+  $("account-title").value = selected.title;
+  $("account-message").textContent = "";
+
+  const body = $("history").tBodies[0];
+  body.replaceChildren();
+  (data.history || []).forEach((h) => {
+    const status = h.previous_status && h.previous_status !== h.status
+      ? `${h.previous_status} → ${h.status}`
+      : h.status;
+    const details = [h.message, h.temp_ban_duration, h.affected_games].filter(Boolean).join(" · ");
+    body.appendChild(el("tr", {}, [
+      el("td", { text: new Date(h.timestamp).toLocaleString() }),
+      el("td", { text: h.log_type || "status_change" }),
+      el("td", { text: status, class: statusClass(h.status) }),
+      el("td", { text: details }),
+    ]));
+  });
+  if (!data.history || data.history.length === 0) {
+    body.appendChild(el("tr", {}, [el("td", { colspan: "4", text: "No history recorded yet." })]));
+  }
+  $("detail").scrollIntoView({ behavior: "smooth" });
+}
+
+async function start() {
+  let me;
+  try {
+    me = await api("api/me");
+  } catch (err) {
+    if (err.status === 401) {
+      $("login").hidden = false;
+      return;
+    }
+    throw err;
+  }
+  csrfToken = me.csrf_token;
+  renderSettings(me);
+  $("whoami").hidden = false;
+  $("portal").hidden = false;
+  await loadAccounts();
+  setInterval(renderAccounts, 60000);
+}
+
+document.addEventListener("DOMContentLoaded", () => {
+  $("account-filter").addEventListener("input", renderAccounts);
+
+  $("logout").addEventListener("click", async () => {
+    await post("logout", {});
+    window.location.reload();
+  });
+
+  $("settings-form").addEventListener("submit", async (e) => {
+    e.preventDefault();
+    const type = $("settings-notification-type").value;
+    try {
+      await post("api/settings", { notification_type: type });
+      $("settings-message").textContent = "Saved.";
+      renderSettings(await api("api/me"));
+      await loadAccounts();
+    } catch (err) {
+      $("settings-message").textContent = err.message;
+    }
+  });
+
+  $("account-form").addEventListener("submit", async (e) => {
+    e.preventDefault();
+    if (!selected) return;
+    try {
+      const updated = await post("api/accounts/update", {
+        account_id: selected.id,
+        title: $("account-title").value.trim(),
+      });
+      $("account-message").textContent = "Saved.";
+      $("detail-title").textContent = updated.title;
+      selected = updated;
+      await loadAccounts();
+    } catch (err) {
+      $("account-message").textContent = err.message;
+    }
+  });
+
+  start().catch((err) => console.error("portal failed to load:", err));
+});
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/bradselph/CODStatusBot/configuration"
+)
+
+// Role selects which parts of the bot a process runs. A single process runs
+// everything; larger deployments run gateways and any number of workers
+// that share the database.
+type Role string
+
+const (
+	RoleAll     Role = "all"     // Gateway, scheduled checks and admin API in one process.
+	RoleGateway Role = "gateway" // Discord interactions, check scheduling and message delivery.
+	RoleWorker  Role = "worker"  // Runs queued checks; never connects to Discord.
+	RoleAPI     Role = "api"     // Admin API and web portal only.
+)
+
+func ParseRole(value string) (Role, error) {
+	switch role := Role(strings.ToLower(strings.TrimSpace(value))); role {
+	case RoleAll, RoleGateway, RoleWorker, RoleAPI:
+		return role, nil
+	default:
+		return "", fmt.Errorf("unknown role %q (want all, gateway, worker or api)", value)
+	}
+}
+
+// ConnectsToDiscord reports whether the role opens gateway shards.
+func (r Role) ConnectsToDiscord() bool {
+	return r == RoleAll || r == RoleGateway
+}
+
+// ServesAPI reports whether the role starts the admin API and portal.
+func (r Role) ServesAPI() bool {
+	return r == RoleAll || r == RoleAPI
+}
+
+var processRole = struct {
+	sync.RWMutex
+	role Role
+}{role: RoleAll}
+
+func SetRole(r Role) {
+	processRole.Lock()
+	processRole.role = r
+	processRole.Unlock()
+}
+
+func CurrentRole() Role {
+	processRole.RLock()
+	defer processRole.RUnlock()
+	return processRole.role
+}
+
+// InstanceID identifies this process in job and leader leases: INSTANCE_ID
+// when set, otherwise the host name and pid.
+func InstanceID() string {
+	if id := configuration.Get().Process.InstanceID; id != "" {
+		return id
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "instance"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
@@ -0,0 +1,474 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/configuration"
+	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RateLimitAlgorithm decides how uses are counted against a policy.
+type RateLimitAlgorithm string
+
+const (
+	// TokenBucket holds up to Limit tokens and refills them evenly over
+	// Window, so bursts are allowed after quiet periods.
+	TokenBucket RateLimitAlgorithm = "token_bucket"
+	// SlidingWindow allows Limit uses in any Window long stretch.
+	SlidingWindow RateLimitAlgorithm = "sliding_window"
+)
+
+// RateLimitPolicy limits Action to Limit uses per Window. Policies with no
+// limit or window are ignored; the algorithm defaults to SlidingWindow.
+type RateLimitPolicy struct {
+	Action    string
+	Algorithm RateLimitAlgorithm
+	Limit     int
+	Window    time.Duration
+}
+
+func (p RateLimitPolicy) active() bool {
+	return p.Action != "" && p.Limit > 0 && p.Window > 0
+}
+
+// RateLimitDecision is the outcome of RateLimiter.Allow.
+type RateLimitDecision struct {
+	Allowed bool
+	// Remaining is how many uses the tightest policy has left: after this
+	// use when allowed, as of now when not.
+	Remaining int
+	// RetryAfter is how long until the request would be allowed.
+	RetryAfter time.Duration
+}
+
+// RateLimitStatus describes one action a subject is limited on.
+type RateLimitStatus struct {
+	Action    string
+	Algorithm RateLimitAlgorithm
+	Limit     int
+	Window    time.Duration
+	Remaining int
+	// RetryAfter is how long until one more use is allowed, zero if one is.
+	RetryAfter time.Duration
+	// ResetAfter is how long until the action is fully available again.
+	ResetAfter time.Duration
+}
+
+// RateLimitStore keeps limiter state per subject and action.
+type RateLimitStore interface {
+	// Update loads the state of every action, creating missing ones, and
+	// passes them to fn in order. The states are saved when fn returns true.
+	// Nothing else may change them until Update returns.
+	Update(subject string, actions []string, fn func(states []*models.RateLimitState) bool) error
+	List(subject string) ([]models.RateLimitState, error)
+	Clear(subject string) (int64, error)
+	Purge(before time.Time) (int64, error)
+}
+
+// RateLimiter enforces rate limit policies keyed by subject and action.
+type RateLimiter struct {
+	store RateLimitStore
+}
+
+func NewRateLimiter(store RateLimitStore) *RateLimiter {
+	return &RateLimiter{store: store}
+}
+
+var (
+	rateLimiterMu sync.RWMutex
+	rateLimiter   = NewRateLimiter(NewMemoryRateLimitStore())
+)
+
+// InitRateLimiter picks the store configured by RATE_LIMIT_STORE. It must
+// run after the database connection is up; until then limits are kept in
+// memory.
+func InitRateLimiter() {
+	store := configuration.Get().RateLimits.Store
+	rateLimiterMu.Lock()
+	defer rateLimiterMu.Unlock()
+	if store == "memory" {
+		rateLimiter = NewRateLimiter(NewMemoryRateLimitStore())
+	} else {
+		rateLimiter = NewRateLimiter(NewDatabaseRateLimitStore(database.DB))
+	}
+	logger.Log.Infof("Rate limits are kept in the %s", store)
+}
+
+// Limiter returns the process-wide rate limiter.
+func Limiter() *RateLimiter {
+	rateLimiterMu.RLock()
+	defer rateLimiterMu.RUnlock()
+	return rateLimiter
+}
+
+// UserSubject is the rate limit subject of a Discord user.
+func UserSubject(userID string) string {
+	return "user:" + userID
+}
+
+// APISubject is the rate limit subject of an admin API key.
+func APISubject(keyName string) string {
+	return "api:" + keyName
+}
+
+// RemoteSubject is the rate limit subject of a remote address calling the
+// admin API before it has authenticated.
+func RemoteSubject(host string) string {
+	return "remote:" + host
+}
+
+// Allow records one use by subject if every policy allows it.
+func (l *RateLimiter) Allow(subject string, policies ...RateLimitPolicy) (RateLimitDecision, error) {
+	return l.AllowN(subject, 1, policies...)
+}
+
+// AllowN records n uses by subject if every policy allows them. Denied
+// requests are not recorded. When the store fails the error is returned
+// with a decision that allows the request.
+func (l *RateLimiter) AllowN(subject string, n int, policies ...RateLimitPolicy) (RateLimitDecision, error) {
+	decision := RateLimitDecision{Allowed: true, Remaining: math.MaxInt}
+
+	var active []RateLimitPolicy
+	var actions []string
+	for _, policy := range policies {
+		if policy.active() {
+			if policy.Algorithm == "" {
+				policy.Algorithm = SlidingWindow
+			}
+			active = append(active, policy)
+			actions = append(actions, policy.Action)
+		}
+	}
+	if len(active) == 0 {
+		return decision, nil
+	}
+
+	now := time.Now()
+	err := l.store.Update(subject, actions, func(states []*models.RateLimitState) bool {
+		for idx, state := range states {
+			applyPolicy(state, active[idx], now)
+		}
+		for _, state := range states {
+			if wait := waitFor(state, n, now); wait > 0 {
+				decision.Allowed = false
+				if wait > decision.RetryAfter {
+					decision.RetryAfter = wait
+				}
+			}
+		}
+		if decision.Allowed {
+			for _, state := range states {
+				consume(state, n, now)
+			}
+		}
+		for _, state := range states {
+			decision.Remaining = min(decision.Remaining, remaining(state))
+		}
+		return decision.Allowed
+	})
+	if err != nil {
+		return RateLimitDecision{Allowed: true}, err
+	}
+	return decision, nil
+}
+
+// Status lists the actions subject has used recently, soonest available
+// first.
+func (l *RateLimiter) Status(subject string) ([]RateLimitStatus, error) {
+	states, err := l.store.List(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var statuses []RateLimitStatus
+	for _, state := range states {
+		refresh(&state, now)
+		if !state.ExpiresAt.After(now) {
+			continue
+		}
+		statuses = append(statuses, RateLimitStatus{
+			Action:     state.Action,
+			Algorithm:  RateLimitAlgorithm(state.Algorithm),
+			Limit:      state.Capacity,
+			Window:     state.Window,
+			Remaining:  remaining(&state),
+			RetryAfter: waitFor(&state, 1, now),
+			ResetAfter: state.ExpiresAt.Sub(now),
+		})
+	}
+	sort.Slice(statuses, func(a, b int) bool {
+		if statuses[a].RetryAfter != statuses[b].RetryAfter {
+			return statuses[a].RetryAfter < statuses[b].RetryAfter
+		}
+		return statuses[a].Action < statuses[b].Action
+	})
+	return statuses, nil
+}
+
+// Reset forgets every use by subject and returns how many actions it had.
+func (l *RateLimiter) Reset(subject string) (int64, error) {
+	return l.store.Clear(subject)
+}
+
+// Purge drops state that has gone back to unused.
+func (l *RateLimiter) Purge() (int64, error) {
+	return l.store.Purge(time.Now())
+}
+
+// allowAction reports whether subject may act under policies. Store
+// errors are logged and let the action through.
+func allowAction(subject string, policies ...RateLimitPolicy) bool {
+	decision, err := Limiter().Allow(subject, policies...)
+	if err != nil {
+		logger.Log.WithError(err).Errorf("Rate limiter unavailable for %s", subject)
+	}
+	return decision.Allowed
+}
+
+// applyPolicy brings state in line with policy, keeping recorded usage
+// when the configured limits change.
+func applyPolicy(state *models.RateLimitState, policy RateLimitPolicy, now time.Time) {
+	fresh := state.Algorithm != string(policy.Algorithm)
+	if fresh {
+		state.Tokens = float64(policy.Limit)
+		state.RefilledAt = now
+		state.Hits = nil
+	}
+	state.Algorithm = string(policy.Algorithm)
+	state.Capacity = policy.Limit
+	state.Window = policy.Window
+	refresh(state, now)
+}
+
+// refresh refills a bucket or drops hits that left the window as of now.
+func refresh(state *models.RateLimitState, now time.Time) {
+	switch RateLimitAlgorithm(state.Algorithm) {
+	case TokenBucket:
+		if elapsed := now.Sub(state.RefilledAt); elapsed > 0 {
+			state.Tokens += float64(elapsed) * refillRate(state)
+			state.RefilledAt = now
+		}
+		state.Tokens = math.Min(state.Tokens, float64(state.Capacity))
+		state.ExpiresAt = now.Add(time.Duration((float64(state.Capacity) - state.Tokens) / refillRate(state)))
+	default:
+		hits := state.Hits[:0]
+		for _, hit := range state.Hits {
+			if now.Sub(hit) < state.Window {
+				hits = append(hits, hit)
+			}
+		}
+		state.Hits = hits
+		state.ExpiresAt = now
+		if len(hits) > 0 {
+			state.ExpiresAt = hits[len(hits)-1].Add(state.Window)
+		}
+	}
+}
+
+// refillRate is a bucket's refill in tokens per nanosecond.
+func refillRate(state *models.RateLimitState) float64 {
+	return float64(state.Capacity) / float64(state.Window)
+}
+
+// waitFor returns how long until n more uses fit in state. Requests larger
+// than the limit never fit; they are told to wait a whole window.
+func waitFor(state *models.RateLimitState, n int, now time.Time) time.Duration {
+	if n > state.Capacity {
+		return state.Window
+	}
+	switch RateLimitAlgorithm(state.Algorithm) {
+	case TokenBucket:
+		missing := float64(n) - state.Tokens
+		if missing <= 0 {
+			return 0
+		}
+		return time.Duration(math.Ceil(missing / refillRate(state)))
+	default:
+		over := len(state.Hits) + n - state.Capacity
+		if over <= 0 {
+			return 0
+		}
+		return state.Hits[over-1].Add(state.Window).Sub(now)
+	}
+}
+
+func consume(state *models.RateLimitState, n int, now time.Time) {
+	switch RateLimitAlgorithm(state.Algorithm) {
+	case TokenBucket:
+		state.Tokens -= float64(n)
+	default:
+		for range n {
+			state.Hits = append(state.Hits, now)
+		}
+	}
+	refresh(state, now)
+}
+
+func remaining(state *models.RateLimitState) int {
+	switch RateLimitAlgorithm(state.Algorithm) {
+	case TokenBucket:
+		return int(math.Floor(state.Tokens))
+	default:
+		if left := state.Capacity - len(state.Hits); left > 0 {
+			return left
+		}
+		return 0
+	}
+}
+
+// MemoryRateLimitStore keeps limiter state in the process. It is lost on
+// restart and not shared between replicas.
+type MemoryRateLimitStore struct {
+	sync.Mutex
+	states map[string]map[string]models.RateLimitState
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{states: make(map[string]map[string]models.RateLimitState)}
+}
+
+func (m *MemoryRateLimitStore) Update(subject string, actions []string, fn func(states []*models.RateLimitState) bool) error {
+	m.Lock()
+	defer m.Unlock()
+
+	states := make([]*models.RateLimitState, len(actions))
+	for n, action := range actions {
+		state := m.states[subject][action]
+		state.Subject = subject
+		state.Action = action
+		state.Hits = append([]time.Time(nil), state.Hits...)
+		states[n] = &state
+	}
+	if !fn(states) {
+		return nil
+	}
+
+	if m.states[subject] == nil {
+		m.states[subject] = make(map[string]models.RateLimitState)
+	}
+	for _, state := range states {
+		m.states[subject][state.Action] = *state
+	}
+	return nil
+}
+
+func (m *MemoryRateLimitStore) List(subject string) ([]models.RateLimitState, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	states := make([]models.RateLimitState, 0, len(m.states[subject]))
+	for _, state := range m.states[subject] {
+		state.Hits = append([]time.Time(nil), state.Hits...)
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+func (m *MemoryRateLimitStore) Clear(subject string) (int64, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	cleared := int64(len(m.states[subject]))
+	delete(m.states, subject)
+	return cleared, nil
+}
+
+func (m *MemoryRateLimitStore) Purge(before time.Time) (int64, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	var purged int64
+	for subject, actions := range m.states {
+		for action, state := range actions {
+			if state.ExpiresAt.Before(before) {
+				delete(actions, action)
+				purged++
+			}
+		}
+		if len(actions) == 0 {
+			delete(m.states, subject)
+		}
+	}
+	return purged, nil
+}
+
+// DatabaseRateLimitStore keeps limiter state in the rate_limit_states
+// table, so limits survive restarts and hold across replicas.
+type DatabaseRateLimitStore struct {
+	db *gorm.DB
+}
+
+func NewDatabaseRateLimitStore(db *gorm.DB) *DatabaseRateLimitStore {
+	return &DatabaseRateLimitStore{db: db}
+}
+
+func (d *DatabaseRateLimitStore) Update(subject string, actions []string, fn func(states []*models.RateLimitState) bool) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		states := make([]*models.RateLimitState, len(actions))
+		for n, action := range actions {
+			state, err := lockRateLimitState(tx, subject, action)
+			if err != nil {
+				return err
+			}
+			states[n] = state
+		}
+		if !fn(states) {
+			return nil
+		}
+		for _, state := range states {
+			if err := tx.Save(state).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// lockRateLimitState selects the row for update, creating it first when
+// missing so that concurrent callers queue on the same row.
+func lockRateLimitState(tx *gorm.DB, subject, action string) (*models.RateLimitState, error) {
+	var state models.RateLimitState
+	query := func() error {
+		return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("subject = ? AND action = ?", subject, action).
+			First(&state).Error
+	}
+
+	err := query()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Another caller may create the row first; the unique index makes
+		// that harmless.
+		tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.RateLimitState{Subject: subject, Action: action})
+		err = query()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (d *DatabaseRateLimitStore) List(subject string) ([]models.RateLimitState, error) {
+	var states []models.RateLimitState
+	err := d.db.Where("subject = ?", subject).Find(&states).Error
+	return states, err
+}
+
+func (d *DatabaseRateLimitStore) Clear(subject string) (int64, error) {
+	result := d.db.Where("subject = ?", subject).Delete(&models.RateLimitState{})
+	return result.RowsAffected, result.Error
+}
+
+func (d *DatabaseRateLimitStore) Purge(before time.Time) (int64, error) {
+	result := d.db.Where("expires_at < ?", before).Delete(&models.RateLimitState{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/models"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestTokenBucketRefill(t *testing.T) {
+	policy := RateLimitPolicy{Action: "test", Algorithm: TokenBucket, Limit: 4, Window: time.Minute}
+	start := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		name          string
+		used          int
+		elapsed       time.Duration
+		want          int
+		wantRemaining int
+		wantWait      time.Duration
+	}{
+		{name: "full bucket", used: 0, elapsed: 0, want: 1, wantRemaining: 4},
+		{name: "empty bucket", used: 4, elapsed: 0, want: 1, wantRemaining: 0, wantWait: 15 * time.Second},
+		{name: "one token back", used: 4, elapsed: 15 * time.Second, want: 1, wantRemaining: 1},
+		{name: "partly refilled", used: 4, elapsed: 20 * time.Second, want: 2, wantRemaining: 1, wantWait: 10 * time.Second},
+		{name: "refill stops at capacity", used: 2, elapsed: time.Hour, want: 4, wantRemaining: 4},
+		{name: "more than capacity", used: 0, elapsed: 0, want: 5, wantRemaining: 4, wantWait: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var state models.RateLimitState
+			applyPolicy(&state, policy, start)
+			if tt.used > 0 {
+				consume(&state, tt.used, start)
+			}
+
+			now := start.Add(tt.elapsed)
+			applyPolicy(&state, policy, now)
+			if got := remaining(&state); got != tt.wantRemaining {
+				t.Errorf("remaining = %d, want %d", got, tt.wantRemaining)
+			}
+			if got := waitFor(&state, tt.want, now); got != tt.wantWait {
+				t.Errorf("waitFor(%d) = %v, want %v", tt.want, got, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestSlidingWindowExpiry(t *testing.T) {
+	policy := RateLimitPolicy{Action: "test", Algorithm: SlidingWindow, Limit: 2, Window: time.Minute}
+	start := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		name          string
+		hits          []time.Duration
+		elapsed       time.Duration
+		wantRemaining int
+		wantWait      time.Duration
+	}{
+		{name: "unused", elapsed: 0, wantRemaining: 2},
+		{name: "one hit inside window", hits: []time.Duration{0}, elapsed: 30 * time.Second, wantRemaining: 1},
+		{name: "full window", hits: []time.Duration{0, 10 * time.Second}, elapsed: 30 * time.Second, wantRemaining: 0, wantWait: 30 * time.Second},
+		{name: "oldest hit left the window", hits: []time.Duration{0, 10 * time.Second}, elapsed: time.Minute, wantRemaining: 1},
+		{name: "every hit left the window", hits: []time.Duration{0, 10 * time.Second}, elapsed: 2 * time.Minute, wantRemaining: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var state models.RateLimitState
+			applyPolicy(&state, policy, start)
+			for _, offset := range tt.hits {
+				consume(&state, 1, start.Add(offset))
+			}
+
+			now := start.Add(tt.elapsed)
+			applyPolicy(&state, policy, now)
+			if got := remaining(&state); got != tt.wantRemaining {
+				t.Errorf("remaining = %d, want %d", got, tt.wantRemaining)
+			}
+			if got := waitFor(&state, 1, now); got != tt.wantWait {
+				t.Errorf("waitFor = %v, want %v", got, tt.wantWait)
+			}
+			if tt.wantRemaining == policy.Limit && state.ExpiresAt.After(now) {
+				t.Errorf("ExpiresAt = %v, want state expired by %v", state.ExpiresAt, now)
+			}
+		})
+	}
+}
+
+func TestRateLimiterAllowAndReset(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm RateLimitAlgorithm
+	}{
+		{name: "token bucket", algorithm: TokenBucket},
+		{name: "sliding window", algorithm: SlidingWindow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewRateLimiter(NewMemoryRateLimitStore())
+			testAllowAndReset(t, limiter, "user:test", RateLimitPolicy{Action: "test", Algorithm: tt.algorithm, Limit: 2, Window: time.Hour})
+		})
+	}
+}
+
+// testAllowAndReset uses up policy, checks the next use is denied, and
+// checks Reset makes it available again.
+func testAllowAndReset(t *testing.T, limiter *RateLimiter, subject string, policy RateLimitPolicy) {
+	t.Helper()
+
+	for n := 1; n <= policy.Limit; n++ {
+		decision, err := limiter.Allow(subject, policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !decision.Allowed || decision.Remaining != policy.Limit-n {
+			t.Fatalf("use %d: got %+v, want allowed with %d remaining", n, decision, policy.Limit-n)
+		}
+	}
+
+	decision, err := limiter.Allow(subject, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decision.Allowed || decision.RetryAfter <= 0 {
+		t.Fatalf("over limit: got %+v, want denied with a retry delay", decision)
+	}
+
+	statuses, err := limiter.Status(subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 1 || statuses[0].Action != policy.Action || statuses[0].Remaining != 0 {
+		t.Fatalf("Status = %+v, want %s with none remaining", statuses, policy.Action)
+	}
+
+	cleared, err := limiter.Reset(subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cleared != 1 {
+		t.Errorf("Reset cleared %d actions, want 1", cleared)
+	}
+
+	decision, err = limiter.Allow(subject, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decision.Allowed || decision.Remaining != policy.Limit-1 {
+		t.Errorf("after reset: got %+v, want allowed with %d remaining", decision, policy.Limit-1)
+	}
+}
+
+// TestDatabaseRateLimitStore runs against the MySQL database named by
+// TEST_DATABASE_DSN and is skipped without one.
+func TestDatabaseRateLimitStore(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.RateLimitState{}); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewDatabaseRateLimitStore(db)
+	subject := fmt.Sprintf("test:%d", time.Now().UnixNano())
+	t.Cleanup(func() { _, _ = store.Clear(subject) })
+
+	tests := []struct {
+		name      string
+		algorithm RateLimitAlgorithm
+	}{
+		{name: "token bucket", algorithm: TokenBucket},
+		{name: "sliding window", algorithm: SlidingWindow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := RateLimitPolicy{Action: "test_" + string(tt.algorithm), Algorithm: tt.algorithm, Limit: 2, Window: time.Hour}
+			testAllowAndReset(t, NewRateLimiter(store), subject, policy)
+
+			states, err := store.List(subject)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(states) != 1 {
+				t.Fatalf("List returned %d states, want 1", len(states))
+			}
+			state := states[0]
+			if state.Algorithm != string(tt.algorithm) || state.Capacity != policy.Limit || state.Window != policy.Window {
+				t.Errorf("stored state = %+v, want the policy settings", state)
+			}
+			if tt.algorithm == SlidingWindow && len(state.Hits) != 1 {
+				t.Errorf("stored %d hits, want 1", len(state.Hits))
+			}
+
+			if _, err := store.Clear(subject); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
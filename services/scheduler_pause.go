@@ -0,0 +1,83 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/models"
+	"gorm.io/gorm/clause"
+)
+
+// checkScheduler names the pause row of the account check scheduler.
+const checkScheduler = "checks"
+
+// schedulerPauseTTL is how long an instance trusts its copy of the pause
+// row. Pausing takes effect everywhere within this long.
+const schedulerPauseTTL = 15 * time.Second
+
+var schedulerPause = struct {
+	sync.Mutex
+	state    models.SchedulerPause
+	loadedAt time.Time
+}{}
+
+// GetSchedulerPause returns the pause state of the check scheduler.
+func GetSchedulerPause() (models.SchedulerPause, error) {
+	schedulerPause.Lock()
+	defer schedulerPause.Unlock()
+
+	if time.Since(schedulerPause.loadedAt) < schedulerPauseTTL {
+		return schedulerPause.state, nil
+	}
+
+	var state models.SchedulerPause
+	if err := database.DB.Where("name = ?", checkScheduler).Limit(1).Find(&state).Error; err != nil {
+		return schedulerPause.state, err
+	}
+	schedulerPause.state = state
+	schedulerPause.loadedAt = time.Now()
+	return state, nil
+}
+
+// IsSchedulerPaused reports whether an admin paused scheduled checks. If the
+// state cannot be loaded the last known one is used.
+func IsSchedulerPaused() bool {
+	state, err := GetSchedulerPause()
+	if err != nil {
+		logger.Log.WithError(err).Warn("Failed to load scheduler pause state")
+	}
+	return state.Paused
+}
+
+// SetSchedulerPaused pauses or resumes scheduled checks on every instance.
+// Manual checks keep working while the scheduler is paused.
+func SetSchedulerPaused(paused bool, changedBy, reason string) (models.SchedulerPause, error) {
+	state := models.SchedulerPause{
+		Name:      checkScheduler,
+		Paused:    paused,
+		Reason:    reason,
+		ChangedBy: changedBy,
+		ChangedAt: time.Now(),
+	}
+	err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"paused", "reason", "changed_by", "changed_at", "updated_at"}),
+	}).Create(&state).Error
+	if err != nil {
+		return models.SchedulerPause{}, err
+	}
+
+	schedulerPause.Lock()
+	schedulerPause.state = state
+	schedulerPause.loadedAt = time.Now()
+	schedulerPause.Unlock()
+
+	if paused {
+		logger.Log.Warnf("Scheduled checks paused by %s: %s", changedBy, reason)
+	} else {
+		logger.Log.Infof("Scheduled checks resumed by %s", changedBy)
+	}
+	return state, nil
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/configuration"
+	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/models"
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxDriftSamples       = 3
+	maxDriftSampleLength  = 600
+	schemaDriftAlertDelay = 6 * time.Hour
+)
+
+type EndpointDriftStats struct {
+	UnknownFields       map[string]int `json:"unknown_fields"`
+	MissingFields       map[string]int `json:"missing_fields"`
+	UnknownEnforcements map[string]int `json:"unknown_enforcements"`
+	ParseFailures       int            `json:"parse_failures"`
+	Samples             []string       `json:"samples"`
+}
+
+type SchemaDriftStatus struct {
+	Endpoints      map[string]*EndpointDriftStats `json:"endpoints"`
+	Events         int                            `json:"events"`
+	Threshold      int                            `json:"threshold"`
+	Tripped        bool                           `json:"tripped"`
+	TrippedAt      time.Time                      `json:"tripped_at,omitempty"`
+	ChecksPaused   bool                           `json:"checks_paused"`
+	AcknowledgedBy string                         `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt time.Time                      `json:"acknowledged_at,omitempty"`
+}
+
+// schemaDriftName names the drift row of the Activision endpoints.
+const schemaDriftName = "activision"
+
+// schemaDriftTTL is how long a process trusts its copy of the drift row, so
+// a pause or an acknowledgement reaches every process within this long.
+const schemaDriftTTL = 15 * time.Second
+
+var schemaDrift = struct {
+	sync.Mutex
+	state    models.SchemaDriftState
+	loadedAt time.Time
+}{}
+
+// trackSchemaDrift compares an Activision response with the endpoint's known
+// fields and records anything unexpected. Only successful responses are
+// inspected for unknown fields; parse failures are counted regardless.
+func trackSchemaDrift(endpoint string, statusCode int, body []byte, parseErr error, unknownEnforcements []string, secrets ...string) {
+	var unknown, missing []string
+	if statusCode == 200 {
+		unknown, missing = activisionSchemaDiff(endpoint, body)
+	}
+
+	if len(unknown) == 0 && len(missing) == 0 && len(unknownEnforcements) == 0 && parseErr == nil {
+		return
+	}
+
+	logger.Log.WithFields(logrus.Fields{
+		"endpoint":            endpoint,
+		"unknownFields":       unknown,
+		"missingFields":       missing,
+		"unknownEnforcements": unknownEnforcements,
+		"parseError":          parseErr,
+	}).Warn("Activision response does not match the known schema")
+
+	sample := redactBody(body, secrets)
+	if len(sample) > maxDriftSampleLength {
+		sample = sample[:maxDriftSampleLength] + "..."
+	}
+
+	cfg := configuration.Get()
+	var state models.SchemaDriftState
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if state, err = lockSchemaDriftState(tx); err != nil {
+			return err
+		}
+
+		endpoints := decodeDriftEndpoints(state.Endpoints)
+		stats, ok := endpoints[endpoint]
+		if !ok {
+			stats = &EndpointDriftStats{
+				UnknownFields:       make(map[string]int),
+				MissingFields:       make(map[string]int),
+				UnknownEnforcements: make(map[string]int),
+			}
+			endpoints[endpoint] = stats
+		}
+		for _, field := range unknown {
+			stats.UnknownFields[field]++
+		}
+		for _, field := range missing {
+			stats.MissingFields[field]++
+		}
+		for _, value := range unknownEnforcements {
+			stats.UnknownEnforcements[value]++
+		}
+		if parseErr != nil {
+			stats.ParseFailures++
+		}
+		if len(stats.Samples) < maxDriftSamples {
+			stats.Samples = append(stats.Samples, sample)
+		}
+
+		encoded, err := json.Marshal(endpoints)
+		if err != nil {
+			return err
+		}
+		state.Endpoints = string(encoded)
+		state.Events++
+
+		if !state.Tripped && state.Events >= cfg.API.DriftThreshold {
+			now := time.Now()
+			state.Tripped = true
+			state.TrippedAt = &now
+			state.ChecksPaused = cfg.API.DriftPauseChecks
+			logger.Log.WithField("events", state.Events).Error("Schema drift threshold crossed")
+		}
+		return tx.Save(&state).Error
+	})
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to record schema drift")
+		return
+	}
+	storeSchemaDriftState(state)
+}
+
+// lockSchemaDriftState loads the drift row for update, creating it first if
+// needed.
+func lockSchemaDriftState(tx *gorm.DB) (models.SchemaDriftState, error) {
+	state := models.SchemaDriftState{Name: schemaDriftName}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&state).Error; err != nil {
+		return state, err
+	}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", schemaDriftName).First(&state).Error
+	return state, err
+}
+
+func storeSchemaDriftState(state models.SchemaDriftState) {
+	schemaDrift.Lock()
+	schemaDrift.state = state
+	schemaDrift.loadedAt = time.Now()
+	schemaDrift.Unlock()
+}
+
+// loadSchemaDriftState returns the drift row, from this process's copy
+// while it is fresh. If the row cannot be loaded the last known one is
+// used.
+func loadSchemaDriftState(fresh bool) models.SchemaDriftState {
+	schemaDrift.Lock()
+	defer schemaDrift.Unlock()
+
+	if !fresh && time.Since(schemaDrift.loadedAt) < schemaDriftTTL {
+		return schemaDrift.state
+	}
+
+	var state models.SchemaDriftState
+	if err := database.DB.Where("name = ?", schemaDriftName).Limit(1).Find(&state).Error; err != nil {
+		logger.Log.WithError(err).Warn("Failed to load schema drift state")
+		return schemaDrift.state
+	}
+	schemaDrift.state = state
+	schemaDrift.loadedAt = time.Now()
+	return state
+}
+
+func decodeDriftEndpoints(encoded string) map[string]*EndpointDriftStats {
+	endpoints := make(map[string]*EndpointDriftStats)
+	if encoded == "" {
+		return endpoints
+	}
+	if err := json.Unmarshal([]byte(encoded), &endpoints); err != nil {
+		logger.Log.WithError(err).Warn("Failed to decode schema drift counts")
+		return make(map[string]*EndpointDriftStats)
+	}
+	for _, stats := range endpoints {
+		if stats.UnknownFields == nil {
+			stats.UnknownFields = make(map[string]int)
+		}
+		if stats.MissingFields == nil {
+			stats.MissingFields = make(map[string]int)
+		}
+		if stats.UnknownEnforcements == nil {
+			stats.UnknownEnforcements = make(map[string]int)
+		}
+	}
+	return endpoints
+}
+
+// IsCheckingPausedForDrift reports whether account checks are held back
+// until an admin acknowledges a schema drift alert. The pause is shared by
+// every process.
+func IsCheckingPausedForDrift() bool {
+	return loadSchemaDriftState(false).ChecksPaused
+}
+
+func GetSchemaDriftStatus() SchemaDriftStatus {
+	state := loadSchemaDriftState(true)
+	status := SchemaDriftStatus{
+		Endpoints:      decodeDriftEndpoints(state.Endpoints),
+		Events:         state.Events,
+		Threshold:      configuration.Get().API.DriftThreshold,
+		Tripped:        state.Tripped,
+		ChecksPaused:   state.ChecksPaused,
+		AcknowledgedBy: state.AcknowledgedBy,
+	}
+	if state.TrippedAt != nil {
+		status.TrippedAt = *state.TrippedAt
+	}
+	if state.AcknowledgedAt != nil {
+		status.AcknowledgedAt = *state.AcknowledgedAt
+	}
+	return status
+}
+
+// AcknowledgeSchemaDrift clears the drift counters and resumes checks on
+// every process.
+func AcknowledgeSchemaDrift(acknowledgedBy string) error {
+	var state models.SchemaDriftState
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if state, err = lockSchemaDriftState(tx); err != nil {
+			return err
+		}
+		now := time.Now()
+		state.Events = 0
+		state.Endpoints = ""
+		state.Tripped = false
+		state.TrippedAt = nil
+		state.ChecksPaused = false
+		state.AcknowledgedBy = acknowledgedBy
+		state.AcknowledgedAt = &now
+		return tx.Save(&state).Error
+	})
+	if err != nil {
+		return err
+	}
+	storeSchemaDriftState(state)
+	logger.Log.WithField("acknowledgedBy", acknowledgedBy).Info("Schema drift acknowledged, account checks resumed")
+	return nil
+}
+
+// reportSchemaDrift alerts the developer once the drift threshold has been
+// crossed, whichever process crossed it. Repeats are throttled by
+// NotifyAdminWithCooldown. Processes without a session leave the alert to
+// those with one.
+func reportSchemaDrift(s *discordgo.Session) {
+	if s == nil {
+		return
+	}
+	status := GetSchemaDriftStatus()
+	if !status.Tripped {
+		return
+	}
+
+	NotifyAdminWithCooldown(s, formatSchemaDriftAlert(status), schemaDriftAlertDelay)
+}
+
+func formatSchemaDriftAlert(status SchemaDriftStatus) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Schema drift detected in Activision responses (%d events, threshold %d).\n", status.Events, status.Threshold)
+	if status.ChecksPaused {
+		b.WriteString("Account checks are paused to avoid spending captchas. Acknowledge via POST /api/drift/acknowledge to resume.\n")
+	}
+
+	endpoints := make([]string, 0, len(status.Endpoints))
+	for endpoint := range status.Endpoints {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+
+	for _, endpoint := range endpoints {
+		stats := status.Endpoints[endpoint]
+		fmt.Fprintf(&b, "\n**%s**\n", endpoint)
+		if len(stats.UnknownFields) > 0 {
+			fmt.Fprintf(&b, "Unknown fields: %s\n", formatCounts(stats.UnknownFields))
+		}
+		if len(stats.MissingFields) > 0 {
+			fmt.Fprintf(&b, "Missing fields: %s\n", formatCounts(stats.MissingFields))
+		}
+		if len(stats.UnknownEnforcements) > 0 {
+			fmt.Fprintf(&b, "Unknown enforcement values: %s\n", formatCounts(stats.UnknownEnforcements))
+		}
+		if stats.ParseFailures > 0 {
+			fmt.Fprintf(&b, "Parse failures: %d\n", stats.ParseFailures)
+		}
+		for _, sample := range stats.Samples {
+			fmt.Fprintf(&b, "```json\n%s\n```\n", sample)
+		}
+	}
+
+	message := b.String()
+	if len(message) > 4000 {
+		message = message[:4000] + "\n..."
+	}
+	return message
+}
+
+func formatCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s (%d)", key, counts[key]))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -2,10 +2,10 @@ package services
 
 import (
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
-	"github.com/bradselph/CODStatusBot/configuration"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bwmarrin/discordgo"
 )
@@ -19,25 +19,30 @@ type ShardManager struct {
 	StartedShards  int
 }
 
-// NewShardManager creates a new shard manager
-func NewShardManager() (*ShardManager, error) {
-	cfg := configuration.Get()
+// NewShardManager creates a shard manager sized by the gateway's
+// recommendation. If the gateway cannot be asked, a single shard is used.
+func NewShardManager(token string) *ShardManager {
+	shards, maxConcurrency := 1, 1
 
-	// Get gateway info to determine shard count
-	gatewayInfo, err := GetGatewayBotInfo(cfg.Discord.Token)
+	gatewayInfo, err := GetGatewayBotInfo(token)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get gateway bot info: %w", err)
+		logger.Log.WithError(err).Warn("Failed to get gateway bot info, starting a single shard")
+	} else {
+		if gatewayInfo.Shards > 1 {
+			shards = gatewayInfo.Shards
+		}
+		if gatewayInfo.SessionStartLimit.MaxConcurrency > 1 {
+			maxConcurrency = gatewayInfo.SessionStartLimit.MaxConcurrency
+		}
 	}
 
-	logger.Log.Infof("Creating shard manager with %d shards and max concurrency %d",
-		gatewayInfo.Shards, gatewayInfo.SessionStartLimit.MaxConcurrency)
+	logger.Log.Infof("Creating shard manager with %d shards and max concurrency %d", shards, maxConcurrency)
 
 	return &ShardManager{
-		Sessions:       make([]*discordgo.Session, gatewayInfo.Shards),
-		MaxConcurrency: gatewayInfo.SessionStartLimit.MaxConcurrency,
-		TotalShards:    gatewayInfo.Shards,
-		StartedShards:  0,
-	}, nil
+		Sessions:       make([]*discordgo.Session, shards),
+		MaxConcurrency: maxConcurrency,
+		TotalShards:    shards,
+	}
 }
 
 // GetGatewayBotInfo retrieves the gateway bot info from Discord
@@ -50,44 +55,31 @@ func GetGatewayBotInfo(token string) (*discordgo.GatewayBotResponse, error) {
 	return s.GatewayBot()
 }
 
-// StartShards initializes and connects all shards
-func (sm *ShardManager) StartShards(token string, handlers map[string]func(*discordgo.Session, *discordgo.InteractionCreate)) error {
+// StartShards creates and connects every shard. configure runs on each
+// session before it connects, so all shards get the same intents and
+// handlers.
+func (sm *ShardManager) StartShards(token string, configure func(*discordgo.Session)) error {
 	sm.Lock()
 	defer sm.Unlock()
 
-	// Track which rate limit buckets we've opened
-	buckets := make(map[int]bool)
-
 	for i := 0; i < sm.TotalShards; i++ {
-		// Calculate the rate limit bucket for this shard
-		bucket := i % sm.MaxConcurrency
-
-		// If this is a new bucket, we need to wait for all shards in the previous bucket to start
-		if i >= sm.MaxConcurrency && !buckets[bucket] {
-			logger.Log.Infof("Waiting for previous bucket to complete before starting shard %d (bucket %d)", i, bucket)
-			time.Sleep(5 * time.Second) // Discord rate limiting between buckets
+		// Discord allows MaxConcurrency identifies per 5 seconds.
+		if i > 0 && i%sm.MaxConcurrency == 0 {
+			logger.Log.Infof("Waiting for the identify rate limit before starting shard %d", i)
+			time.Sleep(5 * time.Second)
 		}
 
-		// Mark this bucket as opened
-		buckets[bucket] = true
-
-		// Create and start the shard
 		logger.Log.Infof("Starting shard %d of %d", i, sm.TotalShards)
 		session, err := discordgo.New("Bot " + token)
 		if err != nil {
 			return fmt.Errorf("error creating session for shard %d: %w", i, err)
 		}
 
-		// Set the shard ID
 		session.ShardID = i
 		session.ShardCount = sm.TotalShards
+		configure(session)
 
-		// Register handlers
-		registerHandlers(session, handlers)
-
-		// Open connection to Discord
-		err = session.Open()
-		if err != nil {
+		if err := session.Open(); err != nil {
 			return fmt.Errorf("error opening session for shard %d: %w", i, err)
 		}
 
@@ -95,11 +87,6 @@ func (sm *ShardManager) StartShards(token string, handlers map[string]func(*disc
 		sm.StartedShards++
 
 		logger.Log.Infof("Shard %d started successfully", i)
-
-		// Wait between shards in the same bucket to avoid hitting rate limits
-		if sm.MaxConcurrency > 1 {
-			time.Sleep(1 * time.Second)
-		}
 	}
 
 	logger.Log.Infof("All %d shards started successfully", sm.TotalShards)
@@ -107,16 +94,25 @@ func (sm *ShardManager) StartShards(token string, handlers map[string]func(*disc
 }
 
 // Close closes all shard connections
-func (sm *ShardManager) Close() {
+func (sm *ShardManager) Close() error {
 	sm.Lock()
 	defer sm.Unlock()
 
+	var firstErr error
 	for i, s := range sm.Sessions {
 		if s != nil {
 			logger.Log.Infof("Closing shard %d", i)
-			s.Close()
+			if err := s.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
 		}
 	}
+	return firstErr
+}
+
+// Primary returns shard 0, which receives DMs.
+func (sm *ShardManager) Primary() *discordgo.Session {
+	return sm.Sessions[0]
 }
 
 // GetSession returns the session for a specific guild
@@ -128,7 +124,7 @@ func (sm *ShardManager) GetSession(guildID string) *discordgo.Session {
 	// Calculate which shard this guild belongs to
 	shardID := getShardIDForGuild(guildID, sm.TotalShards)
 
-	if shardID >= 0 && shardID < len(sm.Sessions) {
+	if shardID >= 0 && shardID < len(sm.Sessions) && sm.Sessions[shardID] != nil {
 		return sm.Sessions[shardID]
 	}
 
@@ -136,37 +132,68 @@ func (sm *ShardManager) GetSession(guildID string) *discordgo.Session {
 	return sm.Sessions[0]
 }
 
-// registerHandlers registers command handlers for a session
-func registerHandlers(s *discordgo.Session, handlers map[string]func(*discordgo.Session, *discordgo.InteractionCreate)) {
-	s.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-		switch i.Type {
-		case discordgo.InteractionApplicationCommand:
-			if h, ok := handlers[i.ApplicationCommandData().Name]; ok {
-				h(s, i)
-			}
-		case discordgo.InteractionModalSubmit:
-			if h, ok := handlers[i.ModalSubmitData().CustomID]; ok {
-				h(s, i)
-			}
-		case discordgo.InteractionMessageComponent:
-			if h, ok := handlers[i.MessageComponentData().CustomID]; ok {
-				h(s, i)
-			}
-		}
-	})
-}
-
 // getShardIDForGuild calculates the shard ID for a guild using Discord's sharding formula
 func getShardIDForGuild(guildID string, shardCount int) int {
 	// If no guild ID (e.g., for DMs), return shard 0
-	if guildID == "" {
+	if guildID == "" || shardCount <= 1 {
 		return 0
 	}
 
-	// Parse guild ID to uint64
-	var id uint64
-	fmt.Sscanf(guildID, "%d", &id)
+	id, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		return 0
+	}
 
 	// Use Discord's sharding formula: (guild_id >> 22) % num_shards
 	return int((id >> 22) % uint64(shardCount))
 }
+
+var activeShards struct {
+	sync.RWMutex
+	manager *ShardManager
+}
+
+// SetShardManager makes the running shards available to background jobs.
+func SetShardManager(sm *ShardManager) {
+	activeShards.Lock()
+	activeShards.manager = sm
+	activeShards.Unlock()
+}
+
+func currentShardManager() *ShardManager {
+	activeShards.RLock()
+	defer activeShards.RUnlock()
+	return activeShards.manager
+}
+
+// SessionForGuild returns the shard that owns guildID. Background jobs pass
+// the session they were started with as fallback, which is used when the
+// bot is not sharded.
+func SessionForGuild(fallback *discordgo.Session, guildID string) *discordgo.Session {
+	if sm := currentShardManager(); sm != nil {
+		return sm.GetSession(guildID)
+	}
+	return fallback
+}
+
+// SessionForDM returns the shard that handles direct messages.
+func SessionForDM(fallback *discordgo.Session) *discordgo.Session {
+	return SessionForGuild(fallback, "")
+}
+
+// AllSessions returns every shard, or just fallback when not sharded.
+func AllSessions(fallback *discordgo.Session) []*discordgo.Session {
+	if sm := currentShardManager(); sm != nil {
+		sessions := make([]*discordgo.Session, 0, len(sm.Sessions))
+		for _, s := range sm.Sessions {
+			if s != nil {
+				sessions = append(sessions, s)
+			}
+		}
+		return sessions
+	}
+	if fallback == nil {
+		return nil
+	}
+	return []*discordgo.Session{fallback}
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bradselph/CODStatusBot/configuration"
+	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/logger"
+	"github.com/bradselph/CODStatusBot/models"
+	"github.com/patrickmn/go-cache"
+	"gorm.io/gorm/clause"
+)
+
+// Tier is a user's plan. Its quotas come from the TIER_* settings.
+type Tier string
+
+const (
+	TierFree      Tier = "free"      // Uses the bot's captcha key.
+	TierOwnKey    Tier = "own_key"   // Set up a captcha key of their own.
+	TierSupporter Tier = "supporter" // Supports the bot.
+	TierGranted   Tier = "granted"   // Given extended limits by an admin.
+)
+
+// Tiers lists every tier from lowest to highest.
+var Tiers = []Tier{TierFree, TierOwnKey, TierSupporter, TierGranted}
+
+// Tier assignment scopes.
+const (
+	TierScopeUser  = "user"
+	TierScopeGuild = "guild"
+)
+
+var ErrUnknownTier = errors.New("unknown tier")
+
+// TierCacheTTL is how long a process keeps a resolved tier assignment.
+// AssignTier and RevokeTier only clear the cache of the process that calls
+// them, so other processes apply a change within this long.
+const TierCacheTTL = 5 * time.Minute
+
+// tierAssignments caches assignments by scope and subject, including the
+// absence of one, so resolving a tier in the check loop stays cheap.
+var tierAssignments = cache.New(TierCacheTTL, 10*time.Minute)
+
+func ParseTier(name string) (Tier, error) {
+	for _, tier := range Tiers {
+		if string(tier) == name {
+			return tier, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q (want free, own_key, supporter or granted)", ErrUnknownTier, name)
+}
+
+func (t Tier) rank() int {
+	for n, tier := range Tiers {
+		if tier == t {
+			return n
+		}
+	}
+	return 0
+}
+
+// Quotas returns the tier's current limits.
+func (t Tier) Quotas() configuration.TierQuotas {
+	tiers := configuration.Get().Tiers
+	switch t {
+	case TierOwnKey:
+		return tiers.OwnKey
+	case TierSupporter:
+		return tiers.Supporter
+	case TierGranted:
+		return tiers.Granted
+	default:
+		return tiers.Free
+	}
+}
+
+// HasOwnCaptchaKey reports whether the user set up an API key of their own
+// instead of using the bot's default one.
+func HasOwnCaptchaKey(settings models.UserSettings) bool {
+	return settings.CapSolverAPIKey != "" || settings.EZCaptchaAPIKey != "" || settings.TwoCaptchaAPIKey != ""
+}
+
+// UserTier resolves the user's plan. A tier assigned to the user wins, so
+// admins can also lower it; otherwise the user gets the higher of the tier
+// assigned to the guild they installed the bot in and the tier their
+// captcha key gives them.
+func UserTier(settings models.UserSettings) Tier {
+	if tier, ok := assignedTier(TierScopeUser, settings.UserID); ok {
+		return tier
+	}
+
+	tier := TierFree
+	if HasOwnCaptchaKey(settings) {
+		tier = TierOwnKey
+	}
+	if settings.InstallationGuildID != "" {
+		if guildTier, ok := assignedTier(TierScopeGuild, settings.InstallationGuildID); ok && guildTier.rank() > tier.rank() {
+			tier = guildTier
+		}
+	}
+	return tier
+}
+
+// UserQuotas returns the limits of the user's plan.
+func UserQuotas(settings models.UserSettings) configuration.TierQuotas {
+	return UserTier(settings).Quotas()
+}
+
+func assignedTier(scope, subjectID string) (Tier, bool) {
+	key := scope + ":" + subjectID
+	if cached, ok := tierAssignments.Get(key); ok {
+		tier := cached.(Tier)
+		return tier, tier != ""
+	}
+
+	var assignment models.TierAssignment
+	err := database.DB.Where("scope = ? AND subject_id = ? AND (expires_at IS NULL OR expires_at > ?)", scope, subjectID, time.Now()).
+		Limit(1).Find(&assignment).Error
+	if err != nil {
+		logger.Log.WithError(err).Warnf("Failed to load tier for %s", key)
+		return "", false
+	}
+
+	tier, err := ParseTier(assignment.Tier)
+	if err != nil {
+		tier = ""
+	}
+	expiry := TierCacheTTL
+	if assignment.ExpiresAt != nil {
+		if until := time.Until(*assignment.ExpiresAt); until < expiry {
+			expiry = until + time.Second
+		}
+	}
+	tierAssignments.Set(key, tier, expiry)
+	return tier, tier != ""
+}
+
+// AssignTier gives a user or guild a tier, replacing any earlier one. A
+// zero duration never expires.
+func AssignTier(scope, subjectID string, tier Tier, duration time.Duration, grantedBy, note string) (models.TierAssignment, error) {
+	if scope != TierScopeUser && scope != TierScopeGuild {
+		return models.TierAssignment{}, fmt.Errorf("unknown tier scope %q (want user or guild)", scope)
+	}
+
+	assignment := models.TierAssignment{
+		Scope:     scope,
+		SubjectID: subjectID,
+		Tier:      string(tier),
+		GrantedBy: grantedBy,
+		Note:      note,
+	}
+	if duration > 0 {
+		expires := time.Now().Add(duration)
+		assignment.ExpiresAt = &expires
+	}
+
+	err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "scope"}, {Name: "subject_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"tier", "granted_by", "note", "expires_at", "updated_at", "deleted_at"}),
+	}).Create(&assignment).Error
+	if err != nil {
+		return models.TierAssignment{}, err
+	}
+
+	tierAssignments.Delete(scope + ":" + subjectID)
+	logger.Log.Infof("Assigned tier %s to %s %s (by %s)", tier, scope, subjectID, grantedBy)
+	return assignment, nil
+}
+
+// RevokeTier removes the tier assigned to a user or guild. It reports
+// whether there was one.
+func RevokeTier(scope, subjectID string) (bool, error) {
+	result := database.DB.Unscoped().Where("scope = ? AND subject_id = ?", scope, subjectID).Delete(&models.TierAssignment{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	tierAssignments.Delete(scope + ":" + subjectID)
+	return result.RowsAffected > 0, nil
+}
+
+// ListTierAssignments returns the assignments that have not expired,
+// newest first.
+func ListTierAssignments() ([]models.TierAssignment, error) {
+	var assignments []models.TierAssignment
+	err := database.DB.Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("updated_at DESC").Find(&assignments).Error
+	return assignments, err
+}
+
+// CleanupExpiredTiers deletes assignments that have lapsed.
+func CleanupExpiredTiers() {
+	result := database.DB.Unscoped().Where("expires_at < ?", time.Now()).Delete(&models.TierAssignment{})
+	if result.Error != nil {
+		logger.Log.WithError(result.Error).Error("Failed to clean up expired tiers")
+		return
+	}
+	if result.RowsAffected > 0 {
+		logger.Log.Infof("Removed %d expired tier assignments", result.RowsAffected)
+	}
+}
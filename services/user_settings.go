@@ -6,6 +6,7 @@ import (
 
 	"github.com/bradselph/CODStatusBot/configuration"
 	"github.com/bradselph/CODStatusBot/database"
+	"github.com/bradselph/CODStatusBot/i18n"
 	"github.com/bradselph/CODStatusBot/logger"
 	"github.com/bradselph/CODStatusBot/models"
 	"github.com/bwmarrin/discordgo"
@@ -43,10 +44,7 @@ func GetUserSettings(userID string) (models.UserSettings, error) {
 		return models.UserSettings{}, fmt.Errorf("error getting user settings: %w", result.Error)
 	}
 
-	// Check if user has custom API key
-	hasCustomKey := settings.CapSolverAPIKey != "" ||
-		settings.EZCaptchaAPIKey != "" ||
-		settings.TwoCaptchaAPIKey != ""
+	hasCustomKey := HasOwnCaptchaKey(settings)
 
 	settings.EnsureMapsInitialized()
 
@@ -132,7 +130,7 @@ func GetUserCaptchaKey(userID string) (string, float64, error) {
 				return "", 0, err
 			}
 			if !isValid {
-				return "", 0, fmt.Errorf("invalid capsolver API key")
+				return "", 0, fmt.Errorf("%w: capsolver", ErrCaptchaKeyInvalid)
 			}
 			return settings.CapSolverAPIKey, balance, nil
 		}
@@ -143,7 +141,7 @@ func GetUserCaptchaKey(userID string) (string, float64, error) {
 			return "", 0, err
 		}
 		if !isValid {
-			return "", 0, fmt.Errorf("invalid default capsolver API key")
+			return "", 0, fmt.Errorf("%w: default capsolver", ErrCaptchaKeyInvalid)
 		}
 		return defaultKey, balance, nil
 
@@ -164,7 +162,7 @@ func GetUserCaptchaKey(userID string) (string, float64, error) {
 				return "", 0, err
 			}
 			if !isValid {
-				return "", 0, fmt.Errorf("invalid ezcaptcha API key")
+				return "", 0, fmt.Errorf("%w: ezcaptcha", ErrCaptchaKeyInvalid)
 			}
 			return settings.EZCaptchaAPIKey, balance, nil
 		}
@@ -186,7 +184,7 @@ func GetUserCaptchaKey(userID string) (string, float64, error) {
 				return "", 0, err
 			}
 			if !isValid {
-				return "", 0, fmt.Errorf("invalid 2captcha API key")
+				return "", 0, fmt.Errorf("%w: 2captcha", ErrCaptchaKeyInvalid)
 			}
 			return settings.TwoCaptchaAPIKey, balance, nil
 		}
@@ -200,7 +198,7 @@ func GetUserCaptchaKey(userID string) (string, float64, error) {
 			return "", 0, err
 		}
 		if !isValid {
-			return "", 0, fmt.Errorf("invalid default capsolver API key")
+			return "", 0, fmt.Errorf("%w: default capsolver", ErrCaptchaKeyInvalid)
 		}
 		return defaultKey, balance, nil
 	}
@@ -255,12 +253,8 @@ func RemoveCaptchaKey(userID string) error {
 	}
 
 	// Check if user had custom keys before removal
-	hadCustomKey := settings.CapSolverAPIKey != "" ||
-		settings.EZCaptchaAPIKey != "" ||
-		settings.TwoCaptchaAPIKey != ""
+	hadCustomKey := HasOwnCaptchaKey(settings)
 
-	// Get configuration and count accounts only once
-	cfg := configuration.Get()
 	var accountCount int64
 	if err := database.DB.Model(&models.Account{}).Where("user_id = ?", userID).Count(&accountCount).Error; err != nil {
 		return fmt.Errorf("failed to count user accounts: %w", err)
@@ -281,10 +275,10 @@ func RemoveCaptchaKey(userID string) error {
 	settings.EnsureMapsInitialized()
 	settings.LastCommandTimes["api_key_removed"] = time.Now()
 
-	defaultMax := cfg.RateLimits.DefaultMaxAccounts
+	defaultMax := UserQuotas(settings).MaxAccounts
 
-	// If user exceeds default limits, send warning
-	if int64(defaultMax) < accountCount {
+	// If user exceeds the limit of the tier they fall back to, send warning
+	if defaultMax > 0 && int64(defaultMax) < accountCount {
 		var accounts []models.Account
 		if err := database.DB.Where("user_id = ?", userID).Find(&accounts).Error; err != nil {
 			logger.Log.WithError(err).Error("Error fetching user accounts while removing API key")
@@ -300,25 +294,24 @@ func RemoveCaptchaKey(userID string) error {
 		}
 
 		// Create warning embed
+		locale := UserLocale(userID)
 		embed := &discordgo.MessageEmbed{
-			Title: "Account Limit Warning",
-			Description: fmt.Sprintf("You currently have %d accounts monitored, which exceeds the default limit of %d accounts.\n"+
-				"To continue monitoring all accounts, please add your own Capsolver API key using /setcaptchaservice.",
-				accountCount, defaultMax),
-			Color: 0xFFA500,
+			Title:       i18n.T(locale, "notify.account_limit.title"),
+			Description: i18n.T(locale, "notify.account_limit.description", accountCount, defaultMax),
+			Color:       0xFFA500,
 			Fields: []*discordgo.MessageEmbedField{
 				{
-					Name:   "Action Required",
-					Value:  fmt.Sprintf("Get your Capsolver API key at https://dashboard.capsolver.com/passport/register?inviteCode=6YjROhACQnvP"),
+					Name:   i18n.T(locale, "field.action_required"),
+					Value:  i18n.T(locale, "notify.account_limit.get_key", CapsolverSignupURL),
 					Inline: false,
 				},
 				{
-					Name:   "Current Accounts",
+					Name:   i18n.T(locale, "field.current_accounts"),
 					Value:  fmt.Sprintf("%d", accountCount),
 					Inline: true,
 				},
 				{
-					Name:   "Default Limit",
+					Name:   i18n.T(locale, "field.default_limit"),
 					Value:  fmt.Sprintf("%d", defaultMax),
 					Inline: true,
 				},
@@ -0,0 +1,116 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bradselph/CODStatusBot/logger"
+	sentryotel "github.com/getsentry/sentry-go/otel"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/bradselph/CODStatusBot"
+
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterSentry = "sentry"
+)
+
+// Config selects where spans are sent. OTLP endpoint, headers and TLS are
+// read by the exporter from the standard OTEL_EXPORTER_OTLP_* variables.
+type Config struct {
+	Exporter    string
+	ServiceName string
+	Environment string
+	SampleRate  float64
+}
+
+// Init installs the global tracer provider and returns a function that
+// flushes and stops it. With the "none" exporter spans are no-ops.
+func Init(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+
+	var processor sdktrace.SpanProcessor
+	sampler := sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRate))
+
+	switch strings.ToLower(cfg.Exporter) {
+	case "", ExporterNone:
+		return noop, nil
+	case ExporterOTLP:
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return noop, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		processor = sdktrace.NewBatchSpanProcessor(exporter)
+	case ExporterSentry:
+		// Sentry samples transactions itself using SENTRY_TRACES_SAMPLE_RATE.
+		if err := logger.InitSentry(true); err != nil {
+			return noop, fmt.Errorf("failed to enable Sentry tracing: %w", err)
+		}
+		processor = sentryotel.NewSentrySpanProcessor()
+		sampler = sdktrace.AlwaysSample()
+	default:
+		return noop, fmt.Errorf("unknown tracing exporter %q (want none, otlp or sentry)", cfg.Exporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.DeploymentEnvironment(cfg.Environment),
+	))
+	if err != nil {
+		return noop, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithSpanProcessor(processor),
+	)
+	otel.SetTracerProvider(provider)
+	if strings.EqualFold(cfg.Exporter, ExporterSentry) {
+		otel.SetTextMapPropagator(sentryotel.NewSentryPropagator())
+	} else {
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	}
+
+	logger.Log.Infof("Tracing enabled: exporter=%s sample_rate=%.2f", strings.ToLower(cfg.Exporter), cfg.SampleRate)
+	return provider.Shutdown, nil
+}
+
+// Start begins a span as a child of any span already in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+	if id := logger.CorrelationID(ctx); id != "" {
+		span.SetAttributes(attribute.String(logger.FieldCorrelationID, id))
+	}
+	return ctx, span
+}
+
+// End records err on the span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(errors.New(logger.RedactString(err.Error())))
+		span.SetStatus(codes.Error, logger.RedactString(err.Error()))
+	}
+	span.End()
+}
+
+// Active reports whether ctx carries a span, so instrumentation can avoid
+// starting a new trace for background work nobody asked to trace.
+func Active(ctx context.Context) bool {
+	return ctx != nil && trace.SpanFromContext(ctx).SpanContext().IsValid()
+}